@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+	"github.com/eythor/mcp-server/internal/handlers"
+)
+
+// auditExportPageSize is how many audit entries the CSV export reads per
+// query, for the same reason as exportPageSize
+var auditExportPageSize = 500
+
+// auditCSVHeader is the first row of the audit log export
+var auditCSVHeader = []string{"timestamp", "action", "entity_id", "practitioner", "details"}
+
+// parseAuditBound parses a ?from= or ?to= value with ParseDateTimeRobust. A
+// value without a time ("2025-03-14", "yesterday") covers the whole day, so
+// it starts the range at midnight or, as the end, includes that day. An empty
+// value leaves that end of the range open.
+func parseAuditBound(value string, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := handlers.ParseDateTimeRobust(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !strings.Contains(value, ":") {
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	return t, nil
+}
+
+// Streams the audit log as CSV for compliance reporting, optionally limited
+// to ?from= and ?to=
+func (h *HTTPServer) handleExportAudit(w http.ResponseWriter, r *http.Request) {
+	debug.Request(r.Method, r.URL.Path, nil)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, err := parseAuditBound(r.URL.Query().Get("from"), false)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+		return
+	}
+	to, err := parseAuditBound(r.URL.Query().Get("to"), true)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+	flusher, _ := w.(http.Flusher)
+	writer := csv.NewWriter(w)
+	writer.Write(auditCSVHeader)
+
+	exported := 0
+	var afterID int64
+	for {
+		entries, err := database.GetAuditEntriesAfter(h.db, afterID, from, to, auditExportPageSize)
+		if err != nil {
+			// The status line has already been sent once a page was written,
+			// so a failure can only end the export early
+			log.Printf("Audit export failed after %d entries: %v", exported, err)
+			if exported == 0 {
+				w.Header().Del("Content-Disposition")
+				http.Error(w, "Failed to export audit log", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		for _, e := range entries {
+			writer.Write([]string{e.Timestamp, e.Action, e.EntityID, e.PractitionerID, e.Details})
+			exported++
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			// Usually the client went away
+			debug.Error("Audit export: failed to write: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(entries) < auditExportPageSize {
+			break
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+
+	debug.Log("Exported %d audit entries (from: %v, to: %v)", exported, from, to)
+}
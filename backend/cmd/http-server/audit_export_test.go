@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHandleExportAudit(t *testing.T) {
+	// Several pages, the last one partial
+	defer func(size int) { auditExportPageSize = size }(auditExportPageSize)
+	auditExportPageSize = 2
+
+	server := newExportServer(t, 0)
+	if _, err := server.db.Exec(`INSERT INTO audit_log (timestamp, action, entity_id, practitioner_id, details) VALUES
+		('2025-03-13T22:00:00Z', 'add_observation', 'p1', 'prac-1', '{"patient_id":"p1"}'),
+		('2025-03-14T08:00:00Z', 'schedule_appointment', 'p1', 'prac-1', '{"patient_id":"p1","date_time":"2025-03-20 10:00"}'),
+		('2025-03-14T12:30:00Z', 'cancel_appointment', 'e1', NULL, '{"encounter_id":"e1"}'),
+		('2025-03-15T09:00:00Z', 'add_condition', 'p2', 'prac-2', NULL),
+		('2025-03-16T09:00:00Z', 'delete_observation', 'o1', 'prac-1', NULL)`); err != nil {
+		t.Fatalf("Failed to seed audit log: %v", err)
+	}
+
+	// Whole days in Europe/Berlin: 14 March 00:00 (13 March 23:00 UTC) up to
+	// the end of 15 March
+	req := httptest.NewRequest(http.MethodGet, "/export/audit.csv?from=2025-03-14&to=15.03.2025", nil)
+	rec := httptest.NewRecorder()
+	server.handleExportAudit(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Response is not valid CSV: %v\n%s", err, rec.Body.String())
+	}
+	want := [][]string{
+		{"timestamp", "action", "entity_id", "practitioner", "details"},
+		{"2025-03-14T08:00:00Z", "schedule_appointment", "p1", "prac-1", `{"patient_id":"p1","date_time":"2025-03-20 10:00"}`},
+		{"2025-03-14T12:30:00Z", "cancel_appointment", "e1", "", `{"encounter_id":"e1"}`},
+		{"2025-03-15T09:00:00Z", "add_condition", "p2", "prac-2", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Unexpected rows:\n got %q\nwant %q", records, want)
+	}
+}
+
+func TestHandleExportAuditInvalidRange(t *testing.T) {
+	server := newExportServer(t, 0)
+	req := httptest.NewRequest(http.MethodGet, "/export/audit.csv?from=someday", nil)
+	rec := httptest.NewRecorder()
+	server.handleExportAudit(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
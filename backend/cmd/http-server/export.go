@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// exportPageSize is how many patients the export reads per query. The
+// database has a single connection, so the export reads a page, releases the
+// connection and then loads each patient's clinical data, instead of keeping
+// one query open for the whole stream.
+var exportPageSize = 500
+
+// patientExport is one line of the NDJSON export. Clinical data is only
+// present when requested with ?include=.
+type patientExport struct {
+	database.Patient
+	Observations  *[]database.Observation        `json:"observations,omitempty"`
+	Conditions    *[]database.Condition          `json:"conditions,omitempty"`
+	Medications   *[]database.MedicationRequest  `json:"medications,omitempty"`
+	Procedures    *[]database.Procedure          `json:"procedures,omitempty"`
+	Immunizations *[]database.Immunization       `json:"immunizations,omitempty"`
+	Allergies     *[]database.AllergyIntolerance `json:"allergies,omitempty"`
+	Encounters    *[]database.Encounter          `json:"encounters,omitempty"`
+}
+
+// exportIncludes loads one kind of clinical data into an export record
+var exportIncludes = map[string]func(db *sql.DB, rec *patientExport) error{
+	"observations": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetObservationsByPatientID(db, rec.ID)
+		rec.Observations = nonNil(v)
+		return err
+	},
+	"conditions": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetConditionsByPatientID(db, rec.ID)
+		rec.Conditions = nonNil(v)
+		return err
+	},
+	"medications": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetMedicationsByPatientID(db, rec.ID)
+		rec.Medications = nonNil(v)
+		return err
+	},
+	"procedures": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetProceduresByPatientID(db, rec.ID)
+		rec.Procedures = nonNil(v)
+		return err
+	},
+	"immunizations": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetImmunizationsByPatientID(db, rec.ID)
+		rec.Immunizations = nonNil(v)
+		return err
+	},
+	"allergies": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetAllergiesByPatientID(db, rec.ID)
+		rec.Allergies = nonNil(v)
+		return err
+	},
+	"encounters": func(db *sql.DB, rec *patientExport) error {
+		v, err := database.GetEncountersByPatientID(db, rec.ID)
+		rec.Encounters = nonNil(v)
+		return err
+	},
+}
+
+// nonNil returns a pointer to items, or to an empty slice when items is nil,
+// so an included category with no data exports as [] rather than null
+func nonNil[T any](items []T) *[]T {
+	if items == nil {
+		items = []T{}
+	}
+	return &items
+}
+
+// parseExportIncludes splits ?include=observations,conditions, rejecting
+// unknown categories
+func parseExportIncludes(value string) ([]string, error) {
+	var includes []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := exportIncludes[name]; !ok {
+			supported := make([]string, 0, len(exportIncludes))
+			for n := range exportIncludes {
+				supported = append(supported, n)
+			}
+			sort.Strings(supported)
+			return nil, fmt.Errorf("unknown include %q (supported: %s)", name, strings.Join(supported, ", "))
+		}
+		includes = append(includes, name)
+	}
+	return includes, nil
+}
+
+// Streams every patient as one JSON object per line for bulk data migration
+func (h *HTTPServer) handleExportPatients(w http.ResponseWriter, r *http.Request) {
+	debug.Request(r.Method, r.URL.Path, nil)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	includes, err := parseExportIncludes(r.URL.Query().Get("include"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	exported := 0
+	afterID := ""
+	for {
+		patients, err := database.GetPatientsAfter(h.db, afterID, exportPageSize)
+		if err != nil {
+			// The status line has already been sent once a page was written,
+			// so a failure can only end the stream early
+			log.Printf("Export failed after %d patients: %v", exported, err)
+			if exported == 0 {
+				http.Error(w, "Failed to export patients", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		for _, p := range patients {
+			rec := patientExport{Patient: p}
+			for _, name := range includes {
+				if err := exportIncludes[name](h.db, &rec); err != nil {
+					log.Printf("Export: failed to load %s for patient %s: %v", name, p.ID, err)
+					return
+				}
+			}
+			if err := encoder.Encode(rec); err != nil {
+				// Usually the client went away
+				debug.Error("Export: failed to write patient %s: %v", p.ID, err)
+				return
+			}
+			exported++
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(patients) < exportPageSize {
+			break
+		}
+		afterID = patients[len(patients)-1].ID
+	}
+
+	debug.Log("Exported %d patients (include: %v)", exported, includes)
+}
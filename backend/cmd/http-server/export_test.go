@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func newExportServer(t *testing.T, patients int) *HTTPServer {
+	t.Helper()
+
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open in-memory database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../../schema.sql")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("Failed to apply schema: %v", err)
+	}
+
+	for i := 0; i < patients; i++ {
+		id := fmt.Sprintf("p%02d", i)
+		if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES (?, 'Test', ?, 'female', '1980-01-01')`,
+			id, fmt.Sprintf("Patient%d", i)); err != nil {
+			t.Fatalf("Failed to seed patient: %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO observations (id, status, category, code, display, patient_id, value_quantity, value_unit)
+		VALUES ('o1', 'final', 'vital-signs', '8867-4', 'Heart rate', 'p01', 72, '/min')`); err != nil {
+		t.Fatalf("Failed to seed observation: %v", err)
+	}
+
+	return &HTTPServer{db: db}
+}
+
+func TestHandleExportPatients(t *testing.T) {
+	// Several pages, the last one partial
+	defer func(size int) { exportPageSize = size }(exportPageSize)
+	exportPageSize = 2
+
+	server := newExportServer(t, 5)
+	req := httptest.NewRequest(http.MethodGet, "/export/patients.ndjson?include=observations,conditions", nil)
+	rec := httptest.NewRecorder()
+	server.handleExportPatients(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v\n%s", len(lines)+1, err, scanner.Text())
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 patients, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("p%02d", i); line["id"] != want {
+			t.Errorf("Line %d: expected patient %s, got %v", i+1, want, line["id"])
+		}
+		if _, ok := line["conditions"].([]interface{}); !ok {
+			t.Errorf("Line %d: conditions missing or not an array: %v", i+1, line["conditions"])
+		}
+		if _, ok := line["medications"]; ok {
+			t.Errorf("Line %d: medications exported without being requested", i+1)
+		}
+	}
+	if obs, _ := lines[1]["observations"].([]interface{}); len(obs) != 1 {
+		t.Errorf("Expected 1 observation for p01, got %v", lines[1]["observations"])
+	}
+}
+
+func TestHandleExportPatientsUnknownInclude(t *testing.T) {
+	server := newExportServer(t, 1)
+	req := httptest.NewRequest(http.MethodGet, "/export/patients.ndjson?include=observations,x-rays", nil)
+	rec := httptest.NewRecorder()
+	server.handleExportPatients(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
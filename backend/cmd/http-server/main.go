@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+	"github.com/eythor/mcp-server/internal/handlers"
+	"github.com/eythor/mcp-server/internal/mcp"
+)
+
+type HTTPServer struct {
+	mcpServer *mcp.Server
+
+	// db is read directly by the export endpoint
+	db *sql.DB
+
+	// queryFunc answers a single natural language query; tests replace it to
+	// avoid calling the model
+	queryFunc func(ctx context.Context, query string, opts queryOptions) (string, error)
+
+	// streamFunc answers a query like queryFunc, passing the answer to
+	// onToken as it is written
+	streamFunc func(ctx context.Context, query string, opts queryOptions, onToken func(string)) (string, error)
+}
+
+// queryOptions are the per-request settings passed along with a query
+type queryOptions struct {
+	PractitionerID string
+	ForAudio       bool
+	// SessionID selects the patient context the query runs in; "" is the
+	// shared default session
+	SessionID string
+}
+
+// practitionerHeader identifies the practitioner making a request. It takes
+// precedence over a practitioner_id in the request body.
+const practitionerHeader = "X-Practitioner-ID"
+
+func requestPractitionerID(r *http.Request, bodyPractitionerID string) string {
+	if id := strings.TrimSpace(r.Header.Get(practitionerHeader)); id != "" {
+		return id
+	}
+	return strings.TrimSpace(bodyPractitionerID)
+}
+
+// sessionHeader identifies the client's session, so that concurrent clients
+// keep separate patient contexts. It takes precedence over a session_id in
+// the request body.
+const sessionHeader = "X-Session-ID"
+
+func requestSessionID(r *http.Request, bodySessionID string) string {
+	if id := strings.TrimSpace(r.Header.Get(sessionHeader)); id != "" {
+		return id
+	}
+	return strings.TrimSpace(bodySessionID)
+}
+
+func NewHTTPServer(mcpServer *mcp.Server, db *sql.DB) *HTTPServer {
+	h := &HTTPServer{
+		mcpServer: mcpServer,
+		db:        db,
+	}
+	h.queryFunc = h.runQuery
+	h.streamFunc = h.runStreamingQuery
+	return h
+}
+
+// Errors returned by runQuery that map to client error statuses
+var (
+	errQueryRateLimited   = errors.New("too many concurrent queries")
+	errQueryInvalidParams = errors.New("invalid query parameters")
+)
+
+// Handle JSON-RPC requests over HTTP
+func (h *HTTPServer) handleJSONRPC(w http.ResponseWriter, r *http.Request) {
+	debug.Request(r.Method, r.URL.Path, nil)
+	
+	// Set CORS headers for browser access
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+sessionHeader)
+	
+	if r.Method == "OPTIONS" {
+		debug.Response(http.StatusOK, "CORS preflight")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	
+	if r.Method != "POST" {
+		debug.Error("Method not allowed: %s", r.Method)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		debug.Error("Invalid JSON: %v", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	debug.Verbose("HTTP request body: %s", string(request))
+
+	ctx := handlers.WithSession(r.Context(), requestSessionID(r, ""))
+	response, err := h.mcpServer.HandlePayload(ctx, request)
+	if err != nil {
+		debug.Error("Error handling message: %v", err)
+		log.Printf("Error handling message: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if response != nil {
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+		}
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Simple health check endpoint
+func (h *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "healthy",
+		"service": "mcp-server",
+	})
+}
+
+// Natural language query endpoint (REST-style)
+func (h *HTTPServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+practitionerHeader+", "+sessionHeader)
+	
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var queryRequest struct {
+		Query          string `json:"query"`
+		ForAudio       bool   `json:"for_audio"`
+		PractitionerID string `json:"practitioner_id"`
+		SessionID      string `json:"session_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&queryRequest); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if queryRequest.Query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+
+	text, err := h.queryFunc(r.Context(), queryRequest.Query, queryOptions{
+		PractitionerID: requestPractitionerID(r, queryRequest.PractitionerID),
+		ForAudio:       queryRequest.ForAudio,
+		SessionID:      requestSessionID(r, queryRequest.SessionID),
+	})
+	if err != nil {
+		if errors.Is(err, errQueryRateLimited) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if errors.Is(err, errQueryInvalidParams) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error handling query: %v", err)
+		http.Error(w, "Failed to process query", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"response": text,
+	})
+}
+
+// Batch natural language query endpoint. Queries run one at a time against the
+// session's context so later queries can refer to earlier ones (e.g. a patient
+// set by the first query), and a failing query doesn't abort the rest of the
+// batch.
+func (h *HTTPServer) handleQueryBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+practitionerHeader+", "+sessionHeader)
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var batchRequest struct {
+		Queries        []string `json:"queries"`
+		ForAudio       bool     `json:"for_audio"`
+		PractitionerID string   `json:"practitioner_id"`
+		SessionID      string   `json:"session_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&batchRequest); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(batchRequest.Queries) == 0 {
+		http.Error(w, "At least one query is required", http.StatusBadRequest)
+		return
+	}
+
+	type batchResult struct {
+		Query    string `json:"query"`
+		Response string `json:"response,omitempty"`
+		Error    string `json:"error,omitempty"`
+	}
+
+	opts := queryOptions{
+		PractitionerID: requestPractitionerID(r, batchRequest.PractitionerID),
+		ForAudio:       batchRequest.ForAudio,
+		SessionID:      requestSessionID(r, batchRequest.SessionID),
+	}
+
+	results := make([]batchResult, 0, len(batchRequest.Queries))
+	for i, query := range batchRequest.Queries {
+		result := batchResult{Query: query}
+		if query == "" {
+			result.Error = "Query is required"
+		} else if text, err := h.queryFunc(r.Context(), query, opts); err != nil {
+			debug.Error("Batch query %d failed: %v", i, err)
+			result.Error = err.Error()
+		} else {
+			result.Response = text
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+	})
+}
+
+// runQuery sends a natural language query through the MCP server and returns
+// the response text
+func (h *HTTPServer) runQuery(ctx context.Context, query string, opts queryOptions) (string, error) {
+	rpcRequest := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name": "natural_language_query",
+			"arguments": map[string]interface{}{
+				"query":           query,
+				"for_audio":       opts.ForAudio,
+				"practitioner_id": opts.PractitionerID,
+			},
+		},
+		"id": 1,
+	}
+
+	requestBytes, _ := json.Marshal(rpcRequest)
+	response, err := h.mcpServer.HandleMessage(handlers.WithSession(ctx, opts.SessionID), requestBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if response != nil && response.Error != nil {
+		switch response.Error.Code {
+		case mcp.ErrCodeTooManyRequests:
+			return "", fmt.Errorf("%w: %s", errQueryRateLimited, response.Error.Message)
+		case mcp.ErrCodeInvalidParams:
+			return "", fmt.Errorf("%w: %s", errQueryInvalidParams, response.Error.Message)
+		}
+		return "", errors.New(response.Error.Message)
+	}
+
+	// Extract the text from MCP response
+	if response != nil && response.Result != nil {
+		if resultMap, ok := response.Result.(map[string]interface{}); ok {
+			if content, ok := resultMap["content"].([]map[string]interface{}); ok && len(content) > 0 {
+				if text, ok := content[0]["text"].(string); ok {
+					return text, nil
+				}
+			}
+		}
+	}
+
+	return "", errors.New("failed to process query")
+}
+
+func main() {
+	debug.Log("HTTP server starting...")
+	
+	// Get configuration from environment
+	dbPath := os.Getenv("DATABASE_PATH")
+	if dbPath == "" {
+		dbPath = "./database.db"
+	}
+	debug.Log("Using database at: %s", dbPath)
+
+	apiKey := os.Getenv("OPENROUTER_API_KEY")
+	if apiKey == "" {
+		log.Fatal("OPENROUTER_API_KEY environment variable is required")
+	}
+	debug.Verbose("OPENROUTER_API_KEY configured")
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Initialize database
+	dbOptions := database.OptionsFromEnv()
+	db, err := database.InitDBWithOptions(dbPath, dbOptions)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	if dbOptions.ReadOnly {
+		debug.Log("Database opened read-only, skipping migrations")
+		if err := database.CheckMigrated(db); err != nil {
+			log.Fatalf("Cannot use read-only database: %v", err)
+		}
+	} else if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Create MCP server
+	handler := handlers.NewHandler(db, apiKey)
+	defer handler.Close()
+	mcpServer := mcp.NewServer(handler)
+
+	// Create HTTP server
+	httpServer := NewHTTPServer(mcpServer, db)
+
+	// Set up routes. MCP_HTTP_INDEX=false turns off the endpoint list at "/".
+	index := true
+	if value := os.Getenv("MCP_HTTP_INDEX"); value != "" {
+		if index, err = strconv.ParseBool(value); err != nil {
+			log.Fatalf("Invalid MCP_HTTP_INDEX %q: %v", value, err)
+		}
+	}
+	mux := httpServer.routes(index)
+
+	// Start server
+	addr := fmt.Sprintf(":%s", port)
+	log.Printf("MCP HTTP Server starting on %s", addr)
+	httpServer.logEndpoints()
+	
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
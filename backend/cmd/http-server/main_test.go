@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleQueryBatch(t *testing.T) {
+	var seen []string
+	server := &HTTPServer{
+		queryFunc: func(_ context.Context, query string, opts queryOptions) (string, error) {
+			seen = append(seen, query)
+			if strings.Contains(query, "unknown") {
+				return "", errors.New("patient not found: unknown")
+			}
+			return "answer to " + query, nil
+		},
+	}
+
+	body := `{"queries": ["show patient 123", "show patient unknown"]}`
+	req := httptest.NewRequest(http.MethodPost, "/query/batch", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.handleQueryBatch(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Results []struct {
+			Query    string `json:"query"`
+			Response string `json:"response"`
+			Error    string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(resp.Results))
+	}
+	if resp.Results[0].Response != "answer to show patient 123" || resp.Results[0].Error != "" {
+		t.Errorf("Unexpected first result: %+v", resp.Results[0])
+	}
+	if resp.Results[1].Error != "patient not found: unknown" || resp.Results[1].Response != "" {
+		t.Errorf("Unexpected second result: %+v", resp.Results[1])
+	}
+	if len(seen) != 2 || seen[0] != "show patient 123" {
+		t.Errorf("Expected queries to run in order, got %v", seen)
+	}
+}
+
+func TestHandleQueryBatchRequiresQueries(t *testing.T) {
+	server := &HTTPServer{queryFunc: func(context.Context, string, queryOptions) (string, error) { return "", nil }}
+
+	req := httptest.NewRequest(http.MethodPost, "/query/batch", strings.NewReader(`{"queries": []}`))
+	rec := httptest.NewRecorder()
+	server.handleQueryBatch(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestHandleQueryPractitionerHeader(t *testing.T) {
+	var got queryOptions
+	server := &HTTPServer{
+		queryFunc: func(_ context.Context, query string, opts queryOptions) (string, error) {
+			got = opts
+			return "ok", nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "who is next?", "practitioner_id": "from-body"}`))
+	req.Header.Set("X-Practitioner-ID", "prac-123")
+	rec := httptest.NewRecorder()
+	server.handleQuery(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got.PractitionerID != "prac-123" {
+		t.Errorf("Expected header practitioner to reach the handler, got %q", got.PractitionerID)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "who is next?", "practitioner_id": "from-body"}`))
+	rec = httptest.NewRecorder()
+	server.handleQuery(rec, req)
+	if got.PractitionerID != "from-body" {
+		t.Errorf("Expected body practitioner without a header, got %q", got.PractitionerID)
+	}
+}
+
+func TestHandleQueryUnknownPractitioner(t *testing.T) {
+	server := &HTTPServer{
+		queryFunc: func(context.Context, string, queryOptions) (string, error) {
+			return "", fmt.Errorf("%w: practitioner not found: nobody", errQueryInvalidParams)
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "hello"}`))
+	req.Header.Set("X-Practitioner-ID", "nobody")
+	rec := httptest.NewRecorder()
+	server.handleQuery(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestHandleQuerySessionID(t *testing.T) {
+	var got queryOptions
+	server := &HTTPServer{
+		queryFunc: func(_ context.Context, query string, opts queryOptions) (string, error) {
+			got = opts
+			return "ok", nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "hello", "session_id": "from-body"}`))
+	req.Header.Set("X-Session-ID", "tab-1")
+	server.handleQuery(httptest.NewRecorder(), req)
+	if got.SessionID != "tab-1" {
+		t.Errorf("Expected header session to reach the handler, got %q", got.SessionID)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "hello", "session_id": "from-body"}`))
+	server.handleQuery(httptest.NewRecorder(), req)
+	if got.SessionID != "from-body" {
+		t.Errorf("Expected body session without a header, got %q", got.SessionID)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "hello"}`))
+	server.handleQuery(httptest.NewRecorder(), req)
+	if got.SessionID != "" {
+		t.Errorf("Expected the default session, got %q", got.SessionID)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// Reports per-tool call counts and cumulative durations in the Prometheus
+// text format
+func (h *HTTPServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := h.mcpServer.WriteMetrics(w); err != nil {
+		debug.Error("Failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/handlers"
+	"github.com/eythor/mcp-server/internal/mcp"
+)
+
+func TestHandleMetrics(t *testing.T) {
+	server := newExportServer(t, 2)
+	server.mcpServer = mcp.NewServer(handlers.NewHandler(server.db, "test-key"))
+
+	calls := []string{
+		`{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "lookup_patient", "arguments": {"query": "Patient1"}}, "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "lookup_patient", "arguments": {"query": "Patient0"}}, "id": 2}`,
+		`{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "get_context", "arguments": {}}, "id": 3}`,
+		`{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "no_such_tool", "arguments": {}}, "id": 4}`,
+	}
+	for _, call := range calls {
+		req := httptest.NewRequest(http.MethodPost, "/jsonrpc", strings.NewReader(call))
+		server.handleJSONRPC(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE mcp_tool_calls_total counter\n",
+		"mcp_tool_calls_total{tool=\"lookup_patient\"} 2\n",
+		"mcp_tool_calls_total{tool=\"get_context\"} 1\n",
+		"# TYPE mcp_tool_call_duration_seconds_total counter\n",
+		"mcp_tool_call_duration_seconds_total{tool=\"lookup_patient\"} ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "no_such_tool") {
+		t.Errorf("Unknown tools should not be counted, got:\n%s", body)
+	}
+}
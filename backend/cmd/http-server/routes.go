@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// endpoint is one route of the HTTP server, listed in the startup log and
+// the API index
+type endpoint struct {
+	Method      string           `json:"method"`
+	Path        string           `json:"path"`
+	Description string           `json:"description"`
+	handler     http.HandlerFunc `json:"-"`
+}
+
+func (h *HTTPServer) endpoints() []endpoint {
+	return []endpoint{
+		{"POST", "/jsonrpc", "JSON-RPC endpoint", h.handleJSONRPC},
+		{"POST", "/query", "Natural language query endpoint", h.handleQuery},
+		{"POST", "/query/batch", "Batch natural language queries", h.handleQueryBatch},
+		{"POST", "/query/stream", "Natural language query streamed as server-sent events", h.handleQueryStream},
+		{"GET", "/ws", "JSON-RPC over WebSocket", h.handleWebSocket},
+		{"GET", "/export/patients.ndjson", "Stream all patients as NDJSON (?include=observations,conditions)", h.handleExportPatients},
+		{"GET", "/export/audit.csv", "Audit log as CSV (?from=, ?to=)", h.handleExportAudit},
+		{"GET", "/metrics", "Tool call counts and durations (Prometheus text format)", h.handleMetrics},
+		{"GET", "/health", "Health check", h.handleHealth},
+	}
+}
+
+// routes returns the server's mux. Paths that match no endpoint get a 404;
+// with index set, "/" itself lists the endpoints.
+func (h *HTTPServer) routes(index bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, e := range h.endpoints() {
+		mux.HandleFunc(e.Path, e.handler)
+	}
+	if index {
+		// {$} matches "/" only, rather than every unmatched path
+		mux.HandleFunc("/{$}", h.handleIndex)
+	}
+	return mux
+}
+
+// logEndpoints writes the endpoint list to the startup log
+func (h *HTTPServer) logEndpoints() {
+	log.Printf("Endpoints:")
+	for _, e := range h.endpoints() {
+		log.Printf("  %-4s %s - %s", e.Method, e.Path, e.Description)
+	}
+}
+
+// API index listing the endpoints
+func (h *HTTPServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"service":   "mcp-server",
+		"endpoints": h.endpoints(),
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	server := &HTTPServer{}
+
+	get := func(mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	mux := server.routes(true)
+	for _, path := range []string{"/nope", "/health/extra", "/jsonrpc/x"} {
+		if rec := get(mux, path); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := get(mux, "/health")
+	var health map[string]string
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &health) != nil || health["status"] != "healthy" {
+		t.Errorf("Expected a health response, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get(mux, "/")
+	var index struct {
+		Endpoints []endpoint `json:"endpoints"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &index) != nil {
+		t.Fatalf("Expected the API index, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(index.Endpoints) != len(server.endpoints()) || index.Endpoints[0].Path != "/jsonrpc" {
+		t.Errorf("Expected the index to list every endpoint, got %+v", index.Endpoints)
+	}
+
+	if rec := get(server.routes(false), "/"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for / without the index, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/debug"
+	"github.com/eythor/mcp-server/internal/handlers"
+)
+
+// acceptsEventStream reports whether the client asked for server-sent events
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.EqualFold(strings.TrimSpace(mediaType), "text/event-stream") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// eventWriter writes server-sent events, sending the response headers with
+// the first event so errors before any output can still use a status code
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+}
+
+func (e *eventWriter) send(event string, data interface{}) {
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.Header().Set("X-Accel-Buffering", "no")
+		e.started = true
+	}
+	payload, _ := json.Marshal(data)
+	fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, payload)
+	e.flusher.Flush()
+}
+
+// Streaming natural language query endpoint. The answer is sent as "token"
+// events while the model writes it, then a "done" event with the whole
+// response, or an "error" event if the query fails part way. Clients that
+// don't accept text/event-stream get the buffered /query response.
+func (h *HTTPServer) handleQueryStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+practitionerHeader+", "+sessionHeader)
+
+	flusher, canFlush := w.(http.Flusher)
+	if r.Method == "OPTIONS" || !acceptsEventStream(r) || !canFlush {
+		h.handleQuery(w, r)
+		return
+	}
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var queryRequest struct {
+		Query          string `json:"query"`
+		ForAudio       bool   `json:"for_audio"`
+		PractitionerID string `json:"practitioner_id"`
+		SessionID      string `json:"session_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&queryRequest); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if queryRequest.Query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+
+	events := &eventWriter{w: w, flusher: flusher}
+	text, err := h.streamFunc(r.Context(), queryRequest.Query, queryOptions{
+		PractitionerID: requestPractitionerID(r, queryRequest.PractitionerID),
+		ForAudio:       queryRequest.ForAudio,
+		SessionID:      requestSessionID(r, queryRequest.SessionID),
+	}, func(token string) {
+		events.send("token", map[string]string{"text": token})
+	})
+	if err != nil {
+		log.Printf("Error handling streamed query: %v", err)
+		if events.started {
+			events.send("error", map[string]string{"error": "Failed to process query"})
+			return
+		}
+		switch {
+		case errors.Is(err, errQueryRateLimited):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case errors.Is(err, errQueryInvalidParams):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to process query", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	events.send("done", map[string]string{"response": text})
+}
+
+// runStreamingQuery answers a query with the handler directly, since
+// JSON-RPC responses can't be streamed
+func (h *HTTPServer) runStreamingQuery(ctx context.Context, query string, opts queryOptions, onToken func(string)) (string, error) {
+	debug.Verbose("Streaming query: '%s'", query)
+	text, err := h.mcpServer.Handler().Session(opts.SessionID).StreamNaturalLanguageQuery(ctx, query, opts.PractitionerID, opts.ForAudio, onToken)
+	switch {
+	case errors.Is(err, handlers.ErrTooManyQueries):
+		return "", fmt.Errorf("%w: %v", errQueryRateLimited, err)
+	case errors.Is(err, handlers.ErrUnknownPractitioner):
+		return "", fmt.Errorf("%w: %v", errQueryInvalidParams, err)
+	}
+	return text, err
+}
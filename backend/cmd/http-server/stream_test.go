@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleQueryStream(t *testing.T) {
+	server := &HTTPServer{
+		streamFunc: func(_ context.Context, query string, opts queryOptions, onToken func(string)) (string, error) {
+			onToken("Kari is ")
+			onToken("39.")
+			return "Kari is 39.", nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query/stream", strings.NewReader(`{"query": "how old is Kari?"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	server.handleQueryStream(rec, req)
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	want := "event: token\ndata: {\"text\":\"Kari is \"}\n\n" +
+		"event: token\ndata: {\"text\":\"39.\"}\n\n" +
+		"event: done\ndata: {\"response\":\"Kari is 39.\"}\n\n"
+	if rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestHandleQueryStreamFallsBackToBuffered(t *testing.T) {
+	server := &HTTPServer{
+		queryFunc: func(context.Context, string, queryOptions) (string, error) { return "buffered answer", nil },
+		streamFunc: func(context.Context, string, queryOptions, func(string)) (string, error) {
+			t.Error("streamFunc called for a client that doesn't accept event-stream")
+			return "", nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query/stream", strings.NewReader(`{"query": "hello"}`))
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	server.handleQueryStream(rec, req)
+
+	var resp map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode buffered response %q: %v", rec.Body.String(), err)
+	}
+	if resp["response"] != "buffered answer" {
+		t.Errorf("response = %q, want buffered answer", resp["response"])
+	}
+}
+
+func TestHandleQueryStreamErrors(t *testing.T) {
+	server := &HTTPServer{
+		streamFunc: func(_ context.Context, query string, opts queryOptions, onToken func(string)) (string, error) {
+			if query == "partial" {
+				onToken("Kari ")
+				return "", fmt.Errorf("model timed out")
+			}
+			return "", fmt.Errorf("%w: practitioner not found: nobody", errQueryInvalidParams)
+		},
+	}
+
+	stream := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/query/stream", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Accept", "text/event-stream")
+		rec := httptest.NewRecorder()
+		server.handleQueryStream(rec, req)
+		return rec
+	}
+
+	// Before any output the error still gets a status code
+	if rec := stream("hello"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+
+	rec := stream("partial")
+	if rec.Code != http.StatusOK || !strings.HasSuffix(rec.Body.String(), "event: error\ndata: {\"error\":\"Failed to process query\"}\n\n") {
+		t.Errorf("expected an error event after the token, got %d %q", rec.Code, rec.Body.String())
+	}
+}
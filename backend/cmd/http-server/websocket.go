@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+	"github.com/eythor/mcp-server/internal/handlers"
+	"github.com/eythor/mcp-server/internal/mcp"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket keepalive and limits. The server pings every wsPingInterval and
+// drops the connection when nothing, not even a pong, arrives for wsPongWait.
+const (
+	wsWriteWait      = 10 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsPingInterval   = wsPongWait * 9 / 10
+	wsMaxMessageSize = 1 << 20
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// Like the HTTP endpoints, which allow any origin via CORS
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// WebSocket endpoint for MCP clients that keep a persistent connection. Each
+// text frame holds a JSON-RPC request or batch, handled exactly like a POST
+// to /jsonrpc, and the response is written back as a text frame; nothing is
+// sent for notifications such as "initialized". Requests on one connection
+// are answered in order. Browsers can't set headers on a WebSocket, so the
+// session can also be given as ?session_id=.
+func (h *HTTPServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	debug.Request(r.Method, r.URL.Path, nil)
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an error status
+		debug.Error("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Cancel in-flight tool calls when the client goes away
+	ctx, cancel := context.WithCancel(handlers.WithSession(r.Context(), requestSessionID(r, r.URL.Query().Get("session_id"))))
+	defer cancel()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+					debug.Verbose("WebSocket ping failed: %v", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	debug.Log("WebSocket client connected from %s", r.RemoteAddr)
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket connection closed: %v", err)
+			}
+			debug.Log("WebSocket client %s disconnected", r.RemoteAddr)
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		debug.Verbose("WebSocket message: %s", string(message))
+
+		var response interface{}
+		response, err = h.mcpServer.HandlePayload(ctx, message)
+		if err != nil {
+			debug.Error("Error handling message: %v", err)
+			response = &mcp.JSONRPCResponse{
+				JSONRPC: "2.0",
+				Error: &mcp.Error{
+					Code:    mcp.ErrCodeParseError,
+					Message: "Parse error: " + err.Error(),
+				},
+			}
+		}
+		if response == nil {
+			continue
+		}
+
+		payload, err := json.Marshal(response)
+		if err != nil {
+			log.Printf("Error encoding response: %v", err)
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+			log.Printf("Error writing WebSocket response: %v", err)
+			return
+		}
+	}
+}
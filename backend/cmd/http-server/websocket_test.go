@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/handlers"
+	"github.com/eythor/mcp-server/internal/mcp"
+	"github.com/gorilla/websocket"
+)
+
+func TestHandleWebSocket(t *testing.T) {
+	server := newExportServer(t, 2)
+	server.mcpServer = mcp.NewServer(handlers.NewHandler(server.db, "test-key"))
+	ts := httptest.NewServer(http.HandlerFunc(server.handleWebSocket))
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	pong := make(chan struct{}, 1)
+	conn.SetPongHandler(func(string) error {
+		pong <- struct{}{}
+		return nil
+	})
+	if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	read := func() mcp.JSONRPCResponse {
+		t.Helper()
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		var response mcp.JSONRPCResponse
+		if err := json.Unmarshal(message, &response); err != nil {
+			t.Fatalf("Invalid response %s: %v", message, err)
+		}
+		return response
+	}
+
+	for _, message := range []string{
+		`{"jsonrpc": "2.0", "method": "initialize", "params": {}, "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "initialized"}`,
+		`{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "lookup_patient", "arguments": {"query": "Patient1"}}, "id": 2}`,
+		`not json`,
+	} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if response := read(); response.ID != float64(1) || response.Error != nil {
+		t.Errorf("Expected the initialize response, got %+v", response)
+	}
+	// No response to "initialized", so the next one answers the tool call
+	response := read()
+	if response.ID != float64(2) || response.Error != nil {
+		t.Fatalf("Expected the tools/call response, got %+v", response)
+	}
+	if result, _ := json.Marshal(response.Result); !strings.Contains(string(result), "p01") {
+		t.Errorf("Expected the lookup to find p01, got %s", result)
+	}
+	if response := read(); response.Error == nil || response.Error.Code != mcp.ErrCodeParseError {
+		t.Errorf("Expected a parse error, got %+v", response)
+	}
+
+	select {
+	case <-pong:
+	default:
+		t.Error("Expected a pong in reply to the ping")
+	}
+
+	if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected the server to close the connection, got %v", err)
+	}
+}
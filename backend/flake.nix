@@ -28,6 +28,7 @@
           ldflags = [
             "-s"
             "-w"
+            "-X github.com/eythor/mcp-server/internal/mcp.Version=${version}"
           ];
         };
       in
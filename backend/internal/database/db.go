@@ -3,14 +3,62 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/eythor/mcp-server/internal/debug"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Options controls how InitDBWithOptions opens the database
+type Options struct {
+	// ReadOnly opens the database with mode=ro; the file must already exist
+	ReadOnly bool
+	// CreateDir creates the database's parent directory if it is missing
+	CreateDir bool
+}
+
+// OptionsFromEnv reads MCP_DB_READONLY and MCP_DB_CREATE_DIR
+func OptionsFromEnv() Options {
+	return Options{
+		ReadOnly:  envBool("MCP_DB_READONLY"),
+		CreateDir: envBool("MCP_DB_CREATE_DIR"),
+	}
+}
+
+func envBool(name string) bool {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		debug.Error("Invalid value for %s: %q, using false", name, value)
+		return false
+	}
+	return b
+}
+
 func InitDB(dbPath string) (*sql.DB, error) {
+	return InitDBWithOptions(dbPath, Options{})
+}
+
+func InitDBWithOptions(dbPath string, opts Options) (*sql.DB, error) {
+	if err := validateDBPath(dbPath, opts); err != nil {
+		return nil, err
+	}
+
 	// Use _busy_timeout and _journal_mode for better SQLite concurrency handling
-	db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000&_journal_mode=WAL")
+	dsn := dbPath + "?_busy_timeout=5000&_journal_mode=WAL"
+	if opts.ReadOnly {
+		// Changing the journal mode needs write access, so leave it as is
+		dsn = "file:" + dbPath + "?mode=ro&_busy_timeout=5000"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -22,12 +70,74 @@ func InitDB(dbPath string) (*sql.DB, error) {
 	db.SetConnMaxLifetime(time.Hour)
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	return db, nil
 }
 
+// validateDBPath checks that dbPath can be opened with opts, so a bad
+// DATABASE_PATH fails at startup with a clear message instead of on the first
+// query. In-memory and URI paths are passed through to SQLite unchecked.
+func validateDBPath(dbPath string, opts Options) error {
+	if dbPath == "" {
+		return fmt.Errorf("database path is empty")
+	}
+	if dbPath == ":memory:" || strings.HasPrefix(dbPath, "file:") {
+		return nil
+	}
+
+	info, err := os.Stat(dbPath)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("database path %s is a directory, not a file", dbPath)
+	case err != nil && !os.IsNotExist(err):
+		return fmt.Errorf("cannot access database path %s: %w", dbPath, err)
+	case err != nil && opts.ReadOnly:
+		return fmt.Errorf("database file %s does not exist (required when MCP_DB_READONLY is set)", dbPath)
+	}
+	exists := err == nil
+
+	dir := filepath.Dir(dbPath)
+	dirInfo, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		if !opts.CreateDir {
+			return fmt.Errorf("database directory %s does not exist (create it or set MCP_DB_CREATE_DIR=true)", dir)
+		}
+		debug.Log("Creating database directory: %s", dir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create database directory %s: %w", dir, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("cannot access database directory %s: %w", dir, err)
+	} else if !dirInfo.IsDir() {
+		return fmt.Errorf("database directory %s is not a directory", dir)
+	}
+
+	if opts.ReadOnly {
+		return nil
+	}
+
+	// SQLite needs to write the database file and, in WAL mode, create
+	// journal files next to it
+	if exists {
+		f, err := os.OpenFile(dbPath, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("database file %s is not writable (set MCP_DB_READONLY=true for read-only access): %w", dbPath, err)
+		}
+		f.Close()
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("database directory %s is not writable: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	return nil
+}
+
 type Patient struct {
 	ID         string  `json:"id"`
 	GivenName  string  `json:"given_name"`
@@ -37,6 +147,30 @@ type Patient struct {
 	Phone      *string `json:"phone,omitempty"`
 	City       *string `json:"city,omitempty"`
 	State      *string `json:"state,omitempty"`
+
+	// Contacts holds additional contact points from patient_contacts. It is
+	// only populated by callers that load them explicitly.
+	Contacts []ContactPoint `json:"contacts,omitempty"`
+}
+
+// ContactPoint is a phone number, email address, etc. for a patient
+type ContactPoint struct {
+	ID              string  `json:"id"`
+	PatientID       string  `json:"patient_id"`
+	System          string  `json:"system"`
+	Use             *string `json:"use,omitempty"`
+	Value           string  `json:"value"`
+	CreatedDateTime *string `json:"created_datetime,omitempty"`
+}
+
+// Consent is a patient's decision about one use of their data (Scope), such
+// as sharing it with the AI model. Recording a new decision replaces the old.
+type Consent struct {
+	PatientID        string  `json:"patient_id"`
+	Scope            string  `json:"scope"`
+	Status           string  `json:"status"`
+	RecordedBy       *string `json:"recorded_by,omitempty"`
+	RecordedDateTime string  `json:"recorded_datetime"`
 }
 
 type Encounter struct {
@@ -48,6 +182,10 @@ type Encounter struct {
 	PractitionerID *string `json:"practitioner_id,omitempty"`
 	StartDateTime  string  `json:"start_datetime"`
 	EndDateTime    *string `json:"end_datetime,omitempty"`
+	// ActualStartDateTime is when the visit actually started (patient arrival)
+	ActualStartDateTime *string `json:"actual_start_datetime,omitempty"`
+	// CancellationReason is why a cancelled encounter was called off
+	CancellationReason *string `json:"cancellation_reason,omitempty"`
 }
 
 type Condition struct {
@@ -66,11 +204,14 @@ type MedicationRequest struct {
 	PatientID         string  `json:"patient_id"`
 	AuthoredOn        string  `json:"authored_on"`
 	DosageText        *string `json:"dosage_text,omitempty"`
+	MedicationCode    *string `json:"medication_code,omitempty"`
+	RequesterID       *string `json:"requester_id,omitempty"`
 }
 
 type Procedure struct {
 	ID                string  `json:"id"`
 	Status            string  `json:"status"`
+	Code              *string `json:"code,omitempty"`
 	Display           string  `json:"display"`
 	PatientID         string  `json:"patient_id"`
 	PerformedDateTime *string `json:"performed_datetime,omitempty"`
@@ -117,6 +258,25 @@ type Practitioner struct {
 	PostalCode  *string `json:"postal_code,omitempty"`
 }
 
+// CareTeamMember is a practitioner a patient has had encounters with.
+// PractitionerID is nil for encounters without a recorded practitioner.
+type CareTeamMember struct {
+	PractitionerID *string `json:"practitioner_id,omitempty"`
+	GivenName      string  `json:"given_name,omitempty"`
+	FamilyName     string  `json:"family_name,omitempty"`
+	EncounterCount int     `json:"encounter_count"`
+	LastEncounter  string  `json:"last_encounter,omitempty"`
+}
+
+// PatientOnMedication is a patient with active prescriptions matching a
+// medication search. Medications lists the matching medication names.
+type PatientOnMedication struct {
+	PatientID   string `json:"patient_id"`
+	GivenName   string `json:"given_name"`
+	FamilyName  string `json:"family_name"`
+	Medications string `json:"medications"`
+}
+
 type Claim struct {
 	ID                  string   `json:"id"`
 	Status              string   `json:"status"`
@@ -131,3 +291,14 @@ type Claim struct {
 	TotalAmount         *float64 `json:"total_amount,omitempty"`
 	Currency            *string  `json:"currency,omitempty"`
 }
+
+// AuditEntry records a change made through a write tool. Timestamp is UTC
+// RFC 3339.
+type AuditEntry struct {
+	ID             int64  `json:"id"`
+	Timestamp      string `json:"timestamp"`
+	Action         string `json:"action"`
+	EntityID       string `json:"entity_id,omitempty"`
+	PractitionerID string `json:"practitioner_id,omitempty"`
+	Details        string `json:"details,omitempty"`
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitDBMissingDirectory(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "missing", "test.db")
+
+	_, err := InitDBWithOptions(dbPath, Options{})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("Expected missing directory error, got %v", err)
+	}
+
+	db, err := InitDBWithOptions(dbPath, Options{CreateDir: true})
+	if err != nil {
+		t.Fatalf("Expected CreateDir to create the directory, got %v", err)
+	}
+	db.Close()
+
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("Expected database file to be created: %v", err)
+	}
+}
+
+func TestInitDBPathIsDirectory(t *testing.T) {
+	_, err := InitDBWithOptions(t.TempDir(), Options{})
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Fatalf("Expected directory error, got %v", err)
+	}
+}
+
+func TestInitDBReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	if _, err := InitDBWithOptions(dbPath, Options{ReadOnly: true}); err == nil {
+		t.Fatal("Expected read-only open of a missing file to fail")
+	}
+
+	db, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE patients (id TEXT PRIMARY KEY); INSERT INTO patients (id) VALUES ('p1')`); err != nil {
+		t.Fatalf("Failed to seed database: %v", err)
+	}
+	db.Close()
+
+	ro, err := InitDBWithOptions(dbPath, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to open database read-only: %v", err)
+	}
+	defer ro.Close()
+
+	var count int
+	if err := ro.QueryRow(`SELECT COUNT(*) FROM patients`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected to read 1 patient, got %d (err: %v)", count, err)
+	}
+	if _, err := ro.Exec(`INSERT INTO patients (id) VALUES ('p2')`); err == nil {
+		t.Error("Expected write to fail on a read-only database")
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("MCP_DB_READONLY", "true")
+	t.Setenv("MCP_DB_CREATE_DIR", "")
+
+	opts := OptionsFromEnv()
+	if !opts.ReadOnly || opts.CreateDir {
+		t.Errorf("Unexpected options from environment: %+v", opts)
+	}
+}
+
+func TestMigrateAddsOutcomeColumn(t *testing.T) {
+	db, err := InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// An encounters table from before the outcome column existed
+	if _, err := db.Exec(`CREATE TABLE encounters (id TEXT PRIMARY KEY, status TEXT, end_datetime DATETIME)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Migrations must be safe to run repeatedly
+	for i := 0; i < 2; i++ {
+		if err := Migrate(db); err != nil {
+			t.Fatalf("Migrate run %d failed: %v", i+1, err)
+		}
+	}
+
+	if _, err := db.Exec(`INSERT INTO encounters (id, status, outcome, actual_start_datetime, cancellation_reason) VALUES ('e1', 'finished', 'ok', '2024-01-01T09:05:00Z', NULL)`); err != nil {
+		t.Errorf("Expected outcome, actual_start_datetime and cancellation_reason columns after migration: %v", err)
+	}
+}
+
+func TestCheckMigratedReadOnlyOldSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	db, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	// encounters as created by schema.sql before the outcome, actual start
+	// and cancellation reason columns
+	if _, err := db.Exec(`CREATE TABLE encounters (id TEXT PRIMARY KEY, status TEXT, class TEXT, type_display TEXT,
+		patient_id TEXT, practitioner_id TEXT, start_datetime DATETIME, end_datetime DATETIME)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	db.Close()
+
+	ro, err := InitDBWithOptions(dbPath, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to open database read-only: %v", err)
+	}
+	err = CheckMigrated(ro)
+	ro.Close()
+	if err == nil || !strings.Contains(err.Error(), "encounters.actual_start_datetime") || !strings.Contains(err.Error(), "encounters.cancellation_reason") {
+		t.Fatalf("Expected the missing encounter columns to be reported, got %v", err)
+	}
+
+	db, err = InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	db.Close()
+
+	ro, err = InitDBWithOptions(dbPath, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to open database read-only: %v", err)
+	}
+	defer ro.Close()
+	if err := CheckMigrated(ro); err != nil {
+		t.Errorf("Expected a migrated database to pass, got %v", err)
+	}
+	if _, err := GetEncountersByPatientID(ro, "p1"); err != nil {
+		t.Errorf("Expected encounter queries to work after migration: %v", err)
+	}
+}
+
+func TestCheckMigratedReportsMissingTables(t *testing.T) {
+	db, err := InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Encounter columns are up to date, but none of the added tables exist
+	if _, err := db.Exec(`CREATE TABLE encounters (id TEXT PRIMARY KEY, outcome TEXT, actual_start_datetime DATETIME, cancellation_reason TEXT)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	err = CheckMigrated(db)
+	if err == nil {
+		t.Fatal("Expected missing tables to be reported")
+	}
+	for _, table := range []string{"patient_contacts", "audit_log", "id_sequences", "consents"} {
+		if !strings.Contains(err.Error(), "create "+table) {
+			t.Errorf("Expected %s to be reported, got %v", table, err)
+		}
+	}
+	if strings.Contains(err.Error(), "encounters.") {
+		t.Errorf("Expected no encounter columns to be reported, got %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if err := CheckMigrated(db); err != nil {
+		t.Errorf("Expected a migrated database to pass, got %v", err)
+	}
+}
+
+func TestGetObservationsOrderedByInstant(t *testing.T) {
+	db, err := InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// o1 is 14:53 UTC, the latest, but sorts before o2 (12:00 UTC) as text
+	if _, err := db.Exec(`CREATE TABLE observations (id TEXT PRIMARY KEY, resource_type TEXT, status TEXT, category TEXT,
+			code TEXT, display TEXT, patient_id TEXT, effective_datetime TEXT, value_quantity REAL, value_unit TEXT, value_string TEXT);
+		INSERT INTO observations (id, status, category, code, display, patient_id, effective_datetime) VALUES
+			('o1', 'final', 'vital-signs', '8867-4', 'Heart rate', 'p1', '2015-03-12T10:53:47-04:00'),
+			('o2', 'final', 'vital-signs', '8867-4', 'Heart rate', 'p1', '2015-03-12T12:00:00Z'),
+			('o3', 'final', 'vital-signs', '8867-4', 'Heart rate', 'p1', NULL)`); err != nil {
+		t.Fatalf("Failed to seed observations: %v", err)
+	}
+	// Stored as 13:30 UTC
+	effective := "2015-03-12T15:30:00+02:00"
+	if err := CreateObservation(db, &Observation{ID: "o4", Status: "final", Category: "vital-signs", Code: "8867-4",
+		Display: "Heart rate", PatientID: "p1", EffectiveDateTime: &effective}); err != nil {
+		t.Fatalf("CreateObservation failed: %v", err)
+	}
+
+	observations, err := GetObservationsByPatientID(db, "p1")
+	if err != nil {
+		t.Fatalf("GetObservationsByPatientID failed: %v", err)
+	}
+	var got []string
+	for _, o := range observations {
+		got = append(got, o.ID)
+	}
+	if want := "o1 o4 o2 o3"; strings.Join(got, " ") != want {
+		t.Errorf("order = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// migration upgrades databases created from an older schema.sql. Every step
+// must be idempotent because Migrate runs on each startup; fresh databases
+// already get the same objects from schema.sql.
+type migration struct {
+	name  string
+	apply func(db *sql.DB) error
+	// applied reports whether the database already has what apply adds.
+	// CheckMigrated uses it for databases that can't be migrated.
+	applied func(db *sql.DB) (bool, error)
+}
+
+var migrations = []migration{
+	{
+		name: "create patient_contacts",
+		apply: func(db *sql.DB) error {
+			_, err := db.Exec(`
+				CREATE TABLE IF NOT EXISTS patient_contacts (
+				    id TEXT PRIMARY KEY,
+				    patient_id TEXT NOT NULL,
+				    system TEXT NOT NULL,
+				    use TEXT,
+				    value TEXT NOT NULL,
+				    created_datetime DATETIME,
+				    FOREIGN KEY (patient_id) REFERENCES patients(id)
+				);
+				CREATE INDEX IF NOT EXISTS idx_patient_contacts_patient ON patient_contacts(patient_id);
+			`)
+			return err
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasTable(db, "patient_contacts")
+		},
+	},
+	{
+		name: "add encounters.outcome",
+		apply: func(db *sql.DB) error {
+			return addColumnIfMissing(db, "encounters", "outcome", "TEXT")
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasColumn(db, "encounters", "outcome")
+		},
+	},
+	{
+		name: "add encounters.actual_start_datetime",
+		apply: func(db *sql.DB) error {
+			return addColumnIfMissing(db, "encounters", "actual_start_datetime", "DATETIME")
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasColumn(db, "encounters", "actual_start_datetime")
+		},
+	},
+	{
+		name: "add encounters.cancellation_reason",
+		apply: func(db *sql.DB) error {
+			return addColumnIfMissing(db, "encounters", "cancellation_reason", "TEXT")
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasColumn(db, "encounters", "cancellation_reason")
+		},
+	},
+	{
+		name: "create audit_log",
+		apply: func(db *sql.DB) error {
+			_, err := db.Exec(`
+				CREATE TABLE IF NOT EXISTS audit_log (
+				    id INTEGER PRIMARY KEY AUTOINCREMENT,
+				    timestamp TEXT NOT NULL,
+				    action TEXT NOT NULL,
+				    entity_id TEXT,
+				    practitioner_id TEXT,
+				    details TEXT
+				);
+				CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+			`)
+			return err
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasTable(db, "audit_log")
+		},
+	},
+	{
+		name: "create id_sequences",
+		apply: func(db *sql.DB) error {
+			_, err := db.Exec(`
+				CREATE TABLE IF NOT EXISTS id_sequences (
+				    name TEXT PRIMARY KEY,
+				    value INTEGER NOT NULL
+				)
+			`)
+			return err
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasTable(db, "id_sequences")
+		},
+	},
+	{
+		name: "create consents",
+		apply: func(db *sql.DB) error {
+			_, err := db.Exec(`
+				CREATE TABLE IF NOT EXISTS consents (
+				    patient_id TEXT NOT NULL,
+				    scope TEXT NOT NULL,
+				    status TEXT NOT NULL,
+				    recorded_by TEXT,
+				    recorded_datetime TEXT NOT NULL,
+				    PRIMARY KEY (patient_id, scope),
+				    FOREIGN KEY (patient_id) REFERENCES patients(id)
+				)
+			`)
+			return err
+		},
+		applied: func(db *sql.DB) (bool, error) {
+			return hasTable(db, "consents")
+		},
+	},
+}
+
+// tableColumns returns the names of table's columns, or none if the table
+// doesn't exist
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// hasTable reports whether table exists
+func hasTable(db *sql.DB, table string) (bool, error) {
+	columns, err := tableColumns(db, table)
+	return len(columns) > 0, err
+}
+
+// hasColumn reports whether table has column
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	columns, err := tableColumns(db, table)
+	return columns[column], err
+}
+
+// addColumnIfMissing adds column to table unless it already exists. Tables that
+// don't exist yet are left alone; schema.sql creates them with the column.
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	columns, err := tableColumns(db, table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 || columns[column] {
+		return nil
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// Migrate applies all migrations in order
+func Migrate(db *sql.DB) error {
+	for _, m := range migrations {
+		debug.Verbose("Applying migration: %s", m.name)
+		if err := m.apply(db); err != nil {
+			return fmt.Errorf("migration %q failed: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// CheckMigrated returns an error listing the migrations the database still
+// needs. Databases opened read-only can't be migrated, so this lets startup
+// fail with a clear message instead of queries failing on missing tables or
+// columns.
+func CheckMigrated(db *sql.DB) error {
+	var pending []string
+	for _, m := range migrations {
+		ok, err := m.applied(db)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %q: %w", m.name, err)
+		}
+		if !ok {
+			pending = append(pending, m.name)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is out of date (pending migrations: %s); open it once without MCP_DB_READONLY to migrate it", strings.Join(pending, ", "))
+	}
+	return nil
+}
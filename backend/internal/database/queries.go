@@ -3,7 +3,9 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/eythor/mcp-server/internal/debug"
 )
@@ -42,6 +44,36 @@ func GetPatientByID(db *sql.DB, id string) (*Patient, error) {
 	return &patient, nil
 }
 
+// GetPatientsAfter returns up to limit patients with an ID greater than
+// afterID, ordered by ID. Passing the last ID of one page as afterID of the
+// next walks the whole table without holding a query open between pages.
+func GetPatientsAfter(db *sql.DB, afterID string, limit int) ([]Patient, error) {
+	rows, err := db.Query(`
+		SELECT id, COALESCE(given_name, ''), COALESCE(family_name, ''), COALESCE(gender, ''),
+		       birth_date, phone, city, state
+		FROM patients
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patients []Patient
+	for rows.Next() {
+		var p Patient
+		var birthDate sql.NullString
+		if err := rows.Scan(&p.ID, &p.GivenName, &p.FamilyName, &p.Gender, &birthDate, &p.Phone, &p.City, &p.State); err != nil {
+			return nil, err
+		}
+		p.BirthDate = birthDate.String
+		patients = append(patients, p)
+	}
+	return patients, rows.Err()
+}
+
 func SearchPatientsByName(db *sql.DB, query string) ([]Patient, error) {
 	debug.Verbose("SearchPatientsByName called with query: '%s'", query)
 	query = strings.TrimSpace(query)
@@ -174,10 +206,66 @@ func SearchPatientsByName(db *sql.DB, query string) ([]Patient, error) {
 		patients = append(patients, p)
 	}
 
+	rankPatientMatches(patients, extractedName)
+
 	debug.Verbose("SearchPatientsByName found %d patients", len(patients))
 	return patients, nil
 }
 
+// Relevance tiers for rankPatientMatches; higher ranks first
+const (
+	matchOther     = iota // matched on individual words only
+	matchSubstring        // name contains the query
+	matchPrefix           // name starts with the query
+	matchExact            // full, given or family name equals the query
+)
+
+// patientMatchScore rates how well a patient's name matches query
+func patientMatchScore(p Patient, query string) int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	names := []string{
+		strings.ToLower(strings.TrimSpace(p.GivenName + " " + p.FamilyName)),
+		strings.ToLower(p.GivenName),
+		strings.ToLower(p.FamilyName),
+	}
+
+	score := matchOther
+	for _, name := range names {
+		switch {
+		case name == query:
+			return matchExact
+		case strings.HasPrefix(name, query):
+			score = max(score, matchPrefix)
+		case strings.Contains(name, query):
+			score = max(score, matchSubstring)
+		}
+	}
+	return score
+}
+
+// rankPatientMatches sorts search results by relevance to query: exact name
+// matches, then prefix, then substring matches. Ties are ordered by family
+// name, given name and ID so the output is stable.
+func rankPatientMatches(patients []Patient, query string) {
+	scores := make(map[string]int, len(patients))
+	for _, p := range patients {
+		scores[p.ID] = patientMatchScore(p, query)
+	}
+	sort.SliceStable(patients, func(i, j int) bool {
+		a, b := patients[i], patients[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+		if fa, fb := strings.ToLower(a.FamilyName), strings.ToLower(b.FamilyName); fa != fb {
+			return fa < fb
+		}
+		if ga, gb := strings.ToLower(a.GivenName), strings.ToLower(b.GivenName); ga != gb {
+			return ga < gb
+		}
+		return a.ID < b.ID
+	})
+}
+
 func CheckPatientExists(db *sql.DB, id string) (bool, error) {
 	var exists bool
 	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM patients WHERE id = ?)", id).Scan(&exists)
@@ -189,11 +277,86 @@ func UpdatePatientBirthDate(db *sql.DB, patientID, birthDate string) error {
 	return err
 }
 
+// CreatePatient inserts a new patient. An empty birth date is stored as NULL.
+func CreatePatient(db *sql.DB, patient *Patient) error {
+	var birthDate interface{}
+	if patient.BirthDate != "" {
+		birthDate = patient.BirthDate
+	}
+	_, err := db.Exec(`
+		INSERT INTO patients (id, resource_type, given_name, family_name, gender, birth_date, phone, city, state)
+		VALUES (?, 'Patient', ?, ?, ?, ?, ?, ?, ?)
+	`, patient.ID, patient.GivenName, patient.FamilyName, patient.Gender, birthDate,
+		patient.Phone, patient.City, patient.State)
+	return err
+}
+
 func UpdatePatientName(db *sql.DB, patientID, givenName, familyName string) error {
 	_, err := db.Exec("UPDATE patients SET given_name = ?, family_name = ? WHERE id = ?", givenName, familyName, patientID)
 	return err
 }
 
+func CreateContactPoint(db *sql.DB, contact *ContactPoint) error {
+	_, err := db.Exec(`
+		INSERT INTO patient_contacts (id, patient_id, system, use, value, created_datetime)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, contact.ID, contact.PatientID, contact.System, contact.Use, contact.Value, contact.CreatedDateTime)
+	return err
+}
+
+func GetContactPointsByPatientID(db *sql.DB, patientID string) ([]ContactPoint, error) {
+	rows, err := db.Query(`
+		SELECT id, patient_id, system, use, value, created_datetime
+		FROM patient_contacts
+		WHERE patient_id = ?
+		ORDER BY created_datetime ASC
+	`, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []ContactPoint
+	for rows.Next() {
+		var c ContactPoint
+		err := rows.Scan(&c.ID, &c.PatientID, &c.System, &c.Use, &c.Value, &c.CreatedDateTime)
+		if err != nil {
+			continue
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, nil
+}
+
+// SetConsent records a patient's consent decision for a scope, replacing any
+// earlier decision
+func SetConsent(db *sql.DB, consent *Consent) error {
+	_, err := db.Exec(`
+		INSERT INTO consents (patient_id, scope, status, recorded_by, recorded_datetime)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(patient_id, scope) DO UPDATE SET
+		    status = excluded.status,
+		    recorded_by = excluded.recorded_by,
+		    recorded_datetime = excluded.recorded_datetime
+	`, consent.PatientID, consent.Scope, consent.Status, consent.RecordedBy, consent.RecordedDateTime)
+	return err
+}
+
+// GetConsent returns the patient's consent decision for a scope, or
+// sql.ErrNoRows if none was recorded
+func GetConsent(db *sql.DB, patientID, scope string) (*Consent, error) {
+	var c Consent
+	err := db.QueryRow(`
+		SELECT patient_id, scope, status, recorded_by, recorded_datetime
+		FROM consents
+		WHERE patient_id = ? AND scope = ?
+	`, patientID, scope).Scan(&c.PatientID, &c.Scope, &c.Status, &c.RecordedBy, &c.RecordedDateTime)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 func CheckPractitionerExists(db *sql.DB, id string) (bool, error) {
 	var exists bool
 	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM practitioners WHERE id = ?)", id).Scan(&exists)
@@ -257,6 +420,43 @@ func UpdateEncounterStatus(db *sql.DB, encounterID, status string) error {
 	return err
 }
 
+// CancelEncounter sets an encounter's status to cancelled with an optional
+// reason; an empty reason is stored as NULL
+func CancelEncounter(db *sql.DB, encounterID, reason string) error {
+	var cancellationReason interface{}
+	if reason != "" {
+		cancellationReason = reason
+	}
+	_, err := db.Exec("UPDATE encounters SET status = 'cancelled', cancellation_reason = ? WHERE id = ?",
+		cancellationReason, encounterID)
+	return err
+}
+
+// UpdateEncounterStartTime moves an encounter to a new start time
+func UpdateEncounterStartTime(db *sql.DB, encounterID, startDateTime string) error {
+	_, err := db.Exec("UPDATE encounters SET start_datetime = ? WHERE id = ?", startDateTime, encounterID)
+	return err
+}
+
+// SetEncounterActualStart records when an encounter actually started
+func SetEncounterActualStart(db *sql.DB, encounterID, actualStartDateTime string) error {
+	_, err := db.Exec("UPDATE encounters SET actual_start_datetime = ? WHERE id = ?", actualStartDateTime, encounterID)
+	return err
+}
+
+// MarkEncounterArrived sets an encounter's status to arrived and records the arrival time
+func MarkEncounterArrived(db *sql.DB, encounterID, arrivedDateTime string) error {
+	_, err := db.Exec("UPDATE encounters SET status = 'arrived', actual_start_datetime = ? WHERE id = ?", arrivedDateTime, encounterID)
+	return err
+}
+
+// CompleteEncounter marks an encounter finished at endDateTime with an outcome note
+func CompleteEncounter(db *sql.DB, encounterID, endDateTime, outcome string) error {
+	_, err := db.Exec("UPDATE encounters SET status = 'finished', end_datetime = ?, outcome = ? WHERE id = ?",
+		endDateTime, outcome, encounterID)
+	return err
+}
+
 func CreateEncounter(db *sql.DB, encounter *Encounter) error {
 	_, err := db.Exec(`
 		INSERT INTO encounters (
@@ -268,14 +468,37 @@ func CreateEncounter(db *sql.DB, encounter *Encounter) error {
 	return err
 }
 
+// patientRecordTables are the tables CountPatientRecords accepts
+var patientRecordTables = map[string]bool{
+	"conditions": true, "medication_requests": true, "procedures": true,
+	"immunizations": true, "allergy_intolerances": true, "observations": true,
+}
+
+// CountPatientRecords returns how many rows of table belong to the patient
+func CountPatientRecords(db *sql.DB, table, patientID string) (int, error) {
+	if !patientRecordTables[table] {
+		return 0, fmt.Errorf("unsupported table: %s", table)
+	}
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE patient_id = ?", patientID).Scan(&count)
+	return count, err
+}
+
 func GetConditionsByPatientID(db *sql.DB, patientID string) ([]Condition, error) {
+	return GetConditionsByPatientIDPage(db, patientID, -1, 0)
+}
+
+// GetConditionsByPatientIDPage returns up to limit of the patient's conditions,
+// skipping the first offset. A negative limit means no limit.
+func GetConditionsByPatientIDPage(db *sql.DB, patientID string, limit, offset int) ([]Condition, error) {
 	debug.Verbose("GetConditionsByPatientID called for patient: %s", patientID)
 	rows, err := db.Query(`
 		SELECT id, clinical_status, code, display, patient_id, onset_datetime
 		FROM conditions
 		WHERE patient_id = ?
 		ORDER BY onset_datetime DESC
-	`, patientID)
+		LIMIT ? OFFSET ?
+	`, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -294,13 +517,20 @@ func GetConditionsByPatientID(db *sql.DB, patientID string) ([]Condition, error)
 }
 
 func GetMedicationsByPatientID(db *sql.DB, patientID string) ([]MedicationRequest, error) {
+	return GetMedicationsByPatientIDPage(db, patientID, -1, 0)
+}
+
+// GetMedicationsByPatientIDPage returns up to limit of the patient's medication requests,
+// skipping the first offset. A negative limit means no limit.
+func GetMedicationsByPatientIDPage(db *sql.DB, patientID string, limit, offset int) ([]MedicationRequest, error) {
 	debug.Verbose("GetMedicationsByPatientID called for patient: %s", patientID)
 	rows, err := db.Query(`
 		SELECT id, status, medication_display, patient_id, authored_on, dosage_text
 		FROM medication_requests
 		WHERE patient_id = ?
 		ORDER BY authored_on DESC
-	`, patientID)
+		LIMIT ? OFFSET ?
+	`, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -319,12 +549,19 @@ func GetMedicationsByPatientID(db *sql.DB, patientID string) ([]MedicationReques
 }
 
 func GetProceduresByPatientID(db *sql.DB, patientID string) ([]Procedure, error) {
+	return GetProceduresByPatientIDPage(db, patientID, -1, 0)
+}
+
+// GetProceduresByPatientIDPage returns up to limit of the patient's procedures,
+// skipping the first offset. A negative limit means no limit.
+func GetProceduresByPatientIDPage(db *sql.DB, patientID string, limit, offset int) ([]Procedure, error) {
 	rows, err := db.Query(`
-		SELECT id, status, display, patient_id, performed_datetime
+		SELECT id, status, code, display, patient_id, performed_datetime
 		FROM procedures
 		WHERE patient_id = ?
 		ORDER BY performed_datetime DESC
-	`, patientID)
+		LIMIT ? OFFSET ?
+	`, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -333,7 +570,7 @@ func GetProceduresByPatientID(db *sql.DB, patientID string) ([]Procedure, error)
 	var procedures []Procedure
 	for rows.Next() {
 		var p Procedure
-		err := rows.Scan(&p.ID, &p.Status, &p.Display, &p.PatientID, &p.PerformedDateTime)
+		err := rows.Scan(&p.ID, &p.Status, &p.Code, &p.Display, &p.PatientID, &p.PerformedDateTime)
 		if err != nil {
 			continue
 		}
@@ -343,12 +580,19 @@ func GetProceduresByPatientID(db *sql.DB, patientID string) ([]Procedure, error)
 }
 
 func GetImmunizationsByPatientID(db *sql.DB, patientID string) ([]Immunization, error) {
+	return GetImmunizationsByPatientIDPage(db, patientID, -1, 0)
+}
+
+// GetImmunizationsByPatientIDPage returns up to limit of the patient's immunizations,
+// skipping the first offset. A negative limit means no limit.
+func GetImmunizationsByPatientIDPage(db *sql.DB, patientID string, limit, offset int) ([]Immunization, error) {
 	rows, err := db.Query(`
 		SELECT id, status, vaccine_display, patient_id, occurrence_datetime
 		FROM immunizations
 		WHERE patient_id = ?
 		ORDER BY occurrence_datetime DESC
-	`, patientID)
+		LIMIT ? OFFSET ?
+	`, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -367,11 +611,19 @@ func GetImmunizationsByPatientID(db *sql.DB, patientID string) ([]Immunization,
 }
 
 func GetAllergiesByPatientID(db *sql.DB, patientID string) ([]AllergyIntolerance, error) {
+	return GetAllergiesByPatientIDPage(db, patientID, -1, 0)
+}
+
+// GetAllergiesByPatientIDPage returns up to limit of the patient's allergies,
+// skipping the first offset. A negative limit means no limit.
+func GetAllergiesByPatientIDPage(db *sql.DB, patientID string, limit, offset int) ([]AllergyIntolerance, error) {
 	rows, err := db.Query(`
 		SELECT id, clinical_status, display, patient_id, criticality
 		FROM allergy_intolerances
 		WHERE patient_id = ?
-	`, patientID)
+		ORDER BY rowid
+		LIMIT ? OFFSET ?
+	`, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -395,6 +647,8 @@ type Medication struct {
 	Form    *string `json:"form,omitempty"`
 }
 
+// SearchMedicationByName returns a medication whose display contains
+// medicationName, preferring an exact (case-insensitive) match
 func SearchMedicationByName(db *sql.DB, medicationName string) (*Medication, error) {
 	var medication Medication
 
@@ -402,8 +656,9 @@ func SearchMedicationByName(db *sql.DB, medicationName string) (*Medication, err
 		SELECT code, display, form
 		FROM medications
 		WHERE display LIKE ?
+		ORDER BY LOWER(display) = LOWER(?) DESC
 		LIMIT 1
-	`, "%"+medicationName+"%").Scan(&medication.Code, &medication.Display, &medication.Form)
+	`, "%"+medicationName+"%", medicationName).Scan(&medication.Code, &medication.Display, &medication.Form)
 
 	if err != nil {
 		return nil, err
@@ -412,11 +667,37 @@ func SearchMedicationByName(db *sql.DB, medicationName string) (*Medication, err
 	return &medication, nil
 }
 
+// SearchMedicationsByName returns the distinct medications (code, display and
+// form) whose display contains medicationName, e.g. each strength and form of
+// a drug
+func SearchMedicationsByName(db *sql.DB, medicationName string) ([]Medication, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT code, display, form
+		FROM medications
+		WHERE display LIKE ?
+		ORDER BY display, form
+	`, "%"+medicationName+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var medications []Medication
+	for rows.Next() {
+		var m Medication
+		if err := rows.Scan(&m.Code, &m.Display, &m.Form); err != nil {
+			continue
+		}
+		medications = append(medications, m)
+	}
+	return medications, rows.Err()
+}
+
 func GetEncountersByPatientID(db *sql.DB, patientID string) ([]Encounter, error) {
 	debug.Verbose("GetEncountersByPatientID called for patient: %s", patientID)
 	rows, err := db.Query(`
 		SELECT id, status, class, type_display, patient_id, practitioner_id, 
-		       start_datetime, end_datetime
+		       start_datetime, end_datetime, actual_start_datetime, cancellation_reason
 		FROM encounters
 		WHERE patient_id = ?
 		ORDER BY start_datetime DESC
@@ -430,7 +711,7 @@ func GetEncountersByPatientID(db *sql.DB, patientID string) ([]Encounter, error)
 	for rows.Next() {
 		var e Encounter
 		err := rows.Scan(&e.ID, &e.Status, &e.Class, &e.TypeDisplay, 
-			&e.PatientID, &e.PractitionerID, &e.StartDateTime, &e.EndDateTime)
+			&e.PatientID, &e.PractitionerID, &e.StartDateTime, &e.EndDateTime, &e.ActualStartDateTime, &e.CancellationReason)
 		if err != nil {
 			continue
 		}
@@ -439,15 +720,149 @@ func GetEncountersByPatientID(db *sql.DB, patientID string) ([]Encounter, error)
 	return encounters, nil
 }
 
+// GetEncountersByPatientInRange returns the patient's planned or ongoing
+// encounters starting strictly between from and to, earliest first. Start
+// times are compared as instants, so stored offsets don't matter.
+func GetEncountersByPatientInRange(db *sql.DB, patientID string, from, to time.Time) ([]Encounter, error) {
+	debug.Verbose("GetEncountersByPatientInRange called for patient: %s", patientID)
+	rows, err := db.Query(`
+		SELECT id, status, class, type_display, patient_id, practitioner_id,
+		       start_datetime, end_datetime, actual_start_datetime, cancellation_reason
+		FROM encounters
+		WHERE patient_id = ?
+		  AND status IN ('planned', 'arrived', 'in-progress')
+		  AND datetime(start_datetime) > datetime(?)
+		  AND datetime(start_datetime) < datetime(?)
+		ORDER BY datetime(start_datetime)
+	`, patientID, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var encounters []Encounter
+	for rows.Next() {
+		var e Encounter
+		err := rows.Scan(&e.ID, &e.Status, &e.Class, &e.TypeDisplay,
+			&e.PatientID, &e.PractitionerID, &e.StartDateTime, &e.EndDateTime, &e.ActualStartDateTime, &e.CancellationReason)
+		if err != nil {
+			continue
+		}
+		encounters = append(encounters, e)
+	}
+	return encounters, rows.Err()
+}
+
+// GetEncountersInRange returns the planned or ongoing encounters of all
+// patients starting at or after from and before to, earliest first
+func GetEncountersInRange(db *sql.DB, from, to time.Time) ([]Encounter, error) {
+	debug.Verbose("GetEncountersInRange called for %s to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	rows, err := db.Query(`
+		SELECT id, status, class, type_display, patient_id, practitioner_id,
+		       start_datetime, end_datetime, actual_start_datetime, cancellation_reason
+		FROM encounters
+		WHERE status IN ('planned', 'arrived', 'in-progress')
+		  AND datetime(start_datetime) >= datetime(?)
+		  AND datetime(start_datetime) < datetime(?)
+		ORDER BY datetime(start_datetime)
+	`, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var encounters []Encounter
+	for rows.Next() {
+		var e Encounter
+		err := rows.Scan(&e.ID, &e.Status, &e.Class, &e.TypeDisplay,
+			&e.PatientID, &e.PractitionerID, &e.StartDateTime, &e.EndDateTime, &e.ActualStartDateTime, &e.CancellationReason)
+		if err != nil {
+			continue
+		}
+		encounters = append(encounters, e)
+	}
+	return encounters, rows.Err()
+}
+
+// GetCareTeamByPatientID returns the distinct practitioners on the patient's
+// encounters with the number of encounters each, most frequent first.
+// Encounters without a practitioner are grouped under a nil PractitionerID.
+func GetCareTeamByPatientID(db *sql.DB, patientID string) ([]CareTeamMember, error) {
+	debug.Verbose("GetCareTeamByPatientID called for patient: %s", patientID)
+	rows, err := db.Query(`
+		SELECT NULLIF(e.practitioner_id, ''), COALESCE(p.given_name, ''), COALESCE(p.family_name, ''),
+		       COUNT(*), COALESCE(MAX(e.start_datetime), '')
+		FROM encounters e
+		LEFT JOIN practitioners p ON p.id = e.practitioner_id
+		WHERE e.patient_id = ?
+		GROUP BY NULLIF(e.practitioner_id, '')
+		ORDER BY COUNT(*) DESC, MAX(e.start_datetime) DESC
+	`, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []CareTeamMember
+	for rows.Next() {
+		var m CareTeamMember
+		if err := rows.Scan(&m.PractitionerID, &m.GivenName, &m.FamilyName, &m.EncounterCount, &m.LastEncounter); err != nil {
+			continue
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// FindPatientsOnMedication returns up to limit distinct patients with an
+// active medication request whose name contains query (case-insensitive),
+// ordered by name
+func FindPatientsOnMedication(db *sql.DB, query string, limit int) ([]PatientOnMedication, error) {
+	debug.Verbose("FindPatientsOnMedication called with query: %s", query)
+	rows, err := db.Query(`
+		SELECT p.id, COALESCE(p.given_name, ''), COALESCE(p.family_name, ''),
+		       GROUP_CONCAT(DISTINCT m.medication_display)
+		FROM medication_requests m
+		JOIN patients p ON p.id = m.patient_id
+		WHERE m.status = 'active' AND LOWER(m.medication_display) LIKE LOWER(?)
+		GROUP BY p.id
+		ORDER BY p.family_name, p.given_name
+		LIMIT ?
+	`, "%"+query+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patients []PatientOnMedication
+	for rows.Next() {
+		var p PatientOnMedication
+		if err := rows.Scan(&p.PatientID, &p.GivenName, &p.FamilyName, &p.Medications); err != nil {
+			continue
+		}
+		patients = append(patients, p)
+	}
+	return patients, nil
+}
+
 func GetObservationsByPatientID(db *sql.DB, patientID string) ([]Observation, error) {
+	return GetObservationsByPatientIDPage(db, patientID, -1, 0)
+}
+
+// GetObservationsByPatientIDPage returns up to limit of the patient's observations,
+// latest first, skipping the first offset. A negative limit means no limit.
+// Times are compared as instants, so rows stored with a UTC offset (e.g. by
+// the data import) sort correctly among the UTC times CreateObservation stores.
+func GetObservationsByPatientIDPage(db *sql.DB, patientID string, limit, offset int) ([]Observation, error) {
 	debug.Verbose("GetObservationsByPatientID called for patient: %s", patientID)
 	rows, err := db.Query(`
 		SELECT id, status, category, code, display, patient_id, 
 		       effective_datetime, value_quantity, value_unit, value_string
 		FROM observations
 		WHERE patient_id = ?
-		ORDER BY effective_datetime DESC
-	`, patientID)
+		ORDER BY julianday(effective_datetime) DESC, effective_datetime DESC
+		LIMIT ? OFFSET ?
+	`, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -467,14 +882,220 @@ func GetObservationsByPatientID(db *sql.DB, patientID string) ([]Observation, er
 	return observations, nil
 }
 
+func CreateMedicationRequest(db *sql.DB, request *MedicationRequest) error {
+	_, err := db.Exec(`
+		INSERT INTO medication_requests (
+			id, resource_type, status, intent, medication_code, medication_display,
+			patient_id, requester_id, authored_on, dosage_text
+		) VALUES (?, 'MedicationRequest', ?, 'order', ?, ?, ?, ?, ?, ?)
+	`, request.ID, request.Status, request.MedicationCode, request.MedicationDisplay,
+		request.PatientID, request.RequesterID, request.AuthoredOn, request.DosageText)
+	return err
+}
+
+func CreateCondition(db *sql.DB, condition *Condition) error {
+	_, err := db.Exec(`
+		INSERT INTO conditions (
+			id, resource_type, clinical_status, code, display,
+			patient_id, onset_datetime, recorded_date
+		) VALUES (?, 'Condition', ?, ?, ?, ?, ?, date('now'))
+	`, condition.ID, condition.ClinicalStatus, condition.Code, condition.Display,
+		condition.PatientID, condition.OnsetDateTime)
+	return err
+}
+
+// normalizeTimestamp converts an RFC 3339 datetime to UTC so stored times sort
+// chronologically as text. Other values are returned unchanged.
+func normalizeTimestamp(value string) string {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// CreateObservation inserts an observation, storing its effective time in UTC
 func CreateObservation(db *sql.DB, observation *Observation) error {
+	var effective *string
+	if observation.EffectiveDateTime != nil {
+		normalized := normalizeTimestamp(*observation.EffectiveDateTime)
+		effective = &normalized
+	}
 	_, err := db.Exec(`
 		INSERT INTO observations (
 			id, resource_type, status, category, code, display,
 			patient_id, effective_datetime, value_quantity, value_unit, value_string
 		) VALUES (?, 'Observation', ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, observation.ID, observation.Status, observation.Category, observation.Code,
-		observation.Display, observation.PatientID, observation.EffectiveDateTime,
+		observation.Display, observation.PatientID, effective,
 		observation.ValueQuantity, observation.ValueUnit, observation.ValueString)
 	return err
 }
+
+func GetObservationByID(db *sql.DB, observationID string) (*Observation, error) {
+	var o Observation
+	err := db.QueryRow(`
+		SELECT id, status, category, code, display, patient_id,
+		       effective_datetime, value_quantity, value_unit, value_string
+		FROM observations
+		WHERE id = ?
+	`, observationID).Scan(&o.ID, &o.Status, &o.Category, &o.Code, &o.Display,
+		&o.PatientID, &o.EffectiveDateTime, &o.ValueQuantity,
+		&o.ValueUnit, &o.ValueString)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// DeleteObservation removes an observation, returning sql.ErrNoRows when no
+// observation has the ID
+func DeleteObservation(db *sql.DB, observationID string) error {
+	result, err := db.Exec("DELETE FROM observations WHERE id = ?", observationID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateObservation sets the given fields of an observation, leaving nil
+// values and an empty status unchanged. It returns sql.ErrNoRows when no
+// observation has the ID.
+func UpdateObservation(db *sql.DB, observationID string, valueQuantity *float64, valueUnit, valueString *string, status string) error {
+	var sets []string
+	var args []interface{}
+	if valueQuantity != nil {
+		sets = append(sets, "value_quantity = ?")
+		args = append(args, *valueQuantity)
+	}
+	if valueUnit != nil {
+		sets = append(sets, "value_unit = ?")
+		args = append(args, *valueUnit)
+	}
+	if valueString != nil {
+		sets = append(sets, "value_string = ?")
+		args = append(args, *valueString)
+	}
+	if status != "" {
+		sets = append(sets, "status = ?")
+		args = append(args, status)
+	}
+	if len(sets) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	args = append(args, observationID)
+	result, err := db.Exec("UPDATE observations SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetAllClaims returns every claim, oldest first. Claims without a created
+// date sort first.
+func GetAllClaims(db *sql.DB) ([]Claim, error) {
+	query := `
+		SELECT id, status, type, use, patient_id, provider_id, priority,
+		       created_datetime, billable_period_start, billable_period_end,
+		       total_amount, currency
+		FROM claims
+		ORDER BY created_datetime ASC
+	`
+	debug.SQL(query)
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []Claim
+	for rows.Next() {
+		var c Claim
+		err := rows.Scan(
+			&c.ID, &c.Status, &c.Type, &c.Use, &c.PatientID, &c.ProviderID,
+			&c.Priority, &c.CreatedDateTime, &c.BillablePeriodStart,
+			&c.BillablePeriodEnd, &c.TotalAmount, &c.Currency,
+		)
+		if err != nil {
+			continue
+		}
+		claims = append(claims, c)
+	}
+	return claims, nil
+}
+
+// RecordAudit appends an entry to the audit log, stamped with the current
+// time
+func RecordAudit(db *sql.DB, action, entityID, practitionerID, details string) error {
+	_, err := db.Exec(`
+		INSERT INTO audit_log (timestamp, action, entity_id, practitioner_id, details)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))
+	`, time.Now().UTC().Format(time.RFC3339), action, entityID, practitionerID, details)
+	return err
+}
+
+// GetAuditEntriesAfter returns up to limit audit entries with an ID above
+// afterID and a timestamp in [from, to), oldest first. A zero from or to
+// leaves that end open.
+func GetAuditEntriesAfter(db *sql.DB, afterID int64, from, to time.Time, limit int) ([]AuditEntry, error) {
+	query := `
+		SELECT id, timestamp, action, COALESCE(entity_id, ''), COALESCE(practitioner_id, ''), COALESCE(details, '')
+		FROM audit_log
+		WHERE id > ?`
+	args := []interface{}{afterID}
+	if !from.IsZero() {
+		query += ` AND timestamp >= ?`
+		args = append(args, from.UTC().Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query += ` AND timestamp < ?`
+		args = append(args, to.UTC().Format(time.RFC3339))
+	}
+	query += ` ORDER BY id LIMIT ?`
+	args = append(args, limit)
+
+	debug.SQL(query, args...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Action, &e.EntityID, &e.PractitionerID, &e.Details); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// NextSequenceValue increments the named counter in id_sequences and returns
+// its new value, starting at 1. The increment is a single statement, so
+// concurrent callers never get the same value.
+func NextSequenceValue(db *sql.DB, name string) (int64, error) {
+	var value int64
+	err := db.QueryRow(`
+		INSERT INTO id_sequences (name, value) VALUES (?, 1)
+		ON CONFLICT(name) DO UPDATE SET value = value + 1
+		RETURNING value
+	`, name).Scan(&value)
+	return value, err
+}
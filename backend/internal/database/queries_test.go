@@ -142,3 +142,42 @@ func TestDebugSearchQuery(t *testing.T) {
 		t.Error("No results found for 'Marty' - this indicates the search is failing")
 	}
 }
+
+func TestRankPatientMatches(t *testing.T) {
+	patients := []Patient{
+		{ID: "p1", GivenName: "Cole117", FamilyName: "Zulauf"},
+		{ID: "p2", GivenName: "Nicole", FamilyName: "Abbott"},
+		{ID: "p3", GivenName: "Cole", FamilyName: "Smith"},
+		{ID: "p4", GivenName: "Colette", FamilyName: "Baker"},
+		{ID: "p5", GivenName: "Marty", FamilyName: "Coleman"},
+		{ID: "p6", GivenName: "Cole", FamilyName: "Brown"},
+	}
+	rankPatientMatches(patients, "cole")
+
+	// Exact (Brown, Smith), then prefix (Baker, Coleman, Zulauf), then substring
+	want := []string{"p6", "p3", "p4", "p5", "p1", "p2"}
+	for i, p := range patients {
+		if p.ID != want[i] {
+			t.Fatalf("position %d: got %s, want %s (order %v)", i, p.ID, want[i], patients)
+		}
+	}
+}
+
+func TestPatientMatchScore(t *testing.T) {
+	p := Patient{GivenName: "Marty", FamilyName: "McFly"}
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"Marty McFly", matchExact},
+		{"mcfly", matchExact},
+		{"Marty Mc", matchPrefix},
+		{"art", matchSubstring},
+		{"Doc Brown", matchOther},
+	}
+	for _, tt := range tests {
+		if got := patientMatchScore(p, tt.query); got != tt.want {
+			t.Errorf("patientMatchScore(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// ErrAdminToolsDisabled is returned by admin tools unless MCP_ENABLE_ADMIN_TOOLS is set
+var ErrAdminToolsDisabled = errors.New("admin tools are disabled (set MCP_ENABLE_ADMIN_TOOLS=true to enable)")
+
+// normalizeName trims a name and collapses runs of whitespace inside it
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+type patientNormalization struct {
+	ID         string
+	GivenName  sql.NullString
+	FamilyName sql.NullString
+	Gender     sql.NullString
+}
+
+// NormalizePatientData trims whitespace in patient names and maps gender
+// values onto the FHIR codes (male, female, other, unknown) in a single
+// transaction, reporting how many patients changed
+func (h *Handler) NormalizePatientData() (interface{}, error) {
+	if !h.adminToolsEnabled {
+		return nil, ErrAdminToolsDisabled
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, given_name, family_name, gender FROM patients`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patients: %w", err)
+	}
+	var patients []patientNormalization
+	for rows.Next() {
+		var p patientNormalization
+		if err := rows.Scan(&p.ID, &p.GivenName, &p.FamilyName, &p.Gender); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read patient: %w", err)
+		}
+		patients = append(patients, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read patients: %w", err)
+	}
+
+	var changed, namesChanged, gendersChanged int
+	for _, p := range patients {
+		given, family, gender := p.GivenName, p.FamilyName, p.Gender
+		if given.Valid {
+			given.String = normalizeName(given.String)
+		}
+		if family.Valid {
+			family.String = normalizeName(family.String)
+		}
+		if gender.Valid {
+			gender.String = fhirGender(gender.String)
+		}
+
+		nameChanged := given != p.GivenName || family != p.FamilyName
+		genderChanged := gender != p.Gender
+		if !nameChanged && !genderChanged {
+			continue
+		}
+
+		if _, err := tx.Exec(`UPDATE patients SET given_name = ?, family_name = ?, gender = ? WHERE id = ?`,
+			given, family, gender, p.ID); err != nil {
+			return nil, fmt.Errorf("failed to update patient %s: %w", p.ID, err)
+		}
+		changed++
+		if nameChanged {
+			namesChanged++
+		}
+		if genderChanged {
+			gendersChanged++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit patient normalization: %w", err)
+	}
+	debug.Log("Normalized patient data: %d of %d patients changed", changed, len(patients))
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Normalized patient data: %d of %d patients changed (%d names trimmed, %d gender values normalized)",
+					changed, len(patients), namesChanged, gendersChanged),
+			},
+		},
+	}, nil
+}
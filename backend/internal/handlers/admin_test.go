@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePatientData(t *testing.T) {
+	t.Setenv("MCP_ENABLE_ADMIN_TOOLS", "true")
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "  Anna ", "Jonsdottir", "F", "1980-01-01")
+	seedPatient(t, h, "p2", "Jon", "Van  der   Berg", "male", "1975-05-05")
+	seedPatient(t, h, "p3", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPatient(t, h, "p4", "Ola", "Nordmann", " MALE ", "1990-01-01")
+	seedPatient(t, h, "p5", "Sam", "Smith", "x", "1990-01-01")
+
+	result, err := h.NormalizePatientData()
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "4 of 5 patients changed (2 names trimmed, 3 gender values normalized)") {
+		t.Errorf("unexpected change count:\n%s", text)
+	}
+
+	want := map[string][3]string{
+		"p1": {"Anna", "Jonsdottir", "female"},
+		"p2": {"Jon", "Van der Berg", "male"},
+		"p3": {"Kari", "Nordmann", "female"},
+		"p4": {"Ola", "Nordmann", "male"},
+		"p5": {"Sam", "Smith", "unknown"},
+	}
+	for id, w := range want {
+		var given, family, gender string
+		if err := h.db.QueryRow(`SELECT given_name, family_name, gender FROM patients WHERE id = ?`, id).Scan(&given, &family, &gender); err != nil {
+			t.Fatalf("Failed to read %s: %v", id, err)
+		}
+		if got := [3]string{given, family, gender}; got != w {
+			t.Errorf("%s = %q, want %q", id, got, w)
+		}
+	}
+
+	// A second run finds nothing to change
+	result, err = h.NormalizePatientData()
+	if text := resultText(t, h, result, err); !strings.Contains(text, "0 of 5 patients changed") {
+		t.Errorf("second run changed data:\n%s", text)
+	}
+}
+
+func TestNormalizePatientDataDisabled(t *testing.T) {
+	h := newTestHandler(t)
+	if _, err := h.NormalizePatientData(); !errors.Is(err, ErrAdminToolsDisabled) {
+		t.Errorf("expected ErrAdminToolsDisabled, got %v", err)
+	}
+}
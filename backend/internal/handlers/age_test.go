@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -90,3 +91,48 @@ func TestCalculateAge(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBirthDate(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		birthDate string
+		wantErr   bool
+	}{
+		{"Valid", "1980-04-01", false},
+		{"Born today", "2025-06-15", false},
+		{"129 years old", "1896-06-16", false},
+		{"Future date", "2030-01-01", true},
+		{"200 years old", "1825-06-15", true},
+		{"Unparseable", "yesterday", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBirthDate(tt.birthDate, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBirthDate(%q) error = %v, wantErr %v", tt.birthDate, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdatePatientBirthDateRejectsImplausibleDates(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+
+	future := time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+	ancient := time.Now().AddDate(-200, 0, 0).Format("2006-01-02")
+	for _, birthDate := range []string{future, ancient} {
+		if _, err := h.UpdatePatientBirthDate("p1", birthDate); err == nil {
+			t.Errorf("birth date %s accepted", birthDate)
+		}
+	}
+
+	var stored string
+	h.db.QueryRow(`SELECT birth_date FROM patients WHERE id = 'p1'`).Scan(&stored)
+	if !strings.HasPrefix(stored, "1980-01-01") {
+		t.Errorf("birth date changed to %q", stored)
+	}
+}
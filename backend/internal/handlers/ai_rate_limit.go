@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// defaultAIPatientRateWindow is the window MCP_AI_PATIENT_RATE_LIMIT counts
+// calls in when MCP_AI_PATIENT_RATE_WINDOW is unset
+const defaultAIPatientRateWindow = time.Minute
+
+// aiTools are the tools that call (or may call) the model. New tools that
+// call the model must be added here so the per-patient limit covers them.
+var aiTools = map[string]bool{
+	"natural_language_query":    true,
+	"get_medication_info":       true,
+	"get_medical_guidelines":    true,
+	"answer_health_question":    true,
+	"check_contraindications":   true,
+	"check_drug_interactions":   true,
+	"suggest_next_actions":      true,
+	"get_ranked_problem_list":   true,
+	"generate_sbar":             true,
+	"explain_observation_trend": true,
+}
+
+// patientRateLimiter allows at most limit calls per patient in any sliding
+// window of the given length
+type patientRateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	calls  map[string][]time.Time
+}
+
+func newPatientRateLimiter(limit int, window time.Duration) *patientRateLimiter {
+	return &patientRateLimiter{limit: limit, window: window, calls: make(map[string][]time.Time)}
+}
+
+// allow records a call about patientID at now and reports whether it is
+// within the limit. Rejected calls are not recorded, so a patient is
+// unblocked as soon as its oldest call leaves the window.
+func (l *patientRateLimiter) allow(patientID string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.calls[patientID]
+	for len(recent) > 0 && now.Sub(recent[0]) >= l.window {
+		recent = recent[1:]
+	}
+	if len(recent) >= l.limit {
+		l.calls[patientID] = recent
+		return false
+	}
+	l.calls[patientID] = append(recent, now)
+	return true
+}
+
+// aiPatientRateLimiterFromEnv returns the limiter configured by
+// MCP_AI_PATIENT_RATE_LIMIT and MCP_AI_PATIENT_RATE_WINDOW, or nil when the
+// limit is unset or 0
+func aiPatientRateLimiterFromEnv() *patientRateLimiter {
+	limit := envInt("MCP_AI_PATIENT_RATE_LIMIT", 0)
+	if limit == 0 {
+		return nil
+	}
+	window := envDuration("MCP_AI_PATIENT_RATE_WINDOW", defaultAIPatientRateWindow)
+	if window == 0 {
+		window = defaultAIPatientRateWindow
+	}
+	debug.Log("AI tools limited to %d calls per patient per %s", limit, window)
+	return newPatientRateLimiter(limit, window)
+}
+
+// AIRateLimitBlocks reports whether toolName must not run because it calls
+// the model and the patient it is about (patientID, or the context patient)
+// has reached MCP_AI_PATIENT_RATE_LIMIT, returning the message to show
+// instead. Calls about no particular patient are not limited.
+func (h *Handler) AIRateLimitBlocks(toolName, patientID string) (string, bool) {
+	if h.aiPatientLimiter == nil || !aiTools[toolName] {
+		return "", false
+	}
+	patientID = h.GetContextPatientID(patientID)
+	if patientID == "" || h.aiPatientLimiter.allow(patientID, time.Now()) {
+		return "", false
+	}
+
+	debug.Log("AI rate limit: blocked %s for patient %s", toolName, patientID)
+	return fmt.Sprintf("Too many AI requests about patient %s: the limit is %d per %s. Wait a moment before asking again; tools that don't use AI are still available.",
+		patientID, h.aiPatientLimiter.limit, h.aiPatientLimiter.window), true
+}
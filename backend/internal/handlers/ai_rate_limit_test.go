@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAIRateLimitPerPatient(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1950-05-05")
+	seedPatient(t, h, "p2", "Kari", "Nordmann", "female", "1955-03-03")
+	h.aiPatientLimiter = newPatientRateLimiter(2, time.Minute)
+
+	// With fewer than two medications the tool doesn't reach the model
+	for i := 0; i < 2; i++ {
+		text, err := h.executeTool(context.Background(), "check_drug_interactions", `{"patient_id": "p1"}`, "")
+		if err != nil || !strings.Contains(text, "no interactions to check") {
+			t.Fatalf("Call %d should be allowed, got %q (err: %v)", i+1, text, err)
+		}
+	}
+
+	text, err := h.executeTool(context.Background(), "check_drug_interactions", `{"patient_id": "p1"}`, "")
+	if err != nil || !strings.Contains(text, "Too many AI requests about patient p1") {
+		t.Errorf("Expected the third call to be throttled, got %q (err: %v)", text, err)
+	}
+
+	// Tools that don't call the model and other patients are unaffected
+	if text, err := h.executeTool(context.Background(), "get_allergy_banner", `{"patient_id": "p1"}`, ""); err != nil || strings.Contains(text, "Too many AI requests") {
+		t.Errorf("Non-AI tool should not be throttled, got %q (err: %v)", text, err)
+	}
+	if text, err := h.executeTool(context.Background(), "check_drug_interactions", `{"patient_id": "p2"}`, ""); err != nil || strings.Contains(text, "Too many AI requests") {
+		t.Errorf("Other patient should not be throttled, got %q (err: %v)", text, err)
+	}
+
+	// The context patient is limited when no patient ID is given
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+	if _, blocked := h.AIRateLimitBlocks("generate_sbar", ""); !blocked {
+		t.Error("Expected the context patient to be throttled")
+	}
+}
+
+func TestPatientRateLimiterWindow(t *testing.T) {
+	l := newPatientRateLimiter(2, time.Minute)
+	start := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	if !l.allow("p1", start) || !l.allow("p1", start.Add(20*time.Second)) {
+		t.Fatal("Expected the first two calls to be allowed")
+	}
+	if l.allow("p1", start.Add(40*time.Second)) {
+		t.Error("Expected a third call within the window to be rejected")
+	}
+	if !l.allow("p1", start.Add(time.Minute)) {
+		t.Error("Expected a call to be allowed once the first left the window")
+	}
+	if l.allow("p1", start.Add(70*time.Second)) {
+		t.Error("Expected the window to still hold two calls")
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// bannerAllergyName shortens SNOMED-style displays such as "Allergy to
+// peanuts" or "Penicillin V (substance)" for the banner
+func bannerAllergyName(display string) string {
+	name := strings.TrimSpace(display)
+	if strings.HasPrefix(strings.ToLower(name), "allergy to ") {
+		name = name[len("allergy to "):]
+	}
+	if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+		name = name[:i]
+	}
+	if name == "" {
+		return "Unknown allergen"
+	}
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// allergyBanner formats active high-criticality allergies as a single line,
+// e.g. "ALLERGIES: Penicillin (high), Latex (high)", or "NKDA" when the
+// patient has no active allergies on file
+func allergyBanner(allergies []database.AllergyIntolerance) string {
+	var high []string
+	active := 0
+	for _, a := range allergies {
+		if a.ClinicalStatus != "active" && a.ClinicalStatus != "" {
+			continue
+		}
+		active++
+		if a.Criticality != nil && strings.EqualFold(*a.Criticality, "high") {
+			high = append(high, bannerAllergyName(a.Display)+" (high)")
+		}
+	}
+
+	switch {
+	case len(high) > 0:
+		return "ALLERGIES: " + strings.Join(high, ", ")
+	case active == 0:
+		return "NKDA"
+	default:
+		// Lower-criticality allergies exist, so "no known allergies" would be wrong
+		return fmt.Sprintf("ALLERGIES: no high-criticality allergies (%d other on file)", active)
+	}
+}
+
+func (h *Handler) GetAllergyBanner(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	allergies, err := database.GetAllergiesByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allergies: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": allergyBanner(allergies),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import "testing"
+
+func TestGetAllergyBanner(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Nora", "Dahl", "female", "1975-07-07")
+	seedAllergy(t, h, "p1", "Allergy to penicillin", "active", "high")
+	seedAllergy(t, h, "p1", "Latex (substance)", "active", "high")
+	seedAllergy(t, h, "p1", "Allergy to grass pollen", "active", "low")
+	seedAllergy(t, h, "p1", "Allergy to peanuts", "resolved", "high")
+
+	result, err := h.GetAllergyBanner("p1")
+	text := resultText(t, h, result, err)
+
+	want := "ALLERGIES: Penicillin (high), Latex (high)"
+	if text != want {
+		t.Errorf("GetAllergyBanner() = %q, want %q", text, want)
+	}
+}
+
+func TestGetAllergyBannerNoAllergies(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Nora", "Dahl", "female", "1975-07-07")
+
+	result, err := h.GetAllergyBanner("p1")
+	if text := resultText(t, h, result, err); text != "NKDA" {
+		t.Errorf("Expected NKDA, got %q", text)
+	}
+
+	seedAllergy(t, h, "p1", "Allergy to grass pollen", "active", "low")
+	result, err = h.GetAllergyBanner("p1")
+	if text := resultText(t, h, result, err); text == "NKDA" {
+		t.Error("Expected banner not to claim NKDA when lower-criticality allergies exist")
+	}
+}
+
+func TestBannerAllergyNameMultiByte(t *testing.T) {
+	if got := bannerAllergyName("Allergy to ölivenöl"); got != "Ölivenöl" {
+		t.Errorf("bannerAllergyName() = %q, want %q", got, "Ölivenöl")
+	}
+}
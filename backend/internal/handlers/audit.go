@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// signedObservationTerms are observations whose values may legitimately be
+// negative, so a negative value is not flagged
+var signedObservationTerms = []string{"base excess", "base deficit", "z-score", "axis", "standard deviation"}
+
+// patientRecords is the clinical data checked by auditRecords
+type patientRecords struct {
+	Patient      *database.Patient
+	Conditions   []database.Condition
+	Observations []database.Observation
+	Medications  []database.MedicationRequest
+	Encounters   []database.Encounter
+}
+
+// auditRecords returns a description of each internal inconsistency in a
+// patient's records, as of now
+func auditRecords(records patientRecords, now time.Time) []string {
+	var findings []string
+
+	for _, c := range records.Conditions {
+		if !isActiveCondition(c) || c.OnsetDateTime == nil {
+			continue
+		}
+		if onset, ok := parseRecordTime(*c.OnsetDateTime); ok && onset.After(now) {
+			findings = append(findings, fmt.Sprintf("Active condition %q (%s) has a future onset date: %s",
+				c.Display, c.ID, recordDay(*c.OnsetDateTime)))
+		}
+	}
+
+	for _, obs := range records.Observations {
+		if obs.ValueQuantity == nil || *obs.ValueQuantity >= 0 || containsAny(obs.Display, signedObservationTerms) {
+			continue
+		}
+		findings = append(findings, fmt.Sprintf("Observation %q (%s) has a negative value: %g %s",
+			obs.Display, obs.ID, *obs.ValueQuantity, derefString(obs.ValueUnit)))
+	}
+
+	birthDate := strings.TrimSpace(records.Patient.BirthDate)
+	if birthDate != "" {
+		if birth, err := parseBirthDate(birthDate); err != nil {
+			findings = append(findings, fmt.Sprintf("Birth date %q can't be parsed", birthDate))
+		} else {
+			for _, med := range records.Medications {
+				if authored, ok := parseRecordTime(med.AuthoredOn); ok && authored.Before(birth) {
+					findings = append(findings, fmt.Sprintf("Medication %q (%s) was prescribed on %s, before the patient's birth date %s",
+						med.MedicationDisplay, med.ID, recordDay(med.AuthoredOn), recordDay(birthDate)))
+				}
+			}
+		}
+	}
+
+	for _, e := range records.Encounters {
+		if e.EndDateTime == nil {
+			continue
+		}
+		start, startOK := parseRecordTime(e.StartDateTime)
+		end, endOK := parseRecordTime(*e.EndDateTime)
+		if startOK && endOK && end.Before(start) {
+			findings = append(findings, fmt.Sprintf("Encounter %s ends (%s) before it starts (%s)",
+				e.ID, formatRecordTime(*e.EndDateTime), formatRecordTime(e.StartDateTime)))
+		}
+	}
+
+	return findings
+}
+
+// AuditPatientData checks a patient's records for internal inconsistencies,
+// such as encounters that end before they start, and lists what it finds
+func (h *Handler) AuditPatientData(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	records := patientRecords{Patient: patient}
+	if records.Conditions, err = database.GetConditionsByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+	if records.Observations, err = database.GetObservationsByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	if records.Medications, err = database.GetMedicationsByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	if records.Encounters, err = database.GetEncountersByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	findings := auditRecords(records, time.Now())
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Data audit for %s (ID: %s)\n\n", patientName, patientID))
+	if len(findings) == 0 {
+		result.WriteString("No inconsistencies found.\n")
+	} else {
+		result.WriteString(fmt.Sprintf("%d issue(s) found:\n", len(findings)))
+		for _, finding := range findings {
+			result.WriteString("• " + finding + "\n")
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// auditEntityArguments are the tool arguments that identify the record a
+// write tool changed, most specific first
+var auditEntityArguments = []string{"encounter_id", "observation_id", "patient_id"}
+
+// RecordToolAudit adds a successful write tool call to the audit log, with
+// the record it changed, the practitioner and the arguments it was called
+// with. Read-only tools and demo mode (where nothing is written) are not
+// logged. A failure to log is reported but not returned, as the change has
+// already been made.
+func (h *Handler) RecordToolAudit(toolName string, arguments []byte) {
+	if !IsWriteTool(toolName) || h.demoMode {
+		return
+	}
+
+	var args map[string]interface{}
+	if json.Unmarshal(arguments, &args) != nil {
+		arguments = []byte(repairJSON(string(arguments)))
+		json.Unmarshal(arguments, &args)
+	}
+	stringArg := func(name string) string {
+		value, _ := args[name].(string)
+		return value
+	}
+
+	entityID := ""
+	for _, name := range auditEntityArguments {
+		if entityID = stringArg(name); entityID != "" {
+			break
+		}
+	}
+	if entityID == "" {
+		entityID = h.GetContextPatientID("")
+	}
+	practitionerID := h.GetContextPractitionerID(stringArg("practitioner_id"))
+
+	details := string(arguments)
+	var compact bytes.Buffer
+	if json.Compact(&compact, arguments) == nil {
+		details = compact.String()
+	}
+
+	if err := database.RecordAudit(h.db, toolName, entityID, practitionerID, details); err != nil {
+		debug.Error("Failed to record %s in the audit log: %v", toolName, err)
+	}
+}
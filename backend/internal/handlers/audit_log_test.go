@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestRecordToolAudit(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	h.SetPatientContext("p1")
+
+	h.RecordToolAudit("get_patient_summary", []byte(`{}`))
+	h.RecordToolAudit("add_observation", []byte("{\"code\": \"8867-4\",\n \"value_quantity\": 72,}"))
+	h.RecordToolAudit("cancel_appointment", []byte(`{"encounter_id":"e1","reason":"ill"}`))
+
+	entries, err := database.GetAuditEntriesAfter(h.db, 0, time.Time{}, time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("GetAuditEntriesAfter failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2 (write tools only): %+v", len(entries), entries)
+	}
+	practitionerID := defaultContext().PractitionerID
+	if e := entries[0]; e.Action != "add_observation" || e.EntityID != "p1" || e.PractitionerID != practitionerID ||
+		e.Details != `{"code":"8867-4","value_quantity":72}` {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e := entries[1]; e.Action != "cancel_appointment" || e.EntityID != "e1" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
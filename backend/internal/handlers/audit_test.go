@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuditPatientData(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedCondition(t, h, "p1", "44054006", "Diabetes mellitus type 2", "active", "2099-01-01")
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2010-05-01")
+	seedCondition(t, h, "p1", "10509002", "Acute bronchitis", "resolved", "2099-01-01")
+	seedObservation(t, h, "p1", "29463-7", "Body Weight", -70, "kg", "2024-03-01")
+	seedObservation(t, h, "p1", "11555-0", "Base excess in Blood", -3.5, "mmol/L", "2024-03-01")
+	seedMedication(t, h, "p1", "Metformin 500 MG", "active", "1980-06-01")
+	seedMedication(t, h, "p1", "Lisinopril 10 MG", "active", "2015-06-01")
+	seedEncounter(t, h, "e1", "p1", "finished", "2024-05-01T09:00:00Z")
+	seedEncounter(t, h, "e2", "p1", "finished", "2024-06-01T09:00:00Z")
+	if _, err := h.db.Exec(`UPDATE encounters SET end_datetime = CASE id
+		WHEN 'e1' THEN '2024-05-01T08:30:00Z' ELSE '2024-06-01T09:30:00Z' END`); err != nil {
+		t.Fatalf("Failed to update encounters: %v", err)
+	}
+
+	result, err := h.AuditPatientData("p1")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"4 issue(s) found",
+		`Active condition "Diabetes mellitus type 2"`,
+		`Observation "Body Weight"`,
+		"negative value: -70 kg",
+		`Medication "Metformin 500 MG"`,
+		"before the patient's birth date 1985-03-03",
+		"Encounter e1 ends",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"Hypertension", "Acute bronchitis", "Base excess", "Lisinopril", "Encounter e2"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("%q flagged:\n%s", unwanted, text)
+		}
+	}
+}
+
+func TestAuditPatientDataClean(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedObservation(t, h, "p1", "29463-7", "Body Weight", 70, "kg", "2024-03-01")
+
+	h.SetPatientContext("p1")
+	result, err := h.AuditPatientData("")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "No inconsistencies found") {
+		t.Errorf("expected a clean audit:\n%s", text)
+	}
+
+	if _, err := h.AuditPatientData("missing"); err == nil {
+		t.Error("expected an error for an unknown patient")
+	}
+}
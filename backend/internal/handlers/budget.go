@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultMedicalHistoryMaxChars is the GetMedicalHistory output budget when
+// MCP_MEDICAL_HISTORY_MAX_CHARS is unset
+const defaultMedicalHistoryMaxChars = 20000
+
+// defaultLastResponseMaxChars is how much of the last response is repeated in
+// the prompt context when MCP_LAST_RESPONSE_MAX_CHARS is unset
+const defaultLastResponseMaxChars = 500
+
+// truncateChars returns the first maxChars characters of s and whether any
+// were cut. It counts runes rather than bytes, so multi-byte characters such
+// as "ü" are never split. Zero or less means unlimited.
+func truncateChars(s string, maxChars int) (string, bool) {
+	if maxChars <= 0 || len(s) <= maxChars {
+		// A string of at most maxChars bytes has at most maxChars runes
+		return s, false
+	}
+	count := 0
+	for i := range s {
+		if count == maxChars {
+			return s[:i], true
+		}
+		count++
+	}
+	return s, false
+}
+
+// budgetWriter is a strings.Builder that stops accepting text once a
+// character budget would be exceeded. Writes are all-or-nothing, so output is
+// cut at a line boundary rather than mid-line.
+type budgetWriter struct {
+	strings.Builder
+	remaining int // characters left; negative means unlimited
+	truncated bool
+}
+
+// newBudgetWriter returns a writer limited to maxChars characters; zero or
+// less means unlimited
+func newBudgetWriter(maxChars int) *budgetWriter {
+	if maxChars <= 0 {
+		maxChars = -1
+	}
+	return &budgetWriter{remaining: maxChars}
+}
+
+func (w *budgetWriter) WriteString(s string) (int, error) {
+	if w.truncated {
+		return 0, nil
+	}
+	if w.remaining >= 0 {
+		n := utf8.RuneCountInString(s)
+		if n > w.remaining {
+			w.truncated = true
+			return 0, nil
+		}
+		w.remaining -= n
+	}
+	return w.Builder.WriteString(s)
+}
+
+// Truncated reports whether any text was dropped
+func (w *budgetWriter) Truncated() bool {
+	return w.truncated
+}
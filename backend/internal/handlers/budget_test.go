@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBudgetWriter(t *testing.T) {
+	w := newBudgetWriter(10)
+	w.WriteString("äöü\n")  // 4 characters, 7 bytes
+	w.WriteString("abcdef") // exactly fills the budget
+	w.WriteString("x")
+	w.WriteString("\n")
+	if got := w.String(); got != "äöü\nabcdef" {
+		t.Errorf("got %q", got)
+	}
+	if !w.Truncated() {
+		t.Error("Truncated() = false after dropping text")
+	}
+
+	unlimited := newBudgetWriter(0)
+	unlimited.WriteString(strings.Repeat("x", 100000))
+	if unlimited.Truncated() || unlimited.Len() != 100000 {
+		t.Error("zero budget should be unlimited")
+	}
+}
+
+func TestTruncateChars(t *testing.T) {
+	tests := []struct {
+		s        string
+		max      int
+		want     string
+		truncate bool
+	}{
+		{"abcdef", 3, "abc", true},
+		{"abc", 3, "abc", false},
+		{"äöüß", 3, "äöü", true},
+		{"äöü", 3, "äöü", false}, // 6 bytes, but only 3 characters
+		{"ab€cd", 3, "ab€", true},
+		{"äöü", 0, "äöü", false},
+	}
+	for _, tt := range tests {
+		got, truncated := truncateChars(tt.s, tt.max)
+		if got != tt.want || truncated != tt.truncate {
+			t.Errorf("truncateChars(%q, %d) = %q, %v; want %q, %v", tt.s, tt.max, got, truncated, tt.want, tt.truncate)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateChars(%q, %d) returned invalid UTF-8 %q", tt.s, tt.max, got)
+		}
+	}
+}
+
+func TestGetContextInfoTruncatesLastResponse(t *testing.T) {
+	h := newTestHandler(t)
+	// The 500th character is a two-byte "ü", so cutting at byte 500 would
+	// split it
+	h.SetLastResponse(strings.Repeat("x", 499) + "übermäßig lange Antwort")
+
+	info := h.GetContextInfo()
+	if !utf8.ValidString(info) {
+		t.Fatalf("GetContextInfo returned invalid UTF-8")
+	}
+	if !strings.Contains(info, strings.Repeat("x", 499)+"ü... (truncated)") {
+		t.Errorf("Expected the response cut after 500 characters, got:\n%s", info)
+	}
+}
+
+func TestGetMedicalHistoryTruncates(t *testing.T) {
+	t.Setenv("MCP_MEDICAL_HISTORY_MAX_CHARS", "2000")
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	for i := 0; i < 100; i++ {
+		seedCondition(t, h, "p1", fmt.Sprintf("C%03d", i), fmt.Sprintf("Condition number %d", i), "active", "2020-01-01")
+		seedObservation(t, h, "p1", "8867-4", "Heart rate", float64(60+i), "/min", fmt.Sprintf("2024-01-01T09:%02d:00Z", i%60))
+	}
+
+	result, err := h.GetMedicalHistory("p1", "all", 100, 0)
+	text := resultText(t, h, result, err)
+	if !strings.HasSuffix(text, "(output truncated — request a specific category)") {
+		t.Errorf("truncation note missing:\n%s", text[len(text)-200:])
+	}
+	if strings.Contains(text, "OBSERVATIONS:") {
+		t.Error("sections after the budget was exhausted were written")
+	}
+	if n := len([]rune(text)); n > 2100 {
+		t.Errorf("output has %d characters, budget is 2000", n)
+	}
+
+	result, err = h.GetMedicalHistory("p1", "allergies", 0, 0)
+	if text := resultText(t, h, result, err); strings.Contains(text, "truncated") {
+		t.Errorf("short output was marked truncated:\n%s", text)
+	}
+}
+
+func TestGetMedicalHistoryPages(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	for i := 0; i < 25; i++ {
+		seedCondition(t, h, "p1", fmt.Sprintf("C%03d", i), fmt.Sprintf("Condition number %d", i), "active", "2020-01-01")
+	}
+
+	result, err := h.GetMedicalHistory("p1", "conditions", 0, 0)
+	text := resultText(t, h, result, err)
+	if n := strings.Count(text, "• "); n != 10 {
+		t.Errorf("default page has %d conditions, want 10", n)
+	}
+	if !strings.Contains(text, "showing 1-10 of 25, use offset=10") {
+		t.Errorf("paging note missing:\n%s", text)
+	}
+
+	result, err = h.GetMedicalHistory("p1", "conditions", 10, 20)
+	text = resultText(t, h, result, err)
+	if n := strings.Count(text, "• "); n != 5 {
+		t.Errorf("last page has %d conditions, want 5", n)
+	}
+	if !strings.Contains(text, "Condition number 24") || strings.Contains(text, "Condition number 19") {
+		t.Errorf("wrong records on the last page:\n%s", text)
+	}
+	if strings.Contains(text, "showing") {
+		t.Errorf("last page should not offer more:\n%s", text)
+	}
+
+	result, err = h.GetMedicalHistory("p1", "conditions", 10, 30)
+	if text := resultText(t, h, result, err); !strings.Contains(text, "offset 30 is past the end; 25 record(s)") {
+		t.Errorf("past-the-end note missing:\n%s", text)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func (h *Handler) GetCareTeam(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	members, err := database.GetCareTeamByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get care team: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Care team for %s (ID: %s)\n\n", patientName, patientID))
+
+	unassigned := 0
+	practitioners := 0
+	for _, m := range members {
+		if m.PractitionerID == nil {
+			unassigned = m.EncounterCount
+			continue
+		}
+		practitioners++
+
+		name := strings.TrimSpace(m.GivenName + " " + m.FamilyName)
+		if name == "" {
+			name = "Unknown practitioner"
+		}
+		result.WriteString(fmt.Sprintf("• %s (ID: %s): %d encounter(s)", name, *m.PractitionerID, m.EncounterCount))
+		if len(m.LastEncounter) >= 10 {
+			result.WriteString(fmt.Sprintf(", last on %s", m.LastEncounter[:10]))
+		}
+		result.WriteString("\n")
+	}
+
+	if practitioners == 0 {
+		result.WriteString("No practitioners recorded on this patient's encounters.\n")
+	}
+	if unassigned > 0 {
+		result.WriteString(fmt.Sprintf("\n%d encounter(s) have no recorded practitioner.\n", unassigned))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
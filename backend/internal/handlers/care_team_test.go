@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetCareTeam(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedPatient(t, h, "p2", "Jennifer", "Parker", "female", "1968-01-01")
+	seedPractitioner(t, h, "dr1", "Emmett", "Brown")
+	seedPractitioner(t, h, "dr2", "Gregory", "House")
+
+	seedEncounter(t, h, "e1", "p1", "finished", "2024-01-01T09:00:00Z")
+	seedEncounter(t, h, "e2", "p1", "finished", "2024-03-01T09:00:00Z")
+	seedEncounter(t, h, "e3", "p1", "finished", "2024-05-01T09:00:00Z")
+	seedEncounter(t, h, "e4", "p1", "finished", "2024-06-01T09:00:00Z")
+	seedEncounter(t, h, "e5", "p1", "finished", "2024-07-01T09:00:00Z")
+	seedEncounter(t, h, "e6", "p2", "finished", "2024-07-01T09:00:00Z")
+	if _, err := h.db.Exec(`UPDATE encounters SET practitioner_id = CASE id
+		WHEN 'e1' THEN 'dr1' WHEN 'e2' THEN 'dr1' WHEN 'e3' THEN 'dr2' WHEN 'e4' THEN '' WHEN 'e6' THEN 'dr2' END`); err != nil {
+		t.Fatalf("Failed to assign practitioners: %v", err)
+	}
+
+	result, err := h.GetCareTeam("p1")
+	text := resultText(t, h, result, err)
+
+	for _, want := range []string{
+		"Emmett Brown (ID: dr1): 2 encounter(s), last on 2024-03-01",
+		"Gregory House (ID: dr2): 1 encounter(s), last on 2024-05-01",
+		"2 encounter(s) have no recorded practitioner",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "Emmett Brown") > strings.Index(text, "Gregory House") {
+		t.Errorf("practitioners not ordered by encounter count:\n%s", text)
+	}
+	if strings.Count(text, "Gregory House") != 1 {
+		t.Errorf("practitioner listed more than once:\n%s", text)
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// charlsonCategory is one comorbidity of the Charlson Comorbidity Index
+// (Charlson 1987 weights). A condition belongs to it when its code starts
+// with one of the ICD-10 prefixes (compared without dots) or its display
+// contains one of the terms and none of the exclusions.
+type charlsonCategory struct {
+	Key     string
+	Label   string
+	Weight  int
+	ICD10   []string
+	Terms   []string
+	Exclude []string
+	// Supersedes is the milder category this one replaces, e.g. metastatic
+	// tumor replaces any tumor, so a patient scores only the more severe
+	Supersedes string
+}
+
+var charlsonCategories = []charlsonCategory{
+	{Key: "mi", Label: "Myocardial infarction", Weight: 1,
+		ICD10: []string{"I21", "I22", "I252"},
+		Terms: []string{"myocardial infarction", "heart attack"}},
+	{Key: "chf", Label: "Congestive heart failure", Weight: 1,
+		ICD10: []string{"I099", "I110", "I130", "I132", "I255", "I420", "I425", "I426", "I427", "I428", "I429", "I43", "I50", "P290"},
+		Terms: []string{"heart failure", "cardiomyopathy"}},
+	{Key: "pvd", Label: "Peripheral vascular disease", Weight: 1,
+		ICD10: []string{"I70", "I71", "I731", "I738", "I739", "I771", "I790", "I792", "K551", "K558", "K559", "Z958", "Z959"},
+		Terms: []string{"peripheral vascular disease", "peripheral arterial disease", "peripheral artery disease", "aortic aneurysm", "intermittent claudication"}},
+	{Key: "cvd", Label: "Cerebrovascular disease", Weight: 1,
+		ICD10: []string{"G45", "G46", "H340", "I60", "I61", "I62", "I63", "I64", "I65", "I66", "I67", "I68", "I69"},
+		Terms: []string{"stroke", "cerebrovascular", "transient ischemic attack", "cerebral infarction", "intracerebral hemorrhage", "subarachnoid hemorrhage"}},
+	{Key: "dementia", Label: "Dementia", Weight: 1,
+		ICD10: []string{"F00", "F01", "F02", "F03", "F051", "G30", "G311"},
+		Terms: []string{"dementia", "alzheimer"}},
+	{Key: "copd", Label: "Chronic pulmonary disease", Weight: 1,
+		ICD10: []string{"I278", "I279", "J40", "J41", "J42", "J43", "J44", "J45", "J46", "J47", "J60", "J61", "J62", "J63", "J64", "J65", "J66", "J67", "J684", "J701", "J703"},
+		Terms: []string{"chronic obstructive", "emphysema", "chronic bronchitis", "asthma", "bronchiectasis", "pulmonary fibrosis", "pneumoconiosis"}},
+	{Key: "rheumatic", Label: "Connective tissue disease", Weight: 1,
+		ICD10: []string{"M05", "M06", "M315", "M32", "M33", "M34", "M351", "M353", "M360"},
+		Terms: []string{"rheumatoid arthritis", "lupus erythematosus", "systemic sclerosis", "scleroderma", "polymyositis", "dermatomyositis", "polymyalgia rheumatica", "mixed connective tissue"}},
+	{Key: "ulcer", Label: "Peptic ulcer disease", Weight: 1,
+		ICD10: []string{"K25", "K26", "K27", "K28"},
+		Terms: []string{"peptic ulcer", "gastric ulcer", "duodenal ulcer", "gastrojejunal ulcer"}},
+	{Key: "mild_liver", Label: "Mild liver disease", Weight: 1,
+		ICD10: []string{"B18", "K700", "K701", "K702", "K703", "K709", "K713", "K714", "K715", "K717", "K73", "K74", "K760", "K762", "K763", "K764", "K768", "K769", "Z944"},
+		Terms: []string{"chronic hepatitis", "hepatitis b", "hepatitis c", "cirrhosis", "fatty liver", "alcoholic liver disease"}},
+	{Key: "diabetes", Label: "Diabetes without end-organ damage", Weight: 1,
+		ICD10:   []string{"E100", "E101", "E106", "E108", "E109", "E110", "E111", "E116", "E118", "E119", "E130", "E131", "E136", "E138", "E139", "E140", "E141", "E146", "E148", "E149"},
+		Terms:   []string{"diabetes"},
+		Exclude: []string{"prediabetes", "gestational", "insipidus"}},
+	{Key: "hemiplegia", Label: "Hemiplegia or paraplegia", Weight: 2,
+		ICD10: []string{"G041", "G114", "G801", "G802", "G81", "G82", "G830", "G831", "G832", "G833", "G834", "G839"},
+		Terms: []string{"hemiplegia", "paraplegia", "quadriplegia", "tetraplegia", "hemiparesis"}},
+	{Key: "renal", Label: "Moderate or severe renal disease", Weight: 2,
+		ICD10: []string{"I120", "I131", "N032", "N033", "N034", "N035", "N036", "N037", "N052", "N053", "N054", "N055", "N056", "N057", "N183", "N184", "N185", "N186", "N19", "N250", "Z490", "Z491", "Z492", "Z940", "Z992"},
+		Terms: []string{"chronic kidney disease stage 3", "chronic kidney disease stage 4", "chronic kidney disease stage 5", "end-stage renal", "end stage renal", "chronic renal failure", "chronic kidney failure", "dependence on renal dialysis", "kidney transplant"}},
+	{Key: "diabetes_complicated", Label: "Diabetes with end-organ damage", Weight: 2, Supersedes: "diabetes",
+		ICD10: []string{"E102", "E103", "E104", "E105", "E107", "E112", "E113", "E114", "E115", "E117", "E132", "E133", "E134", "E135", "E137", "E142", "E143", "E144", "E145", "E147"},
+		Terms: []string{"diabetic retinopathy", "diabetic nephropathy", "diabetic neuropathy", "diabetic kidney disease", "diabetic foot",
+			"due to type 1 diabetes", "due to type 2 diabetes", "due to type ii diabetes", "associated with type 1 diabetes", "associated with type 2 diabetes", "associated with type ii diabetes"}},
+	{Key: "tumor", Label: "Any tumor (solid, without metastasis)", Weight: 2,
+		ICD10:   []string{"C0", "C1", "C20", "C21", "C22", "C23", "C24", "C25", "C26", "C30", "C31", "C32", "C33", "C34", "C37", "C38", "C39", "C40", "C41", "C43", "C45", "C46", "C47", "C48", "C49", "C50", "C51", "C52", "C53", "C54", "C55", "C56", "C57", "C58", "C6", "C70", "C71", "C72", "C73", "C74", "C75", "C76", "C97"},
+		Terms:   []string{"cancer", "carcinoma", "malignant neoplasm", "neoplasm of", "sarcoma", "melanoma", "glioblastoma", "mesothelioma"},
+		Exclude: []string{"benign", "in situ", "leukemia", "leukaemia", "lymphoma", "myeloma", "metasta", "secondary malignant", "screening", "history of"}},
+	{Key: "leukemia", Label: "Leukemia", Weight: 2,
+		ICD10: []string{"C91", "C92", "C93", "C94", "C95"},
+		Terms: []string{"leukemia", "leukaemia"}},
+	{Key: "lymphoma", Label: "Lymphoma", Weight: 2,
+		ICD10: []string{"C81", "C82", "C83", "C84", "C85", "C88", "C90", "C96"},
+		Terms: []string{"lymphoma", "multiple myeloma", "waldenstrom"}},
+	{Key: "severe_liver", Label: "Moderate or severe liver disease", Weight: 3, Supersedes: "mild_liver",
+		ICD10: []string{"I850", "I859", "I864", "I982", "K704", "K711", "K721", "K729", "K765", "K766", "K767"},
+		Terms: []string{"portal hypertension", "esophageal varices", "oesophageal varices", "hepatic encephalopathy", "liver failure", "hepatic failure", "hepatorenal syndrome"}},
+	{Key: "metastatic", Label: "Metastatic solid tumor", Weight: 6, Supersedes: "tumor",
+		ICD10: []string{"C77", "C78", "C79", "C80"},
+		Terms: []string{"metastatic", "metastasis", "metastases", "secondary malignant neoplasm"}},
+	{Key: "aids", Label: "AIDS", Weight: 6,
+		ICD10: []string{"B20", "B21", "B22", "B24"},
+		Terms: []string{"acquired immunodeficiency syndrome", "acquired immune deficiency syndrome", "human immunodeficiency virus", "hiv infection", "hiv disease"}},
+}
+
+// matches reports whether the condition belongs to the category
+func (c charlsonCategory) matches(condition database.Condition) bool {
+	code := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(condition.Code), ".", ""))
+	for _, prefix := range c.ICD10 {
+		if code != "" && strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return containsAny(condition.Display, c.Terms) && !containsAny(condition.Display, c.Exclude)
+}
+
+// charlsonAgePoints returns the age adjustment: one point per decade from 50,
+// up to four points from 80
+func charlsonAgePoints(age int) int {
+	if age < 50 {
+		return 0
+	}
+	return min(4, (age-40)/10)
+}
+
+// charlsonTenYearSurvival returns the estimated 10-year survival in percent,
+// 0.983^(e^(0.9 × index))
+func charlsonTenYearSurvival(index int) float64 {
+	return 100 * math.Pow(0.983, math.Exp(0.9*float64(index)))
+}
+
+// charlsonEvidence groups conditions by the Charlson category they belong
+// to. superseded maps a category replaced by a more severe one in the record
+// to that one's label; conditions matching no category are unmapped.
+func charlsonEvidence(conditions []database.Condition) (evidence map[string][]string, superseded map[string]string, unmapped []string) {
+	// The conditions found for each category, and those matching none
+	evidence = make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, condition := range conditions {
+		// A condition matching a category and the one superseding it, such
+		// as diabetic retinopathy, is evidence for the more severe only
+		matched := make(map[string]bool)
+		for _, category := range charlsonCategories {
+			if category.matches(condition) {
+				matched[category.Key] = true
+			}
+		}
+		for _, category := range charlsonCategories {
+			if matched[category.Key] && category.Supersedes != "" {
+				delete(matched, category.Supersedes)
+			}
+		}
+
+		for _, category := range charlsonCategories {
+			if matched[category.Key] && !seen[category.Key+"|"+condition.Display] {
+				seen[category.Key+"|"+condition.Display] = true
+				evidence[category.Key] = append(evidence[category.Key], condition.Display)
+			}
+		}
+		if len(matched) == 0 && !seen["|"+condition.Display] {
+			seen["|"+condition.Display] = true
+			unmapped = append(unmapped, condition.Display)
+		}
+	}
+
+	superseded = make(map[string]string)
+	for _, category := range charlsonCategories {
+		if category.Supersedes != "" && len(evidence[category.Key]) > 0 {
+			superseded[category.Supersedes] = category.Label
+		}
+	}
+	return evidence, superseded, unmapped
+}
+
+// CalculateCharlson calculates the age-adjusted Charlson Comorbidity Index
+// from the patient's conditions with the estimated 10-year survival.
+// Resolved conditions count too, since the index weighs history (e.g. a
+// previous myocardial infarction). Conditions that map to no category are
+// listed so the clinician can check nothing was missed.
+func (h *Handler) CalculateCharlson(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+
+	evidence, superseded, unmapped := charlsonEvidence(conditions)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Charlson Comorbidity Index for %s %s (ID: %s)\n\n", patient.GivenName, patient.FamilyName, patientID))
+
+	index := 0
+	counted := 0
+	for _, category := range charlsonCategories {
+		found := evidence[category.Key]
+		if len(found) == 0 {
+			continue
+		}
+		if replacement, ok := superseded[category.Key]; ok {
+			result.WriteString(fmt.Sprintf("[ ] %s: 0 (counted as %s; from data: %s)\n", category.Label, replacement, strings.Join(found, "; ")))
+			continue
+		}
+		index += category.Weight
+		counted++
+		result.WriteString(fmt.Sprintf("[x] %s: +%d (from data: %s)\n", category.Label, category.Weight, strings.Join(found, "; ")))
+	}
+	if counted == 0 {
+		result.WriteString("No Charlson comorbidities found in the record.\n")
+	}
+
+	if age, err := calculateAge(patient.BirthDate); err == nil {
+		points := charlsonAgePoints(age)
+		index += points
+		result.WriteString(fmt.Sprintf("Age %d: +%d\n", age, points))
+	} else {
+		result.WriteString("Age: unknown, no age points added\n")
+	}
+
+	result.WriteString(fmt.Sprintf("\nCharlson Comorbidity Index: %d\n", index))
+	result.WriteString(fmt.Sprintf("Estimated 10-year survival: %.0f%%\n", charlsonTenYearSurvival(index)))
+
+	if len(unmapped) > 0 {
+		result.WriteString(fmt.Sprintf("\nNot mapped to a Charlson comorbidity (not scored): %s\n", strings.Join(unmapped, "; ")))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestCharlsonEvidence(t *testing.T) {
+	condition := func(code, display string) database.Condition {
+		return database.Condition{Code: code, Display: display}
+	}
+
+	tests := []struct {
+		name           string
+		conditions     []database.Condition
+		wantScored     []string // category keys, in category order
+		wantSuperseded []string
+		wantUnmapped   []string
+	}{
+		{"none", nil, nil, nil, nil},
+		{"matched by display", []database.Condition{
+			condition("22298006", "Myocardial infarction"),
+			condition("88805009", "Chronic congestive heart failure (disorder)"),
+			condition("431857002", "Chronic kidney disease stage 4 (disorder)"),
+		}, []string{"mi", "chf", "renal"}, nil, nil},
+		{"matched by ICD-10 code with a dot", []database.Condition{
+			condition("I21.4", "Acute subendocardial infarction"),
+		}, []string{"mi"}, nil, nil},
+		{"complicated diabetes replaces diabetes", []database.Condition{
+			condition("44054006", "Type 2 diabetes mellitus"),
+			condition("422034002", "Diabetic retinopathy associated with type II diabetes mellitus (disorder)"),
+		}, []string{"diabetes_complicated"}, []string{"diabetes"}, nil},
+		{"metastasis replaces the primary tumor", []database.Condition{
+			condition("C18.9", "Primary malignant neoplasm of colon"),
+			condition("C78.7", "Secondary malignant neoplasm of liver"),
+		}, []string{"metastatic"}, []string{"tumor"}, nil},
+		{"exclusions and unrelated conditions are unmapped", []database.Condition{
+			condition("714628002", "Prediabetes"),
+			condition("36971009", "Sinusitis (disorder)"),
+			condition("36971009", "Sinusitis (disorder)"),
+		}, nil, nil, []string{"Prediabetes", "Sinusitis (disorder)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evidence, superseded, unmapped := charlsonEvidence(tt.conditions)
+			var scored, replaced []string
+			for _, category := range charlsonCategories {
+				if _, ok := superseded[category.Key]; ok {
+					replaced = append(replaced, category.Key)
+				} else if len(evidence[category.Key]) > 0 {
+					scored = append(scored, category.Key)
+				}
+			}
+			if !reflect.DeepEqual(scored, tt.wantScored) || !reflect.DeepEqual(replaced, tt.wantSuperseded) || !reflect.DeepEqual(unmapped, tt.wantUnmapped) {
+				t.Errorf("scored %v, superseded %v, unmapped %v; want %v, %v, %v",
+					scored, replaced, unmapped, tt.wantScored, tt.wantSuperseded, tt.wantUnmapped)
+			}
+		})
+	}
+}
+
+func TestCalculateCharlson(t *testing.T) {
+	h := newTestHandler(t)
+	birthDate := time.Now().AddDate(-72, 0, -1).Format("2006-01-02")
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", birthDate)
+	seedCondition(t, h, "p1", "44054006", "Type 2 diabetes mellitus", "active", "2010-01-01T00:00:00Z")
+	seedCondition(t, h, "p1", "422034002", "Diabetic retinopathy associated with type II diabetes mellitus (disorder)", "active", "2018-01-01T00:00:00Z")
+	seedCondition(t, h, "p1", "36971009", "Sinusitis (disorder)", "resolved", "2020-01-01T00:00:00Z")
+
+	result, err := h.CalculateCharlson("p1")
+	text := resultText(t, h, result, err)
+
+	// Diabetes with end-organ damage (2) and age 72 (3)
+	for _, want := range []string{
+		"[ ] Diabetes without end-organ damage: 0 (counted as Diabetes with end-organ damage; from data: Type 2 diabetes mellitus)",
+		"[x] Diabetes with end-organ damage: +2 (from data: Diabetic retinopathy",
+		"Age 72: +3",
+		"Charlson Comorbidity Index: 5\n",
+		"Not mapped to a Charlson comorbidity (not scored): Sinusitis (disorder)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestCharlsonAgeAndSurvival(t *testing.T) {
+	for age, want := range map[int]int{35: 0, 49: 0, 50: 1, 59: 1, 60: 2, 75: 3, 80: 4, 97: 4} {
+		if got := charlsonAgePoints(age); got != want {
+			t.Errorf("charlsonAgePoints(%d) = %d, want %d", age, got, want)
+		}
+	}
+	for index, want := range map[int]float64{0: 98.3, 2: 90.1, 4: 53.4, 6: 2.25} {
+		if got := charlsonTenYearSurvival(index); math.Abs(got-want) > 0.05 {
+			t.Errorf("charlsonTenYearSurvival(%d) = %.2f, want %.2f", index, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// defaultAgingClaimsListed is how many of the oldest claims GetClaimsAging
+// lists when no limit is given
+const defaultAgingClaimsListed = 10
+
+// agingBucket totals the claims whose age in days falls in [MinDays, MaxDays]
+// (MaxDays 0 means no upper bound)
+type agingBucket struct {
+	Label   string
+	MinDays int
+	MaxDays int
+	Count   int
+	Totals  map[string]float64 // by currency
+}
+
+func newAgingBuckets() []agingBucket {
+	return []agingBucket{
+		{Label: "0-30 days", MinDays: 0, MaxDays: 30},
+		{Label: "31-60 days", MinDays: 31, MaxDays: 60},
+		{Label: "61-90 days", MinDays: 61, MaxDays: 90},
+		{Label: "90+ days", MinDays: 91},
+	}
+}
+
+func (b *agingBucket) add(c database.Claim) {
+	b.Count++
+	if c.TotalAmount == nil {
+		return
+	}
+	if b.Totals == nil {
+		b.Totals = make(map[string]float64)
+	}
+	b.Totals[derefString(c.Currency)] += *c.TotalAmount
+}
+
+// formatTotals renders the per-currency totals as "USD 120.00, EUR 15.50"
+func (b *agingBucket) formatTotals() string {
+	if len(b.Totals) == 0 {
+		return "0.00"
+	}
+	currencies := make([]string, 0, len(b.Totals))
+	for currency := range b.Totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %.2f", currency, b.Totals[currency])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// claimAgeDays returns the whole days between the claim's created date and
+// now. Claims dated in the future count as 0 days old.
+func claimAgeDays(c database.Claim, now time.Time) (int, bool) {
+	created, ok := parseRecordTime(derefString(c.CreatedDateTime))
+	if !ok {
+		return 0, false
+	}
+	days := int(now.Sub(created).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+	return days, true
+}
+
+// claimsAging sorts claims into age buckets relative to now. Claims without a
+// parseable created date are totalled in the returned undated bucket.
+func claimsAging(claims []database.Claim, now time.Time) ([]agingBucket, agingBucket) {
+	buckets := newAgingBuckets()
+	undated := agingBucket{Label: "Unknown date"}
+
+	for _, c := range claims {
+		days, ok := claimAgeDays(c, now)
+		if !ok {
+			undated.add(c)
+			continue
+		}
+		for i := range buckets {
+			if days >= buckets[i].MinDays && (buckets[i].MaxDays == 0 || days <= buckets[i].MaxDays) {
+				buckets[i].add(c)
+				break
+			}
+		}
+	}
+	return buckets, undated
+}
+
+// GetClaimsAging buckets all claims by days since they were created and lists
+// the limit oldest claims
+func (h *Handler) GetClaimsAging(limit int) (interface{}, error) {
+	if limit <= 0 {
+		limit = defaultAgingClaimsListed
+	}
+
+	claims, err := database.GetAllClaims(h.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claims: %w", err)
+	}
+
+	now := time.Now()
+	buckets, undated := claimsAging(claims, now)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Claims aging report (%d claims, as of %s)\n\n", len(claims), now.Format("2006-01-02")))
+	for _, b := range buckets {
+		result.WriteString(fmt.Sprintf("%-13s %5d claims  %s\n", b.Label+":", b.Count, b.formatTotals()))
+	}
+	if undated.Count > 0 {
+		result.WriteString(fmt.Sprintf("%-13s %5d claims  %s\n", undated.Label+":", undated.Count, undated.formatTotals()))
+	}
+
+	var oldest []string
+	for _, c := range claims {
+		if len(oldest) == limit {
+			break
+		}
+		days, ok := claimAgeDays(c, now)
+		if !ok {
+			continue
+		}
+		line := fmt.Sprintf("• %s: %d days (patient %s, status %s", c.ID, days, c.PatientID, c.Status)
+		if c.TotalAmount != nil {
+			line += strings.TrimRight(fmt.Sprintf(", %.2f %s", *c.TotalAmount, derefString(c.Currency)), " ")
+		}
+		oldest = append(oldest, line+")")
+	}
+	if len(oldest) > 0 {
+		result.WriteString(fmt.Sprintf("\nOldest claims:\n%s\n", strings.Join(oldest, "\n")))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
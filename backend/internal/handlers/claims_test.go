@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestClaimsAging(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	claim := func(id, created string, amount float64) database.Claim {
+		c := database.Claim{ID: id, Status: "active", TotalAmount: &amount}
+		if created != "" {
+			c.CreatedDateTime = &created
+		}
+		usd := "USD"
+		c.Currency = &usd
+		return c
+	}
+
+	claims := []database.Claim{
+		claim("c1", "2025-06-29T10:00:00Z", 100),  // 1 day
+		claim("c2", "2025-05-31T12:00:00Z", 50),   // 30 days
+		claim("c3", "2025-05-30", 20),             // 31 days
+		claim("c4", "2025-04-01T08:00:00Z", 10),   // 90 days
+		claim("c5", "2024-12-01T08:00:00Z", 5),    // 211 days
+		claim("c6", "", 1),                        // no created date
+		claim("c7", "not a date", 2),              // unparseable
+		claim("c8", "2025-07-15T00:00:00Z", 1000), // future
+	}
+
+	buckets, undated := claimsAging(claims, now)
+
+	want := []struct {
+		count int
+		total float64
+	}{
+		{3, 1150}, // c1, c2, c8
+		{1, 20},   // c3
+		{1, 10},   // c4
+		{1, 5},    // c5
+	}
+	for i, w := range want {
+		if buckets[i].Count != w.count || buckets[i].Totals["USD"] != w.total {
+			t.Errorf("%s: got %d claims totalling %.2f, want %d totalling %.2f",
+				buckets[i].Label, buckets[i].Count, buckets[i].Totals["USD"], w.count, w.total)
+		}
+	}
+	if undated.Count != 2 || undated.Totals["USD"] != 3 {
+		t.Errorf("undated: got %d claims totalling %.2f, want 2 totalling 3.00", undated.Count, undated.Totals["USD"])
+	}
+}
+
+func TestGetClaimsAging(t *testing.T) {
+	h := newTestHandler(t)
+	if _, err := h.db.Exec(`INSERT INTO claims (id, status, patient_id, created_datetime, total_amount, currency)
+		VALUES ('c1', 'active', 'p1', NULL, 10, 'USD'), ('c2', 'active', 'p1', '2020-01-01T00:00:00Z', 25.5, 'USD')`); err != nil {
+		t.Fatalf("Failed to seed claims: %v", err)
+	}
+
+	result, err := h.GetClaimsAging(0)
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"2 claims", "90+ days:", "USD 25.50", "Unknown date:", "c2:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+}
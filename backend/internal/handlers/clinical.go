@@ -0,0 +1,679 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// devineIdealBodyWeight returns the ideal body weight in kg using the Devine
+// formula: 50 kg (male) or 45.5 kg (female) + 2.3 kg per inch over 5 feet.
+func devineIdealBodyWeight(heightCm float64, gender string) (float64, error) {
+	var base float64
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m":
+		base = 50.0
+	case "female", "f":
+		base = 45.5
+	default:
+		return 0, fmt.Errorf("gender must be male or female to estimate ideal body weight (got %q)", gender)
+	}
+	if heightCm <= 0 {
+		return 0, fmt.Errorf("height must be positive")
+	}
+
+	heightInches := heightCm / 2.54
+	return base + 2.3*(heightInches-60.0), nil
+}
+
+// adjustedBodyWeight returns IBW + 0.4 × (actual − IBW), used for dosing in
+// patients whose actual weight exceeds their ideal body weight.
+func adjustedBodyWeight(idealKg, actualKg float64) float64 {
+	return idealKg + 0.4*(actualKg-idealKg)
+}
+
+func (h *Handler) CalculateIdealBodyWeight(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("Ideal Body Weight for %s (ID: %s)\n\n", patientName, patientID)
+
+	height := latestHeightCm(observations)
+	if height == nil {
+		resultText += "Unable to calculate ideal body weight: no body height found in observations. Please add a height observation first."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": resultText,
+				},
+			},
+		}, nil
+	}
+
+	ibw, err := devineIdealBodyWeight(*height, patient.Gender)
+	if err != nil {
+		return nil, err
+	}
+
+	resultText += fmt.Sprintf("Height: %.1f cm\nGender: %s\n", *height, patient.Gender)
+	resultText += fmt.Sprintf("Ideal body weight (Devine): %.1f kg\n", ibw)
+	if *height < 152.4 {
+		resultText += "Note: Devine formula is less reliable for heights under 5 feet (152.4 cm).\n"
+	}
+
+	weight := latestWeightKg(observations)
+	if weight == nil {
+		resultText += "\nActual body weight: not found in observations (adjusted body weight not calculated)"
+	} else {
+		resultText += fmt.Sprintf("\nActual body weight: %.1f kg (%.0f%% of IBW)\n", *weight, *weight/ibw*100)
+		if *weight > ibw {
+			resultText += fmt.Sprintf("Adjusted body weight: %.1f kg", adjustedBodyWeight(ibw, *weight))
+			if *weight >= ibw*1.2 {
+				resultText += "\n\nActual weight is ≥120% of IBW: consider adjusted body weight for weight-based dosing."
+			}
+		} else {
+			resultText += "Adjusted body weight: not applicable (actual weight does not exceed IBW)"
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// bodyMassIndex returns weight / height² in kg/m²
+func bodyMassIndex(weightKg, heightCm float64) float64 {
+	heightM := heightCm / 100.0
+	return weightKg / (heightM * heightM)
+}
+
+// bmiCategory returns the WHO adult BMI category
+func bmiCategory(bmi float64) string {
+	switch {
+	case bmi < 18.5:
+		return "underweight"
+	case bmi < 25:
+		return "normal weight"
+	case bmi < 30:
+		return "overweight"
+	default:
+		return "obese"
+	}
+}
+
+func (h *Handler) CalculateBMI(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("BMI for %s (ID: %s)\n\n", patientName, patientID)
+
+	weight := latestWeightKg(observations)
+	height := latestHeightCm(observations)
+
+	var missing []string
+	if weight == nil {
+		missing = append(missing, "body weight")
+	}
+	if height == nil {
+		missing = append(missing, "body height")
+	}
+	if len(missing) > 0 {
+		resultText += "Unable to calculate BMI: no " + strings.Join(missing, " or ") + " found in observations. Please add a " +
+			strings.Join(missing, " and a ") + " observation first."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": resultText,
+				},
+			},
+		}, nil
+	}
+
+	bmi := bodyMassIndex(*weight, *height)
+	resultText += fmt.Sprintf("Weight: %.1f kg\nHeight: %.1f cm\n", *weight, *height)
+	resultText += fmt.Sprintf("\nBMI: %.1f kg/m² (%s)", bmi, bmiCategory(bmi))
+	if age, err := calculateAge(patient.BirthDate); err == nil && age < 18 {
+		resultText += "\n\nNote: these are adult categories; use BMI-for-age percentiles for patients under 18."
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// ckdEPI2021 returns the eGFR in mL/min/1.73m² using the race-free CKD-EPI
+// 2021 creatinine equation:
+// 142 × min(Scr/κ, 1)^α × max(Scr/κ, 1)^-1.200 × 0.9938^age (× 1.012 if female),
+// with κ = 0.7 (female) or 0.9 (male) and α = -0.241 (female) or -0.302 (male).
+func ckdEPI2021(creatinineMgDL float64, age int, gender string) (float64, error) {
+	var kappa, alpha, sexFactor float64
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "female", "f":
+		kappa, alpha, sexFactor = 0.7, -0.241, 1.012
+	case "male", "m":
+		kappa, alpha, sexFactor = 0.9, -0.302, 1.0
+	default:
+		return 0, fmt.Errorf("gender must be male or female to estimate eGFR (got %q)", gender)
+	}
+	if creatinineMgDL <= 0 {
+		return 0, fmt.Errorf("creatinine must be positive")
+	}
+
+	ratio := creatinineMgDL / kappa
+	return 142 * math.Pow(math.Min(ratio, 1), alpha) * math.Pow(math.Max(ratio, 1), -1.200) *
+		math.Pow(0.9938, float64(age)) * sexFactor, nil
+}
+
+// ckdStage returns the KDIGO GFR category for an eGFR
+func ckdStage(egfr float64) string {
+	switch {
+	case egfr >= 90:
+		return "G1 (normal or high)"
+	case egfr >= 60:
+		return "G2 (mildly decreased)"
+	case egfr >= 45:
+		return "G3a (mildly to moderately decreased)"
+	case egfr >= 30:
+		return "G3b (moderately to severely decreased)"
+	case egfr >= 15:
+		return "G4 (severely decreased)"
+	default:
+		return "G5 (kidney failure)"
+	}
+}
+
+func (h *Handler) CalculateEGFR(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if patient.BirthDate == "" {
+		return nil, fmt.Errorf("cannot calculate eGFR: patient %s has no birth date on record", patientID)
+	}
+	if err := validateBirthDate(patient.BirthDate, time.Now()); err != nil {
+		return nil, fmt.Errorf("cannot calculate eGFR: %w", err)
+	}
+	age, err := calculateAge(patient.BirthDate)
+	if err != nil {
+		return nil, fmt.Errorf("cannot calculate eGFR: %w", err)
+	}
+	if age < 18 {
+		return nil, fmt.Errorf("cannot calculate eGFR: CKD-EPI is validated for adults only (patient is %d)", age)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	creatinineObs, creatinine, ok := latestCreatinineMgDL(observations, serumCreatinineTerms)
+	if !ok {
+		return nil, fmt.Errorf("cannot calculate eGFR: no serum creatinine in mg/dL or µmol/L found for patient %s", patientID)
+	}
+
+	egfr, err := ckdEPI2021(creatinine, age, patient.Gender)
+	if err != nil {
+		return nil, err
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("eGFR for %s (ID: %s)\n\n", patientName, patientID)
+	resultText += fmt.Sprintf("Serum creatinine: %.2f mg/dL (%s)\nAge: %d years\nGender: %s\n",
+		creatinine, observationDay(*creatinineObs), age, patient.Gender)
+	resultText += fmt.Sprintf("\neGFR (CKD-EPI 2021): %.0f mL/min/1.73m²\nCKD stage: %s", egfr, ckdStage(egfr))
+	if egfr >= 60 {
+		resultText += "\n\nNote: G1 and G2 indicate CKD only with other markers of kidney damage (e.g. albuminuria)."
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// Corrected sodium lab lookups, matched against the observation display
+var (
+	sodiumTerms  = []string{"sodium [moles/volume] in serum", "sodium [moles/volume] in blood", "serum sodium"}
+	glucoseTerms = []string{"glucose [mass/volume] in serum", "glucose [mass/volume] in blood", "glucose [moles/volume] in serum", "glucose [moles/volume] in blood", "serum glucose", "blood glucose"}
+)
+
+// correctedSodium adjusts measured sodium for hyperglycemia (Katz): add
+// 1.6 mEq/L for every 100 mg/dL of glucose above 100 mg/dL
+func correctedSodium(sodiumMEqL, glucoseMgDL float64) float64 {
+	if glucoseMgDL <= 100 {
+		return sodiumMEqL
+	}
+	return sodiumMEqL + 1.6*(glucoseMgDL-100)/100
+}
+
+func (h *Handler) CalculateCorrectedSodium(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	sodiumObs, sodium, ok := latestElectrolyte(observations, sodiumTerms)
+	if !ok {
+		return nil, fmt.Errorf("cannot calculate corrected sodium: no serum sodium in mmol/L or mEq/L found for patient %s", patientID)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("Corrected Sodium for %s (ID: %s)\n\n", patientName, patientID)
+	resultText += fmt.Sprintf("Measured sodium: %.0f mEq/L (%s)\n", sodium, observationDay(*sodiumObs))
+
+	glucoseObs, glucose, ok := latestGlucoseMgDL(observations, glucoseTerms)
+	if !ok {
+		resultText += "\nNo glucose in mg/dL or mmol/L found in observations, so the measured sodium is shown uncorrected. Please add a glucose result to correct for hyperglycemia."
+	} else {
+		resultText += fmt.Sprintf("Glucose: %.0f mg/dL (%s)\n", glucose, observationDay(*glucoseObs))
+		resultText += fmt.Sprintf("\nCorrected sodium: %.1f mEq/L", correctedSodium(sodium, glucose))
+		if glucose <= 100 {
+			resultText += "\n\nGlucose is not above 100 mg/dL, so no correction is needed."
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// Anion gap lab lookups, matched against the observation display
+var (
+	chlorideTerms    = []string{"chloride [moles/volume] in serum", "chloride [moles/volume] in blood", "serum chloride"}
+	bicarbonateTerms = []string{"bicarbonate [moles/volume] in serum", "bicarbonate [moles/volume] in blood", "carbon dioxide, total [moles/volume] in serum", "carbon dioxide, total [moles/volume] in blood", "serum bicarbonate"}
+	albuminTerms     = []string{"albumin [mass/volume] in serum", "albumin [mass/volume] in blood", "serum albumin"}
+)
+
+// Anion gap reference range in mEq/L, without potassium
+const (
+	anionGapLow  = 8.0
+	anionGapHigh = 12.0
+)
+
+// anionGap returns Na - (Cl + HCO3) in mEq/L
+func anionGap(sodium, chloride, bicarbonate float64) float64 {
+	return sodium - (chloride + bicarbonate)
+}
+
+// albuminCorrectedAnionGap adds 2.5 mEq/L for every 1 g/dL of albumin below
+// 4 g/dL (Figge), since albumin is the main unmeasured anion
+func albuminCorrectedAnionGap(gap, albuminGDL float64) float64 {
+	return gap + 2.5*(4.0-albuminGDL)
+}
+
+// anionGapCategory classifies an anion gap against the reference range
+func anionGapCategory(gap float64) string {
+	switch {
+	case gap > anionGapHigh:
+		return "high"
+	case gap < anionGapLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+func (h *Handler) CalculateAnionGap(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("Anion Gap for %s (ID: %s)\n\n", patientName, patientID)
+
+	sodiumObs, sodium, hasSodium := latestElectrolyte(observations, sodiumTerms)
+	chlorideObs, chloride, hasChloride := latestElectrolyte(observations, chlorideTerms)
+	bicarbonateObs, bicarbonate, hasBicarbonate := latestElectrolyte(observations, bicarbonateTerms)
+
+	var missing []string
+	if !hasSodium {
+		missing = append(missing, "serum sodium")
+	}
+	if !hasChloride {
+		missing = append(missing, "serum chloride")
+	}
+	if !hasBicarbonate {
+		missing = append(missing, "serum bicarbonate")
+	}
+	if len(missing) > 0 {
+		resultText += "Unable to calculate anion gap: no " + strings.Join(missing, ", ") +
+			" in mmol/L or mEq/L found in observations. Please add the missing result(s) first."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": resultText,
+				},
+			},
+		}, nil
+	}
+
+	gap := anionGap(sodium, chloride, bicarbonate)
+	resultText += fmt.Sprintf("Sodium: %.0f mEq/L (%s)\n", sodium, observationDay(*sodiumObs))
+	resultText += fmt.Sprintf("Chloride: %.0f mEq/L (%s)\n", chloride, observationDay(*chlorideObs))
+	resultText += fmt.Sprintf("Bicarbonate: %.0f mEq/L (%s)\n", bicarbonate, observationDay(*bicarbonateObs))
+	resultText += fmt.Sprintf("\nAnion gap: %.1f mEq/L (%s, reference %.0f-%.0f)", gap, anionGapCategory(gap), anionGapLow, anionGapHigh)
+
+	if albuminObs, albumin, ok := latestAlbuminGDL(observations, albuminTerms); ok {
+		corrected := albuminCorrectedAnionGap(gap, albumin)
+		resultText += fmt.Sprintf("\nAlbumin: %.1f g/dL (%s)", albumin, observationDay(*albuminObs))
+		resultText += fmt.Sprintf("\nAlbumin-corrected anion gap: %.1f mEq/L (%s)", corrected, anionGapCategory(corrected))
+	} else {
+		resultText += "\n\nNo albumin result found, so the gap is not corrected for hypoalbuminemia."
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// meldScore computes the original (UNOS) MELD score:
+// 3.78×ln(bilirubin) + 11.2×ln(INR) + 9.57×ln(creatinine) + 6.43.
+// Values below 1 are raised to 1, creatinine is capped at 4 mg/dL and set to 4
+// for patients on dialysis, and the result is rounded and limited to 6-40.
+func meldScore(bilirubinMgDL, creatinineMgDL, inr float64, onDialysis bool) int {
+	bilirubin := math.Max(bilirubinMgDL, 1.0)
+	inr = math.Max(inr, 1.0)
+	creatinine := math.Min(math.Max(creatinineMgDL, 1.0), 4.0)
+	if onDialysis {
+		creatinine = 4.0
+	}
+
+	score := 3.78*math.Log(bilirubin) + 11.2*math.Log(inr) + 9.57*math.Log(creatinine) + 6.43
+	return int(math.Min(math.Max(math.Round(score), 6), 40))
+}
+
+// MELD lab lookups, matched against the observation display. Serum creatinine
+// is also used by CalculateEGFR.
+var (
+	meldBilirubinTerms   = []string{"bilirubin.total", "total bilirubin"}
+	serumCreatinineTerms = []string{"creatinine [mass/volume] in serum", "creatinine [moles/volume] in serum", "serum creatinine", "creatinine [mass/volume] in blood"}
+	meldINRTerms         = []string{"inr", "coagulation surface induced"}
+)
+
+// onDialysis reports whether the patient was dialysed in the past week or has
+// an active dialysis-dependence condition
+func onDialysis(procedures []database.Procedure, conditions []database.Condition, now time.Time) bool {
+	weekAgo := now.AddDate(0, 0, -7)
+	for _, p := range procedures {
+		if containsAny(p.Display, []string{"dialysis"}) && changedSince(derefString(p.PerformedDateTime), weekAgo) {
+			return true
+		}
+	}
+	for _, c := range conditions {
+		if (c.ClinicalStatus == "active" || c.ClinicalStatus == "") && containsAny(c.Display, []string{"dependence on renal dialysis", "hemodialysis"}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) CalculateMELD(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("MELD Score for %s (ID: %s)\n\n", patientName, patientID)
+
+	bilirubinObs := latestLab(observations, meldBilirubinTerms)
+	creatinineObs := latestLab(observations, serumCreatinineTerms)
+	inrObs := latestLab(observations, meldINRTerms)
+
+	var missing []string
+	var bilirubin, creatinine float64
+	if bilirubinObs == nil {
+		missing = append(missing, "total bilirubin")
+	} else if v, ok := toMgPerDL(*bilirubinObs.ValueQuantity, derefString(bilirubinObs.ValueUnit), 17.1); ok {
+		bilirubin = v
+	} else {
+		missing = append(missing, fmt.Sprintf("total bilirubin in a known unit (got %s)", derefString(bilirubinObs.ValueUnit)))
+	}
+	if creatinineObs == nil {
+		missing = append(missing, "serum creatinine")
+	} else if v, ok := toMgPerDL(*creatinineObs.ValueQuantity, derefString(creatinineObs.ValueUnit), 88.4); ok {
+		creatinine = v
+	} else {
+		missing = append(missing, fmt.Sprintf("serum creatinine in a known unit (got %s)", derefString(creatinineObs.ValueUnit)))
+	}
+	if inrObs == nil {
+		missing = append(missing, "INR")
+	}
+
+	if len(missing) > 0 {
+		resultText += "Unable to calculate MELD: missing " + strings.Join(missing, ", ") + ". Please add the latest lab results first."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": resultText,
+				},
+			},
+		}, nil
+	}
+
+	dialysis := onDialysis(procedures, conditions, time.Now())
+	score := meldScore(bilirubin, creatinine, *inrObs.ValueQuantity, dialysis)
+
+	resultText += fmt.Sprintf("Bilirubin: %.2f mg/dL (%s)\n", bilirubin, observationDay(*bilirubinObs))
+	resultText += fmt.Sprintf("Creatinine: %.2f mg/dL (%s)\n", creatinine, observationDay(*creatinineObs))
+	resultText += fmt.Sprintf("INR: %.2f (%s)\n", *inrObs.ValueQuantity, observationDay(*inrObs))
+	if dialysis {
+		resultText += "Dialysis in the past week: yes (creatinine set to 4.0 mg/dL)\n"
+	}
+	resultText += fmt.Sprintf("\nMELD score: %d\n", score)
+
+	switch {
+	case score >= 40:
+		resultText += "Estimated 3-month mortality: ~71%"
+	case score >= 30:
+		resultText += "Estimated 3-month mortality: ~53%"
+	case score >= 20:
+		resultText += "Estimated 3-month mortality: ~20%"
+	case score >= 10:
+		resultText += "Estimated 3-month mortality: ~6%"
+	default:
+		resultText += "Estimated 3-month mortality: ~2%"
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// maintenanceFluidRate returns the maintenance IV fluid rate in mL/hr by the
+// 4-2-1 rule: 4 mL/kg/hr for the first 10 kg, 2 mL/kg/hr for the next 10 kg
+// and 1 mL/kg/hr for every kg above 20
+func maintenanceFluidRate(weightKg float64) float64 {
+	switch {
+	case weightKg <= 10:
+		return 4 * weightKg
+	case weightKg <= 20:
+		return 40 + 2*(weightKg-10)
+	default:
+		return 60 + (weightKg - 20)
+	}
+}
+
+// CalculateMaintenanceFluids shows the maintenance IV fluid rate and daily
+// volume for the patient's latest body weight
+func (h *Handler) CalculateMaintenanceFluids(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("Maintenance IV fluids for %s (ID: %s)\n\n", patientName, patientID)
+
+	weightObs, weight, ok := latestWeight(observations)
+	if !ok {
+		resultText += "Unable to calculate maintenance fluids: no body weight found in observations. Please add a weight observation first."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": resultText,
+				},
+			},
+		}, nil
+	}
+
+	rate := maintenanceFluidRate(weight)
+	resultText += fmt.Sprintf("Weight: %.1f kg", weight)
+	if date := observationDate(*weightObs); date != "" {
+		resultText += fmt.Sprintf(" (measured %s)", formatRecordTime(date))
+	}
+	resultText += fmt.Sprintf("\n\nMaintenance rate (4-2-1 rule): %.0f mL/hr\n", rate)
+	resultText += fmt.Sprintf("Daily volume: %.0f mL/24 h\n", rate*24)
+	resultText += "\nNote: adjust for ongoing losses, renal and cardiac function, and fluid given with medications."
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestDevineIdealBodyWeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		heightCm float64
+		gender   string
+		want     float64
+		wantErr  bool
+	}{
+		{name: "Male 5ft exactly", heightCm: 152.4, gender: "male", want: 50.0},
+		{name: "Female 5ft exactly", heightCm: 152.4, gender: "female", want: 45.5},
+		{name: "Male 6ft", heightCm: 182.88, gender: "male", want: 77.6},
+		{name: "Female 5ft 6in", heightCm: 167.64, gender: "female", want: 59.3},
+		{name: "Unknown gender", heightCm: 170, gender: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := devineIdealBodyWeight(tt.heightCm, tt.gender)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("devineIdealBodyWeight() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got-tt.want) > 0.05 {
+				t.Errorf("devineIdealBodyWeight() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustedBodyWeight(t *testing.T) {
+	// IBW 70 kg, actual 120 kg: 70 + 0.4 × 50 = 90 kg
+	if got := adjustedBodyWeight(70, 120); math.Abs(got-90) > 0.001 {
+		t.Errorf("adjustedBodyWeight() = %.2f, want 90.00", got)
+	}
+}
+
+func TestMeldScore(t *testing.T) {
+	tests := []struct {
+		name                       string
+		bilirubin, creatinine, inr float64
+		dialysis                   bool
+		want                       int
+	}{
+		// 3.78×ln(2) + 11.2×ln(1.5) + 9.57×ln(1.2) + 6.43 = 15.34
+		{name: "Moderate disease", bilirubin: 2.0, creatinine: 1.2, inr: 1.5, want: 15},
+		{name: "Values below 1 are clamped", bilirubin: 0.5, creatinine: 0.7, inr: 0.9, want: 6},
+		// Creatinine capped at 4: 3.78×ln(1) + 11.2×ln(1) + 9.57×ln(4) + 6.43 = 19.70
+		{name: "Creatinine capped at 4", bilirubin: 1.0, creatinine: 6.5, inr: 1.0, want: 20},
+		{name: "Dialysis sets creatinine to 4", bilirubin: 1.0, creatinine: 1.0, inr: 1.0, dialysis: true, want: 20},
+		{name: "Score capped at 40", bilirubin: 40, creatinine: 4, inr: 8, want: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := meldScore(tt.bilirubin, tt.creatinine, tt.inr, tt.dialysis); got != tt.want {
+				t.Errorf("meldScore() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBMICategory(t *testing.T) {
+	tests := []struct {
+		bmi  float64
+		want string
+	}{
+		{17.9, "underweight"},
+		{18.5, "normal weight"},
+		{24.9, "normal weight"},
+		{25, "overweight"},
+		{30, "obese"},
+	}
+	for _, tt := range tests {
+		if got := bmiCategory(tt.bmi); got != tt.want {
+			t.Errorf("bmiCategory(%.1f) = %q, want %q", tt.bmi, got, tt.want)
+		}
+	}
+}
+
+func TestBodyMassIndex(t *testing.T) {
+	tests := []struct {
+		weightKg, heightCm, want float64
+	}{
+		{69.85, 177.8, 22.1},
+		{120, 182.88, 35.9},
+		{12, 86, 16.2},
+	}
+	for _, tt := range tests {
+		if got := bodyMassIndex(tt.weightKg, tt.heightCm); math.Abs(got-tt.want) > 0.05 {
+			t.Errorf("bodyMassIndex(%.2f, %.2f) = %.2f, want %.1f", tt.weightKg, tt.heightCm, got, tt.want)
+		}
+	}
+}
+
+func TestCKDEPI2021(t *testing.T) {
+	tests := []struct {
+		name       string
+		creatinine float64
+		age        int
+		gender     string
+		want       float64
+	}{
+		{name: "Male 50, Scr 1.0", creatinine: 1.0, age: 50, gender: "male", want: 92},
+		{name: "Female 60, Scr 1.2", creatinine: 1.2, age: 60, gender: "female", want: 52},
+		{name: "Female 40, Scr 0.6", creatinine: 0.6, age: 40, gender: "female", want: 116},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ckdEPI2021(tt.creatinine, tt.age, tt.gender)
+			if err != nil {
+				t.Fatalf("ckdEPI2021() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("ckdEPI2021() = %.1f, want %.0f", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ckdEPI2021(1.0, 50, "unknown"); err == nil {
+		t.Error("expected an error for unknown gender")
+	}
+}
+
+func TestCKDStage(t *testing.T) {
+	tests := []struct {
+		egfr float64
+		want string
+	}{
+		{92, "G1"},
+		{90, "G1"},
+		{89.9, "G2"},
+		{60, "G2"},
+		{52, "G3a"},
+		{44, "G3b"},
+		{29, "G4"},
+		{14, "G5"},
+	}
+	for _, tt := range tests {
+		if got := ckdStage(tt.egfr); !strings.HasPrefix(got, tt.want+" ") {
+			t.Errorf("ckdStage(%.1f) = %q, want %s", tt.egfr, got, tt.want)
+		}
+	}
+}
+
+func TestCorrectedSodium(t *testing.T) {
+	tests := []struct {
+		sodium, glucose, want float64
+	}{
+		{130, 600, 138}, // 1.6 × 5
+		{135, 100, 135}, // at the threshold
+		{140, 80, 140},  // normal glucose is not corrected downwards
+		{128, 350, 132}, // 1.6 × 2.5
+	}
+	for _, tt := range tests {
+		if got := correctedSodium(tt.sodium, tt.glucose); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("correctedSodium(%v, %v) = %v, want %v", tt.sodium, tt.glucose, got, tt.want)
+		}
+	}
+}
+
+func TestAnionGap(t *testing.T) {
+	tests := []struct {
+		name         string
+		na, cl, hco3 float64
+		want         float64
+		category     string
+	}{
+		{"normal", 140, 104, 24, 12, "normal"},
+		{"high gap metabolic acidosis", 140, 98, 14, 28, "high"},
+		{"low", 138, 108, 26, 4, "low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gap := anionGap(tt.na, tt.cl, tt.hco3)
+			if math.Abs(gap-tt.want) > 0.001 || anionGapCategory(gap) != tt.category {
+				t.Errorf("anionGap = %.1f (%s), want %.1f (%s)", gap, anionGapCategory(gap), tt.want, tt.category)
+			}
+		})
+	}
+
+	// Albumin 2 g/dL hides 5 mEq/L of gap
+	if got := albuminCorrectedAnionGap(10, 2.0); math.Abs(got-15) > 0.001 {
+		t.Errorf("albuminCorrectedAnionGap(10, 2.0) = %.1f, want 15", got)
+	}
+}
+
+func TestMaintenanceFluidRate(t *testing.T) {
+	tests := []struct {
+		weightKg, want float64
+	}{
+		{3.5, 14},
+		{10, 40},
+		{10.5, 41},
+		{15, 50},
+		{20, 60},
+		{21, 61},
+		{70, 110},
+	}
+	for _, tt := range tests {
+		if got := maintenanceFluidRate(tt.weightKg); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("maintenanceFluidRate(%.1f) = %.2f, want %.2f", tt.weightKg, got, tt.want)
+		}
+	}
+}
+
+// labObservation returns a numeric observation for the latest* helpers, which
+// only look at the display, value and unit
+func labObservation(id, display string, value float64, unit string) database.Observation {
+	return database.Observation{ID: id, Display: display, ValueQuantity: &value, ValueUnit: &unit}
+}
+
+func TestLatestMeasurements(t *testing.T) {
+	creatinine := func(o []database.Observation) (*database.Observation, float64, bool) {
+		return latestCreatinineMgDL(o, serumCreatinineTerms)
+	}
+	glucose := func(o []database.Observation) (*database.Observation, float64, bool) {
+		return latestGlucoseMgDL(o, glucoseTerms)
+	}
+	sodium := func(o []database.Observation) (*database.Observation, float64, bool) {
+		return latestElectrolyte(o, sodiumTerms)
+	}
+	albumin := func(o []database.Observation) (*database.Observation, float64, bool) {
+		return latestAlbuminGDL(o, albuminTerms)
+	}
+
+	// Observations are newest first, as the database returns them
+	tests := []struct {
+		name         string
+		latest       func([]database.Observation) (*database.Observation, float64, bool)
+		observations []database.Observation
+		wantID       string // empty when nothing should be found
+		want         float64
+	}{
+		{"weight in pounds", latestWeight, []database.Observation{
+			labObservation("w1", "Body Weight", 264.55, "lb"),
+		}, "w1", 120.0},
+		{"newest weight wins", latestWeight, []database.Observation{
+			labObservation("w2", "Body Weight", 154, "[lb_av]"),
+			labObservation("w1", "Body Weight", 80, "kg"),
+		}, "w2", 69.85},
+		{"weight skips percentiles", latestWeight, []database.Observation{
+			labObservation("p1", "Weight-for-length Per age and sex", 45, "%"),
+			labObservation("w1", "Body Weight", 12, "kg"),
+		}, "w1", 12},
+		{"height in inches", latestHeight, []database.Observation{
+			labObservation("h1", "Body Height", 72, "in"),
+		}, "h1", 182.88},
+		{"height skips unknown units", latestHeight, []database.Observation{
+			labObservation("h2", "Body Height", 5.8, "[ft_us]"),
+			labObservation("h1", "Body Height", 70, "[in_i]"),
+		}, "h1", 177.8},
+		{"no height", latestHeight, []database.Observation{
+			labObservation("w1", "Body Weight", 70, "kg"),
+		}, "", 0},
+		{"creatinine in µmol/L", creatinine, []database.Observation{
+			labObservation("c2", "Creatinine [Moles/volume] in Serum or Plasma", 88.4, "umol/L"),
+			labObservation("c1", "Creatinine [Mass/volume] in Serum or Plasma", 2.5, "mg/dL"),
+		}, "c2", 1.0},
+		{"creatinine skips unknown units", creatinine, []database.Observation{
+			labObservation("c2", "Creatinine [Moles/volume] in Serum or Plasma", 0.09, "mmol/L"),
+			labObservation("c1", "Creatinine [Mass/volume] in Serum or Plasma", 1.2, "mg/dL"),
+		}, "c1", 1.2},
+		{"glucose in mmol/L", glucose, []database.Observation{
+			labObservation("g1", "Glucose [Moles/volume] in Blood", 33.3, "mmol/L"),
+		}, "g1", 599.4},
+		{"sodium in mEq/L", sodium, []database.Observation{
+			labObservation("n1", "Sodium [Moles/volume] in Serum or Plasma", 138, "mEq/L"),
+		}, "n1", 138},
+		{"sodium skips mass units", sodium, []database.Observation{
+			labObservation("n2", "Sodium [Moles/volume] in Serum or Plasma", 317, "mg/dL"),
+			labObservation("n1", "Sodium [Moles/volume] in Serum or Plasma", 130, "mmol/L"),
+		}, "n1", 130},
+		{"albumin in g/L", albumin, []database.Observation{
+			labObservation("a1", "Albumin [Mass/volume] in Serum or Plasma", 25, "g/L"),
+		}, "a1", 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obs, got, ok := tt.latest(tt.observations)
+			if tt.wantID == "" {
+				if ok {
+					t.Errorf("found %s (%.2f), want none", obs.ID, got)
+				}
+				return
+			}
+			if !ok || obs.ID != tt.wantID || math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("got %v %.2f (found %v), want %s %.2f", obs, got, ok, tt.wantID, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculatorsReportMissingData(t *testing.T) {
+	seed := func(display string, value float64, unit string) func(t *testing.T, h *Handler) {
+		return func(t *testing.T, h *Handler) {
+			seedObservation(t, h, "p1", "", display, value, unit, "2024-01-01T09:00:00Z")
+		}
+	}
+	clearBirthDate := func(t *testing.T, h *Handler) {
+		if _, err := h.db.Exec("UPDATE patients SET birth_date = NULL WHERE id = 'p1'"); err != nil {
+			t.Fatalf("Failed to clear birth date: %v", err)
+		}
+		seed("Creatinine [Mass/volume] in Serum or Plasma", 1.0, "mg/dL")(t, h)
+	}
+	noINR := func(t *testing.T, h *Handler) {
+		seed("Bilirubin.total [Mass/volume] in Serum or Plasma", 2.0, "mg/dL")(t, h)
+		seed("Creatinine [Mass/volume] in Serum or Plasma", 1.2, "mg/dL")(t, h)
+	}
+	weight := seed("Body Weight", 70, "kg")
+	sodium := seed("Sodium [Moles/volume] in Serum or Plasma", 130, "mmol/L")
+
+	// The message is in the result text, or in the error for calculators
+	// that can't say anything without the value
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, h *Handler)
+		calculate func(h *Handler, patientID string) (interface{}, error)
+		patientID string
+		want      string
+		notWant   string
+	}{
+		{name: "ideal body weight without height", setup: weight, calculate: (*Handler).CalculateIdealBodyWeight, want: "no body height found"},
+		{name: "BMI without height", setup: weight, calculate: (*Handler).CalculateBMI, want: "no body height found", notWant: "no body weight"},
+		{name: "eGFR without creatinine", calculate: (*Handler).CalculateEGFR, want: "no serum creatinine"},
+		{name: "eGFR without birth date", setup: clearBirthDate, calculate: (*Handler).CalculateEGFR, want: "no birth date"},
+		{name: "MELD without INR", setup: noINR, calculate: (*Handler).CalculateMELD, want: "missing INR", notWant: "bilirubin"},
+		{name: "corrected sodium without sodium", calculate: (*Handler).CalculateCorrectedSodium, want: "no serum sodium"},
+		{name: "corrected sodium without glucose", setup: sodium, calculate: (*Handler).CalculateCorrectedSodium, want: "shown uncorrected"},
+		{name: "anion gap without chloride and bicarbonate", setup: sodium, calculate: (*Handler).CalculateAnionGap, want: "no serum chloride, serum bicarbonate", notWant: "no serum sodium"},
+		{name: "maintenance fluids without weight", calculate: (*Handler).CalculateMaintenanceFluids, want: "no body weight found"},
+		{name: "unknown patient", calculate: (*Handler).CalculateMaintenanceFluids, patientID: "nobody", want: "patient not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+			if tt.setup != nil {
+				tt.setup(t, h)
+			}
+			patientID := tt.patientID
+			if patientID == "" {
+				patientID = "p1"
+			}
+
+			result, err := tt.calculate(h, patientID)
+			got := h.ExtractTextFromMCPResult(result)
+			if err != nil {
+				got = err.Error()
+			}
+			if !strings.Contains(got, tt.want) || (tt.notWant != "" && strings.Contains(got, tt.notWant)) {
+				t.Errorf("want %q without %q, got:\n%s", tt.want, tt.notWant, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProcessNaturalLanguageQuery_ConcurrencyLimit(t *testing.T) {
+	t.Setenv("MCP_MAX_CONCURRENT_QUERIES", "2")
+	h := newTestHandler(t)
+
+	// Occupy every slot as if two queries were in flight
+	for i := 0; i < 2; i++ {
+		if !h.acquireQuerySlot() {
+			t.Fatalf("expected slot %d to be available", i+1)
+		}
+	}
+
+	_, err := h.ProcessNaturalLanguageQuery(context.Background(), "What is the patient's blood pressure?", "", false)
+	if !errors.Is(err, ErrTooManyQueries) {
+		t.Fatalf("expected ErrTooManyQueries for the third concurrent query, got %v", err)
+	}
+
+	h.releaseQuerySlot()
+	if !h.acquireQuerySlot() {
+		t.Error("expected a slot to be available after release")
+	}
+}
+
+func TestAcquireQuerySlot_QueueTimeout(t *testing.T) {
+	t.Setenv("MCP_MAX_CONCURRENT_QUERIES", "1")
+	t.Setenv("MCP_QUERY_QUEUE_TIMEOUT", "500ms")
+	h := newTestHandler(t)
+
+	if !h.acquireQuerySlot() {
+		t.Fatal("expected first slot to be available")
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		h.releaseQuerySlot()
+	}()
+
+	// The queued caller should get the slot once it is released
+	if !h.acquireQuerySlot() {
+		t.Error("expected queued query to acquire the released slot")
+	}
+}
+
+func TestAcquireQuerySlot_Unlimited(t *testing.T) {
+	h := newTestHandler(t)
+	for i := 0; i < 100; i++ {
+		if !h.acquireQuerySlot() {
+			t.Fatal("expected unlimited slots when MCP_MAX_CONCURRENT_QUERIES is unset")
+		}
+	}
+}
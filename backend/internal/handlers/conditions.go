@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// conditionClinicalStatuses are the FHIR Condition.clinicalStatus codes
+var conditionClinicalStatuses = []string{
+	"active", "recurrence", "relapse", "inactive", "remission", "resolved",
+}
+
+func isConditionClinicalStatus(status string) bool {
+	for _, s := range conditionClinicalStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// AddCondition records a diagnosis for the patient. The clinical status
+// defaults to "active" and the onset date is optional.
+func (h *Handler) AddCondition(patientID, code, display, clinicalStatus, onsetDateTime string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	code = strings.TrimSpace(code)
+	display = strings.TrimSpace(display)
+	if display == "" {
+		return nil, fmt.Errorf("condition display name is required")
+	}
+
+	clinicalStatus = strings.ToLower(strings.TrimSpace(clinicalStatus))
+	if clinicalStatus == "" {
+		clinicalStatus = "active"
+	}
+	if !isConditionClinicalStatus(clinicalStatus) {
+		return nil, fmt.Errorf("invalid clinical status: %s (use %s)", clinicalStatus, strings.Join(conditionClinicalStatuses, ", "))
+	}
+
+	var onset *string
+	if strings.TrimSpace(onsetDateTime) != "" {
+		onsetTime, err := ParseDateTimeRobust(onsetDateTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid onset date: %w", err)
+		}
+		if onsetTime.After(time.Now()) {
+			return nil, fmt.Errorf("onset date %s is in the future", onsetDateTime)
+		}
+		formatted := onsetTime.Format(time.RFC3339)
+		onset = &formatted
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	id, err := h.newID(idKindCondition)
+	if err != nil {
+		return nil, err
+	}
+	condition := &database.Condition{
+		ID:             id,
+		ClinicalStatus: clinicalStatus,
+		Code:           code,
+		Display:        display,
+		PatientID:      patientID,
+		OnsetDateTime:  onset,
+	}
+	if err := database.CreateCondition(h.db, condition); err != nil {
+		return nil, fmt.Errorf("failed to add condition: %w", err)
+	}
+	debug.Log("Added condition %s for patient %s", condition.ID, patientID)
+
+	h.refreshContextSummary(patientID)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("Successfully added condition:\n\nCondition ID: %s\nPatient: %s (ID: %s)\nDisplay: %s\n",
+		condition.ID, patientName, patientID, display)
+	if code != "" {
+		resultText += fmt.Sprintf("Code: %s\n", code)
+	}
+	resultText += fmt.Sprintf("Clinical Status: %s\n", clinicalStatus)
+	if onset != nil {
+		resultText += fmt.Sprintf("Onset: %s\n", *onset)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestAddCondition(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext: %v", err)
+	}
+
+	// Patient comes from the context; status defaults to active
+	result, err := h.AddCondition("", "59621000", "Essential hypertension", "", "14.03.2024")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"Marty McFly (ID: p1)", "Essential hypertension", "Clinical Status: active", "Onset: 2024-03-14"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	conditions, err := database.GetConditionsByPatientID(h.db, "p1")
+	if err != nil || len(conditions) != 1 {
+		t.Fatalf("got %d conditions (err %v), want 1", len(conditions), err)
+	}
+	if c := conditions[0]; c.Code != "59621000" || c.ClinicalStatus != "active" || c.OnsetDateTime == nil {
+		t.Errorf("stored condition = %+v", c)
+	}
+
+	found := false
+	for _, c := range currentContext(h).PatientSummary.ActiveConditions {
+		found = found || strings.Contains(c, "Essential hypertension")
+	}
+	if !found {
+		t.Errorf("context summary not refreshed: %v", currentContext(h).PatientSummary.ActiveConditions)
+	}
+
+	// Code and onset are optional
+	result, err = h.AddCondition("p1", "", "Seasonal allergic rhinitis", "Remission", "")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "Clinical Status: remission") || strings.Contains(text, "Onset:") {
+		t.Errorf("unexpected result:\n%s", text)
+	}
+}
+
+func TestAddConditionValidation(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+
+	tests := []struct {
+		name, patientID, display, status, onset, wantErr string
+	}{
+		{"no patient", "", "Asthma", "", "", "patient ID is required"},
+		{"unknown patient", "p2", "Asthma", "", "", "patient not found"},
+		{"no display", "p1", " ", "", "", "display name is required"},
+		{"bad status", "p1", "Asthma", "cured", "", "invalid clinical status"},
+		{"bad onset", "p1", "Asthma", "", "sometime", "invalid onset date"},
+		{"ambiguous onset", "p1", "Asthma", "", "03/04/2024", "ambiguous date"},
+		{"future onset", "p1", "Asthma", "", "2999-01-01", "in the future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.AddCondition(tt.patientID, "", tt.display, tt.status, tt.onset)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
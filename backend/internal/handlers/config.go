@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// envInt reads a non-negative integer from the environment, falling back to
+// def when the variable is unset or invalid
+func envInt(name string, def int) int {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		debug.Error("Invalid value for %s: %q, using default %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// envDuration reads a Go duration (e.g. "30s", "15m") from the environment,
+// falling back to def when the variable is unset or invalid
+func envDuration(name string, def time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		debug.Error("Invalid value for %s: %q, using default %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+// envBool reads a boolean ("true", "1", ...) from the environment, falling
+// back to false when the variable is unset or invalid
+func envBool(name string) bool {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		debug.Error("Invalid value for %s: %q, using false", name, value)
+		return false
+	}
+	return b
+}
+
+// envList reads a comma-separated list from the environment, dropping empty
+// entries
+func envList(name string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// envString reads a trimmed string from the environment, falling back to def
+// when the variable is unset or blank
+func envString(name, def string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return def
+}
+
+// modelConfig names the OpenRouter models used for each kind of call
+type modelConfig struct {
+	// Chat answers free-text prompts without tools (callOpenRouter)
+	Chat string
+	// Tools drives natural language queries with function calling
+	Tools string
+	// Guidelines answers clinical guideline and summarisation prompts
+	Guidelines string
+}
+
+// Default models, used when the corresponding environment variable is unset
+const (
+	defaultChatModel       = "meta-llama/llama-3.2-3b-instruct:free"
+	defaultToolsModel      = "google/gemini-2.5-flash"
+	defaultGuidelinesModel = "google/gemini-2.5-flash"
+)
+
+// modelConfigFromEnv reads OPENROUTER_MODEL, OPENROUTER_TOOLS_MODEL and
+// OPENROUTER_GUIDELINES_MODEL
+func modelConfigFromEnv() modelConfig {
+	return modelConfig{
+		Chat:       envString("OPENROUTER_MODEL", defaultChatModel),
+		Tools:      envString("OPENROUTER_TOOLS_MODEL", defaultToolsModel),
+		Guidelines: envString("OPENROUTER_GUIDELINES_MODEL", defaultGuidelinesModel),
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// consentScopeAI is the consent scope covering sharing a patient's data with
+// the AI model. Consent is only recorded through direct MCP tool calls; the
+// model is never offered record_consent or revoke_consent, so it can't grant
+// itself access.
+const consentScopeAI = "ai_data_sharing"
+
+// Consent statuses
+const (
+	consentGranted = "granted"
+	consentRevoked = "revoked"
+)
+
+// aiPatientDataTools are the AI tools that send a patient's records to the
+// model themselves. They are refused outright for a patient who hasn't
+// consented; other tools still run, but their results are withheld from the
+// model (see withholdWithoutConsent).
+var aiPatientDataTools = map[string]bool{
+	"check_contraindications":   true,
+	"check_drug_interactions":   true,
+	"suggest_next_actions":      true,
+	"get_ranked_problem_list":   true,
+	"generate_sbar":             true,
+	"explain_observation_trend": true,
+}
+
+// aiConsentAllows reports whether patientID's data may be sent to the model.
+// A revoked consent always forbids it; a missing one forbids it only when
+// MCP_REQUIRE_AI_CONSENT is set. If consent can't be read, it is refused.
+func (h *Handler) aiConsentAllows(patientID string) bool {
+	consent, err := database.GetConsent(h.db, patientID, consentScopeAI)
+	if errors.Is(err, sql.ErrNoRows) {
+		return !h.requireAIConsent
+	}
+	if err != nil {
+		debug.Error("Failed to read AI consent for patient %s: %v", patientID, err)
+		return false
+	}
+	return consent.Status == consentGranted
+}
+
+// multiPatientTools list records of several patients. Their results are
+// redacted per patient (see withheldFromModel) rather than withheld as a
+// whole for the context patient.
+var multiPatientTools = map[string]bool{
+	"lookup_patient":              true,
+	"find_patients_on_medication": true,
+	"get_day_schedule":            true,
+}
+
+// withheldPatientText stands in for a patient's entry in a list sent to the
+// model when the patient hasn't consented
+const withheldPatientText = "patient details withheld: no consent to AI data sharing"
+
+// withheldFromModel reports whether patientID's records must be left out of
+// a tool result because it goes to the model and the patient hasn't
+// consented. Results of direct tool calls are never redacted.
+func (h *Handler) withheldFromModel(patientID string) bool {
+	return h.forModel && patientID != "" && !h.aiConsentAllows(patientID)
+}
+
+// aiConsentMessage explains why a patient's data isn't shared with the model
+func aiConsentMessage(patientID string) string {
+	return fmt.Sprintf("Patient %s has not consented to sharing their data with the AI assistant, so it is withheld. A practitioner can record their consent, or use tools that don't use AI.", patientID)
+}
+
+// AIConsentBlocks reports whether toolName must not run because it sends the
+// records of the patient it is about (patientID, or the context patient) to
+// the model and that patient hasn't consented, returning the message to show
+// instead
+func (h *Handler) AIConsentBlocks(toolName, patientID string) (string, bool) {
+	if !aiPatientDataTools[toolName] {
+		return "", false
+	}
+	patientID = h.GetContextPatientID(patientID)
+	if patientID == "" || h.aiConsentAllows(patientID) {
+		return "", false
+	}
+
+	debug.Log("AI consent: blocked %s for patient %s", toolName, patientID)
+	return aiConsentMessage(patientID), true
+}
+
+// withholdWithoutConsent returns the tool result to hand back to the model:
+// text itself, or a notice in its place when the patient the tool was about
+// (patientID, or the context patient) hasn't consented to AI data sharing.
+// Results of multiPatientTools are already redacted and pass through.
+func (h *Handler) withholdWithoutConsent(toolName, patientID, text string) string {
+	if multiPatientTools[toolName] {
+		return text
+	}
+	patientID = h.GetContextPatientID(patientID)
+	if patientID == "" || h.aiConsentAllows(patientID) {
+		return text
+	}
+	debug.Log("AI consent: withheld %s result for patient %s", toolName, patientID)
+	return aiConsentMessage(patientID)
+}
+
+// RecordConsent records that the patient consents to sharing their data
+// with the AI model
+func (h *Handler) RecordConsent(patientID string) (interface{}, error) {
+	return h.setAIConsent(patientID, consentGranted)
+}
+
+// RevokeConsent records that the patient withdraws consent to sharing their
+// data with the AI model
+func (h *Handler) RevokeConsent(patientID string) (interface{}, error) {
+	return h.setAIConsent(patientID, consentRevoked)
+}
+
+func (h *Handler) setAIConsent(patientID, status string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	consent := &database.Consent{
+		PatientID:        patientID,
+		Scope:            consentScopeAI,
+		Status:           status,
+		RecordedDateTime: time.Now().UTC().Format(time.RFC3339),
+	}
+	if practitionerID := h.GetContextPractitionerID(""); practitionerID != "" {
+		consent.RecordedBy = &practitionerID
+	}
+	if err := database.SetConsent(h.db, consent); err != nil {
+		return nil, fmt.Errorf("failed to record consent: %w", err)
+	}
+	debug.Log("AI consent %s for patient %s", status, patientID)
+
+	text := fmt.Sprintf("Recorded consent to AI data sharing for patient %s.", patientID)
+	if status == consentRevoked {
+		text = fmt.Sprintf("Revoked consent to AI data sharing for patient %s. Their data will no longer be sent to the AI assistant.", patientID)
+	}
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+	}, nil
+}
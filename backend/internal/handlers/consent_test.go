@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingModel serves the given model messages in order, one per request,
+// recording each raw request body
+func recordingModel(t *testing.T, h *Handler, replies ...map[string]interface{}) *[]string {
+	t.Helper()
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) > len(replies) {
+			t.Errorf("Unexpected model request #%d", len(bodies))
+			http.Error(w, "no more replies", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": replies[len(bodies)-1]}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+	return &bodies
+}
+
+func TestContextInfoRespectsAIConsent(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2020-01-01T00:00:00Z")
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+
+	// Without a recorded decision the summary is shared unless consent is required
+	if info := h.GetContextInfo(); !strings.Contains(info, "Hypertension") {
+		t.Errorf("Expected the medical summary without a recorded decision, got %q", info)
+	}
+	h.requireAIConsent = true
+	if info := h.GetContextInfo(); strings.Contains(info, "Hypertension") || !strings.Contains(info, "has not consented") {
+		t.Errorf("Expected the summary withheld when consent is required, got %q", info)
+	}
+
+	if _, err := h.RecordConsent(""); err != nil {
+		t.Fatalf("RecordConsent failed: %v", err)
+	}
+	if info := h.GetContextInfo(); !strings.Contains(info, "Hypertension") {
+		t.Errorf("Expected the medical summary after consent, got %q", info)
+	}
+
+	result, err := h.RevokeConsent("p1")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "Revoked consent") {
+		t.Errorf("Unexpected revoke result %q", text)
+	}
+	h.requireAIConsent = false
+	if info := h.GetContextInfo(); strings.Contains(info, "Hypertension") || !strings.Contains(info, "has not consented") {
+		t.Errorf("Expected the summary withheld after revocation, got %q", info)
+	}
+}
+
+func TestAIConsentBlocksPatientDataTools(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedAllergy(t, h, "p1", "Penicillin", "active", "high")
+	seedPractitioner(t, h, "prac-1", "Erik", "Hansen")
+	if _, err := h.SetPractitionerContext("prac-1"); err != nil {
+		t.Fatalf("SetPractitionerContext failed: %v", err)
+	}
+	if _, err := h.RevokeConsent("p1"); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+
+	var recordedBy string
+	if err := h.db.QueryRow(`SELECT recorded_by FROM consents WHERE patient_id = 'p1'`).Scan(&recordedBy); err != nil {
+		t.Fatalf("Failed to read consent: %v", err)
+	}
+	if recordedBy != "prac-1" {
+		t.Errorf("recorded_by = %q, want prac-1", recordedBy)
+	}
+
+	if _, blocked := h.AIConsentBlocks("generate_sbar", "p1"); !blocked {
+		t.Error("Expected generate_sbar to be blocked without consent")
+	}
+	if _, blocked := h.AIConsentBlocks("get_allergy_banner", "p1"); blocked {
+		t.Error("Local tools should not be blocked")
+	}
+
+	// Refused tools don't run; local tools run but their result is withheld
+	text, err := h.executeTool(context.Background(), "check_drug_interactions", `{"patient_id": "p1"}`, "")
+	if err != nil || !strings.Contains(text, "has not consented") {
+		t.Errorf("Expected check_drug_interactions to be refused, got %q (err: %v)", text, err)
+	}
+	text, err = h.executeTool(context.Background(), "get_allergy_banner", `{"patient_id": "p1"}`, "")
+	if err != nil || strings.Contains(text, "Penicillin") || !strings.Contains(text, "has not consented") {
+		t.Errorf("Expected the allergy banner withheld from the model, got %q (err: %v)", text, err)
+	}
+	result, err := h.GetAllergyBanner("p1")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "Penicillin") {
+		t.Errorf("Direct calls should still return the banner, got %q", text)
+	}
+
+	if _, err := h.RecordConsent("p1"); err != nil {
+		t.Fatalf("RecordConsent failed: %v", err)
+	}
+	if _, blocked := h.AIConsentBlocks("generate_sbar", "p1"); blocked {
+		t.Error("Expected generate_sbar to be allowed after consent")
+	}
+}
+
+func TestNaturalLanguageQueryWithholdsUnconsentedPatient(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2020-01-01T00:00:00Z")
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+	if _, err := h.RevokeConsent("p1"); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+
+	bodies := recordingModel(t, h,
+		map[string]interface{}{
+			"role": "assistant",
+			"tool_calls": []map[string]interface{}{{
+				"id":       "call-1",
+				"type":     "function",
+				"function": map[string]interface{}{"name": "get_medical_history", "arguments": `{}`},
+			}},
+		},
+		map[string]interface{}{"role": "assistant", "content": "I can't share this patient's records."},
+	)
+
+	if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "what conditions does she have?", "", false); err != nil {
+		t.Fatalf("ProcessNaturalLanguageQuery failed: %v", err)
+	}
+	if len(*bodies) != 2 {
+		t.Fatalf("Expected 2 model requests, got %d", len(*bodies))
+	}
+	for i, body := range *bodies {
+		if strings.Contains(body, "Hypertension") || strings.Contains(body, "Nordmann") {
+			t.Errorf("Model request %d contains the patient's records: %s", i+1, body)
+		}
+	}
+	if !strings.Contains((*bodies)[1], "has not consented") {
+		t.Errorf("Expected the tool result to be replaced with a consent notice, got %s", (*bodies)[1])
+	}
+}
+
+func TestModelCannotRecordConsent(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	if _, err := h.RevokeConsent("p1"); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+
+	for _, name := range []string{"record_consent", "revoke_consent"} {
+		if _, offered := h.ToolRequiredFields(name); offered {
+			t.Errorf("%s should not be offered to the model", name)
+		}
+		if _, err := h.executeTool(context.Background(), name, `{"patient_id": "p1"}`, ""); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+			t.Errorf("Expected the model to be refused %s, got %v", name, err)
+		}
+	}
+	if _, blocked := h.AIConsentBlocks("generate_sbar", "p1"); !blocked {
+		t.Error("Consent should still be revoked")
+	}
+}
+
+func TestMultiPatientResultsRedactedPerPatient(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPatient(t, h, "p2", "Ola", "Nordmann", "male", "1950-05-05")
+	seedMedication(t, h, "p1", "Metformin 500 MG Oral Tablet", "active", "2024-01-01T09:00:00Z")
+	seedMedication(t, h, "p2", "Metformin 500 MG Oral Tablet", "active", "2024-01-01T09:00:00Z")
+	day := inNextYear("YYYY-03-14")
+	seedEncounter(t, h, "enc-1", "p1", "planned", day+"T09:00:00Z")
+	seedEncounter(t, h, "enc-2", "p2", "planned", day+"T10:00:00Z")
+	if _, err := h.RevokeConsent("p1"); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+
+	tests := []struct {
+		tool, arguments string
+	}{
+		{"lookup_patient", `{"query": "Nordmann"}`},
+		{"find_patients_on_medication", `{"medication": "metformin"}`},
+		{"get_day_schedule", `{"date": "` + day + `"}`},
+	}
+	for _, tt := range tests {
+		text, err := h.executeTool(context.Background(), tt.tool, tt.arguments, "")
+		if err != nil {
+			t.Errorf("%s failed: %v", tt.tool, err)
+			continue
+		}
+		if strings.Contains(text, "Kari") || !strings.Contains(text, withheldPatientText) {
+			t.Errorf("%s: expected Kari's entry to be withheld, got:\n%s", tt.tool, text)
+		}
+		if !strings.Contains(text, "Ola") {
+			t.Errorf("%s: expected Ola's entry to be shown, got:\n%s", tt.tool, text)
+		}
+	}
+
+	// Direct calls list everyone
+	result, err := h.FindPatientsOnMedication("metformin", 0)
+	if text := resultText(t, h, result, err); !strings.Contains(text, "Kari") {
+		t.Errorf("Direct calls should not be redacted, got:\n%s", text)
+	}
+}
+
+func TestContextInfoDropsLastResponseWithoutConsent(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+	h.SetLastResponse("Kari's HbA1c has risen to 8.1%.")
+
+	if info := h.GetContextInfo(); !strings.Contains(info, "HbA1c") {
+		t.Errorf("Expected the last response before revocation, got %q", info)
+	}
+	if _, err := h.RevokeConsent("p1"); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+	if info := h.GetContextInfo(); strings.Contains(info, "HbA1c") || strings.Contains(info, "Previous Response") {
+		t.Errorf("Expected the last response dropped after revocation, got %q", info)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// Contact point systems and uses follow the FHIR ContactPoint value sets
+var (
+	contactSystems = map[string]bool{"phone": true, "email": true, "sms": true, "fax": true, "pager": true, "url": true, "other": true}
+	contactUses    = map[string]bool{"home": true, "work": true, "mobile": true, "temp": true, "old": true}
+
+	contactSystemLabels = map[string]string{
+		"phone": "Phone", "email": "Email", "sms": "SMS", "fax": "Fax",
+		"pager": "Pager", "url": "URL", "other": "Other",
+	}
+)
+
+// loadContacts attaches the patient's contact points so formatPatientInfo can
+// list them. Failures are logged and leave the patient unchanged.
+func (h *Handler) loadContacts(p *database.Patient) {
+	contacts, err := database.GetContactPointsByPatientID(h.db, p.ID)
+	if err != nil {
+		debug.Error("Failed to load contact points for patient %s: %v", p.ID, err)
+		return
+	}
+	p.Contacts = contacts
+}
+
+func (h *Handler) AddContactPoint(patientID, system, value, use string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	system = strings.ToLower(strings.TrimSpace(system))
+	use = strings.ToLower(strings.TrimSpace(use))
+	value = strings.TrimSpace(value)
+
+	// Allow "mobile"/"home" etc. as the system for convenience, meaning a phone number
+	if contactUses[system] {
+		if use == "" {
+			use = system
+		}
+		system = "phone"
+	}
+	if system == "" {
+		system = "phone"
+	}
+	if !contactSystems[system] {
+		return nil, fmt.Errorf("invalid contact system: %s (use phone, email, sms, fax, pager, url, or other)", system)
+	}
+	if use != "" && !contactUses[use] {
+		return nil, fmt.Errorf("invalid contact use: %s (use home, work, mobile, temp, or old)", use)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("contact value is required")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	createdDateTime := time.Now().Format(time.RFC3339)
+	id, err := h.newID(idKindContact)
+	if err != nil {
+		return nil, err
+	}
+	contact := &database.ContactPoint{
+		ID:              id,
+		PatientID:       patientID,
+		System:          system,
+		Value:           value,
+		CreatedDateTime: &createdDateTime,
+	}
+	if use != "" {
+		contact.Use = &use
+	}
+
+	if err := database.CreateContactPoint(h.db, contact); err != nil {
+		return nil, fmt.Errorf("failed to add contact point: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("✓ Added contact point for %s (ID: %s)\n%s", patientName, patientID, formatContactPoint(*contact)),
+			},
+		},
+	}, nil
+}
+
+func (h *Handler) ListContactPoints(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+	h.loadContacts(patient)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Contact points for %s %s (ID: %s)\n\n", patient.GivenName, patient.FamilyName, patientID))
+
+	count := 0
+	if patient.Phone != nil && *patient.Phone != "" {
+		result.WriteString(fmt.Sprintf("• Phone: %s (primary)\n", *patient.Phone))
+		count++
+	}
+	for _, c := range patient.Contacts {
+		result.WriteString("• " + formatContactPoint(c) + "\n")
+		count++
+	}
+	if count == 0 {
+		result.WriteString("No contact points on file.")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
+
+// formatContactPoint renders a contact point as e.g. "Phone (mobile): 555-0100"
+func formatContactPoint(c database.ContactPoint) string {
+	label, ok := contactSystemLabels[c.System]
+	if !ok {
+		label = c.System
+	}
+	if c.Use != nil && *c.Use != "" {
+		label += fmt.Sprintf(" (%s)", *c.Use)
+	}
+	return fmt.Sprintf("%s: %s", label, c.Value)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddAndListContactPoints(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jones", "female", "1980-01-01")
+
+	if _, err := h.AddContactPoint("p1", "mobile", "555-0100", ""); err != nil {
+		t.Fatalf("AddContactPoint(mobile) failed: %v", err)
+	}
+	if _, err := h.AddContactPoint("p1", "email", "anna@example.com", "work"); err != nil {
+		t.Fatalf("AddContactPoint(email) failed: %v", err)
+	}
+
+	result, err := h.ListContactPoints("p1")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"Phone (mobile): 555-0100", "Email (work): anna@example.com"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in contact list, got:\n%s", want, text)
+		}
+	}
+
+	result, err = h.LookupPatient("p1")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "Phone (mobile): 555-0100") {
+		t.Errorf("Expected patient info to include contact points, got:\n%s", text)
+	}
+}
+
+func TestAddContactPointValidation(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jones", "female", "1980-01-01")
+
+	tests := []struct {
+		name               string
+		system, value, use string
+	}{
+		{"unknown system", "pigeon", "coop 4", ""},
+		{"unknown use", "phone", "555-0100", "holiday"},
+		{"missing value", "phone", "  ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := h.AddContactPoint("p1", tt.system, tt.value, tt.use); err == nil {
+				t.Errorf("Expected an error for %s", tt.name)
+			}
+		})
+	}
+
+	if _, err := h.AddContactPoint("missing", "phone", "555-0100", ""); err == nil {
+		t.Error("Expected an error for unknown patient")
+	}
+}
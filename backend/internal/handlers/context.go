@@ -70,12 +70,12 @@ func (h *Handler) fetchPatientMedicalSummary(patientID string) (*PatientMedicalS
 			}
 			obsText := o.Display
 			if o.ValueQuantity != nil && o.ValueUnit != nil {
-				obsText += fmt.Sprintf(": %.2f %s", *o.ValueQuantity, *o.ValueUnit)
+				obsText += fmt.Sprintf(": %s %s", FormatValue(*o.ValueQuantity, o.Code, *o.ValueUnit), *o.ValueUnit)
 			} else if o.ValueString != nil {
 				obsText += fmt.Sprintf(": %s", *o.ValueString)
 			}
 			if o.EffectiveDateTime != nil {
-				obsText += fmt.Sprintf(" (%s)", (*o.EffectiveDateTime)[:10])
+				obsText += fmt.Sprintf(" (%s)", recordDay(*o.EffectiveDateTime))
 			}
 			summary.RecentObservations = append(summary.RecentObservations, obsText)
 			count++
@@ -167,6 +167,22 @@ func (h *Handler) fetchPatientMedicalSummary(patientID string) (*PatientMedicalS
 	return summary, nil
 }
 
+// switchPatientContext makes patientID the current patient. The last response
+// is cleared so it isn't mistaken for an answer about the new patient, unless
+// preserveLastResponse is set.
+func (h *Handler) switchPatientContext(patientID string, summary *PatientMedicalSummary) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.context.PatientID = patientID
+	h.context.PatientSummary = summary
+	if !h.preserveLastResponse {
+		h.context.LastResponse = ""
+		debug.Verbose("Last response cleared on patient change")
+	}
+	h.saveContextLocked()
+}
+
 // SetPatientContext sets the default patient ID in context
 func (h *Handler) SetPatientContext(patientID string) (interface{}, error) {
 	// Validate patient exists
@@ -189,13 +205,9 @@ func (h *Handler) SetPatientContext(patientID string) (interface{}, error) {
 		medicalSummary = nil
 	}
 
-	h.mu.Lock()
-	h.context.PatientID = patientID
-	h.context.PatientSummary = medicalSummary
-	h.context.LastResponse = "" // Clear last response when changing patient
-	h.mu.Unlock()
+	h.switchPatientContext(patientID, medicalSummary)
 	
-	debug.Log("Patient context set: %s %s (ID: %s), medical summary loaded: %v, last response cleared",
+	debug.Log("Patient context set: %s %s (ID: %s), medical summary loaded: %v",
 		patient.GivenName, patient.FamilyName, patientID, medicalSummary != nil)
 
 	return map[string]interface{}{
@@ -219,6 +231,7 @@ func (h *Handler) SetPractitionerContext(practitionerID string) (interface{}, er
 
 	h.mu.Lock()
 	h.context.PractitionerID = practitionerID
+	h.saveContextLocked()
 	h.mu.Unlock()
 
 	return map[string]interface{}{
@@ -235,6 +248,7 @@ func (h *Handler) SetPractitionerContext(practitionerID string) (interface{}, er
 func (h *Handler) GetContext() (interface{}, error) {
 	h.mu.RLock()
 	ctx := h.context
+	ctx.PractitionerID = h.practitionerIDLocked()
 	h.mu.RUnlock()
 
 	message := "Current context:\n"
@@ -283,6 +297,7 @@ func (h *Handler) GetContext() (interface{}, error) {
 func (h *Handler) ClearContext() (interface{}, error) {
 	h.mu.Lock()
 	h.context = Context{}
+	h.saveContextLocked()
 	h.mu.Unlock()
 	
 	debug.Log("Context cleared, including patient medical summary and last response")
@@ -316,94 +331,135 @@ func (h *Handler) GetContextPractitionerID(providedID string) string {
 
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	return h.practitionerIDLocked()
+}
+
+// practitionerIDLocked returns the practitioner the current query is
+// attributed to, or else the context practitioner. h.mu must be held.
+func (h *Handler) practitionerIDLocked() string {
+	if h.queryPractitionerID != "" {
+		return h.queryPractitionerID
+	}
 	return h.context.PractitionerID
 }
 
+// ContextRequired returns the required tool arguments among fields, leaving
+// out patient_id when a patient is set in the context and practitioner_id
+// when a practitioner is, since tools fall back to the context for those.
+// Tool schemas use it so the advertised required fields match what tools
+// actually accept.
+func (h *Handler) ContextRequired(fields ...string) []string {
+	h.mu.RLock()
+	hasPatientContext := h.context.PatientID != ""
+	hasPractitionerContext := h.practitionerIDLocked() != ""
+	h.mu.RUnlock()
+
+	required := []string{}
+	for _, field := range fields {
+		if (field == "patient_id" && hasPatientContext) || (field == "practitioner_id" && hasPractitionerContext) {
+			continue
+		}
+		required = append(required, field)
+	}
+	return required
+}
+
 // SetLastResponse updates the last response in context
 func (h *Handler) SetLastResponse(response string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.context.LastResponse = response
+	h.saveContextLocked()
 	debug.Verbose("Last response updated in context (length: %d)", len(response))
 }
 
 // GetContextInfo returns formatted context information for inclusion in prompts
 func (h *Handler) GetContextInfo() string {
+	// Copy the context so the consent and practitioner lookups below don't
+	// hold up context writers. Summaries are replaced, never modified, so
+	// sharing the pointer is safe.
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	c := h.context
+	practitionerID := h.practitionerIDLocked()
+	h.mu.RUnlock()
 
 	// Always include current timestamp
 	currentTime := time.Now().Format(time.RFC3339)
 	info := fmt.Sprintf("\n\nCurrent date and time: %s", currentTime)
 	
-	// Include last response if available (for conversation continuity)
-	if h.context.LastResponse != "" {
+	// Include last response if available (for conversation continuity),
+	// unless it may be about a patient who hasn't consented to sharing it
+	patientConsents := c.PatientID == "" || h.aiConsentAllows(c.PatientID)
+	if c.LastResponse != "" && patientConsents {
 		info += "\n\n**Previous Response:**"
 		// Truncate if too long to avoid context bloat
-		if len(h.context.LastResponse) > 500 {
-			info += fmt.Sprintf("\n%s... (truncated)", h.context.LastResponse[:500])
+		if lastResponse, truncated := truncateChars(c.LastResponse, h.lastResponseMaxChars); truncated {
+			info += fmt.Sprintf("\n%s... (truncated)", lastResponse)
 		} else {
-			info += fmt.Sprintf("\n%s", h.context.LastResponse)
+			info += fmt.Sprintf("\n%s", lastResponse)
 		}
 	}
 
-	if h.context.PatientID != "" || h.context.PractitionerID != "" {
+	if c.PatientID != "" || practitionerID != "" {
 		info += "\n\nCurrent context:"
-		if h.context.PatientID != "" {
-			info += fmt.Sprintf("\n- Current Patient ID: %s", h.context.PatientID)
+		if c.PatientID != "" {
+			info += fmt.Sprintf("\n- Current Patient ID: %s", c.PatientID)
 			
-			// Include patient medical summary if available
-			if h.context.PatientSummary != nil {
+			// Include patient medical summary if available and the
+			// patient agreed to share it with the model
+			if !patientConsents {
+				info += "\n- " + aiConsentMessage(c.PatientID)
+			} else if c.PatientSummary != nil {
 				info += "\n\n**Patient Medical Summary:**"
-				info += fmt.Sprintf("\n- Demographics: %s", h.context.PatientSummary.Demographics)
+				info += fmt.Sprintf("\n- Demographics: %s", c.PatientSummary.Demographics)
 				
 				// Encounter information
-				if h.context.PatientSummary.LastEncounter != "" {
-					info += fmt.Sprintf("\n- Last Visit: %s", h.context.PatientSummary.LastEncounter)
+				if c.PatientSummary.LastEncounter != "" {
+					info += fmt.Sprintf("\n- Last Visit: %s", c.PatientSummary.LastEncounter)
 				}
-				if h.context.PatientSummary.TotalEncounters > 0 {
-					info += fmt.Sprintf(" (Total visits: %d)", h.context.PatientSummary.TotalEncounters)
+				if c.PatientSummary.TotalEncounters > 0 {
+					info += fmt.Sprintf(" (Total visits: %d)", c.PatientSummary.TotalEncounters)
 				}
 				
-				if len(h.context.PatientSummary.RecentEncounters) > 0 {
+				if len(c.PatientSummary.RecentEncounters) > 0 {
 					info += "\n- Recent Encounters:"
-					for _, enc := range h.context.PatientSummary.RecentEncounters {
+					for _, enc := range c.PatientSummary.RecentEncounters {
 						info += fmt.Sprintf("\n  • %s", enc)
 					}
 				}
 				
-				if len(h.context.PatientSummary.ActiveConditions) > 0 {
+				if len(c.PatientSummary.ActiveConditions) > 0 {
 					info += "\n- Active Conditions:"
-					for _, condition := range h.context.PatientSummary.ActiveConditions {
+					for _, condition := range c.PatientSummary.ActiveConditions {
 						info += fmt.Sprintf("\n  • %s", condition)
 					}
 				}
 				
-				if len(h.context.PatientSummary.CurrentMedications) > 0 {
+				if len(c.PatientSummary.CurrentMedications) > 0 {
 					info += "\n- Current Medications:"
-					for _, med := range h.context.PatientSummary.CurrentMedications {
+					for _, med := range c.PatientSummary.CurrentMedications {
 						info += fmt.Sprintf("\n  • %s", med)
 					}
 				}
 				
-				if len(h.context.PatientSummary.Allergies) > 0 {
+				if len(c.PatientSummary.Allergies) > 0 {
 					info += "\n- Allergies:"
-					for _, allergy := range h.context.PatientSummary.Allergies {
+					for _, allergy := range c.PatientSummary.Allergies {
 						info += fmt.Sprintf("\n  • %s", allergy)
 					}
 				}
 				
-				if len(h.context.PatientSummary.RecentObservations) > 0 {
+				if len(c.PatientSummary.RecentObservations) > 0 {
 					info += "\n- Recent Observations:"
-					for _, obs := range h.context.PatientSummary.RecentObservations {
+					for _, obs := range c.PatientSummary.RecentObservations {
 						info += fmt.Sprintf("\n  • %s", obs)
 					}
 				}
 			}
 		}
-		if h.context.PractitionerID != "" {
+		if practitionerID != "" {
 			// Fetch practitioner details to include name and relevant info
-			practitioner, err := database.GetPractitionerByID(h.db, h.context.PractitionerID)
+			practitioner, err := database.GetPractitionerByID(h.db, practitionerID)
 			if err == nil {
 				info += "\n\n**Practitioner Information:**"
 				practitionerName := fmt.Sprintf("%s %s", practitioner.GivenName, practitioner.FamilyName)
@@ -411,13 +467,13 @@ func (h *Handler) GetContextInfo() string {
 					practitionerName = fmt.Sprintf("%s %s %s", *practitioner.Prefix, practitioner.GivenName, practitioner.FamilyName)
 				}
 				info += fmt.Sprintf("\n- Name: %s", practitionerName)
-				info += fmt.Sprintf("\n- ID: %s", h.context.PractitionerID)
+				info += fmt.Sprintf("\n- ID: %s", practitionerID)
 				
 				if practitioner.Gender != nil && *practitioner.Gender != "" {
 					info += fmt.Sprintf("\n- Gender: %s", *practitioner.Gender)
 				}
 			} else {
-				info += fmt.Sprintf("\n- Current Practitioner ID: %s", h.context.PractitionerID)
+				info += fmt.Sprintf("\n- Current Practitioner ID: %s", practitionerID)
 			}
 		}
 	}
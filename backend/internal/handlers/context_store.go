@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// storedContext is the part of the context written to CONTEXT_STORE_PATH.
+// The medical summary is left out; it is re-fetched on load so it is never
+// stale.
+type storedContext struct {
+	PatientID      string `json:"patient_id,omitempty"`
+	PractitionerID string `json:"practitioner_id,omitempty"`
+	LastResponse   string `json:"last_response,omitempty"`
+}
+
+// saveContextLocked writes the context to the context store, if one is
+// configured. Only the default session is stored. The caller must hold h.mu.
+// Failures are logged, not returned, so a read-only disk doesn't break the
+// conversation.
+func (h *Handler) saveContextLocked() {
+	if h.contextStorePath == "" || h.sessionID != "" {
+		return
+	}
+
+	data, err := json.Marshal(storedContext{
+		PatientID:      h.context.PatientID,
+		PractitionerID: h.context.PractitionerID,
+		LastResponse:   h.context.LastResponse,
+	})
+	if err != nil {
+		debug.Error("Failed to encode context: %v", err)
+		return
+	}
+
+	// Write to a temporary file and rename it so a crash never leaves a
+	// half-written store behind
+	tmp, err := os.CreateTemp(filepath.Dir(h.contextStorePath), ".context-*")
+	if err != nil {
+		debug.Error("Failed to save context: %v", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), h.contextStorePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		debug.Error("Failed to save context: %v", err)
+	}
+}
+
+// loadContext restores the context saved in the context store, if any, and
+// fetches a fresh medical summary for the restored patient. A patient that no
+// longer exists is dropped.
+func (h *Handler) loadContext() {
+	if h.contextStorePath == "" {
+		return
+	}
+
+	data, err := os.ReadFile(h.contextStorePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			debug.Error("Failed to read context store: %v", err)
+		}
+		return
+	}
+	var stored storedContext
+	if err := json.Unmarshal(data, &stored); err != nil {
+		debug.Error("Ignoring invalid context store %s: %v", h.contextStorePath, err)
+		return
+	}
+
+	var summary *PatientMedicalSummary
+	if stored.PatientID != "" {
+		exists, err := database.CheckPatientExists(h.db, stored.PatientID)
+		if err != nil || !exists {
+			debug.Log("Not restoring patient %s from context store: patient not found", stored.PatientID)
+			stored.PatientID = ""
+			stored.LastResponse = ""
+		} else if summary, err = h.fetchPatientMedicalSummary(stored.PatientID); err != nil {
+			debug.Error("Failed to fetch medical summary: %v", err)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.context.PatientID = stored.PatientID
+	h.context.PractitionerID = stored.PractitionerID
+	h.context.LastResponse = stored.LastResponse
+	h.context.PatientSummary = summary
+	debug.Log("Context restored from %s (patient: %q, practitioner: %q)",
+		h.contextStorePath, stored.PatientID, stored.PractitionerID)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "context.json")
+	t.Setenv("CONTEXT_STORE_PATH", path)
+
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPractitioner(t, h, "prac-1", "Ola", "Lege")
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+	if _, err := h.SetPractitionerContext("prac-1"); err != nil {
+		t.Fatalf("SetPractitionerContext failed: %v", err)
+	}
+	h.SetLastResponse("Blood pressure is stable.")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("context store not written: %v", err)
+	}
+	if strings.Contains(string(data), "Demographics") || strings.Contains(string(data), "patient_summary") {
+		t.Errorf("medical summary persisted: %s", data)
+	}
+
+	// A restarted server sees the same database and store
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2010-05-01")
+	restarted := NewHandler(h.db, "test-key")
+	ctx := restarted.context
+	if ctx.PatientID != "p1" || ctx.PractitionerID != "prac-1" || ctx.LastResponse != "Blood pressure is stable." {
+		t.Errorf("restored context = %+v", ctx)
+	}
+	if ctx.PatientSummary == nil || len(ctx.PatientSummary.ActiveConditions) != 1 {
+		t.Errorf("summary not re-fetched on load: %+v", ctx.PatientSummary)
+	}
+
+	if _, err := restarted.ClearContext(); err != nil {
+		t.Fatalf("ClearContext failed: %v", err)
+	}
+	if ctx := NewHandler(h.db, "test-key").context; ctx.PatientID != "" || ctx.LastResponse != "" {
+		t.Errorf("cleared context restored: %+v", ctx)
+	}
+}
+
+func TestContextStoreDropsMissingPatient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "context.json")
+	if err := os.WriteFile(path, []byte(`{"patient_id":"gone","last_response":"old"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONTEXT_STORE_PATH", path)
+
+	h := newTestHandler(t)
+	if h.context.PatientID != "" || h.context.LastResponse != "" {
+		t.Errorf("context restored for a missing patient: %+v", h.context)
+	}
+}
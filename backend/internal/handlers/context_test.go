@@ -0,0 +1,48 @@
+package handlers
+
+import "testing"
+
+func TestLastResponseOnPatientChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		preserve string
+		want     string
+	}{
+		{"cleared by default", "", ""},
+		{"preserved when configured", "true", "answer about p1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCP_PRESERVE_LAST_RESPONSE", tt.preserve)
+			h := newTestHandler(t)
+			seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+			seedPatient(t, h, "p2", "Jon", "Sigurdsson", "male", "1975-05-05")
+
+			if _, err := h.SetPatientContext("p1"); err != nil {
+				t.Fatalf("SetPatientContext(p1): %v", err)
+			}
+			h.SetLastResponse("answer about p1")
+
+			// Switch through both entry points that change the patient
+			if _, err := h.SetPatientContext("p2"); err != nil {
+				t.Fatalf("SetPatientContext(p2): %v", err)
+			}
+			if got := currentContext(h).LastResponse; got != tt.want {
+				t.Errorf("after SetPatientContext: LastResponse = %q, want %q", got, tt.want)
+			}
+
+			h.SetLastResponse("answer about p1")
+			if _, err := h.LookupPatient("p1"); err != nil {
+				t.Fatalf("LookupPatient(p1): %v", err)
+			}
+			ctx := currentContext(h)
+			if ctx.PatientID != "p1" {
+				t.Errorf("PatientID = %q, want p1", ctx.PatientID)
+			}
+			if ctx.LastResponse != tt.want {
+				t.Errorf("after LookupPatient: LastResponse = %q, want %q", ctx.LastResponse, tt.want)
+			}
+		})
+	}
+}
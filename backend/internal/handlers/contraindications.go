@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// contraindicationRule flags a medication class that should be avoided (or
+// used with caution) in patients with a matching condition. Terms are matched
+// as case-insensitive substrings of the medication and condition displays.
+type contraindicationRule struct {
+	Drug            string
+	MedicationTerms []string
+	Condition       string
+	ConditionTerms  []string
+	Reason          string
+}
+
+var contraindicationRules = []contraindicationRule{
+	{
+		Drug:            "Metformin",
+		MedicationTerms: []string{"metformin"},
+		Condition:       "Severe renal impairment",
+		ConditionTerms:  []string{"chronic kidney disease stage 4", "chronic kidney disease stage 5", "end-stage renal disease", "end stage renal disease", "renal failure", "dialysis"},
+		Reason:          "Risk of lactic acidosis; contraindicated when eGFR < 30 mL/min/1.73m²",
+	},
+	{
+		Drug:            "NSAID",
+		MedicationTerms: []string{"ibuprofen", "naproxen", "diclofenac", "celecoxib", "meloxicam", "ketorolac", "indomethacin"},
+		Condition:       "Chronic kidney disease",
+		ConditionTerms:  []string{"chronic kidney disease", "renal insufficiency", "renal failure", "end-stage renal disease"},
+		Reason:          "NSAIDs reduce renal perfusion and can accelerate loss of kidney function",
+	},
+	{
+		Drug:            "NSAID",
+		MedicationTerms: []string{"ibuprofen", "naproxen", "diclofenac", "celecoxib", "meloxicam", "ketorolac", "indomethacin"},
+		Condition:       "Heart failure",
+		ConditionTerms:  []string{"heart failure"},
+		Reason:          "NSAIDs cause fluid retention and increase the risk of heart failure exacerbation",
+	},
+	{
+		Drug:            "NSAID",
+		MedicationTerms: []string{"ibuprofen", "naproxen", "diclofenac", "meloxicam", "ketorolac", "indomethacin"},
+		Condition:       "Peptic ulcer / GI bleeding",
+		ConditionTerms:  []string{"peptic ulcer", "gastric ulcer", "duodenal ulcer", "gastrointestinal hemorrhage"},
+		Reason:          "Increased risk of gastrointestinal bleeding and ulcer recurrence",
+	},
+	{
+		Drug:            "Non-selective beta-blocker",
+		MedicationTerms: []string{"propranolol", "nadolol", "timolol", "sotalol", "carvedilol"},
+		Condition:       "Asthma",
+		ConditionTerms:  []string{"asthma"},
+		Reason:          "Non-selective beta-blockade can precipitate bronchospasm",
+	},
+	{
+		Drug:            "ACE inhibitor / ARB",
+		MedicationTerms: []string{"lisinopril", "enalapril", "ramipril", "captopril", "benazepril", "losartan", "valsartan", "irbesartan"},
+		Condition:       "Pregnancy",
+		ConditionTerms:  []string{"pregnancy", "pregnant"},
+		Reason:          "Fetotoxic in the second and third trimesters",
+	},
+	{
+		Drug:            "ACE inhibitor",
+		MedicationTerms: []string{"lisinopril", "enalapril", "ramipril", "captopril", "benazepril"},
+		Condition:       "Angioedema",
+		ConditionTerms:  []string{"angioedema"},
+		Reason:          "History of angioedema is a contraindication to ACE inhibitors",
+	},
+	{
+		Drug:            "Warfarin",
+		MedicationTerms: []string{"warfarin"},
+		Condition:       "Active bleeding",
+		ConditionTerms:  []string{"hemorrhage", "haemorrhage", "bleeding"},
+		Reason:          "Anticoagulation is contraindicated with active bleeding",
+	},
+	{
+		Drug:            "Estrogen-containing contraceptive",
+		MedicationTerms: []string{"estradiol", "ethinyl estradiol", "conjugated estrogens"},
+		Condition:       "Thromboembolism / stroke",
+		ConditionTerms:  []string{"thrombosis", "pulmonary embolism", "stroke", "cerebrovascular accident"},
+		Reason:          "Estrogens increase the risk of thromboembolic events",
+	},
+	{
+		Drug:            "Thiazolidinedione / non-dihydropyridine CCB",
+		MedicationTerms: []string{"pioglitazone", "rosiglitazone", "verapamil", "diltiazem"},
+		Condition:       "Heart failure",
+		ConditionTerms:  []string{"heart failure"},
+		Reason:          "Can worsen heart failure (fluid retention or negative inotropy)",
+	},
+	{
+		Drug:            "Statin",
+		MedicationTerms: []string{"simvastatin", "atorvastatin", "rosuvastatin", "pravastatin", "lovastatin"},
+		Condition:       "Active liver disease",
+		ConditionTerms:  []string{"hepatic failure", "liver failure", "cirrhosis", "hepatitis"},
+		Reason:          "Contraindicated in active liver disease or unexplained transaminase elevation",
+	},
+}
+
+type contraindicationFinding struct {
+	Medication string
+	Condition  string
+	Rule       contraindicationRule
+}
+
+func containsAny(text string, terms []string) bool {
+	textLower := strings.ToLower(text)
+	for _, term := range terms {
+		if strings.Contains(textLower, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// findContraindications cross-references active medications against active
+// conditions using contraindicationRules
+func findContraindications(medications []database.MedicationRequest, conditions []database.Condition) []contraindicationFinding {
+	var findings []contraindicationFinding
+	for _, m := range medications {
+		if m.Status != "active" && m.Status != "" {
+			continue
+		}
+		for _, c := range conditions {
+			if c.ClinicalStatus != "active" && c.ClinicalStatus != "" {
+				continue
+			}
+			for _, rule := range contraindicationRules {
+				if containsAny(m.MedicationDisplay, rule.MedicationTerms) && containsAny(c.Display, rule.ConditionTerms) {
+					findings = append(findings, contraindicationFinding{
+						Medication: m.MedicationDisplay,
+						Condition:  c.Display,
+						Rule:       rule,
+					})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func (h *Handler) CheckContraindications(ctx context.Context, patientID string, includeAIReview bool) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	medications, err := database.GetMedicationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	findings := findContraindications(medications, conditions)
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Contraindication Check for %s (ID: %s)\n\n", patientName, patientID))
+	if len(findings) == 0 {
+		result.WriteString("No contraindicated medication/condition pairs found among active medications and conditions.\n")
+	} else {
+		result.WriteString(fmt.Sprintf("⚠ %d potential contraindication(s) found:\n", len(findings)))
+		for _, f := range findings {
+			result.WriteString(fmt.Sprintf("• %s + %s\n", f.Medication, f.Condition))
+			result.WriteString(fmt.Sprintf("  %s with %s: %s\n", f.Rule.Drug, f.Rule.Condition, f.Rule.Reason))
+		}
+	}
+
+	if includeAIReview {
+		var activeMeds, activeConditions []string
+		for _, m := range medications {
+			if m.Status == "active" || m.Status == "" {
+				activeMeds = append(activeMeds, m.MedicationDisplay)
+			}
+		}
+		for _, c := range conditions {
+			if c.ClinicalStatus == "active" || c.ClinicalStatus == "" {
+				activeConditions = append(activeConditions, c.Display)
+			}
+		}
+
+		if len(activeMeds) > 0 && len(activeConditions) > 0 {
+			prompt := fmt.Sprintf("Review the following active medications against the patient's active conditions and list any clinically significant drug-disease contraindications not already flagged. Be concise.\n\nActive medications:\n- %s\n\nActive conditions:\n- %s",
+				strings.Join(activeMeds, "\n- "), strings.Join(activeConditions, "\n- "))
+			aiReview, err := h.callGuidelinesModel(ctx, prompt)
+			if err != nil {
+				result.WriteString("\nAI review unavailable: unable to reach the guidelines model.\n")
+			} else {
+				result.WriteString("\nAI review:\n")
+				result.WriteString(aiReview)
+				result.WriteString("\n")
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
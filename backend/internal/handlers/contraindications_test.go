@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCheckContraindications(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ann", "Lee", "female", "1950-03-01")
+	seedMedication(t, h, "p1", "metFORMIN hydrochloride 500 MG Oral Tablet", "active", "2023-01-01T00:00:00Z")
+	seedMedication(t, h, "p1", "Ibuprofen 200 MG Oral Tablet", "stopped", "2020-01-01T00:00:00Z")
+	seedCondition(t, h, "p1", "431857002", "Chronic kidney disease stage 4 (disorder)", "active", "2022-06-01T00:00:00Z")
+
+	result, err := h.CheckContraindications(context.Background(), "p1", false)
+	text := resultText(t, h, result, err)
+
+	if !strings.Contains(text, "1 potential contraindication(s) found") {
+		t.Errorf("expected exactly one finding, got:\n%s", text)
+	}
+	if !strings.Contains(text, "metFORMIN hydrochloride 500 MG Oral Tablet + Chronic kidney disease stage 4 (disorder)") {
+		t.Errorf("expected metformin/CKD pair to be flagged, got:\n%s", text)
+	}
+	// Stopped medications are not checked
+	if strings.Contains(text, "Ibuprofen") {
+		t.Errorf("did not expect inactive ibuprofen to be flagged, got:\n%s", text)
+	}
+}
+
+func TestCheckContraindications_None(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Bob", "Ray", "male", "1960-01-01")
+	seedMedication(t, h, "p1", "Lisinopril 10 MG Oral Tablet", "active", "2023-01-01T00:00:00Z")
+	seedCondition(t, h, "p1", "59621000", "Essential hypertension (disorder)", "active", "2022-06-01T00:00:00Z")
+
+	result, err := h.CheckContraindications(context.Background(), "p1", false)
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "No contraindicated medication/condition pairs found") {
+		t.Errorf("expected no findings, got:\n%s", text)
+	}
+}
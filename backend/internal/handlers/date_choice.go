@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// pendingDateChoice is an operation held back because its date was
+// ambiguous. ConfirmDateChoice runs it with the option the user picked.
+type pendingDateChoice struct {
+	Ambiguous   *AmbiguousDateError
+	Description string
+	complete    func(time.Time) (interface{}, error)
+}
+
+// awaitDateChoice stores the operation in the context, replacing any earlier
+// pending choice, and returns the question to put to the user
+func (h *Handler) awaitDateChoice(ambiguous *AmbiguousDateError, description string, complete func(time.Time) (interface{}, error)) interface{} {
+	h.mu.Lock()
+	h.context.pendingDate = &pendingDateChoice{Ambiguous: ambiguous, Description: description, complete: complete}
+	h.mu.Unlock()
+	debug.Log("Awaiting date choice for %s: %v", description, ambiguous)
+
+	return ambiguousDateResult(ambiguous)
+}
+
+// ambiguousDateResult asks the user to choose between the readings of an
+// ambiguous date
+func ambiguousDateResult(err *AmbiguousDateError) interface{} {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("The date \"%s\" is ambiguous. Which did you mean?\n\n", err.Input))
+	keys := make([]string, len(err.Options))
+	for i, o := range err.Options {
+		text.WriteString(fmt.Sprintf("%s) %s\n", o.Key, o.Description))
+		keys[i] = o.Key
+	}
+	text.WriteString(fmt.Sprintf("\nPlease confirm with %s.", strings.Join(keys, " or ")))
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text.String(),
+			},
+		},
+	}
+}
+
+// ConfirmDateChoice completes the operation waiting on an ambiguous date with
+// the chosen option (e.g. "A") and clears it. An unknown choice leaves the
+// operation pending so the user can try again.
+func (h *Handler) ConfirmDateChoice(choice string) (interface{}, error) {
+	h.mu.Lock()
+	pending := h.context.pendingDate
+	if pending == nil {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("no ambiguous date is waiting for confirmation")
+	}
+	option, ok := pending.Ambiguous.Option(choice)
+	if !ok {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("invalid choice %q for %s", choice, pending.Ambiguous)
+	}
+	h.context.pendingDate = nil
+	h.mu.Unlock()
+
+	debug.Log("Date choice %s confirmed for %s: %s", option.Key, pending.Description, option.Time.Format(time.RFC3339))
+	return pending.complete(option.Time)
+}
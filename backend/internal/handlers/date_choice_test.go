@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfirmDateChoiceSchedulesAppointment(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPractitioner(t, h, "dr1", "Gregory", "House")
+
+	result, err := h.ScheduleAppointment("p1", "dr1", inNextYear("03/04/YYYY 10:00"), "Check-up")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "A) ") || !strings.Contains(text, "B) ") {
+		t.Fatalf("expected A/B choices, got: %s", text)
+	}
+
+	var count int
+	h.db.QueryRow(`SELECT COUNT(*) FROM encounters`).Scan(&count)
+	if count != 0 {
+		t.Fatalf("appointment created before the date was confirmed")
+	}
+
+	if _, err := h.ConfirmDateChoice("C"); err == nil {
+		t.Error("unknown choice accepted")
+	}
+
+	result, err = h.ConfirmDateChoice("b")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, inNextYear("YYYY-03-04 10:00")) {
+		t.Errorf("option B (4 March) not scheduled: %s", text)
+	}
+
+	var start, appointmentType string
+	if err := h.db.QueryRow(`SELECT start_datetime, type_display FROM encounters WHERE patient_id = 'p1'`).Scan(&start, &appointmentType); err != nil {
+		t.Fatalf("appointment not created: %v", err)
+	}
+	if want := inNextYear("YYYY-03-04T10:00:00+01:00"); start != want || appointmentType != "Check-up" {
+		t.Errorf("appointment = %s %q, want %s \"Check-up\"", start, appointmentType, want)
+	}
+
+	if _, err := h.ConfirmDateChoice("A"); err == nil {
+		t.Error("pending choice was not cleared")
+	}
+}
+
+func TestConfirmDateChoiceReschedules(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "e1", "p1", "planned", inNextYear("YYYY-01-10T09:00:00Z"))
+
+	if _, err := h.RescheduleAppointment("e1", inNextYear("03/04/YYYY")); err != nil {
+		t.Fatalf("RescheduleAppointment: %v", err)
+	}
+	result, err := h.ConfirmDateChoice("A")
+	resultText(t, h, result, err)
+
+	var start string
+	h.db.QueryRow(`SELECT start_datetime FROM encounters WHERE id = 'e1'`).Scan(&start)
+	if want := inNextYear("YYYY-04-03T09:00:00+02:00"); start != want {
+		t.Errorf("start_datetime = %q, want %s", start, want)
+	}
+}
+
+func TestConfirmDateChoiceWithoutPending(t *testing.T) {
+	h := newTestHandler(t)
+	if _, err := h.ConfirmDateChoice("A"); err == nil {
+		t.Error("expected an error with no pending date")
+	}
+}
+
+func TestConfirmDateChoiceAmbiguousHour(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPractitioner(t, h, "dr1", "Gregory", "House")
+
+	result, err := h.ScheduleAppointment("p1", "dr1", "tomorrow at 3", "Check-up")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "03:00 (AM)") || !strings.Contains(text, "15:00 (PM)") {
+		t.Fatalf("expected AM/PM choices, got: %s", text)
+	}
+
+	result, err = h.ConfirmDateChoice("B")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "15:00") {
+		t.Errorf("option B (15:00) not scheduled: %s", text)
+	}
+}
+
+// inNextYear replaces YYYY in s with next year, so appointment dates in tests
+// stay in the future and within the booking horizon
+func inNextYear(s string) string {
+	return strings.ReplaceAll(s, "YYYY", strconv.Itoa(time.Now().Year()+1))
+}
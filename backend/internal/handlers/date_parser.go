@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // appointment times are in Europe/Berlin regardless of the host's zone database
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// Date-only input is scheduled at 09:00 unless configured otherwise
+const (
+	fallbackAppointmentHour   = 9
+	fallbackAppointmentMinute = 0
+)
+
+// defaultAppointmentHour and defaultAppointmentMinute are the time of day
+// input without a time is scheduled at (MCP_DEFAULT_APPOINTMENT_HOUR and
+// MCP_DEFAULT_APPOINTMENT_MINUTE, default 09:00). Every date format reads
+// them, so a clinic opening at 08:30 gets 08:30 however the date was typed.
+var defaultAppointmentHour, defaultAppointmentMinute = loadDefaultAppointmentTime()
+
+func loadDefaultAppointmentTime() (hour, minute int) {
+	hour = envInt("MCP_DEFAULT_APPOINTMENT_HOUR", fallbackAppointmentHour)
+	minute = envInt("MCP_DEFAULT_APPOINTMENT_MINUTE", fallbackAppointmentMinute)
+	if hour > 23 || minute > 59 {
+		debug.Error("Invalid default appointment time %02d:%02d, using %02d:%02d",
+			hour, minute, fallbackAppointmentHour, fallbackAppointmentMinute)
+		return fallbackAppointmentHour, fallbackAppointmentMinute
+	}
+	return hour, minute
+}
+
+// appointmentLocation is the time zone of input without an explicit offset
+var appointmentLocation = loadAppointmentLocation()
+
+func loadAppointmentLocation() *time.Location {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		debug.Error("Failed to load Europe/Berlin time zone, using UTC: %v", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// displayLocation is the time zone record times are shown in
+// (MCP_DISPLAY_TIMEZONE, default Europe/Berlin). They are stored in UTC.
+var displayLocation = loadDisplayLocation()
+
+func loadDisplayLocation() *time.Location {
+	name := strings.TrimSpace(os.Getenv("MCP_DISPLAY_TIMEZONE"))
+	if name == "" {
+		return appointmentLocation
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		debug.Error("Invalid MCP_DISPLAY_TIMEZONE %q, using Europe/Berlin: %v", name, err)
+		return appointmentLocation
+	}
+	return loc
+}
+
+// formatRecordTime shows a stored datetime in the display zone, e.g.
+// "2024-03-14 10:30 CET". Plain dates and values that don't parse are
+// returned unchanged.
+func formatRecordTime(value string) string {
+	t, ok := parseRecordTime(value)
+	if !ok || len(strings.TrimSpace(value)) == len("2006-01-02") {
+		return value
+	}
+	return t.In(displayLocation).Format("2006-01-02 15:04 MST")
+}
+
+// recordDay returns the date of a stored datetime in the display zone, or
+// the first ten characters of a plain date or a value that doesn't parse
+func recordDay(value string) string {
+	if t, ok := parseRecordTime(value); ok && len(strings.TrimSpace(value)) > len("2006-01-02") {
+		return t.In(displayLocation).Format("2006-01-02")
+	}
+	if len(value) > 10 {
+		return value[:10]
+	}
+	return value
+}
+
+var (
+	// 14.03.2025, optionally followed by a time ("14.03.2025 10:30", "14.03.2025 um 10:30")
+	germanDateRegex = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})\.(\d{4})(?:,?\s+(?:um\s+)?(\d{1,2}):(\d{2})(?:\s*uhr)?)?$`)
+	// 03/04/2025, optionally followed by a time ("03/04/2025 14:30", "03/04/2025 at 2:30 pm", "03/04/2025 2pm")
+	slashDateRegex = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/(\d{4})(?:,?\s+(?:at\s+)?(\d{1,2})(?::(\d{2}))?\s*(am|pm)?)?$`)
+	// "a.m." and "p.m.", normalized to "am" and "pm" before parsing
+	dottedMeridiemRegex = regexp.MustCompile(`\b([ap])\.\s?m\b\.?`)
+	// A time of day inside relative input, e.g. "tomorrow at 14:30" or "next monday 2:30 pm"
+	timeRegex = regexp.MustCompile(`\b(\d{1,2}):(\d{2})\s*(am|pm)?\b`)
+	// A whole hour inside relative input, e.g. "tomorrow 3pm" or "tomorrow at 15"
+	hourRegex = regexp.MustCompile(`\b(?:at\s+)?(\d{1,2})\s*(am|pm)\b|\bat\s+(\d{1,2})\b`)
+	// A past offset such as "3 days ago" or "a week ago"
+	agoRegex = regexp.MustCompile(`\b(\d+|a|one)\s+(day|week)s?\s+ago\b`)
+	// A future offset such as "in 3 days", "in 2 weeks" or "in a month"
+	inRegex = regexp.MustCompile(`\bin\s+(\d+|a|one)\s+(day|week|month)s?\b`)
+)
+
+// DateOption is one interpretation of an ambiguous date
+type DateOption struct {
+	Key         string    `json:"key"`
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+}
+
+// AmbiguousDateError is returned when input such as "03/04/2025" reads as
+// more than one date. The caller should ask the user to pick an option.
+type AmbiguousDateError struct {
+	Input   string
+	Options []DateOption
+}
+
+func (e *AmbiguousDateError) Error() string {
+	choices := make([]string, len(e.Options))
+	for i, o := range e.Options {
+		choices[i] = fmt.Sprintf("%s) %s", o.Key, o.Description)
+	}
+	return fmt.Sprintf("ambiguous date %q: %s", e.Input, strings.Join(choices, ", "))
+}
+
+// Option returns the option with the given key (case-insensitive)
+func (e *AmbiguousDateError) Option(key string) (DateOption, bool) {
+	key = strings.TrimSpace(key)
+	for _, o := range e.Options {
+		if strings.EqualFold(o.Key, key) {
+			return o, true
+		}
+	}
+	return DateOption{}, false
+}
+
+// ParseDateTimeRobust parses the date/time formats users actually type:
+// RFC 3339, ISO dates with or without a time, German dates (14.03.2025),
+// slash dates (03/04/2025) and relative dates ("tomorrow at 10:00",
+// "next monday"). Times after slash and relative dates may carry am/pm
+// ("2pm", "2:30 p.m."). Input without an offset is in Europe/Berlin time and
+// input without a time is at the default appointment time (09:00 unless
+// configured). Slash dates that read differently as
+// day/month and month/day, and a bare hour such as "at 3", return an
+// *AmbiguousDateError.
+func ParseDateTimeRobust(input string) (time.Time, error) {
+	return parseDateTimeAt(input, time.Now().In(appointmentLocation))
+}
+
+func parseDateTimeAt(input string, now time.Time) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, fmt.Errorf("date/time is required")
+	}
+
+	if t, err := time.Parse(time.RFC3339, input); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, input, appointmentLocation); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", input, appointmentLocation); err == nil {
+		return atDefaultTime(t), nil
+	}
+
+	lower := dottedMeridiemRegex.ReplaceAllString(strings.ToLower(input), "${1}m")
+
+	if m := germanDateRegex.FindStringSubmatch(lower); m != nil {
+		day, month, year := atoi(m[1]), atoi(m[2]), atoi(m[3])
+		hour, minute := defaultAppointmentHour, defaultAppointmentMinute
+		if m[4] != "" {
+			hour, minute = atoi(m[4]), atoi(m[5])
+		}
+		if t, ok := makeDateTime(year, month, day, hour, minute); ok {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("invalid date: %s", input)
+	}
+
+	if m := slashDateRegex.FindStringSubmatch(lower); m != nil {
+		return parseSlashDate(input, m)
+	}
+
+	if date, ok := parseRelativeDate(lower, now); ok {
+		hour, minute := defaultAppointmentHour, defaultAppointmentMinute
+		if m := timeRegex.FindStringSubmatch(lower); m != nil {
+			var valid bool
+			if hour, minute, valid = clockTime(m[1], m[2], m[3]); !valid {
+				return time.Time{}, fmt.Errorf("invalid time: %s", input)
+			}
+		} else if m := hourRegex.FindStringSubmatch(lower); m != nil {
+			hourText, meridiem := m[1], m[2]
+			if hourText == "" {
+				// "at 3" could be 03:00 or 15:00; 0 and 12 to 23 can't
+				hourText = m[3]
+				if h := atoi(hourText); h >= 1 && h <= 11 {
+					return time.Time{}, ambiguousHour(input, date, h)
+				}
+			}
+			var valid bool
+			if hour, minute, valid = clockTime(hourText, "0", meridiem); !valid {
+				return time.Time{}, fmt.Errorf("invalid time: %s", input)
+			}
+		}
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, appointmentLocation), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date/time %q (use e.g. 2025-03-14 14:30, 14.03.2025 or \"tomorrow at 10:00\")", input)
+}
+
+// ambiguousHour offers the morning and afternoon readings of a bare hour
+// from 1 to 11 on date
+func ambiguousHour(input string, date time.Time, hour int) *AmbiguousDateError {
+	am := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, appointmentLocation)
+	pm := am.Add(12 * time.Hour)
+	return &AmbiguousDateError{
+		Input: input,
+		Options: []DateOption{
+			{Key: "A", Time: am, Description: am.Format("Monday, 2 January 2006 15:04") + " (AM)"},
+			{Key: "B", Time: pm, Description: pm.Format("Monday, 2 January 2006 15:04") + " (PM)"},
+		},
+	}
+}
+
+// parseSlashDate resolves a slash date as day/month or month/day. When only
+// one reading is a valid date it is used; when both are valid and differ the
+// user has to choose.
+func parseSlashDate(input string, m []string) (time.Time, error) {
+	first, second, year := atoi(m[1]), atoi(m[2]), atoi(m[3])
+	hour, minute := defaultAppointmentHour, defaultAppointmentMinute
+	if m[4] != "" {
+		// A bare hour needs am or pm ("2pm"), otherwise minutes ("14:00")
+		var valid bool
+		if hour, minute, valid = clockTime(m[4], m[5], m[6]); !valid || (m[5] == "" && m[6] == "") {
+			return time.Time{}, fmt.Errorf("invalid time: %s", input)
+		}
+	}
+
+	dayMonth, dayMonthOK := makeDateTime(year, second, first, hour, minute)
+	monthDay, monthDayOK := makeDateTime(year, first, second, hour, minute)
+
+	switch {
+	case dayMonthOK && monthDayOK && !dayMonth.Equal(monthDay):
+		return time.Time{}, &AmbiguousDateError{
+			Input: input,
+			Options: []DateOption{
+				{Key: "A", Time: dayMonth, Description: dayMonth.Format("Monday, 2 January 2006 15:04") + " (day/month)"},
+				{Key: "B", Time: monthDay, Description: monthDay.Format("Monday, 2 January 2006 15:04") + " (month/day)"},
+			},
+		}
+	case dayMonthOK:
+		return dayMonth, nil
+	case monthDayOK:
+		return monthDay, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s", input)
+}
+
+// parseRelativeDate recognizes "today", "tomorrow", "day after tomorrow",
+// "next week" and "next <weekday>" (also "on <weekday>" or a bare weekday),
+// "week after next" and "in <n> days/weeks/months", and for backdating
+// "yesterday", "day before yesterday", "last week", "last <weekday>" and
+// "<n> days/weeks ago", returning the date relative to now
+func parseRelativeDate(input string, now time.Time) (time.Time, bool) {
+	switch {
+	case strings.Contains(input, "day after tomorrow"):
+		return now.AddDate(0, 0, 2), true
+	case strings.Contains(input, "tomorrow"):
+		return now.AddDate(0, 0, 1), true
+	case strings.Contains(input, "day before yesterday"):
+		return now.AddDate(0, 0, -2), true
+	case strings.Contains(input, "yesterday"):
+		return now.AddDate(0, 0, -1), true
+	case strings.Contains(input, "today"):
+		return now, true
+	case strings.Contains(input, "week after next"):
+		return now.AddDate(0, 0, 14), true
+	case strings.Contains(input, "next week"):
+		return now.AddDate(0, 0, 7), true
+	case strings.Contains(input, "last week"):
+		return now.AddDate(0, 0, -7), true
+	}
+
+	if m := inRegex.FindStringSubmatch(input); m != nil {
+		n := offsetCount(m[1])
+		switch m[2] {
+		case "month":
+			return addMonths(now, n), true
+		case "week":
+			n *= 7
+		}
+		return now.AddDate(0, 0, n), true
+	}
+
+	if m := agoRegex.FindStringSubmatch(input); m != nil {
+		n := offsetCount(m[1])
+		if m[2] == "week" {
+			n *= 7
+		}
+		return now.AddDate(0, 0, -n), true
+	}
+
+	fields := strings.Fields(input)
+	for i, field := range fields {
+		if weekday, ok := weekdays[strings.Trim(field, ",.")]; ok {
+			if i > 0 && fields[i-1] == "last" {
+				return lastWeekday(now, weekday), true
+			}
+			return nextWeekday(now, weekday), true
+		}
+	}
+	return time.Time{}, false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// nextWeekday returns the next date after now falling on weekday; asking for
+// today's weekday gives the date a week from now
+func nextWeekday(now time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return now.AddDate(0, 0, days)
+}
+
+// lastWeekday returns the most recent date before now falling on weekday;
+// asking for today's weekday gives the date a week ago
+func lastWeekday(now time.Time, weekday time.Weekday) time.Time {
+	days := (int(now.Weekday()) - int(weekday) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return now.AddDate(0, 0, -days)
+}
+
+// addMonths adds n months to t, keeping the day of the month but clamping it
+// to the last day of a shorter month: 31 January plus one month is 28 (or 29)
+// February, where AddDate would roll over into March
+func addMonths(t time.Time, n int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	target := firstOfMonth.AddDate(0, n, 0)
+	lastDay := target.AddDate(0, 1, -1).Day()
+	return target.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// offsetCount converts a matched count ("3", "a" or "one") to a number
+func offsetCount(text string) int {
+	if text == "a" || text == "one" {
+		return 1
+	}
+	return atoi(text)
+}
+
+// clockTime converts "2", "30", "pm" to 14:30, reporting whether the time is valid
+func clockTime(hourText, minuteText, meridiem string) (int, int, bool) {
+	hour, minute := atoi(hourText), atoi(minuteText)
+	switch meridiem {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	}
+	return hour, minute, hour <= 23 && minute <= 59
+}
+
+// makeDateTime builds a Europe/Berlin time, rejecting dates such as 31.02.
+// that time.Date would silently normalize
+func makeDateTime(year, month, day, hour, minute int) (time.Time, bool) {
+	if month < 1 || month > 12 || hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, time.Month(month), day, hour, minute, 0, 0, appointmentLocation)
+	if t.Day() != day || int(t.Month()) != month {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func atDefaultTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), defaultAppointmentHour, defaultAppointmentMinute, 0, 0, appointmentLocation)
+}
+
+// atoi converts regex-matched digits, so it can't fail
+func atoi(digits string) int {
+	n, _ := strconv.Atoi(digits)
+	return n
+}
+
+// maxAppointmentAhead is how far ahead, in years, an appointment can be
+// booked
+const maxAppointmentAhead = 2
+
+// ValidateAppointmentDateTime rejects appointment times in the past or more
+// than maxAppointmentAhead years ahead. A 24 hour grace period allows
+// entering an appointment that has just started.
+func ValidateAppointmentDateTime(t time.Time) error {
+	now := time.Now()
+	if t.Before(now.Add(-24 * time.Hour)) {
+		return fmt.Errorf("date/time %s is in the past", t.Format("2006-01-02 15:04"))
+	}
+	if t.After(now.AddDate(maxAppointmentAhead, 0, 0)) {
+		return fmt.Errorf("date/time %s is more than %d years in the future", t.Format("2006-01-02 15:04"), maxAppointmentAhead)
+	}
+	return nil
+}
+
+// ValidateClinicalDateTime rejects clinical record times after today in the
+// display zone. Any past time is allowed, as records are often backdated.
+func ValidateClinicalDateTime(t time.Time) error {
+	now := time.Now().In(displayLocation)
+	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, displayLocation).AddDate(0, 0, 1)
+	if !t.Before(endOfToday) {
+		return fmt.Errorf("date/time %s is in the future", t.In(displayLocation).Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// ValidateDateTime validates an appointment time.
+//
+// Deprecated: use ValidateAppointmentDateTime, or ValidateClinicalDateTime
+// for clinical records.
+func ValidateDateTime(t time.Time) error {
+	return ValidateAppointmentDateTime(t)
+}
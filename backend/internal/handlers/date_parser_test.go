@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDateTimeRobust(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 3, 12, 16, 0, 0, 0, appointmentLocation)
+	berlin := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, appointmentLocation)
+	}
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2025-03-14T10:30:00Z", time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)},
+		{"2025-03-14T10:30:00", berlin(2025, 3, 14, 10, 30)},
+		{"2025-03-14 10:30", berlin(2025, 3, 14, 10, 30)},
+		{"2025-03-14", berlin(2025, 3, 14, 9, 0)},
+		{"14.03.2025", berlin(2025, 3, 14, 9, 0)},
+		{"14.03.2025 um 10:30 Uhr", berlin(2025, 3, 14, 10, 30)},
+		{"4.3.2025 8:15", berlin(2025, 3, 4, 8, 15)},
+		{"25/03/2025", berlin(2025, 3, 25, 9, 0)},
+		{"03/25/2025 14:00", berlin(2025, 3, 25, 14, 0)},
+		{"05/05/2025", berlin(2025, 5, 5, 9, 0)},
+		{"today at 17:45", berlin(2025, 3, 12, 17, 45)},
+		{"tomorrow", berlin(2025, 3, 13, 9, 0)},
+		{"Tomorrow at 2:30 pm", berlin(2025, 3, 13, 14, 30)},
+		{"day after tomorrow 08:00", berlin(2025, 3, 14, 8, 0)},
+		{"next week", berlin(2025, 3, 19, 9, 0)},
+		{"next Monday at 11:00", berlin(2025, 3, 17, 11, 0)},
+		{"wednesday", berlin(2025, 3, 19, 9, 0)},
+		{"yesterday", berlin(2025, 3, 11, 9, 0)},
+		{"yesterday at 7:15 am", berlin(2025, 3, 11, 7, 15)},
+		{"day before yesterday", berlin(2025, 3, 10, 9, 0)},
+		{"last week", berlin(2025, 3, 5, 9, 0)},
+		{"last Monday", berlin(2025, 3, 10, 9, 0)},
+		{"last wednesday 14:00", berlin(2025, 3, 5, 14, 0)},
+		{"last Friday", berlin(2025, 3, 7, 9, 0)},
+		{"3 days ago", berlin(2025, 3, 9, 9, 0)},
+		{"1 day ago", berlin(2025, 3, 11, 9, 0)},
+		{"a week ago at 10:30", berlin(2025, 3, 5, 10, 30)},
+		{"2 weeks ago", berlin(2025, 2, 26, 9, 0)},
+		{"the week after next", berlin(2025, 3, 26, 9, 0)},
+		{"in 3 days", berlin(2025, 3, 15, 9, 0)},
+		{"in 2 weeks at 10:00", berlin(2025, 3, 26, 10, 0)},
+		{"in a week", berlin(2025, 3, 19, 9, 0)},
+		{"in 3 months", berlin(2025, 6, 12, 9, 0)},
+		{"in 1 month at 8:30", berlin(2025, 4, 12, 8, 30)},
+		{"in 12 months", berlin(2026, 3, 12, 9, 0)},
+		{"tomorrow at 3pm", berlin(2025, 3, 13, 15, 0)},
+		{"tomorrow at 3 am", berlin(2025, 3, 13, 3, 0)},
+		{"tomorrow 11am", berlin(2025, 3, 13, 11, 0)},
+		{"tomorrow at 15", berlin(2025, 3, 13, 15, 0)},
+		{"tomorrow at 12", berlin(2025, 3, 13, 12, 0)},
+		{"in 3 days at 10pm", berlin(2025, 3, 15, 22, 0)},
+		{"tomorrow at 2pm", berlin(2025, 3, 13, 14, 0)},
+		{"tomorrow at 2 p.m.", berlin(2025, 3, 13, 14, 0)},
+		{"tomorrow at 12am", berlin(2025, 3, 13, 0, 0)},
+		{"tomorrow at 12pm", berlin(2025, 3, 13, 12, 0)},
+		{"tomorrow at 12:30 a.m.", berlin(2025, 3, 13, 0, 30)},
+		{"tomorrow at 12:30 P.M.", berlin(2025, 3, 13, 12, 30)},
+		{"25/03/2025 2:30 pm", berlin(2025, 3, 25, 14, 30)},
+		{"25/03/2025 at 12am", berlin(2025, 3, 25, 0, 0)},
+		{"25/03/2025 12pm", berlin(2025, 3, 25, 12, 0)},
+		{"03/25/2025 at 9 a.m.", berlin(2025, 3, 25, 9, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseDateTimeAt(tt.input, now)
+			if err != nil {
+				t.Fatalf("parseDateTimeAt(%q): %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDateTimeAt(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), 1, time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), 1, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), 2, time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 8, 31, 9, 0, 0, 0, time.UTC), 1, time.Date(2025, 9, 30, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 11, 30, 9, 0, 0, 0, time.UTC), 3, time.Date(2026, 2, 28, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 15, 9, 0, 0, 0, time.UTC), 6, time.Date(2025, 9, 15, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := addMonths(tt.from, tt.months); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from.Format("2006-01-02"), tt.months, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+
+	// Through the parser, from the last day of January
+	now := time.Date(2025, 1, 31, 16, 0, 0, 0, appointmentLocation)
+	got, err := parseDateTimeAt("in 1 month", now)
+	if want := time.Date(2025, 2, 28, 9, 0, 0, 0, appointmentLocation); err != nil || !got.Equal(want) {
+		t.Errorf("parseDateTimeAt(in 1 month) = %s, %v; want %s", got, err, want)
+	}
+}
+
+func TestParseDateTimeRobustAmbiguous(t *testing.T) {
+	_, err := ParseDateTimeRobust("03/04/2025 10:00")
+
+	var ambiguous *AmbiguousDateError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousDateError, got %v", err)
+	}
+
+	a, ok := ambiguous.Option("a")
+	if !ok || !a.Time.Equal(time.Date(2025, 4, 3, 10, 0, 0, 0, appointmentLocation)) {
+		t.Errorf("option A = %+v, want 3 April 2025 10:00", a)
+	}
+	b, ok := ambiguous.Option("B")
+	if !ok || !b.Time.Equal(time.Date(2025, 3, 4, 10, 0, 0, 0, appointmentLocation)) {
+		t.Errorf("option B = %+v, want 4 March 2025 10:00", b)
+	}
+	if _, ok := ambiguous.Option("C"); ok {
+		t.Error("unexpected option C")
+	}
+}
+
+func TestParseDateTimeAmbiguousHour(t *testing.T) {
+	now := time.Date(2025, 3, 12, 16, 0, 0, 0, appointmentLocation)
+	_, err := parseDateTimeAt("tomorrow at 3", now)
+
+	var ambiguous *AmbiguousDateError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousDateError, got %v", err)
+	}
+	a, ok := ambiguous.Option("A")
+	if !ok || !a.Time.Equal(time.Date(2025, 3, 13, 3, 0, 0, 0, appointmentLocation)) || !strings.HasSuffix(a.Description, "(AM)") {
+		t.Errorf("option A = %+v, want 13 March 2025 03:00 (AM)", a)
+	}
+	b, ok := ambiguous.Option("B")
+	if !ok || !b.Time.Equal(time.Date(2025, 3, 13, 15, 0, 0, 0, appointmentLocation)) || !strings.HasSuffix(b.Description, "(PM)") {
+		t.Errorf("option B = %+v, want 13 March 2025 15:00 (PM)", b)
+	}
+}
+
+func TestParseDateTimeDefaultAppointmentTime(t *testing.T) {
+	hour, minute := defaultAppointmentHour, defaultAppointmentMinute
+	t.Cleanup(func() { defaultAppointmentHour, defaultAppointmentMinute = hour, minute })
+	defaultAppointmentHour, defaultAppointmentMinute = 8, 30
+
+	now := time.Date(2025, 3, 12, 16, 0, 0, 0, appointmentLocation)
+	for _, input := range []string{"2025-03-14", "14.03.2025", "14/03/2025", "friday"} {
+		got, err := parseDateTimeAt(input, now)
+		if err != nil {
+			t.Errorf("parseDateTimeAt(%q) failed: %v", input, err)
+			continue
+		}
+		if want := time.Date(2025, 3, 14, 8, 30, 0, 0, appointmentLocation); !got.Equal(want) {
+			t.Errorf("parseDateTimeAt(%q) = %s, want %s", input, got, want)
+		}
+	}
+
+	// An explicit time still wins
+	got, err := parseDateTimeAt("14.03.2025 10:15", now)
+	if want := time.Date(2025, 3, 14, 10, 15, 0, 0, appointmentLocation); err != nil || !got.Equal(want) {
+		t.Errorf("parseDateTimeAt with a time = %s (err: %v), want %s", got, err, want)
+	}
+}
+
+func TestLoadDefaultAppointmentTime(t *testing.T) {
+	tests := []struct {
+		hour, minute         string
+		wantHour, wantMinute int
+	}{
+		{"", "", 9, 0},
+		{"8", "30", 8, 30},
+		{"7", "", 7, 0},
+		{"24", "0", 9, 0},
+		{"10", "60", 9, 0},
+		{"early", "", 9, 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("MCP_DEFAULT_APPOINTMENT_HOUR", tt.hour)
+		t.Setenv("MCP_DEFAULT_APPOINTMENT_MINUTE", tt.minute)
+		if hour, minute := loadDefaultAppointmentTime(); hour != tt.wantHour || minute != tt.wantMinute {
+			t.Errorf("loadDefaultAppointmentTime() with %q:%q = %02d:%02d, want %02d:%02d",
+				tt.hour, tt.minute, hour, minute, tt.wantHour, tt.wantMinute)
+		}
+	}
+}
+
+func TestParseDateTimeRobustInvalid(t *testing.T) {
+	for _, input := range []string{"", "soon", "31.02.2025", "13/13/2025", "tomorrow at 25:00", "2025-13-01", "tomorrow at 24", "tomorrow at 13pm", "25/03/2025 14", "25/03/2025 13pm", "25/03/2025 0am"} {
+		if got, err := ParseDateTimeRobust(input); err == nil {
+			t.Errorf("ParseDateTimeRobust(%q) = %s, want error", input, got)
+		}
+	}
+}
+
+func TestValidateAppointmentDateTime(t *testing.T) {
+	if err := ValidateAppointmentDateTime(time.Now().Add(time.Hour)); err != nil {
+		t.Errorf("future time rejected: %v", err)
+	}
+	if err := ValidateAppointmentDateTime(time.Now().Add(-time.Hour)); err != nil {
+		t.Errorf("time within the grace period rejected: %v", err)
+	}
+	if err := ValidateAppointmentDateTime(time.Now().AddDate(0, 0, -2)); err == nil {
+		t.Error("past time accepted")
+	}
+	if err := ValidateAppointmentDateTime(time.Now().AddDate(maxAppointmentAhead, 0, 1)); err == nil {
+		t.Error("time beyond the booking horizon accepted")
+	}
+}
+
+func TestValidateClinicalDateTime(t *testing.T) {
+	if err := ValidateClinicalDateTime(time.Now().AddDate(-30, 0, 0)); err != nil {
+		t.Errorf("past time rejected: %v", err)
+	}
+	if err := ValidateClinicalDateTime(time.Now()); err != nil {
+		t.Errorf("current time rejected: %v", err)
+	}
+	if err := ValidateClinicalDateTime(time.Now().AddDate(0, 0, 2)); err == nil {
+		t.Error("future time accepted")
+	}
+}
+
+func TestFormatRecordTime(t *testing.T) {
+	tests := []struct {
+		value, wantTime, wantDay string
+	}{
+		{"2024-03-01T23:30:00Z", "2024-03-02 00:30 CET", "2024-03-02"},
+		{"2024-07-01T08:00:00-04:00", "2024-07-01 14:00 CEST", "2024-07-01"},
+		{"2024-03-01", "2024-03-01", "2024-03-01"},
+		{"unknown", "unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := formatRecordTime(tt.value); got != tt.wantTime {
+			t.Errorf("formatRecordTime(%q) = %q, want %q", tt.value, got, tt.wantTime)
+		}
+		if got := recordDay(tt.value); got != tt.wantDay {
+			t.Errorf("recordDay(%q) = %q, want %q", tt.value, got, tt.wantDay)
+		}
+	}
+}
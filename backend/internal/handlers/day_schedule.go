@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// GetDaySchedule lists the planned and ongoing encounters of all
+// practitioners on a day, grouped by practitioner, for daily huddles. The day
+// runs from midnight to midnight in the display zone.
+func (h *Handler) GetDaySchedule(date string) (interface{}, error) {
+	day, err := ParseDateTimeRobust(date)
+	if err != nil {
+		var ambiguous *AmbiguousDateError
+		if errors.As(err, &ambiguous) {
+			return h.awaitDateChoice(ambiguous, "day schedule", func(t time.Time) (interface{}, error) {
+				return h.daySchedule(t)
+			}), nil
+		}
+		return nil, fmt.Errorf("invalid date: %w", err)
+	}
+	return h.daySchedule(day)
+}
+
+func (h *Handler) daySchedule(day time.Time) (interface{}, error) {
+	day = day.In(displayLocation)
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, displayLocation)
+	to := from.AddDate(0, 0, 1)
+
+	encounters, err := database.GetEncountersInRange(h.db, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	// Group by practitioner, in order of name with unassigned encounters last
+	practitionerName := h.practitionerNameLookup()
+	byPractitioner := make(map[string][]database.Encounter)
+	var practitioners []string
+	for _, e := range encounters {
+		id := ""
+		if e.PractitionerID != nil {
+			id = *e.PractitionerID
+		}
+		if _, ok := byPractitioner[id]; !ok {
+			practitioners = append(practitioners, id)
+		}
+		byPractitioner[id] = append(byPractitioner[id], e)
+	}
+	sort.SliceStable(practitioners, func(i, j int) bool {
+		if practitioners[i] == "" || practitioners[j] == "" {
+			return practitioners[j] == ""
+		}
+		return practitionerName(&practitioners[i]) < practitionerName(&practitioners[j])
+	})
+
+	patientNames := make(map[string]string)
+	patientName := func(patientID string) string {
+		if name, ok := patientNames[patientID]; ok {
+			return name
+		}
+		name, err := database.GetPatientName(h.db, patientID)
+		if err != nil {
+			name = "Unknown patient"
+		}
+		patientNames[patientID] = name
+		return name
+	}
+
+	var result strings.Builder
+	heading := from.Format("Monday, 2006-01-02")
+	if len(encounters) == 0 {
+		result.WriteString(fmt.Sprintf("No appointments scheduled for %s.", heading))
+	} else {
+		result.WriteString(fmt.Sprintf("Schedule for %s: %d appointment(s) with %d practitioner(s)\n",
+			heading, len(encounters), len(practitioners)))
+	}
+
+	for _, id := range practitioners {
+		group := byPractitioner[id]
+		if id == "" {
+			result.WriteString(fmt.Sprintf("\nUnassigned (%d):\n", len(group)))
+		} else {
+			result.WriteString(fmt.Sprintf("\n%s (ID: %s), %d appointment(s):\n", practitionerName(&id), id, len(group)))
+		}
+		for _, e := range group {
+			start := e.StartDateTime
+			if t, ok := parseRecordTime(e.StartDateTime); ok {
+				start = t.In(displayLocation).Format("15:04")
+			}
+			if h.withheldFromModel(e.PatientID) {
+				result.WriteString(fmt.Sprintf("• %s - %s\n", start, withheldPatientText))
+				continue
+			}
+			line := fmt.Sprintf("• %s - %s (ID: %s)", start, patientName(e.PatientID), e.PatientID)
+			if e.TypeDisplay != nil && *e.TypeDisplay != "" {
+				line += fmt.Sprintf(" - %s", *e.TypeDisplay)
+			}
+			if e.Status != "planned" {
+				line += fmt.Sprintf(" [%s]", e.Status)
+			}
+			result.WriteString(fmt.Sprintf("%s, encounter %s\n", line, e.ID))
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
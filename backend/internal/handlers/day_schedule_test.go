@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetDaySchedule(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1960-05-05")
+	seedPatient(t, h, "p2", "Kari", "Nordmann", "female", "1970-02-02")
+	seedPatient(t, h, "p3", "Per", "Hansen", "male", "1980-08-08")
+	seedPractitioner(t, h, "dr-b", "Berit", "Berg")
+	seedPractitioner(t, h, "dr-a", "Anders", "Aas")
+
+	for _, e := range []struct{ id, status, patient, practitioner, start string }{
+		{"e1", "planned", "p1", "dr-b", "2025-03-14T13:30:00Z"},
+		{"e2", "planned", "p2", "dr-b", "2025-03-14T08:00:00Z"},
+		{"e3", "arrived", "p3", "dr-a", "2025-03-14T09:15:00Z"},
+		// 00:30 on the 14th in Berlin
+		{"e4", "planned", "p2", "", "2025-03-13T23:30:00Z"},
+		// 00:30 on the 15th in Berlin
+		{"e5", "planned", "p1", "dr-a", "2025-03-14T23:30:00Z"},
+		{"e6", "cancelled", "p3", "dr-a", "2025-03-14T10:00:00Z"},
+	} {
+		if _, err := h.db.Exec(`INSERT INTO encounters (id, status, class, patient_id, practitioner_id, start_datetime) VALUES (?, ?, 'AMB', ?, NULLIF(?, ''), ?)`,
+			e.id, e.status, e.patient, e.practitioner, e.start); err != nil {
+			t.Fatalf("Failed to seed encounter: %v", err)
+		}
+	}
+
+	result, err := h.GetDaySchedule("14.03.2025")
+	text := resultText(t, h, result, err)
+
+	want := "Schedule for Friday, 2025-03-14: 4 appointment(s) with 3 practitioner(s)\n" +
+		"\nAnders Aas (ID: dr-a), 1 appointment(s):\n" +
+		"• 10:15 - Per Hansen (ID: p3) [arrived], encounter e3\n" +
+		"\nBerit Berg (ID: dr-b), 2 appointment(s):\n" +
+		"• 09:00 - Kari Nordmann (ID: p2), encounter e2\n" +
+		"• 14:30 - Ola Nordmann (ID: p1), encounter e1\n" +
+		"\nUnassigned (1):\n" +
+		"• 00:30 - Kari Nordmann (ID: p2), encounter e4\n"
+	if text != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, text)
+	}
+
+	result, err = h.GetDaySchedule("2025-03-16")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "No appointments scheduled for Sunday, 2025-03-16.") {
+		t.Errorf("Expected an empty day, got:\n%s", text)
+	}
+
+	if _, err := h.GetDaySchedule("someday"); err == nil {
+		t.Error("Expected an error for an invalid date")
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import "github.com/eythor/mcp-server/internal/debug"
+
+// demoModeMessage is returned instead of running a write tool in demo mode
+const demoModeMessage = "Writes are disabled in demo mode; no changes were made. Read-only tools are still available."
+
+// writeTools are the tools that modify the database. New tools that write
+// must be added here so demo mode blocks them.
+var writeTools = map[string]bool{
+	"schedule_appointment":      true,
+	"reschedule_appointment":    true,
+	"cancel_appointment":        true,
+	"confirm_date_choice":       true,
+	"complete_encounter":        true,
+	"mark_patient_arrived":      true,
+	"add_condition":             true,
+	"add_medication":            true,
+	"add_observation":           true,
+	"record_gcs":                true,
+	"delete_observation":        true,
+	"update_observation":        true,
+	"create_patient":            true,
+	"add_contact_point":         true,
+	"update_patient_birth_date": true,
+	"normalize_patient_data":    true,
+	"record_consent":            true,
+	"revoke_consent":            true,
+}
+
+// IsWriteTool reports whether the named tool modifies the database
+func IsWriteTool(toolName string) bool {
+	return writeTools[toolName]
+}
+
+// DemoModeBlocks reports whether toolName must not run because
+// MCP_DEMO_MODE is set, returning the message to show instead
+func (h *Handler) DemoModeBlocks(toolName string) (string, bool) {
+	if !h.demoMode || !IsWriteTool(toolName) {
+		return "", false
+	}
+	debug.Log("Demo mode: blocked write tool %s", toolName)
+	return demoModeMessage, true
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDemoModeBlocksWrites(t *testing.T) {
+	t.Setenv("MCP_DEMO_MODE", "true")
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+
+	text, err := h.executeTool(context.Background(), "add_observation", `{"patient_id":"p1","code":"8867-4","display":"Heart rate","value_quantity":72,"value_unit":"/min"}`, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(text, "disabled in demo mode") {
+		t.Errorf("write tool not blocked: %q", text)
+	}
+	var count int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM observations").Scan(&count); err != nil {
+		t.Fatalf("Failed to count observations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("demo mode wrote %d observation(s)", count)
+	}
+
+	text, err = h.executeTool(context.Background(), "lookup_patient", `{"query":"McFly"}`, "")
+	if err != nil {
+		t.Fatalf("read tool failed in demo mode: %v", err)
+	}
+	if !strings.Contains(text, "Marty") {
+		t.Errorf("read tool result missing patient: %q", text)
+	}
+}
+
+func TestDemoModeOff(t *testing.T) {
+	h := newTestHandler(t)
+	if _, blocked := h.DemoModeBlocks("schedule_appointment"); blocked {
+		t.Error("write tool blocked without MCP_DEMO_MODE")
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestCompleteEncounter(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "e1", "p1", "planned", "2025-01-10T09:00:00Z")
+
+	result, err := h.CompleteEncounter("e1", "Follow-up in 3 months", "")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "marked as finished") {
+		t.Errorf("Unexpected result: %s", text)
+	}
+
+	var status string
+	var endDateTime, outcome sql.NullString
+	err = h.db.QueryRow(`SELECT status, end_datetime, outcome FROM encounters WHERE id = 'e1'`).Scan(&status, &endDateTime, &outcome)
+	if err != nil {
+		t.Fatalf("Failed to read encounter: %v", err)
+	}
+	if status != "finished" {
+		t.Errorf("Expected status finished, got %q", status)
+	}
+	if !endDateTime.Valid || endDateTime.String == "" {
+		t.Error("Expected end_datetime to be set")
+	}
+	if outcome.String != "Follow-up in 3 months" {
+		t.Errorf("Expected outcome to be stored, got %q", outcome.String)
+	}
+}
+
+func TestCompleteEncounterRejectsCancelled(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "e1", "p1", "cancelled", "2025-01-10T09:00:00Z")
+
+	if _, err := h.CompleteEncounter("e1", "Seen", ""); err == nil {
+		t.Fatal("Expected completing a cancelled encounter to fail")
+	}
+
+	var status string
+	if err := h.db.QueryRow(`SELECT status FROM encounters WHERE id = 'e1'`).Scan(&status); err != nil {
+		t.Fatalf("Failed to read encounter: %v", err)
+	}
+	if status != "cancelled" {
+		t.Errorf("Expected status to remain cancelled, got %q", status)
+	}
+
+	if _, err := h.CompleteEncounter("missing", "", ""); err == nil {
+		t.Error("Expected an error for an unknown encounter")
+	}
+}
+
+func TestListAppointments(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPractitioner(t, h, "dr1", "Gregory", "House")
+	seedEncounter(t, h, "e1", "p1", "finished", "2024-05-01T09:00:00Z")
+	seedEncounter(t, h, "e2", "p1", "planned", "2030-01-10T09:00:00Z")
+	if _, err := h.db.Exec(`UPDATE encounters SET practitioner_id = 'dr1', type_display = 'Follow-up visit' WHERE id = 'e2'`); err != nil {
+		t.Fatalf("Failed to update encounter: %v", err)
+	}
+
+	h.SetPatientContext("p1")
+	result, err := h.ListAppointments("", "")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"e1", "e2", "Follow-up visit", "Gregory House", "status: planned"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	result, err = h.ListAppointments("p1", "Planned")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "e2") || strings.Contains(text, "e1") {
+		t.Errorf("status filter not applied:\n%s", text)
+	}
+
+	result, err = h.ListAppointments("p1", "cancelled")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "No appointments found") {
+		t.Errorf("expected no appointments message, got:\n%s", text)
+	}
+}
+
+func TestRescheduleAppointment(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "e1", "p1", "planned", inNextYear("YYYY-01-10T09:00:00Z"))
+	seedEncounter(t, h, "e2", "p1", "finished", "2024-01-10T09:00:00Z")
+	seedEncounter(t, h, "e3", "p1", "cancelled", inNextYear("YYYY-01-11T09:00:00Z"))
+
+	result, err := h.RescheduleAppointment("e1", inNextYear("15.02.YYYY 14:30"))
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, inNextYear("rescheduled appointment e1 to YYYY-02-15 14:30")) {
+		t.Errorf("Unexpected result: %s", text)
+	}
+
+	var start string
+	if err := h.db.QueryRow(`SELECT start_datetime FROM encounters WHERE id = 'e1'`).Scan(&start); err != nil {
+		t.Fatalf("Failed to read encounter: %v", err)
+	}
+	if want := inNextYear("YYYY-02-15T14:30:00+01:00"); start != want {
+		t.Errorf("start_datetime = %q, want %s", start, want)
+	}
+
+	for _, id := range []string{"e2", "e3"} {
+		if _, err := h.RescheduleAppointment(id, inNextYear("YYYY-02-15 14:30")); err == nil {
+			t.Errorf("rescheduling %s should fail", id)
+		}
+	}
+	if _, err := h.RescheduleAppointment("e1", "2020-01-01 10:00"); err == nil {
+		t.Error("rescheduling into the past should fail")
+	}
+	if _, err := h.RescheduleAppointment("missing", inNextYear("YYYY-02-15 14:30")); err == nil {
+		t.Error("rescheduling an unknown appointment should fail")
+	}
+}
+
+func TestRescheduleAppointmentAmbiguousDate(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "e1", "p1", "planned", "2030-01-10T09:00:00Z")
+
+	result, err := h.RescheduleAppointment("e1", "03/04/2030")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "ambiguous") || !strings.Contains(text, "A) ") || !strings.Contains(text, "B) ") {
+		t.Errorf("expected A/B choices, got: %s", text)
+	}
+
+	var start string
+	h.db.QueryRow(`SELECT start_datetime FROM encounters WHERE id = 'e1'`).Scan(&start)
+	if start != "2030-01-10T09:00:00Z" {
+		t.Errorf("ambiguous date changed start_datetime to %q", start)
+	}
+}
+
+func TestGetEncounters(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedPractitioner(t, h, "dr1", "Emmett", "Brown")
+	seedEncounter(t, h, "e1", "p1", "finished", "2024-05-01T09:00:00Z")
+	seedEncounter(t, h, "e2", "p1", "planned", "2030-01-10T09:00:00Z")
+	if _, err := h.db.Exec(`UPDATE encounters SET practitioner_id = 'dr1', end_datetime = '2024-05-01T09:30:00Z' WHERE id = 'e1'`); err != nil {
+		t.Fatalf("Failed to update encounter: %v", err)
+	}
+
+	result, err := h.GetEncounters("p1")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"Total visits: 2", "Practitioner: Emmett Brown", "Practitioner: unassigned",
+		"End: 2024-05-01T09:30:00Z", "Status: planned", "Class: AMB"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	seedPatient(t, h, "p2", "Jennifer", "Parker", "female", "1968-01-01")
+	result, err = h.GetEncounters("p2")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "No visits found") {
+		t.Errorf("expected no visits message, got:\n%s", text)
+	}
+}
+
+func TestScheduleAppointmentRejectsPatientConflict(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPractitioner(t, h, "dr1", "Gregory", "House")
+	seedEncounter(t, h, "e1", "p1", "planned", inNextYear("YYYY-01-10T09:00:00Z"))
+	seedEncounter(t, h, "e2", "p1", "cancelled", inNextYear("YYYY-01-11T09:00:00Z"))
+	if _, err := h.db.Exec(`UPDATE encounters SET type_display = 'Follow-up visit' WHERE id = 'e1'`); err != nil {
+		t.Fatalf("Failed to update encounter: %v", err)
+	}
+
+	// 10:15 in Berlin is 09:15 UTC, during e1
+	_, err := h.ScheduleAppointment("p1", "dr1", inNextYear("YYYY-01-10 10:15"), "Check-up")
+	if err == nil {
+		t.Fatal("expected double-booking the patient to fail")
+	}
+	for _, want := range []string{"already booked", "e1", inNextYear("YYYY-01-10 10:00 CET"), "Follow-up visit"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q missing from error: %v", want, err)
+		}
+	}
+
+	var count int
+	h.db.QueryRow(`SELECT COUNT(*) FROM encounters WHERE patient_id = 'p1'`).Scan(&count)
+	if count != 2 {
+		t.Errorf("conflicting appointment was created (%d encounters)", count)
+	}
+
+	// Right after e1, and over the cancelled e2, are free
+	for _, when := range []string{inNextYear("YYYY-01-10 10:30"), inNextYear("YYYY-01-11 10:00")} {
+		result, err := h.ScheduleAppointment("p1", "dr1", when, "Check-up")
+		if text := resultText(t, h, result, err); !strings.Contains(text, "Successfully scheduled") {
+			t.Errorf("booking at %s: %s", when, text)
+		}
+	}
+}
+
+func TestCancelAppointmentWithReason(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "e1", "p1", "planned", "2030-01-10T09:00:00Z")
+	seedEncounter(t, h, "e2", "p1", "planned", "2030-01-11T09:00:00Z")
+
+	result, err := h.CancelAppointment("e1", "  no-show ")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "cancelled appointment e1 (reason: no-show)") {
+		t.Errorf("Unexpected result: %s", text)
+	}
+	result, err = h.CancelAppointment("e2", "")
+	if text := resultText(t, h, result, err); strings.Contains(text, "reason") {
+		t.Errorf("no reason given, got: %s", text)
+	}
+
+	var reason sql.NullString
+	h.db.QueryRow(`SELECT cancellation_reason FROM encounters WHERE id = 'e2'`).Scan(&reason)
+	if reason.Valid {
+		t.Errorf("empty reason stored as %q, want NULL", reason.String)
+	}
+
+	result, err = h.GetEncounters("p1")
+	text := resultText(t, h, result, err)
+	if strings.Count(text, "Cancellation reason") != 1 || !strings.Contains(text, "Cancellation reason: no-show") {
+		t.Errorf("reason not shown once in visit history:\n%s", text)
+	}
+
+	result, err = h.ListAppointments("p1", "cancelled")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "ID: e1) - cancelled: no-show") {
+		t.Errorf("reason not shown in appointment list:\n%s", text)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// FHIRPatient is the subset of the FHIR R4 Patient resource we can populate
+// from the patients table
+type FHIRPatient struct {
+	ResourceType string             `json:"resourceType"`
+	ID           string             `json:"id"`
+	Name         []FHIRHumanName    `json:"name,omitempty"`
+	Telecom      []FHIRContactPoint `json:"telecom,omitempty"`
+	Gender       string             `json:"gender,omitempty"`
+	BirthDate    string             `json:"birthDate,omitempty"`
+	Address      []FHIRAddress      `json:"address,omitempty"`
+}
+
+type FHIRHumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type FHIRContactPoint struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+	Use    string `json:"use,omitempty"`
+}
+
+type FHIRAddress struct {
+	City  string `json:"city,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+// fhirGender maps stored gender values onto the FHIR AdministrativeGender codes
+func fhirGender(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m":
+		return "male"
+	case "female", "f":
+		return "female"
+	case "":
+		return ""
+	case "other":
+		return "other"
+	default:
+		return "unknown"
+	}
+}
+
+// patientToFHIR maps the internal patient record onto a FHIR Patient resource
+func patientToFHIR(p database.Patient) FHIRPatient {
+	resource := FHIRPatient{
+		ResourceType: "Patient",
+		ID:           p.ID,
+		Gender:       fhirGender(p.Gender),
+	}
+
+	if p.GivenName != "" || p.FamilyName != "" {
+		name := FHIRHumanName{Use: "official", Family: p.FamilyName}
+		if p.GivenName != "" {
+			name.Given = strings.Fields(p.GivenName)
+		}
+		resource.Name = []FHIRHumanName{name}
+	}
+
+	// Only emit dates FHIR accepts (YYYY, YYYY-MM or YYYY-MM-DD)
+	if len(p.BirthDate) >= 10 {
+		resource.BirthDate = p.BirthDate[:10]
+	}
+
+	if p.Phone != nil && *p.Phone != "" {
+		resource.Telecom = append(resource.Telecom, FHIRContactPoint{System: "phone", Value: *p.Phone, Use: "home"})
+	}
+	for _, c := range p.Contacts {
+		contact := FHIRContactPoint{System: c.System, Value: c.Value}
+		if c.Use != nil {
+			contact.Use = *c.Use
+		}
+		resource.Telecom = append(resource.Telecom, contact)
+	}
+
+	var address FHIRAddress
+	if p.City != nil {
+		address.City = *p.City
+	}
+	if p.State != nil {
+		address.State = *p.State
+	}
+	if address.City != "" || address.State != "" {
+		resource.Address = []FHIRAddress{address}
+	}
+
+	return resource
+}
+
+func (h *Handler) GetPatientFHIR(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	h.loadContacts(patient)
+
+	resource, err := json.MarshalIndent(patientToFHIR(*patient), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode FHIR Patient: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": string(resource),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetPatientFHIR(t *testing.T) {
+	h := newTestHandler(t)
+	_, err := h.db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date, phone, city, state)
+		VALUES ('p1', 'Marty', 'McFly', 'male', '1968-06-12', '555-0123', 'Hill Valley', 'CA')`)
+	if err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+
+	result, err := h.GetPatientFHIR("p1")
+	text := resultText(t, h, result, err)
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &resource); err != nil {
+		t.Fatalf("Result is not valid JSON: %v\n%s", err, text)
+	}
+
+	if resource["resourceType"] != "Patient" {
+		t.Errorf("Expected resourceType Patient, got %v", resource["resourceType"])
+	}
+	if resource["id"] != "p1" {
+		t.Errorf("Expected id p1, got %v", resource["id"])
+	}
+	if resource["gender"] != "male" {
+		t.Errorf("Expected gender male, got %v", resource["gender"])
+	}
+	if resource["birthDate"] != "1968-06-12" {
+		t.Errorf("Expected birthDate 1968-06-12, got %v", resource["birthDate"])
+	}
+
+	names, ok := resource["name"].([]interface{})
+	if !ok || len(names) != 1 {
+		t.Fatalf("Expected one name entry, got %v", resource["name"])
+	}
+	name := names[0].(map[string]interface{})
+	if name["family"] != "McFly" {
+		t.Errorf("Expected family McFly, got %v", name["family"])
+	}
+	if given, ok := name["given"].([]interface{}); !ok || len(given) != 1 || given[0] != "Marty" {
+		t.Errorf("Expected given [Marty], got %v", name["given"])
+	}
+
+	telecom, ok := resource["telecom"].([]interface{})
+	if !ok || len(telecom) != 1 {
+		t.Fatalf("Expected one telecom entry, got %v", resource["telecom"])
+	}
+	phone := telecom[0].(map[string]interface{})
+	if phone["system"] != "phone" || phone["value"] != "555-0123" {
+		t.Errorf("Unexpected telecom entry: %v", phone)
+	}
+
+	address, ok := resource["address"].([]interface{})
+	if !ok || len(address) != 1 {
+		t.Fatalf("Expected one address entry, got %v", resource["address"])
+	}
+	if city := address[0].(map[string]interface{})["city"]; city != "Hill Valley" {
+		t.Errorf("Expected city Hill Valley, got %v", city)
+	}
+
+	// The resource uses FHIR element names, not our column names
+	if _, exists := resource["given_name"]; exists {
+		t.Error("Internal field names must not leak into the FHIR resource")
+	}
+}
+
+func TestGetPatientFHIR_NotFound(t *testing.T) {
+	h := newTestHandler(t)
+	if _, err := h.GetPatientFHIR("missing"); err == nil {
+		t.Error("Expected error for unknown patient")
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// frequentFindingsLimit is how many findings GetFrequentFindings lists
+const frequentFindingsLimit = 5
+
+// findingCount is how often a condition or encounter type appears in a
+// patient's record
+type findingCount struct {
+	Label string
+	Kind  string // "condition" or "encounter"
+	Count int
+}
+
+// countFindings counts condition displays and encounter types, ignoring case
+// and surrounding spaces, and orders them most frequent first, then by label
+func countFindings(conditions []database.Condition, encounters []database.Encounter) []findingCount {
+	var findings []findingCount
+	index := make(map[string]int)
+	add := func(kind, label string) {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return
+		}
+		key := kind + "\x00" + strings.ToLower(label)
+		if i, ok := index[key]; ok {
+			findings[i].Count++
+			return
+		}
+		index[key] = len(findings)
+		findings = append(findings, findingCount{Label: label, Kind: kind, Count: 1})
+	}
+
+	for _, c := range conditions {
+		add("condition", c.Display)
+	}
+	for _, e := range encounters {
+		add("encounter", derefString(e.TypeDisplay))
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Count != findings[j].Count {
+			return findings[i].Count > findings[j].Count
+		}
+		return strings.ToLower(findings[i].Label) < strings.ToLower(findings[j].Label)
+	})
+	return findings
+}
+
+// GetFrequentFindings lists the conditions and encounter types that recur
+// most often in a patient's record
+func (h *Handler) GetFrequentFindings(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	exists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+	encounters, err := database.GetEncountersByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	findings := countFindings(conditions, encounters)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Most frequent findings for %s (ID: %s)\n\n", patientName, patientID))
+	if len(findings) == 0 {
+		result.WriteString("No conditions or encounters recorded.\n")
+	} else {
+		if len(findings) > frequentFindingsLimit {
+			findings = findings[:frequentFindingsLimit]
+		}
+		for i, f := range findings {
+			times := "times"
+			if f.Count == 1 {
+				times = "time"
+			}
+			result.WriteString(fmt.Sprintf("%d. %s (%s): seen %d %s\n", i+1, f.Label, f.Kind, f.Count, times))
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
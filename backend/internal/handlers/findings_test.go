@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetFrequentFindings(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	for i := 0; i < 3; i++ {
+		seedCondition(t, h, "p1", "37796009", "Migraine", "resolved", "2023-01-01")
+	}
+	seedCondition(t, h, "p1", "37796009", "migraine ", "resolved", "2020-01-01")
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2010-05-01")
+	for _, id := range []string{"e1", "e2"} {
+		seedEncounter(t, h, id, "p1", "finished", "2024-05-01T09:00:00Z")
+	}
+	if _, err := h.db.Exec(`UPDATE encounters SET type_display = 'General examination'`); err != nil {
+		t.Fatalf("Failed to update encounters: %v", err)
+	}
+
+	h.SetPatientContext("p1")
+	result, err := h.GetFrequentFindings("")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"1. Migraine (condition): seen 4 times",
+		"2. General examination (encounter): seen 2 times",
+		"3. Hypertension (condition): seen 1 time",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	if _, err := h.GetFrequentFindings("missing"); err == nil {
+		t.Error("expected an error for an unknown patient")
+	}
+}
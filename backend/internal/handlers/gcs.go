@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// LOINC code for the Glasgow Coma Scale total score
+const gcsTotalCode = "9269-2"
+
+// gcsComponent is one GCS component with its valid score range
+type gcsComponent struct {
+	Name     string
+	Score    int
+	Min, Max int
+}
+
+// validateGCS checks each component against its range, reporting every
+// component that is out of range
+func validateGCS(eye, verbal, motor int) error {
+	var invalid []string
+	for _, c := range []gcsComponent{
+		{"eye", eye, 1, 4},
+		{"verbal", verbal, 1, 5},
+		{"motor", motor, 1, 6},
+	} {
+		if c.Score < c.Min || c.Score > c.Max {
+			invalid = append(invalid, fmt.Sprintf("%s %d (must be %d-%d)", c.Name, c.Score, c.Min, c.Max))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid GCS component: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// gcsSeverity returns the brain injury severity for a GCS total (3-15)
+func gcsSeverity(total int) string {
+	switch {
+	case total <= 8:
+		return "severe"
+	case total <= 12:
+		return "moderate"
+	default:
+		return "mild"
+	}
+}
+
+// RecordGCS validates and totals a Glasgow Coma Scale assessment and stores
+// the total as an observation
+func (h *Handler) RecordGCS(patientID string, eye, verbal, motor int) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	if err := validateGCS(eye, verbal, motor); err != nil {
+		return nil, err
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	total := float64(eye + verbal + motor)
+	unit := "{score}"
+	breakdown := fmt.Sprintf("E%d V%d M%d", eye, verbal, motor)
+	effectiveDateTime := time.Now().Format(time.RFC3339)
+	id, err := h.newID(idKindObservation)
+	if err != nil {
+		return nil, err
+	}
+	observation := &database.Observation{
+		ID:                id,
+		Status:            "final",
+		Category:          "survey",
+		Code:              gcsTotalCode,
+		Display:           "Glasgow coma score total (" + breakdown + ")",
+		PatientID:         patientID,
+		EffectiveDateTime: &effectiveDateTime,
+		ValueQuantity:     &total,
+		ValueUnit:         &unit,
+	}
+	if err := database.CreateObservation(h.db, observation); err != nil {
+		return nil, fmt.Errorf("failed to record GCS: %w", err)
+	}
+	h.refreshContextSummary(patientID)
+
+	severity := gcsSeverity(int(total))
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("✓ Glasgow Coma Scale recorded for %s (ID: %s)\nGCS %d/15 (%s) at %s\nSeverity: %s\nObservation ID: %s",
+		patientName, patientID, int(total), breakdown, effectiveDateTime, severity, observation.ID)
+	if severity == "severe" {
+		resultText += "\n\nGCS ≤8: consider airway protection."
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordGCS(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+
+	result, err := h.RecordGCS("p1", 3, 4, 5)
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"GCS 12/15 (E3 V4 M5)", "Severity: moderate"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	var code string
+	var value float64
+	if err := h.db.QueryRow("SELECT code, value_quantity FROM observations WHERE patient_id = 'p1'").Scan(&code, &value); err != nil {
+		t.Fatalf("GCS observation not stored: %v", err)
+	}
+	if code != gcsTotalCode || value != 12 {
+		t.Errorf("stored %s = %v, want %s = 12", code, value, gcsTotalCode)
+	}
+	if warning := ValidateLOINC(gcsTotalCode, "Glasgow coma score total (E3 V4 M5)", "{score}"); warning != "" {
+		t.Errorf("stored observation fails LOINC validation: %s", warning)
+	}
+}
+
+func TestRecordGCSRejectsOutOfRange(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+
+	_, err := h.RecordGCS("p1", 5, 0, 6)
+	if err == nil {
+		t.Fatal("out-of-range components accepted")
+	}
+	for _, want := range []string{"eye 5 (must be 1-4)", "verbal 0 (must be 1-5)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q missing from error: %v", want, err)
+		}
+	}
+
+	var count int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM observations").Scan(&count); err != nil {
+		t.Fatalf("Failed to count observations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("invalid GCS stored %d observation(s)", count)
+	}
+}
+
+func TestGCSSeverity(t *testing.T) {
+	for total, want := range map[int]string{3: "severe", 8: "severe", 9: "moderate", 12: "moderate", 13: "mild", 15: "mild"} {
+		if got := gcsSeverity(total); got != want {
+			t.Errorf("gcsSeverity(%d) = %q, want %q", total, got, want)
+		}
+	}
+}
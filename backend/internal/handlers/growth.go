@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// daysPerMonth is the average month length the WHO standards use for age
+const daysPerMonth = 30.4375
+
+// growthSex returns the growth reference key for a patient's gender
+func growthSex(gender string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m":
+		return "male", true
+	case "female", "f":
+		return "female", true
+	}
+	return "", false
+}
+
+// ageInMonths returns the age in fractional months on a given day
+func ageInMonths(birth, at time.Time) float64 {
+	return at.Sub(birth).Hours() / 24 / daysPerMonth
+}
+
+// interpolateLMS returns the reference parameters at ageMonths, interpolating
+// linearly between whole months. It reports false outside the table's range.
+func interpolateLMS(table []lmsParams, ageMonths float64) (lmsParams, bool) {
+	last := float64(len(table) - 1)
+	if ageMonths < 0 || ageMonths > last {
+		return lmsParams{}, false
+	}
+	lower := math.Floor(ageMonths)
+	if lower == last {
+		return table[int(lower)], true
+	}
+	a, b := table[int(lower)], table[int(lower)+1]
+	f := ageMonths - lower
+	return lmsParams{
+		L: a.L + f*(b.L-a.L),
+		M: a.M + f*(b.M-a.M),
+		S: a.S + f*(b.S-a.S),
+	}, true
+}
+
+// lmsZScore returns the z-score of measurement x: ((x/M)^L - 1) / (L·S), or
+// ln(x/M) / S when L is 0
+func lmsZScore(x float64, p lmsParams) float64 {
+	if p.L == 0 {
+		return math.Log(x/p.M) / p.S
+	}
+	return (math.Pow(x/p.M, p.L) - 1) / (p.L * p.S)
+}
+
+// zPercentile returns the percentile of a standard normal z-score
+func zPercentile(z float64) float64 {
+	return 50 * math.Erfc(-z/math.Sqrt2)
+}
+
+// describeGrowthPercentile formats one growth measurement against its
+// reference table, or explains why no percentile can be given
+func describeGrowthPercentile(label, unit string, obs *database.Observation, value float64, birth time.Time, table []lmsParams) string {
+	if obs == nil {
+		return fmt.Sprintf("%s: no measurement recorded\n", label)
+	}
+
+	measured := time.Now()
+	day := "today"
+	if obs.EffectiveDateTime != nil {
+		if t, ok := parseRecordTime(*obs.EffectiveDateTime); ok {
+			measured = t
+			day = recordDay(*obs.EffectiveDateTime)
+		}
+	}
+	age := ageInMonths(birth, measured)
+
+	params, ok := interpolateLMS(table, age)
+	if !ok {
+		return fmt.Sprintf("%s: %.1f %s measured %s at %.1f months, outside the reference range (0-%d months); no percentile available\n",
+			label, value, unit, day, age, growthReferenceMaxMonths)
+	}
+
+	z := lmsZScore(value, params)
+	line := fmt.Sprintf("%s: %.1f %s measured %s at %.1f months: percentile %.1f (z-score %.2f)",
+		label, value, unit, day, age, zPercentile(z), z)
+	if math.Abs(z) > 3 {
+		line += ", beyond 3 SD; please check the measurement"
+	}
+	return line + "\n"
+}
+
+func (h *Handler) GetGrowthPercentile(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	sex, ok := growthSex(patient.Gender)
+	if !ok {
+		return nil, fmt.Errorf("gender must be male or female to look up growth percentiles (got %q)", patient.Gender)
+	}
+	birth, err := parseBirthDate(strings.TrimSpace(patient.BirthDate))
+	if err != nil {
+		return nil, fmt.Errorf("a valid birth date is required for growth percentiles: %w", err)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Growth percentiles for %s (ID: %s)\n", patientName, patientID))
+	result.WriteString(fmt.Sprintf("Reference: WHO Child Growth Standards, %s, 0-%d months\n\n", sex, growthReferenceMaxMonths))
+
+	weightObs, weight, _ := latestWeight(observations)
+	result.WriteString(describeGrowthPercentile("Weight-for-age", "kg", weightObs, weight, birth, whoWeightForAge[sex]))
+	lengthObs, length, _ := latestHeight(observations)
+	result.WriteString(describeGrowthPercentile("Length-for-age", "cm", lengthObs, length, birth, whoLengthForAge[sex]))
+
+	if ageInMonths(birth, time.Now()) > growthReferenceMaxMonths {
+		result.WriteString("\nNote: percentiles are only available for measurements taken before 24 months of age; the CDC charts for 2-20 years are not included.\n")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
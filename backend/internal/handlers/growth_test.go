@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestLMSPercentileReferencePoints(t *testing.T) {
+	// Values from the WHO chart SD lines, which are rounded to 0.1 kg or cm:
+	// the median is the 50th percentile and -2 SD / +2 SD are the 2.3rd / 97.7th
+	tests := []struct {
+		name   string
+		table  []lmsParams
+		months float64
+		value  float64
+		wantZ  float64
+	}{
+		{"boy weight median at 12 months", whoWeightForAge["male"], 12, 9.6479, 0},
+		{"boy weight -2 SD at 12 months", whoWeightForAge["male"], 12, 7.7, -2},
+		{"girl weight +2 SD at birth", whoWeightForAge["female"], 0, 4.2, 2},
+		{"girl length +2 SD at 6 months", whoLengthForAge["female"], 6, 70.3, 2},
+		{"boy length -2 SD at 24 months", whoLengthForAge["male"], 24, 81.7, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := interpolateLMS(tt.table, tt.months)
+			if !ok {
+				t.Fatalf("age %.1f months out of range", tt.months)
+			}
+			if z := lmsZScore(tt.value, params); math.Abs(z-tt.wantZ) > 0.1 {
+				t.Errorf("z = %.3f, want %.1f", z, tt.wantZ)
+			}
+		})
+	}
+
+	if p := zPercentile(-2); math.Abs(p-2.28) > 0.01 {
+		t.Errorf("zPercentile(-2) = %.2f, want 2.28", p)
+	}
+}
+
+func TestInterpolateLMS(t *testing.T) {
+	table := whoWeightForAge["male"]
+	params, ok := interpolateLMS(table, 6.5)
+	if !ok {
+		t.Fatal("6.5 months out of range")
+	}
+	if want := (table[6].M + table[7].M) / 2; math.Abs(params.M-want) > 1e-9 {
+		t.Errorf("M = %.4f, want %.4f", params.M, want)
+	}
+	for _, age := range []float64{-0.5, 24.1, 60} {
+		if _, ok := interpolateLMS(table, age); ok {
+			t.Errorf("age %.1f months should be out of range", age)
+		}
+	}
+}
+
+func TestGrowthSex(t *testing.T) {
+	tests := []struct {
+		gender string
+		want   string
+		ok     bool
+	}{
+		{"male", "male", true},
+		{" F ", "female", true},
+		{"Female", "female", true},
+		{"unknown", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := growthSex(tt.gender); got != tt.want || ok != tt.ok {
+			t.Errorf("growthSex(%q) = %q, %v; want %q, %v", tt.gender, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDescribeGrowthPercentile(t *testing.T) {
+	birth := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	measured := func(date string) *database.Observation {
+		return &database.Observation{EffectiveDateTime: &date}
+	}
+
+	tests := []struct {
+		name  string
+		label string
+		unit  string
+		obs   *database.Observation
+		value float64
+		birth time.Time
+		table []lmsParams
+		want  string
+	}{
+		{"weight at 12 months", "Weight-for-age", "kg", measured("2024-12-31"), 9.2, birth, whoWeightForAge["male"],
+			"Weight-for-age: 9.2 kg measured 2024-12-31 at 12.0 months: percentile 33.3 (z-score -0.43)\n"},
+		{"length at 12 months", "Length-for-age", "cm", measured("2024-12-31"), 75.7, birth, whoLengthForAge["male"],
+			"Length-for-age: 75.7 cm measured 2024-12-31 at 12.0 months: percentile 49.3 (z-score -0.02)\n"},
+		{"beyond 3 SD", "Weight-for-age", "kg", measured("2024-12-31"), 15, birth, whoWeightForAge["male"],
+			", beyond 3 SD; please check the measurement\n"},
+		{"older than the reference", "Weight-for-age", "kg", measured("2022-07-01"), 14, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), whoWeightForAge["female"],
+			"outside the reference range (0-24 months); no percentile available\n"},
+		{"no measurement", "Length-for-age", "cm", nil, 0, birth, whoLengthForAge["female"],
+			"Length-for-age: no measurement recorded\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeGrowthPercentile(tt.label, tt.unit, tt.obs, tt.value, tt.birth, tt.table); !strings.HasSuffix(got, tt.want) {
+				t.Errorf("got %q, want it to end with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGrowthPercentile(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Emil", "Nordmann", "male", "2024-01-01")
+	seedObservation(t, h, "p1", "29463-7", "Body Weight", 9.2, "kg", "2024-12-31")
+
+	result, err := h.GetGrowthPercentile("p1")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"WHO Child Growth Standards, male", "Weight-for-age: 9.2 kg", "Length-for-age: no measurement recorded", "CDC charts for 2-20 years are not included"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	seedPatient(t, h, "p2", "Alex", "Doe", "unknown", "2024-01-01")
+	if _, err := h.GetGrowthPercentile("p2"); err == nil {
+		t.Error("expected an error without a male or female gender")
+	}
+}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -14,7 +14,6 @@ import (
 
 	"github.com/eythor/mcp-server/internal/database"
 	"github.com/eythor/mcp-server/internal/debug"
-	"github.com/google/uuid"
 )
 
 type PatientMedicalSummary struct {
@@ -34,23 +33,232 @@ type Context struct {
 	PractitionerID string                `json:"practitioner_id,omitempty"`
 	PatientSummary *PatientMedicalSummary `json:"patient_summary,omitempty"`
 	LastResponse   string                `json:"last_response,omitempty"`
+
+	// pendingDate is an ambiguous date awaiting confirm_date_choice
+	pendingDate *pendingDateChoice
+}
+
+// defaultContext is the context a new (or idle-expired) handler starts with
+func defaultContext() Context {
+	return Context{
+		// We set a default practitioner ID because we assume this information is given during authentication
+		PractitionerID: "5df7a318-69e4-3ed2-a046-bad7b3e321b5",
+	}
 }
 
+// ErrTooManyQueries is returned when the concurrent natural language query
+// limit (MCP_MAX_CONCURRENT_QUERIES) is reached
+var ErrTooManyQueries = errors.New("too many concurrent natural language queries, please retry shortly")
+
+// ErrUnknownPractitioner is returned when a query is attributed to a
+// practitioner ID that doesn't exist
+var ErrUnknownPractitioner = errors.New("practitioner not found")
+
+// Handler runs the healthcare tools for one session. Handlers returned by
+// Session share the database, configuration and limits with the Handler
+// NewHandler returned, but each has its own context.
 type Handler struct {
-	db      *sql.DB
-	apiKey  string
-	context Context
-	mu      sync.RWMutex
+	*handlerState
+	*session
+
+	// sessionID is "" for the default session
+	sessionID string
+
+	// queryPractitionerID is the practitioner a natural language query is
+	// attributed to. It overrides the context practitioner for that query
+	// only and is never saved to the session.
+	queryPractitionerID string
+
+	// forModel marks a Handler running tools for the model, whose results
+	// must leave out the records of patients without AI data sharing
+	// consent (see withheldFromModel)
+	forModel bool
+}
+
+// handlerState is shared by every session of a Handler
+type handlerState struct {
+	db     *sql.DB
+	apiKey string
+
+	// mu guards sessions and the context and lastAccessed of every session
+	mu       sync.RWMutex
+	sessions map[string]*session
+
+	// querySlots bounds concurrent natural language queries; nil means unlimited
+	querySlots        chan struct{}
+	queryQueueTimeout time.Duration
+
+	// openRouterURL is the chat completions endpoint; tests point it at a fake server
+	openRouterURL string
+
+	// openRouterModelsURL lists OpenRouter's models; modelList caches it
+	openRouterModelsURL string
+	modelList           modelListCache
+
+	// openRouterClient sends all OpenRouter requests, reusing connections;
+	// its timeout is MCP_OPENROUTER_TIMEOUT
+	openRouterClient *http.Client
+
+	// retryBaseDelay is the backoff before the first retry of a failed
+	// OpenRouter request; tests shorten it
+	retryBaseDelay time.Duration
+
+	// models are the OpenRouter models for each kind of call
+	// (OPENROUTER_MODEL, OPENROUTER_TOOLS_MODEL, OPENROUTER_GUIDELINES_MODEL)
+	models modelConfig
+
+	// audioStopSequences are sent as OpenRouter stop sequences for queries
+	// whose response will be spoken
+	audioStopSequences []string
+
+	// A session's context is cleared once it has not been touched for
+	// idleTTL (MCP_SESSION_IDLE_TTL, 0 disables)
+	idleTTL      time.Duration
+	stopSweeper  chan struct{}
+	sweeperDone  chan struct{}
+	closeOnce    sync.Once
+
+	// surgicalCodes and surgicalTerms select the procedures shown by
+	// GetSurgicalHistory; empty means the built-in defaults
+	surgicalCodes []string
+	surgicalTerms []string
+
+	// preserveLastResponse keeps LastResponse when the patient context
+	// changes (MCP_PRESERVE_LAST_RESPONSE), e.g. to compare two patients
+	preserveLastResponse bool
+
+	// polishSBAR has the guidelines model rewrite GenerateSBAR output
+	// (MCP_SBAR_POLISH)
+	polishSBAR bool
+
+	// adminToolsEnabled allows bulk data maintenance tools
+	// (MCP_ENABLE_ADMIN_TOOLS)
+	adminToolsEnabled bool
+
+	// strictLOINC rejects lab and vital sign observations whose code isn't
+	// in LOINC format instead of only warning (MCP_STRICT_LOINC)
+	strictLOINC bool
+
+	// demoMode makes write tools return a message instead of changing data
+	// (MCP_DEMO_MODE)
+	demoMode bool
+
+	// requireAIConsent withholds the data of patients with no recorded AI
+	// data sharing consent from the model, not only of those who revoked it
+	// (MCP_REQUIRE_AI_CONSENT)
+	requireAIConsent bool
+
+	// medicalHistoryMaxChars caps GetMedicalHistory output; 0 means unlimited
+	// (MCP_MEDICAL_HISTORY_MAX_CHARS)
+	medicalHistoryMaxChars int
+
+	// lastResponseMaxChars caps the last response repeated in the prompt
+	// context; 0 means unlimited (MCP_LAST_RESPONSE_MAX_CHARS)
+	lastResponseMaxChars int
+
+	// usage accumulates model token usage and estimated cost, priced per
+	// model (MCP_MODEL_PRICES)
+	usage *usageTracker
+
+	// contextStorePath is a JSON file the context is saved to on every change
+	// and restored from on start (CONTEXT_STORE_PATH); empty disables it
+	contextStorePath string
+
+	// idGenerator creates the IDs of new records (MCP_ID_STRATEGY)
+	idGenerator IDGenerator
+
+	// aiPatientLimiter caps AI tool calls per patient
+	// (MCP_AI_PATIENT_RATE_LIMIT); nil means unlimited
+	aiPatientLimiter *patientRateLimiter
 }
 
+const defaultOpenRouterURL = "https://openrouter.ai/api/v1/chat/completions"
+
 func NewHandler(db *sql.DB, apiKey string) *Handler {
-	return &Handler{
-		db:     db,
-		apiKey: apiKey,
-		context: Context{
-			// We set a default practitioner ID because we assume this information is given during authentication
-			PractitionerID: "5df7a318-69e4-3ed2-a046-bad7b3e321b5",
-		},
+	state := &handlerState{
+		db:                db,
+		apiKey:            apiKey,
+		queryQueueTimeout: envDuration("MCP_QUERY_QUEUE_TIMEOUT", 0),
+		openRouterURL:     defaultOpenRouterURL,
+		models:            modelConfigFromEnv(),
+		openRouterClient:  newOpenRouterClient(envDuration("MCP_OPENROUTER_TIMEOUT", defaultOpenRouterTimeout)),
+		retryBaseDelay:    defaultRetryBaseDelay,
+
+		openRouterModelsURL: defaultOpenRouterModelsURL,
+
+		audioStopSequences: envList("MCP_AUDIO_STOP_SEQUENCES"),
+
+		idleTTL: envDuration("MCP_SESSION_IDLE_TTL", 0),
+
+		surgicalCodes: envList("MCP_SURGICAL_PROCEDURE_CODES"),
+		surgicalTerms: envList("MCP_SURGICAL_PROCEDURE_TERMS"),
+
+		preserveLastResponse: envBool("MCP_PRESERVE_LAST_RESPONSE"),
+		polishSBAR:           envBool("MCP_SBAR_POLISH"),
+		adminToolsEnabled:    envBool("MCP_ENABLE_ADMIN_TOOLS"),
+		strictLOINC:          envBool("MCP_STRICT_LOINC"),
+		demoMode:             envBool("MCP_DEMO_MODE"),
+		requireAIConsent:     envBool("MCP_REQUIRE_AI_CONSENT"),
+
+		medicalHistoryMaxChars: envInt("MCP_MEDICAL_HISTORY_MAX_CHARS", defaultMedicalHistoryMaxChars),
+		lastResponseMaxChars:   envInt("MCP_LAST_RESPONSE_MAX_CHARS", defaultLastResponseMaxChars),
+
+		usage: newUsageTracker(modelPricesFromEnv()),
+
+		contextStorePath: envString("CONTEXT_STORE_PATH", ""),
+
+		idGenerator: idGeneratorFromEnv(db),
+
+		aiPatientLimiter: aiPatientRateLimiterFromEnv(),
+	}
+	h := &Handler{handlerState: state, session: newSession()}
+	state.sessions = map[string]*session{"": h.session}
+
+	h.loadContext()
+
+	if maxQueries := envInt("MCP_MAX_CONCURRENT_QUERIES", 0); maxQueries > 0 {
+		h.querySlots = make(chan struct{}, maxQueries)
+		debug.Log("Natural language query concurrency limited to %d (queue timeout: %s)", maxQueries, h.queryQueueTimeout)
+	}
+
+	if h.idleTTL > 0 {
+		h.startIdleSweeper()
+		debug.Log("Context will be cleared after %s idle", h.idleTTL)
+	}
+
+	return h
+}
+
+// acquireQuerySlot reserves a slot for a natural language query, waiting up to
+// the configured queue timeout. It returns false when no slot became free.
+func (h *Handler) acquireQuerySlot() bool {
+	if h.querySlots == nil {
+		return true
+	}
+
+	select {
+	case h.querySlots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if h.queryQueueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(h.queryQueueTimeout)
+	defer timer.Stop()
+	select {
+	case h.querySlots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (h *Handler) releaseQuerySlot() {
+	if h.querySlots != nil {
+		<-h.querySlots
 	}
 }
 
@@ -67,15 +275,15 @@ func (h *Handler) LookupPatient(query string) (interface{}, error) {
 			medicalSummary = nil
 		}
 		
-		h.mu.Lock()
-		h.context.PatientID = patient.ID
-		h.context.PatientSummary = medicalSummary
-		h.context.LastResponse = "" // Clear last response when changing patient
-		h.mu.Unlock()
+		h.switchPatientContext(patient.ID, medicalSummary)
 
+		h.loadContacts(patient)
 		resultText := formatPatientInfo(*patient)
 		resultText += fmt.Sprintf("\n\n✓ Context updated: Current patient set to %s %s (ID: %s)",
 			patient.GivenName, patient.FamilyName, patient.ID)
+		if h.withheldFromModel(patient.ID) {
+			resultText = aiConsentMessage(patient.ID)
+		}
 
 		return map[string]interface{}{
 			"content": []map[string]interface{}{
@@ -114,6 +322,10 @@ func (h *Handler) LookupPatient(query string) (interface{}, error) {
 		}, nil
 	}
 
+	for i := range patients {
+		h.loadContacts(&patients[i])
+	}
+
 	// If exactly one patient found, auto-set context
 	if len(patients) == 1 {
 		p := patients[0]
@@ -124,15 +336,14 @@ func (h *Handler) LookupPatient(query string) (interface{}, error) {
 			medicalSummary = nil
 		}
 		
-		h.mu.Lock()
-		h.context.PatientID = p.ID
-		h.context.PatientSummary = medicalSummary
-		h.context.LastResponse = "" // Clear last response when changing patient
-		h.mu.Unlock()
+		h.switchPatientContext(p.ID, medicalSummary)
 
 		resultText := formatPatientInfo(p)
 		resultText += fmt.Sprintf("\n\n✓ Context updated: Current patient set to %s %s (ID: %s)",
 			p.GivenName, p.FamilyName, p.ID)
+		if h.withheldFromModel(p.ID) {
+			resultText = aiConsentMessage(p.ID)
+		}
 
 		return map[string]interface{}{
 			"content": []map[string]interface{}{
@@ -148,7 +359,12 @@ func (h *Handler) LookupPatient(query string) (interface{}, error) {
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Found %d patients matching '%s':\n\n", len(patients), query))
 	for _, p := range patients {
-		result.WriteString(formatPatientInfo(p))
+		if h.withheldFromModel(p.ID) {
+			// Keep the ID so the patient can still be picked
+			result.WriteString(fmt.Sprintf("Patient ID: %s (%s)", p.ID, withheldPatientText))
+		} else {
+			result.WriteString(formatPatientInfo(p))
+		}
 		result.WriteString("\n---\n")
 	}
 	result.WriteString("\nNote: Multiple patients found. Use 'set_patient_context' with a specific patient ID to set the Current.")
@@ -188,18 +404,59 @@ func (h *Handler) ScheduleAppointment(patientID, practitionerID, dateTime, appoi
 		return nil, fmt.Errorf("practitioner not found: %s", practitionerID)
 	}
 
-	// Parse and validate datetime
-	appointmentTime, err := time.Parse(time.RFC3339, dateTime)
+	// Set default appointment type if not provided
+	if appointmentType == "" {
+		appointmentType = "General Consultation"
+	}
+
+	// Parse datetime; an ambiguous date is completed by ConfirmDateChoice
+	appointmentTime, err := ParseDateTimeRobust(dateTime)
 	if err != nil {
-		return nil, fmt.Errorf("invalid datetime format (use ISO 8601): %s", dateTime)
+		var ambiguous *AmbiguousDateError
+		if errors.As(err, &ambiguous) {
+			return h.awaitDateChoice(ambiguous, "schedule appointment", func(t time.Time) (interface{}, error) {
+				return h.createAppointment(patientID, practitionerID, t, appointmentType)
+			}), nil
+		}
+		return nil, fmt.Errorf("invalid datetime: %w", err)
 	}
 
-	// Generate new encounter ID
-	encounterID := uuid.New().String()
+	return h.createAppointment(patientID, practitionerID, appointmentTime, appointmentType)
+}
 
-	// Set default appointment type if not provided
-	if appointmentType == "" {
-		appointmentType = "General Consultation"
+// appointmentLength is how long a booked appointment occupies; another
+// appointment starting less than this before or after it conflicts
+const appointmentLength = 30 * time.Minute
+
+// describeAppointment identifies an encounter by ID, start time and type
+func describeAppointment(e database.Encounter) string {
+	description := fmt.Sprintf("appointment %s at %s", e.ID, formatRecordTime(e.StartDateTime))
+	if e.TypeDisplay != nil && *e.TypeDisplay != "" {
+		description += fmt.Sprintf(" (%s)", *e.TypeDisplay)
+	}
+	return description
+}
+
+// createAppointment books a validated patient and practitioner at appointmentTime
+func (h *Handler) createAppointment(patientID, practitionerID string, appointmentTime time.Time, appointmentType string) (interface{}, error) {
+	if err := ValidateAppointmentDateTime(appointmentTime); err != nil {
+		return nil, err
+	}
+
+	// Don't double-book the patient
+	conflicts, err := database.GetEncountersByPatientInRange(h.db, patientID,
+		appointmentTime.Add(-appointmentLength), appointmentTime.Add(appointmentLength))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing appointments: %w", err)
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("patient %s is already booked at that time: %s", patientID, describeAppointment(conflicts[0]))
+	}
+
+	// Generate new encounter ID
+	encounterID, err := h.newID(idKindEncounter)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create new encounter
@@ -229,7 +486,11 @@ func (h *Handler) ScheduleAppointment(patientID, practitionerID, dateTime, appoi
 	}, nil
 }
 
-func (h *Handler) CancelAppointment(encounterID string) (interface{}, error) {
+// CancelAppointment cancels a planned appointment, recording the reason (e.g.
+// "no-show") when one is given
+func (h *Handler) CancelAppointment(encounterID, reason string) (interface{}, error) {
+	reason = strings.TrimSpace(reason)
+
 	// Check if encounter exists and is cancellable
 	status, err := database.GetEncounterStatus(h.db, encounterID)
 	if err != nil {
@@ -255,24 +516,307 @@ func (h *Handler) CancelAppointment(encounterID string) (interface{}, error) {
 	}
 
 	// Update status to cancelled
-	err = database.UpdateEncounterStatus(h.db, encounterID, "cancelled")
+	err = database.CancelEncounter(h.db, encounterID, reason)
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel appointment: %w", err)
 	}
 
+	resultText := fmt.Sprintf("Successfully cancelled appointment %s", encounterID)
+	if reason != "" {
+		resultText += fmt.Sprintf(" (reason: %s)", reason)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+func (h *Handler) RescheduleAppointment(encounterID, newDateTime string) (interface{}, error) {
+	encounterID = strings.TrimSpace(encounterID)
+	if encounterID == "" {
+		return nil, fmt.Errorf("appointment ID is required")
+	}
+
+	status, err := database.GetEncounterStatus(h.db, encounterID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("appointment not found: %s", encounterID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if status == "finished" || status == "cancelled" {
+		return nil, fmt.Errorf("cannot reschedule %s appointment: %s", status, encounterID)
+	}
+
+	appointmentTime, err := ParseDateTimeRobust(newDateTime)
+	if err != nil {
+		var ambiguous *AmbiguousDateError
+		if errors.As(err, &ambiguous) {
+			return h.awaitDateChoice(ambiguous, "reschedule appointment "+encounterID, func(t time.Time) (interface{}, error) {
+				return h.moveAppointment(encounterID, t)
+			}), nil
+		}
+		return nil, fmt.Errorf("invalid datetime: %w", err)
+	}
+
+	return h.moveAppointment(encounterID, appointmentTime)
+}
+
+// moveAppointment sets a reschedulable encounter's start to appointmentTime
+func (h *Handler) moveAppointment(encounterID string, appointmentTime time.Time) (interface{}, error) {
+	if err := ValidateAppointmentDateTime(appointmentTime); err != nil {
+		return nil, err
+	}
+
+	if err := database.UpdateEncounterStartTime(h.db, encounterID, appointmentTime.Format(time.RFC3339)); err != nil {
+		return nil, fmt.Errorf("failed to reschedule appointment: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Successfully rescheduled appointment %s to %s",
+					encounterID, appointmentTime.Format("2006-01-02 15:04")),
+			},
+		},
+	}, nil
+}
+
+// CompleteEncounter marks an encounter finished. actualStart, when given,
+// records when the visit actually started for punctuality tracking.
+func (h *Handler) CompleteEncounter(encounterID, outcome, actualStart string) (interface{}, error) {
+	encounterID = strings.TrimSpace(encounterID)
+	outcome = strings.TrimSpace(outcome)
+	if encounterID == "" {
+		return nil, fmt.Errorf("encounter ID is required")
+	}
+
+	var actualStartTime time.Time
+	if strings.TrimSpace(actualStart) != "" {
+		var err error
+		if actualStartTime, err = ParseDateTimeRobust(actualStart); err != nil {
+			return nil, fmt.Errorf("invalid actual start time: %w", err)
+		}
+	}
+
+	status, err := database.GetEncounterStatus(h.db, encounterID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("encounter not found: %s", encounterID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if status == "cancelled" {
+		return nil, fmt.Errorf("cannot complete cancelled encounter: %s", encounterID)
+	}
+
+	if status == "finished" {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("Encounter %s is already finished", encounterID),
+				},
+			},
+		}, nil
+	}
+
+	endDateTime := time.Now().Format(time.RFC3339)
+	if err := database.CompleteEncounter(h.db, encounterID, endDateTime, outcome); err != nil {
+		return nil, fmt.Errorf("failed to complete encounter: %w", err)
+	}
+	if !actualStartTime.IsZero() {
+		if err := database.SetEncounterActualStart(h.db, encounterID, actualStartTime.Format(time.RFC3339)); err != nil {
+			return nil, fmt.Errorf("failed to record actual start time: %w", err)
+		}
+	}
+
+	resultText := fmt.Sprintf("✓ Encounter %s marked as finished at %s", encounterID, endDateTime)
+	if !actualStartTime.IsZero() {
+		resultText += fmt.Sprintf("\nActual start: %s", actualStartTime.Format("2006-01-02 15:04"))
+	}
+	if outcome != "" {
+		resultText += fmt.Sprintf("\nOutcome: %s", outcome)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+func (h *Handler) ListAppointments(patientID, status string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+	status = strings.ToLower(strings.TrimSpace(status))
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	encounters, err := database.GetEncountersByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	practitionerName := h.practitionerNameLookup()
+
+	var lines []string
+	for _, e := range encounters {
+		if status != "" && e.Status != status {
+			continue
+		}
+
+		appointmentType := e.Class
+		if e.TypeDisplay != nil && *e.TypeDisplay != "" {
+			appointmentType = *e.TypeDisplay
+		}
+		practitioner := practitionerName(e.PractitionerID)
+
+		line := fmt.Sprintf("• %s - %s with %s (status: %s, ID: %s)",
+			e.StartDateTime, appointmentType, practitioner, e.Status, e.ID)
+		if e.CancellationReason != nil && *e.CancellationReason != "" {
+			line += fmt.Sprintf(" - cancelled: %s", *e.CancellationReason)
+		}
+		lines = append(lines, line)
+	}
+
+	var resultText string
+	if len(lines) == 0 {
+		if status != "" {
+			resultText = fmt.Sprintf("No appointments found for %s (ID: %s) with status '%s'.", patientName, patientID, status)
+		} else {
+			resultText = fmt.Sprintf("No appointments found for %s (ID: %s).", patientName, patientID)
+		}
+	} else {
+		resultText = fmt.Sprintf("Appointments for %s (ID: %s):\n\n%s", patientName, patientID, strings.Join(lines, "\n"))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
+
+// practitionerNameLookup returns a function resolving encounter practitioner
+// IDs to names, caching each lookup. Unknown IDs resolve to the ID itself and
+// a missing ID to "unassigned".
+func (h *Handler) practitionerNameLookup() func(practitionerID *string) string {
+	names := make(map[string]string)
+	return func(practitionerID *string) string {
+		if practitionerID == nil || *practitionerID == "" {
+			return "unassigned"
+		}
+		name, ok := names[*practitionerID]
+		if !ok {
+			name = *practitionerID
+			if p, err := database.GetPractitionerByID(h.db, *practitionerID); err == nil {
+				name = fmt.Sprintf("%s %s", p.GivenName, p.FamilyName)
+			}
+			names[*practitionerID] = name
+		}
+		return name
+	}
+}
+
+func (h *Handler) GetEncounters(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	encounters, err := database.GetEncountersByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	if len(encounters) == 0 {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("No visits found for %s (ID: %s)", patientName, patientID),
+				},
+			},
+		}, nil
+	}
+
+	practitionerName := h.practitionerNameLookup()
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Visit history for %s (ID: %s)\n", patientName, patientID))
+	result.WriteString(fmt.Sprintf("Total visits: %d\n\n", len(encounters)))
+
+	for _, e := range encounters {
+		visitType := e.Class
+		if e.TypeDisplay != nil && *e.TypeDisplay != "" {
+			visitType = *e.TypeDisplay
+		}
+		result.WriteString(fmt.Sprintf("• %s\n", visitType))
+		result.WriteString(fmt.Sprintf("  ID: %s\n", e.ID))
+		result.WriteString(fmt.Sprintf("  Class: %s\n", e.Class))
+		result.WriteString(fmt.Sprintf("  Practitioner: %s\n", practitionerName(e.PractitionerID)))
+		result.WriteString(fmt.Sprintf("  Start: %s\n", e.StartDateTime))
+		if e.EndDateTime != nil && *e.EndDateTime != "" {
+			result.WriteString(fmt.Sprintf("  End: %s\n", *e.EndDateTime))
+		}
+		result.WriteString(fmt.Sprintf("  Status: %s\n", e.Status))
+		if e.CancellationReason != nil && *e.CancellationReason != "" {
+			result.WriteString(fmt.Sprintf("  Cancellation reason: %s\n", *e.CancellationReason))
+		}
+	}
+
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": fmt.Sprintf("Successfully cancelled appointment %s", encounterID),
+				"text": result.String(),
 			},
 		},
 	}, nil
 }
 
-func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, error) {
+// GetMedicalHistory lists the patient's records in category (or "all"). Each
+// category is paged separately: up to limit records (default 10) from offset.
+func (h *Handler) GetMedicalHistory(patientID, category string, limit, offset int) (interface{}, error) {
 	// Use context if patient ID not provided
 	patientID = h.GetContextPatientID(patientID)
+	if limit <= 0 {
+		limit = defaultHistoryPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
 
 	if patientID == "" {
 		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
@@ -287,13 +831,14 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
-	var result strings.Builder
+	result := newBudgetWriter(h.medicalHistoryMaxChars)
 	result.WriteString(fmt.Sprintf("Medical History for %s (ID: %s)\n\n", patientName, patientID))
 
 	switch category {
 	case "conditions", "all":
-		conditions, err := database.GetConditionsByPatientID(h.db, patientID)
-		if err == nil && len(conditions) > 0 {
+		conditions, err := database.GetConditionsByPatientIDPage(h.db, patientID, limit, offset)
+		total := h.recordTotal("conditions", patientID, offset+len(conditions))
+		if err == nil && total > 0 {
 			result.WriteString("CONDITIONS:\n")
 			for _, c := range conditions {
 				result.WriteString(fmt.Sprintf("• %s (Code: %s)\n", c.Display, c.Code))
@@ -302,6 +847,7 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 				}
 				result.WriteString(fmt.Sprintf("  Status: %s\n", c.ClinicalStatus))
 			}
+			result.WriteString(historyPageNote(offset, len(conditions), total))
 			result.WriteString("\n")
 		}
 		if category == "conditions" {
@@ -311,8 +857,9 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 
 	case "medications":
 		if category == "medications" || category == "all" {
-			medications, err := database.GetMedicationsByPatientID(h.db, patientID)
-			if err == nil && len(medications) > 0 {
+			medications, err := database.GetMedicationsByPatientIDPage(h.db, patientID, limit, offset)
+			total := h.recordTotal("medication_requests", patientID, offset+len(medications))
+			if err == nil && total > 0 {
 				result.WriteString("MEDICATIONS:\n")
 				for _, m := range medications {
 					result.WriteString(fmt.Sprintf("• %s\n", m.MedicationDisplay))
@@ -322,6 +869,7 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 						result.WriteString(fmt.Sprintf("  Dosage: %s\n", *m.DosageText))
 					}
 				}
+				result.WriteString(historyPageNote(offset, len(medications), total))
 				result.WriteString("\n")
 			}
 		}
@@ -332,8 +880,9 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 
 	case "procedures":
 		if category == "procedures" || category == "all" {
-			procedures, err := database.GetProceduresByPatientID(h.db, patientID)
-			if err == nil && len(procedures) > 0 {
+			procedures, err := database.GetProceduresByPatientIDPage(h.db, patientID, limit, offset)
+			total := h.recordTotal("procedures", patientID, offset+len(procedures))
+			if err == nil && total > 0 {
 				result.WriteString("PROCEDURES:\n")
 				for _, p := range procedures {
 					result.WriteString(fmt.Sprintf("• %s\n", p.Display))
@@ -342,6 +891,7 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 						result.WriteString(fmt.Sprintf("  Performed: %s\n", *p.PerformedDateTime))
 					}
 				}
+				result.WriteString(historyPageNote(offset, len(procedures), total))
 				result.WriteString("\n")
 			}
 		}
@@ -352,14 +902,16 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 
 	case "immunizations":
 		if category == "immunizations" || category == "all" {
-			immunizations, err := database.GetImmunizationsByPatientID(h.db, patientID)
-			if err == nil && len(immunizations) > 0 {
+			immunizations, err := database.GetImmunizationsByPatientIDPage(h.db, patientID, limit, offset)
+			total := h.recordTotal("immunizations", patientID, offset+len(immunizations))
+			if err == nil && total > 0 {
 				result.WriteString("IMMUNIZATIONS:\n")
 				for _, i := range immunizations {
 					result.WriteString(fmt.Sprintf("• %s\n", i.VaccineDisplay))
 					result.WriteString(fmt.Sprintf("  Date: %s\n", i.OccurrenceDateTime))
 					result.WriteString(fmt.Sprintf("  Status: %s\n", i.Status))
 				}
+				result.WriteString(historyPageNote(offset, len(immunizations), total))
 				result.WriteString("\n")
 			}
 		}
@@ -370,8 +922,9 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 
 	case "allergies":
 		if category == "allergies" || category == "all" {
-			allergies, err := database.GetAllergiesByPatientID(h.db, patientID)
-			if err == nil && len(allergies) > 0 {
+			allergies, err := database.GetAllergiesByPatientIDPage(h.db, patientID, limit, offset)
+			total := h.recordTotal("allergy_intolerances", patientID, offset+len(allergies))
+			if err == nil && total > 0 {
 				result.WriteString("ALLERGIES:\n")
 				for _, a := range allergies {
 					result.WriteString(fmt.Sprintf("• %s\n", a.Display))
@@ -380,6 +933,7 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 						result.WriteString(fmt.Sprintf("  Criticality: %s\n", *a.Criticality))
 					}
 				}
+				result.WriteString(historyPageNote(offset, len(allergies), total))
 				result.WriteString("\n")
 			}
 		}
@@ -390,32 +944,48 @@ func (h *Handler) GetMedicalHistory(patientID, category string) (interface{}, er
 
 	case "observations":
 		if category == "observations" || category == "all" {
-			observations, err := database.GetObservationsByPatientID(h.db, patientID)
-			if err == nil && len(observations) > 0 {
+			observations, err := database.GetObservationsByPatientIDPage(h.db, patientID, limit, offset)
+			total := h.recordTotal("observations", patientID, offset+len(observations))
+			if err == nil && total > 0 {
 				result.WriteString("OBSERVATIONS:\n")
-				for _, o := range observations {
+				for _, panel := range groupObservationPanels(observations) {
+					if len(panel.Observations) > 1 {
+						writeObservationPanel(result, panel)
+						continue
+					}
+					o := panel.Observations[0]
 					result.WriteString(fmt.Sprintf("• %s\n", o.Display))
 					result.WriteString(fmt.Sprintf("  Category: %s\n", o.Category))
 					if o.EffectiveDateTime != nil {
-						result.WriteString(fmt.Sprintf("  Date: %s\n", *o.EffectiveDateTime))
+						result.WriteString(fmt.Sprintf("  Date: %s\n", formatRecordTime(*o.EffectiveDateTime)))
 					}
 					if o.ValueQuantity != nil && o.ValueUnit != nil {
-						result.WriteString(fmt.Sprintf("  Value: %.2f %s\n", *o.ValueQuantity, *o.ValueUnit))
+						result.WriteString(fmt.Sprintf("  Value: %s %s\n", FormatValue(*o.ValueQuantity, o.Code, *o.ValueUnit), *o.ValueUnit))
 					} else if o.ValueString != nil {
 						result.WriteString(fmt.Sprintf("  Value: %s\n", *o.ValueString))
 					}
 					result.WriteString(fmt.Sprintf("  Status: %s\n", o.Status))
 				}
+				result.WriteString(historyPageNote(offset, len(observations), total))
 				result.WriteString("\n")
 			}
 		}
 	}
 
+	text := result.String()
+	if result.Truncated() {
+		if category == "all" {
+			text += "\n(output truncated — request a specific category)"
+		} else {
+			text += "\n(output truncated)"
+		}
+	}
+
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": result.String(),
+				"text": text,
 			},
 		},
 	}, nil
@@ -537,6 +1107,9 @@ func (h *Handler) UpdatePatientBirthDate(patientID, birthDate string) (interface
 	if birthDate == "" {
 		return nil, fmt.Errorf("birth date is required")
 	}
+	if err := validateBirthDate(birthDate, time.Now()); err != nil {
+		return nil, err
+	}
 
 	// Verify patient exists
 	exists, err := database.CheckPatientExists(h.db, patientID)
@@ -612,18 +1185,51 @@ func (h *Handler) AddObservation(patientID, code, display, category, status, eff
 	if effectiveDateTime == "" {
 		effectiveDateTime = time.Now().Format(time.RFC3339)
 	} else {
-		// Validate datetime format
-		_, err := time.Parse(time.RFC3339, effectiveDateTime)
+		// Accept ISO 8601 as well as dates like "yesterday at 10:00"
+		effectiveTime, err := ParseDateTimeRobust(effectiveDateTime)
 		if err != nil {
-			return nil, fmt.Errorf("invalid datetime format (use ISO 8601): %s", effectiveDateTime)
+			var ambiguous *AmbiguousDateError
+			if errors.As(err, &ambiguous) {
+				return h.awaitDateChoice(ambiguous, "observation date", func(t time.Time) (interface{}, error) {
+					return h.AddObservation(patientID, code, display, category, status, t.Format(time.RFC3339), valueQuantity, valueUnit, valueString)
+				}), nil
+			}
+			return nil, fmt.Errorf("invalid datetime (use ISO 8601): %w", err)
+		}
+		if err := ValidateClinicalDateTime(effectiveTime); err != nil {
+			return nil, err
 		}
+		effectiveDateTime = effectiveTime.Format(time.RFC3339)
 	}
 
-	// Generate new observation ID
-	observationID := uuid.New().String()
-
-	// Create observation
-	observation := &database.Observation{
+	// Lab and vital sign codes must be LOINC. Inconsistencies are reported
+	// but don't block the write, unless MCP_STRICT_LOINC rejects a malformed
+	// code.
+	loincWarning := ""
+	if loincCategories[category] {
+		if h.strictLOINC {
+			if formatErr := loincFormatError(code); formatErr != "" {
+				return nil, fmt.Errorf("invalid %s observation: %s", category, formatErr)
+			}
+		}
+		unit := ""
+		if valueUnit != nil {
+			unit = *valueUnit
+		}
+		loincWarning = ValidateLOINC(code, display, unit)
+		if loincWarning != "" {
+			debug.Log("AddObservation LOINC warning: %s", loincWarning)
+		}
+	}
+
+	// Generate new observation ID
+	observationID, err := h.newID(idKindObservation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create observation
+	observation := &database.Observation{
 		ID:                observationID,
 		Status:            status,
 		Category:          category,
@@ -663,7 +1269,7 @@ func (h *Handler) AddObservation(patientID, code, display, category, status, eff
 	// Format response
 	var valueText string
 	if valueQuantity != nil && valueUnit != nil {
-		valueText = fmt.Sprintf("%.2f %s", *valueQuantity, *valueUnit)
+		valueText = fmt.Sprintf("%s %s", FormatValue(*valueQuantity, code, *valueUnit), *valueUnit)
 	} else if valueString != nil {
 		valueText = *valueString
 	} else {
@@ -672,7 +1278,10 @@ func (h *Handler) AddObservation(patientID, code, display, category, status, eff
 
 	patientName, _ := database.GetPatientName(h.db, patientID)
 	resultText := fmt.Sprintf("Successfully added observation:\n\nObservation ID: %s\nPatient: %s (ID: %s)\nCode: %s\nDisplay: %s\nCategory: %s\nStatus: %s\nEffective Date: %s\nValue: %s",
-		observationID, patientName, patientID, code, display, category, status, effectiveDateTime, valueText)
+		observationID, patientName, patientID, code, display, category, status, formatRecordTime(effectiveDateTime), valueText)
+	if loincWarning != "" {
+		resultText += "\n\nWarning: " + loincWarning
+	}
 
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
@@ -684,7 +1293,7 @@ func (h *Handler) AddObservation(patientID, code, display, category, status, eff
 	}, nil
 }
 
-func (h *Handler) GetMedicationInfo(medicationName string) (interface{}, error) {
+func (h *Handler) GetMedicationInfo(ctx context.Context, medicationName string) (interface{}, error) {
 	// First check database for medication
 	medication, err := database.SearchMedicationByName(h.db, medicationName)
 
@@ -700,7 +1309,7 @@ func (h *Handler) GetMedicationInfo(medicationName string) (interface{}, error)
 	// Use OpenRouter to get general medication information
 	prompt := fmt.Sprintf("For the medication %s, provide: 1) Primary indications, 2) Standard dosing regimens, 3) Key contraindications and drug interactions, 4) Significant adverse effects. Be concise and clinically focused.", medicationName)
 
-	aiResponse, err := h.callOpenRouter(prompt)
+	aiResponse, err := h.callOpenRouter(ctx, prompt)
 	if err != nil {
 		if dbInfo != "" {
 			return map[string]interface{}{
@@ -857,25 +1466,9 @@ func (h *Handler) DetermineApixabanDose(patientID string) (interface{}, error) {
 	// Find most recent serum creatinine observation
 	var serumCreatinine *float64
 	creatinineMet := false
-	for _, obs := range observations {
-		displayLower := strings.ToLower(obs.Display)
-		if (strings.Contains(displayLower, "creatinine") || strings.Contains(displayLower, "serum creatinine")) &&
-			obs.ValueQuantity != nil && obs.ValueUnit != nil {
-			unitLower := strings.ToLower(*obs.ValueUnit)
-			creatinine := *obs.ValueQuantity
-			// Check if unit is mg/dL or convert if needed
-			if strings.Contains(unitLower, "mg/dl") || strings.Contains(unitLower, "mg/dL") {
-				serumCreatinine = &creatinine
-				creatinineMet = creatinine >= 1.5
-				break
-			} else if strings.Contains(unitLower, "umol/l") || strings.Contains(unitLower, "μmol/l") {
-				// Convert μmol/L to mg/dL (1 mg/dL = 88.4 μmol/L)
-				creatinineMgDl := creatinine / 88.4
-				serumCreatinine = &creatinineMgDl
-				creatinineMet = creatinineMgDl >= 1.5
-				break
-			}
-		}
+	if _, creatinine, ok := latestCreatinineMgDL(observations, []string{"creatinine"}); ok {
+		serumCreatinine = &creatinine
+		creatinineMet = creatinine >= 1.5
 	}
 
 	// Count how many conditions are met
@@ -938,7 +1531,7 @@ func (h *Handler) DetermineApixabanDose(patientID string) (interface{}, error) {
 	}, nil
 }
 
-func (h *Handler) GetMedicalGuidelines(query string) (interface{}, error) {
+func (h *Handler) GetMedicalGuidelines(ctx context.Context, query string) (interface{}, error) {
 	// Build a comprehensive prompt for medical guidelines and information
 	systemContext := `You are a medical information assistant providing evidence-based information about:
 - Clinical guidelines and best practices
@@ -957,9 +1550,32 @@ Important guidelines:
 
 	prompt := fmt.Sprintf("%s\n\nUser Query: %s", systemContext, query)
 
+	response, err := h.callGuidelinesModel(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add context information if available
+	if h.context.PatientID != "" {
+		response += fmt.Sprintf("\n\nNote: This information is general medical guidance. For patient-specific recommendations for Patient ID %s, please consult with the treating physician.", h.context.PatientID)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": response,
+			},
+		},
+	}, nil
+}
+
+// callGuidelinesModel sends a prompt to the more capable model used for
+// detailed clinical guidance and returns the raw completion text
+func (h *Handler) callGuidelinesModel(ctx context.Context, prompt string) (string, error) {
 	// Use OpenRouter with a more capable model for medical information
 	reqBody := map[string]interface{}{
-		"model": "google/gemini-2.5-flash", // Using a more capable model for medical info
+		"model": h.models.Guidelines,
 		"messages": []map[string]interface{}{
 			{
 				"role":    "system",
@@ -974,35 +1590,15 @@ Important guidelines:
 		"max_tokens":  1500, // Allow longer responses for detailed medical info
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+h.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("HTTP-Referer", "https://github.com/eythor/mcp-server")
-	req.Header.Set("X-Title", "Healthcare MCP Server")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := h.postOpenRouter(ctx, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var result struct {
@@ -1011,21 +1607,27 @@ Important guidelines:
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage tokenUsage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	h.recordUsage(reqBody, result.Usage)
 
 	if len(result.Choices) == 0 {
-		return nil, fmt.Errorf("no response from API")
+		return "", fmt.Errorf("no response from API")
 	}
 
-	response := result.Choices[0].Message.Content
+	return result.Choices[0].Message.Content, nil
+}
 
-	// Add context information if available
-	if h.context.PatientID != "" {
-		response += fmt.Sprintf("\n\nNote: This information is general medical guidance. For patient-specific recommendations for Patient ID %s, please consult with the treating physician.", h.context.PatientID)
+func (h *Handler) AnswerHealthQuestion(ctx context.Context, question string) (interface{}, error) {
+	prompt := fmt.Sprintf("As a healthcare information assistant, answer this health-related question accurately and helpfully. Be conversational and don't format responses for textual responses. Be succinct.  %s", question)
+
+	response, err := h.callOpenRouter(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer question: %w", err)
 	}
 
 	return map[string]interface{}{
@@ -1038,12 +1640,17 @@ Important guidelines:
 	}, nil
 }
 
-func (h *Handler) AnswerHealthQuestion(question string) (interface{}, error) {
-	prompt := fmt.Sprintf("As a healthcare information assistant, answer this health-related question accurately and helpfully. Be conversational and don't format responses for textual responses. Be succinct.  %s", question)
+// ProcessNaturalLanguageQuery answers a free-text query using the LLM and the
+// healthcare tools. A non-empty practitionerID identifies who is asking and
+// is used instead of the context practitioner for this query only. When forAudio is set the response is
+// cleaned up for text-to-speech (see cleanForSpeech). Cancelling ctx, e.g. when
+// the client disconnects, aborts the model request.
+func (h *Handler) ProcessNaturalLanguageQuery(ctx context.Context, query string, practitionerID string, forAudio bool) (interface{}, error) {
+	debug.Log("ProcessNaturalLanguageQuery called with query: '%s'", query)
 
-	response, err := h.callOpenRouter(prompt)
+	response, err := h.answerQuery(ctx, query, practitionerID, forAudio, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to answer question: %w", err)
+		return nil, err
 	}
 
 	return map[string]interface{}{
@@ -1056,27 +1663,47 @@ func (h *Handler) AnswerHealthQuestion(question string) (interface{}, error) {
 	}, nil
 }
 
-func (h *Handler) ProcessNaturalLanguageQuery(query string, practitionerID string) (interface{}, error) {
-	debug.Log("ProcessNaturalLanguageQuery called with query: '%s'", query)
+// answerQuery runs a natural language query through the model and tools. A
+// non-nil onToken receives the answer as it streams in.
+func (h *Handler) answerQuery(ctx context.Context, query string, practitionerID string, forAudio bool, onToken func(string)) (string, error) {
+	h.Touch()
+
+	if !h.acquireQuerySlot() {
+		debug.Error("Rejecting natural language query: concurrency limit reached")
+		return "", ErrTooManyQueries
+	}
+	defer h.releaseQuerySlot()
+
+	if practitionerID != "" {
+		practitionerExists, err := database.CheckPractitionerExists(h.db, practitionerID)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up practitioner: %w", err)
+		}
+		if !practitionerExists {
+			return "", fmt.Errorf("%w: %s", ErrUnknownPractitioner, practitionerID)
+		}
+		// Attribute this query only; the session's context is left alone so
+		// later queries without a practitioner aren't attributed to this one
+		attributed := *h
+		attributed.queryPractitionerID = practitionerID
+		h = &attributed
+	}
+
 	// Use function calling with OpenRouter to process natural language queries
-	response, err := h.callOpenRouterWithTools(query, practitionerID)
+	response, err := h.callOpenRouterWithTools(ctx, query, practitionerID, forAudio, onToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process query: %w", err)
+		return "", fmt.Errorf("failed to process query: %w", err)
 	}
 
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": response,
-			},
-		},
-	}, nil
+	if forAudio {
+		response = cleanForSpeech(response)
+	}
+
+	return response, nil
 }
 
-func (h *Handler) callOpenRouter(prompt string) (string, error) {
-	// TODO: Breaking on callOpenRouter
-	fmt.Errorf("callOpenRouter called with prompt: '%s'", prompt)
+func (h *Handler) callOpenRouter(ctx context.Context, prompt string) (string, error) {
+	debug.Verbose("callOpenRouter called with prompt: '%s'", prompt)
 
 	// Build system message with context
 	systemContent := "You are an expert physician consultant providing information to a healthcare practitioner. Be factual, succinct, and use appropriate medical terminology. Focus on clinically relevant information."
@@ -1088,7 +1715,7 @@ func (h *Handler) callOpenRouter(prompt string) (string, error) {
 	}
 
 	reqBody := map[string]interface{}{
-		"model": "meta-llama/llama-3.2-3b-instruct:free",
+		"model": h.models.Chat,
 		"messages": []map[string]string{
 			{
 				"role":    "system",
@@ -1103,23 +1730,7 @@ func (h *Handler) callOpenRouter(prompt string) (string, error) {
 		"max_tokens":  500,
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", err
-	}
-
-	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+h.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("HTTP-Referer", "https://github.com/eythor/mcp-server")
-	req.Header.Set("X-Title", "Healthcare MCP Server")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := h.postOpenRouter(ctx, reqBody)
 	if err != nil {
 		return "", err
 	}
@@ -1130,21 +1741,19 @@ func (h *Handler) callOpenRouter(prompt string) (string, error) {
 		return "", err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenRouter API error: %s", string(body))
-	}
-
 	var result struct {
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage tokenUsage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", err
 	}
+	h.recordUsage(reqBody, result.Usage)
 
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("no response from OpenRouter")
@@ -1153,35 +1762,21 @@ func (h *Handler) callOpenRouter(prompt string) (string, error) {
 	return result.Choices[0].Message.Content, nil
 }
 
-func (h *Handler) callOpenRouterWithTools(query string, practitionerID string) (string, error) {
+// llmTools returns the tools offered to the model. Arguments the context
+// supplies are left out of each tool's required list (see ContextRequired).
+func (h *Handler) llmTools() []map[string]interface{} {
 	// Get context info
 	h.mu.RLock()
 	hasPatientContext := h.context.PatientID != ""
-	// Assume the practitioner ID is passed in the function parameter
-	hasPractitionerContext := h.context.PractitionerID != ""
+	hasPractitionerContext := h.practitionerIDLocked() != ""
 	h.mu.RUnlock()
 
 	// Build required fields dynamically based on context
-	scheduleRequired := []string{"datetime"}
-	if !hasPatientContext {
-		scheduleRequired = append(scheduleRequired, "patient_id")
-	}
-	if !hasPractitionerContext {
-		scheduleRequired = append(scheduleRequired, "practitioner_id")
-	}
+	scheduleRequired := h.ContextRequired("datetime", "patient_id", "practitioner_id")
+	historyRequired := h.ContextRequired("patient_id")
+	ageRequired := h.ContextRequired("patient_id")
 
-	historyRequired := []string{}
-	if !hasPatientContext {
-		historyRequired = append(historyRequired, "patient_id")
-	}
-
-	ageRequired := []string{}
-	if !hasPatientContext {
-		ageRequired = append(ageRequired, "patient_id")
-	}
-
-	// Define available tools for the LLM
-	tools := []map[string]interface{}{
+	return []map[string]interface{}{
 		{
 			"type": "function",
 			"function": map[string]interface{}{
@@ -1298,6 +1893,14 @@ func (h *Handler) callOpenRouterWithTools(query string, practitionerID string) (
 							"description": "Category of history (conditions, medications, procedures, immunizations, allergies, observations, all)",
 							"enum":        []string{"conditions", "medications", "procedures", "immunizations", "allergies", "observations", "all"},
 						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum number of records per category (default 10)",
+						},
+						"offset": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of records to skip in each category; use it to see more when the output says there are more",
+						},
 					},
 					"required": historyRequired,
 				},
@@ -1331,7 +1934,7 @@ func (h *Handler) callOpenRouterWithTools(query string, practitionerID string) (
 						},
 						"datetime": map[string]interface{}{
 							"type":        "string",
-							"description": "Appointment date and time (ISO 8601 format)",
+							"description": "Appointment date and time, e.g. 2025-03-14T10:30:00+01:00, 14.03.2025 10:30 or \"tomorrow at 10:30\" (Europe/Berlin if no offset)",
 						},
 						"type": map[string]interface{}{
 							"type":        "string",
@@ -1416,7 +2019,7 @@ func (h *Handler) callOpenRouterWithTools(query string, practitionerID string) (
 						},
 						"effective_datetime": map[string]interface{}{
 							"type":        "string",
-							"description": "Date and time when observation was made (ISO 8601 format, defaults to now)",
+							"description": "Date and time when observation was made (ISO 8601 or e.g. 'yesterday at 10:00', defaults to now; not in the future)",
 						},
 						"value_quantity": map[string]interface{}{
 							"type":        "number",
@@ -1537,372 +2140,2208 @@ func (h *Handler) callOpenRouterWithTools(query string, practitionerID string) (
 				},
 			},
 		},
-	}
-
-	// Build system prompt with context information
-	systemPrompt := `You are an expert physician consultant providing support to a practitioner who is currently seeing a patient. You are highly knowledgeable, evidence-based, and provide factual, clinically relevant information.
-
-Key behaviors:
-• You are speaking to a healthcare practitioner (not the patient)
-• Be succinct and to-the-point - the practitioner needs quick, actionable information
-• Focus on clinical facts and evidence-based recommendations
-• Assume the practitioner has medical knowledge - use appropriate medical terminology
-• Keep responses to 2-4 sentences maximum (responses will be converted to audio)
-• When discussing the patient, refer to them as "the patient" or by name if known
-• Refer to yourself as VoiceMed if asked or it is relevant
-• Refer to me by my name which is available via practitioner information, if possible. Otherwise refer to me as 'you'
-• Provide specific, actionable guidance when possible`
-	
-	// Add specific guidance when we have patient context
-	contextInfo := h.GetContextInfo()
-	if strings.Contains(contextInfo, "Patient Medical Summary") {
-		systemPrompt += "\n\nThe patient's medical summary is available below. Consider their specific conditions, current medications, recent encounters, and allergies when providing recommendations. Be aware of potential drug interactions and contraindications based on their medical history."
-	}
-	if strings.Contains(contextInfo, "Practitioner Information") {
-		systemPrompt += "\n\nAddress the practitioner by their name when appropriate, using the practitioner information provided below."
-	}
-	systemPrompt += contextInfo
-
-	reqBody := map[string]interface{}{
-		"model": "google/gemini-2.5-flash",
-		"messages": []map[string]interface{}{
-			{
-				"role":    "system",
-				"content": systemPrompt,
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_ibw",
+				"description": "Estimate ideal body weight (Devine formula) and adjusted body weight from the patient's latest height, weight, and gender. Use for weight-based dosing in obese patients." + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
 			},
-			{
-				"role":    "user",
-				"content": query,
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "check_contraindications",
+				"description": "Check the patient's active medications against their active conditions for contraindicated drug-disease pairs" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"include_ai_review": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Also ask the guidelines model to review for additional contraindications",
+						},
+					},
+					"required": historyRequired,
+				},
 			},
 		},
-		"tools":       tools,
-		"tool_choice": "auto",
-		"temperature": 0.3,
-		"max_tokens":  1000,
-	}
-
-	// return log.Printf("Sending request to google/gemini-2.5-flash")
-	response, err := h.executeToolLoop(reqBody, query, practitionerID)
-	if err == nil && response != "" {
-		h.SetLastResponse(response)
-	}
-	return response, err
-}
-
-func (h *Handler) executeToolLoop(reqBody map[string]interface{}, originalQuery string, practitionerID string) (string, error) {
-	maxIterations := 5
-	messages := reqBody["messages"].([]map[string]interface{})
-	debug.Verbose("Starting tool execution loop for query: '%s'", originalQuery)
-
-	for i := 0; i < maxIterations; i++ {
-		// Update messages in request
-		reqBody["messages"] = messages
-
-		jsonBody, err := json.Marshal(reqBody)
-		if err != nil {
-			return "", fmt.Errorf("failed to marshal request: %w", err)
-		}
-
-		req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonBody))
-		if err != nil {
-			return "", fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("Authorization", "Bearer "+h.apiKey)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("HTTP-Referer", "https://github.com/eythor/mcp-server")
-		req.Header.Set("X-Title", "Healthcare MCP Server")
-
-		client := &http.Client{Timeout: 60 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			return "", fmt.Errorf("request failed: %w", err)
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("failed to read response: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("OpenRouter API error (%d): %s", resp.StatusCode, string(body))
-		}
-
-		var result struct {
-			Choices []struct {
-				Message struct {
-					Role      string `json:"role"`
-					Content   string `json:"content,omitempty"`
-					ToolCalls []struct {
-						ID       string `json:"id"`
-						Type     string `json:"type"`
-						Function struct {
-							Name      string `json:"name"`
-							Arguments string `json:"arguments"`
-						} `json:"function"`
-					} `json:"tool_calls,omitempty"`
-				} `json:"message"`
-			} `json:"choices"`
-		}
-
-		if err := json.Unmarshal(body, &result); err != nil {
-			return "", fmt.Errorf("failed to unmarshal response: %w", err)
-		}
-
-		if len(result.Choices) == 0 {
-			return "", fmt.Errorf("no response from OpenRouter")
-		}
-
-		message := result.Choices[0].Message
-
-		// Add assistant message to conversation
-		messages = append(messages, map[string]interface{}{
-			"role":       message.Role,
-			"content":    message.Content,
-			"tool_calls": message.ToolCalls,
-		})
-
-		// If no tool calls, return the content
-		if len(message.ToolCalls) == 0 {
-			return message.Content, nil
-		}
-
-		// Execute tool calls
-		for _, toolCall := range message.ToolCalls {
-			result, err := h.executeTool(toolCall.Function.Name, toolCall.Function.Arguments, practitionerID)
-			if err != nil {
-				result = fmt.Sprintf("Error executing %s: %v", toolCall.Function.Name, err)
-			}
-
-			// Add tool result to conversation
-			messages = append(messages, map[string]interface{}{
-				"role":         "tool",
-				"tool_call_id": toolCall.ID,
-				"content":      result,
-			})
-		}
-	}
-
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_patient_fhir",
+				"description": "Get the patient's demographics as a FHIR R4 Patient resource in JSON, for interoperability with other systems" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "add_contact_point",
+				"description": "Add a phone number, email address or other contact point for a patient, e.g. a mobile or work number" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"system": map[string]interface{}{
+							"type":        "string",
+							"description": "Contact system: phone, email, sms, fax, pager, url or other (default: phone)",
+						},
+						"value": map[string]interface{}{
+							"type":        "string",
+							"description": "Phone number, email address, etc.",
+						},
+						"use": map[string]interface{}{
+							"type":        "string",
+							"description": "Purpose of the contact point: home, work, mobile, temp or old",
+						},
+					},
+					"required": append([]string{"value"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "list_contact_points",
+				"description": "List all phone numbers, email addresses and other contact points for a patient" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_screening_recommendations",
+				"description": "List routine screenings the patient is due or overdue for (e.g. mammography, colonoscopy, HbA1c), based on age, gender and their procedure and observation history" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "explain_observation_trend",
+				"description": "Explain whether a lab result or vital sign (e.g. HbA1c, blood pressure, eGFR) is improving or worsening over time and what it means clinically" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"code": map[string]interface{}{
+							"type":        "string",
+							"description": "Observation code (e.g. LOINC 4548-4) or name (e.g. 'hemoglobin a1c')",
+						},
+					},
+					"required": append([]string{"code"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_vaccination_card",
+				"description": "Get the patient's vaccination record card listing each vaccine dose with date and status in chronological order" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "diff_patient_summary",
+				"description": "Report what is new in the patient's record (conditions, medications, observations, encounters, etc.) since a given date, or since the patient was selected if no date is given" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"since": map[string]interface{}{
+							"type":        "string",
+							"description": "Date or datetime to compare against, e.g. 2024-01-01 (defaults to when the patient was selected)",
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "complete_encounter",
+				"description": "Mark an encounter/appointment as finished after the visit and record its outcome",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"encounter_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Encounter/Appointment ID to complete",
+						},
+						"outcome": map[string]interface{}{
+							"type":        "string",
+							"description": "Outcome of the visit, e.g. 'Follow-up in 3 months, labs ordered'",
+						},
+						"actual_start": map[string]interface{}{
+							"type":        "string",
+							"description": "When the visit actually started, if different from the scheduled time (optional)",
+						},
+					},
+					"required": []string{"encounter_id"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "suggest_next_actions",
+				"description": "Suggest prioritized next actions for the patient, combining contraindications, abnormal results, polypharmacy, overdue immunizations, due screenings and upcoming appointments" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"include_ai_review": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Also ask the guidelines model to refine the prioritization",
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_allergy_banner",
+				"description": "Get a short alert-banner line of the patient's high-criticality allergies (e.g. for a wristband), or NKDA when none" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_meld",
+				"description": "Calculate the patient's MELD score (liver disease severity) from their latest bilirubin, creatinine and INR lab results" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_surgical_history",
+				"description": "Get the patient's surgical history (operations only, oldest first)" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "list_appointments",
+				"description": "List the patient's appointments (encounters) with type, practitioner, start time and status. Optionally filter by status such as planned, finished or cancelled" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"status": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional status filter (planned, finished, cancelled)",
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "get_claims_aging",
+				"description": "Get a billing aging report of all claims grouped by days since creation (0-30, 31-60, 61-90, 90+) with totals per group",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Number of oldest claims to list individually (default 10)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "generate_sbar",
+				"description": "Generate an SBAR (Situation, Background, Assessment, Recommendation) handoff summary for shift change" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "confirm_date_choice",
+				"description": "Confirm which option (e.g. A or B) the user chose after being asked about an ambiguous appointment date",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"choice": map[string]interface{}{
+							"type":        "string",
+							"description": "The chosen option key, e.g. A or B",
+						},
+					},
+					"required": []string{"choice"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "cancel_appointment",
+				"description": "Cancel an existing appointment by its encounter/appointment ID, optionally recording why (e.g. no-show, patient request)",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"encounter_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Encounter/Appointment ID",
+						},
+						"reason": map[string]interface{}{
+							"type":        "string",
+							"description": "Why the appointment is cancelled (optional), e.g. 'no-show'",
+						},
+					},
+					"required": []string{"encounter_id"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_encounters",
+				"description": "Get the patient's full visit history (all encounters) with type, practitioner, start/end times and status" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_care_team",
+				"description": "List the patient's care team: the practitioners they have had encounters with and how many encounters with each" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_visit_punctuality",
+				"description": "Report how late or early the patient's visits started compared to their scheduled times" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "mark_patient_arrived",
+				"description": "Record that the patient has arrived for an appointment",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"encounter_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Encounter/Appointment ID",
+						},
+						"arrived_at": map[string]interface{}{
+							"type":        "string",
+							"description": "Arrival time (optional, defaults to now)",
+						},
+					},
+					"required": []string{"encounter_id"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "find_patients_on_medication",
+				"description": "Find all patients currently on a medication (active prescriptions), e.g. 'which patients are on this recalled drug?'",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"medication": map[string]interface{}{
+							"type":        "string",
+							"description": "Medication name or part of it",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": "Maximum number of patients to list (default 50)",
+						},
+					},
+					"required": []string{"medication"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_bmi",
+				"description": "Calculate the patient's BMI and weight category from the latest weight and height" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "record_gcs",
+				"description": "Record a Glasgow Coma Scale assessment from its eye, verbal and motor scores" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"eye": map[string]interface{}{
+							"type":        "integer",
+							"description": "Eye opening response (1-4)",
+						},
+						"verbal": map[string]interface{}{
+							"type":        "integer",
+							"description": "Verbal response (1-5)",
+						},
+						"motor": map[string]interface{}{
+							"type":        "integer",
+							"description": "Motor response (1-6)",
+						},
+					},
+					"required": append([]string{"eye", "verbal", "motor"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_egfr",
+				"description": "Estimate the patient's eGFR and CKD stage from the latest serum creatinine, e.g. for renal dosing" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_corrected_sodium",
+				"description": "Calculate the patient's sodium corrected for high blood glucose (adds 1.6 mEq/L per 100 mg/dL glucose above 100)" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "get_medication_forms",
+				"description": "List the available formulations (strengths and forms) of a medication, e.g. to ask which one is meant before prescribing",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"medication_name": map[string]interface{}{
+							"type":        "string",
+							"description": "Medication name or part of it",
+						},
+					},
+					"required": []string{"medication_name"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "add_condition",
+				"description": "Record a diagnosis (condition) for a patient" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"code": map[string]interface{}{
+							"type":        "string",
+							"description": "Condition code (e.g. SNOMED CT), if known",
+						},
+						"display": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the condition, e.g. 'Essential hypertension'",
+						},
+						"clinical_status": map[string]interface{}{
+							"type":        "string",
+							"description": "Clinical status: active, recurrence, relapse, inactive, remission or resolved (default: active)",
+						},
+						"onset_datetime": map[string]interface{}{
+							"type":        "string",
+							"description": "When the condition started, e.g. 2024-03-14 (optional)",
+						},
+					},
+					"required": append([]string{"display"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "add_medication",
+				"description": "Prescribe a medication for a patient. If several formulations exist, confirm which one with get_medication_forms first" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"medication": map[string]interface{}{
+							"type":        "string",
+							"description": "Medication and formulation, e.g. 'Metformin hydrochloride 500 MG Oral Tablet'",
+						},
+						"dosage": map[string]interface{}{
+							"type":        "string",
+							"description": "Dosage instructions, e.g. '1 tablet twice daily with meals'",
+						},
+						"status": map[string]interface{}{
+							"type":        "string",
+							"description": "Prescription status (default: active)",
+						},
+					},
+					"required": append([]string{"medication"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_ranked_problem_list",
+				"description": "List the patient's active problems (conditions) ranked by severity, most serious first" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_growth_percentile",
+				"description": "Get growth-chart percentiles for weight and length of a young child (under 2 years)" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "audit_patient_data",
+				"description": "Check a patient's records for data inconsistencies and list the issues found" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_anion_gap",
+				"description": "Calculate the anion gap (Na - Cl - HCO3) from the patient's latest electrolytes, corrected for albumin if available" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_frequent_findings",
+				"description": "List a patient's most frequent diagnoses and encounter types with counts, to spot recurring problems" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_patient_summary",
+				"description": "Get a full clinical overview of a patient (demographics, active conditions, current medications, allergies, recent observations and encounters, totals) in one call" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "reconcile_medications",
+				"description": "Medication reconciliation: list which of the patient's medications were started, stopped or continued since a given date (e.g. admission or last visit)" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"since": map[string]interface{}{
+							"type":        "string",
+							"description": "Date or datetime to reconcile against, e.g. the admission date 2024-01-01",
+						},
+					},
+					"required": append([]string{"since"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "check_drug_interactions",
+				"description": "Check the patient's current medications for clinically significant drug-drug interactions, with severity and management" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_wells_score",
+				"description": "Calculate the Wells score for PE or DVT and the risk category. Items not in the record (e.g. leg swelling, PE most likely diagnosis) can be given as true/false criteria" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"score_type": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"PE", "DVT"},
+							"description": "PE for pulmonary embolism or DVT for deep vein thrombosis",
+						},
+						"criteria": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "boolean"},
+							"description":          "Clinician-assessed criteria as true/false, overriding the record. PE: dvt_signs, pe_most_likely, heart_rate_over_100, immobilization_or_surgery, previous_dvt_pe, hemoptysis, malignancy. DVT: active_cancer, bedridden_or_surgery, calf_swelling, collateral_veins, entire_leg_swollen, localized_tenderness, pitting_edema, paralysis_or_cast, previous_dvt, alternative_diagnosis_likely",
+						},
+					},
+					"required": append([]string{"score_type"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_observation_trend",
+				"description": "Show the chronological history of a measurement (by LOINC code or name, e.g. 'weight', 'blood pressure') with values, units and dates, plus whether it is rising, falling or stable" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"code": map[string]interface{}{
+							"type":        "string",
+							"description": "Observation code (e.g. LOINC 29463-7) or name (e.g. 'weight', 'blood pressure')",
+						},
+					},
+					"required": append([]string{"code"}, historyRequired...),
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        "get_day_schedule",
+				"description": "List everyone scheduled on a day across all practitioners, grouped by practitioner with patient names and times, e.g. 'who is coming in tomorrow?'",
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"date": map[string]interface{}{
+							"type":        "string",
+							"description": "Day to list, e.g. '2025-03-14', '14.03.2025', 'today' or 'tomorrow'",
+						},
+					},
+					"required": []string{"date"},
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_charlson",
+				"description": "Calculate the patient's age-adjusted Charlson Comorbidity Index and estimated 10-year survival from their conditions" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "get_panel_result",
+				"description": "Show an observation panel (related results taken together, e.g. lipid panel, vital signs) as one block; the most recent panel, or those on a given date" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+						"date": map[string]interface{}{
+							"type":        "string",
+							"description": "Day the panel was taken (optional, defaults to the most recent panel)",
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+		{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name": "calculate_maintenance_fluids",
+				"description": "Calculate the patient's maintenance IV fluid rate and daily volume from the latest weight (4-2-1 rule)" + func() string {
+					if hasPatientContext {
+						return " (uses current patient if not specified)"
+					}
+					return ""
+				}(),
+				"parameters": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patient_id": map[string]interface{}{
+							"type": "string",
+							"description": "Patient ID" + func() string {
+								if hasPatientContext {
+									return " (optional, uses context if not provided)"
+								}
+								return ""
+							}(),
+						},
+					},
+					"required": historyRequired,
+				},
+			},
+		},
+	}
+}
+
+// ToolRequiredFields returns the required arguments of the named tool as
+// offered to the model for the current context, and whether the model is
+// offered the tool at all
+func (h *Handler) ToolRequiredFields(name string) ([]string, bool) {
+	for _, tool := range h.llmTools() {
+		function := tool["function"].(map[string]interface{})
+		if function["name"] != name {
+			continue
+		}
+		parameters, _ := function["parameters"].(map[string]interface{})
+		required, _ := parameters["required"].([]string)
+		return required, true
+	}
+	return nil, false
+}
+
+func (h *Handler) callOpenRouterWithTools(ctx context.Context, query string, practitionerID string, forAudio bool, onToken func(string)) (string, error) {
+	// Define available tools for the LLM
+	tools := h.llmTools()
+
+	// Build system prompt with context information
+	systemPrompt := `You are an expert physician consultant providing support to a practitioner who is currently seeing a patient. You are highly knowledgeable, evidence-based, and provide factual, clinically relevant information.
+
+Key behaviors:
+• You are speaking to a healthcare practitioner (not the patient)
+• Be succinct and to-the-point - the practitioner needs quick, actionable information
+• Focus on clinical facts and evidence-based recommendations
+• Assume the practitioner has medical knowledge - use appropriate medical terminology
+• Keep responses to 2-4 sentences maximum (responses will be converted to audio)
+• When discussing the patient, refer to them as "the patient" or by name if known
+• Refer to yourself as VoiceMed if asked or it is relevant
+• Refer to me by my name which is available via practitioner information, if possible. Otherwise refer to me as 'you'
+• Provide specific, actionable guidance when possible`
+	
+	// Add specific guidance when we have patient context
+	contextInfo := h.GetContextInfo()
+	if strings.Contains(contextInfo, "Patient Medical Summary") {
+		systemPrompt += "\n\nThe patient's medical summary is available below. Consider their specific conditions, current medications, recent encounters, and allergies when providing recommendations. Be aware of potential drug interactions and contraindications based on their medical history."
+	}
+	if strings.Contains(contextInfo, "Practitioner Information") {
+		systemPrompt += "\n\nAddress the practitioner by their name when appropriate, using the practitioner information provided below."
+	}
+	systemPrompt += contextInfo
+
+	reqBody := map[string]interface{}{
+		"model": h.models.Tools,
+		"messages": []map[string]interface{}{
+			{
+				"role":    "system",
+				"content": systemPrompt,
+			},
+			{
+				"role":    "user",
+				"content": query,
+			},
+		},
+		"tools":       tools,
+		"tool_choice": "auto",
+		"temperature": 0.3,
+		"max_tokens":  1000,
+	}
+	if forAudio && len(h.audioStopSequences) > 0 {
+		reqBody["stop"] = h.audioStopSequences
+	}
+
+	// return log.Printf("Sending request to google/gemini-2.5-flash")
+	response, err := h.executeToolLoop(ctx, reqBody, query, practitionerID, onToken)
+	if err == nil && response != "" {
+		h.SetLastResponse(response)
+	}
+	return response, err
+}
+
+// executeToolLoop sends reqBody to the model, runs the tools it calls and
+// repeats until it answers. With a non-nil onToken the completions are
+// streamed and their content is passed to onToken as it arrives.
+func (h *Handler) executeToolLoop(ctx context.Context, reqBody map[string]interface{}, originalQuery string, practitionerID string, onToken func(string)) (string, error) {
+	maxIterations := 5
+	messages := reqBody["messages"].([]map[string]interface{})
+	debug.Verbose("Starting tool execution loop for query: '%s'", originalQuery)
+	if onToken != nil {
+		reqBody["stream"] = true
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		// Update messages in request
+		reqBody["messages"] = messages
+
+		resp, err := h.postOpenRouter(ctx, reqBody)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		var message chatMessage
+		if onToken != nil {
+			var usage tokenUsage
+			message, usage, err = readCompletionStream(resp.Body, onToken)
+			if err != nil {
+				return "", err
+			}
+			h.recordUsage(reqBody, usage)
+		} else {
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return "", fmt.Errorf("failed to read response: %w", err)
+			}
+
+			var result struct {
+				Choices []struct {
+					Message chatMessage `json:"message"`
+				} `json:"choices"`
+				Usage tokenUsage `json:"usage"`
+			}
+
+			if err := json.Unmarshal(body, &result); err != nil {
+				return "", fmt.Errorf("failed to unmarshal response: %w", err)
+			}
+			h.recordUsage(reqBody, result.Usage)
+
+			if len(result.Choices) == 0 {
+				return "", fmt.Errorf("no response from OpenRouter")
+			}
+			message = result.Choices[0].Message
+		}
+
+		// Add assistant message to conversation
+		messages = append(messages, map[string]interface{}{
+			"role":       message.Role,
+			"content":    message.Content,
+			"tool_calls": message.ToolCalls,
+		})
+
+		// If no tool calls, return the content
+		if len(message.ToolCalls) == 0 {
+			return message.Content, nil
+		}
+
+		// Execute tool calls
+		for _, toolCall := range message.ToolCalls {
+			result, err := h.executeTool(ctx, toolCall.Function.Name, toolCall.Function.Arguments, practitionerID)
+			if err != nil {
+				result = fmt.Sprintf("Error executing %s: %v", toolCall.Function.Name, err)
+			} else {
+				h.RecordToolAudit(toolCall.Function.Name, []byte(toolCall.Function.Arguments))
+			}
+
+			// Add tool result to conversation
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": toolCall.ID,
+				"content":      result,
+			})
+		}
+	}
+
 	return "I apologize, but I wasn't able to complete your request after multiple attempts.", nil
 }
 
-func (h *Handler) executeTool(toolName, argumentsJSON string, defaultPractitionerID string) (string, error) {
-	debug.Log("Executing tool: %s", toolName)
-	debug.Trace("Tool arguments: %s", argumentsJSON)
-	
-	var args map[string]interface{}
-	if err := json.Unmarshal([]byte(argumentsJSON), &args); err != nil {
-		return "", fmt.Errorf("failed to parse arguments: %w", err)
-	}
+func (h *Handler) executeTool(ctx context.Context, toolName, argumentsJSON string, defaultPractitionerID string) (text string, err error) {
+	debug.Log("Executing tool: %s", toolName)
+	debug.Trace("Tool arguments: %s", argumentsJSON)
+
+	// Results go back to the model
+	modelHandler := *h
+	modelHandler.forModel = true
+	h = &modelHandler
+	
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(argumentsJSON), &args); err != nil {
+		// Models sometimes wrap arguments in code fences or leave trailing
+		// commas; try to repair them before giving up
+		repaired := repairJSON(argumentsJSON)
+		if repaired == argumentsJSON || json.Unmarshal([]byte(repaired), &args) != nil {
+			return "", fmt.Errorf("failed to parse arguments: %w", err)
+		}
+		debug.Log("Repaired malformed arguments for tool %s", toolName)
+	}
+
+	if message, blocked := h.DemoModeBlocks(toolName); blocked {
+		return message, nil
+	}
+	patientArg, _ := args["patient_id"].(string)
+	if message, blocked := h.AIConsentBlocks(toolName, patientArg); blocked {
+		return message, nil
+	}
+	if message, blocked := h.AIRateLimitBlocks(toolName, patientArg); blocked {
+		return message, nil
+	}
+	// Tool results go back to the model, so a non-consenting patient's
+	// records are withheld from them
+	defer func() {
+		if err == nil {
+			text = h.withholdWithoutConsent(toolName, patientArg, text)
+		}
+	}()
+
+	switch toolName {
+	case "set_patient_context":
+		patientID, ok := args["patient_id"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid patient_id parameter")
+		}
+		result, err := h.SetPatientContext(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "set_practitioner_context":
+		// NOTE: Keep the practitioner ID hardcoded to avoid mistakes during demo
+		practitionerID := "5df7a318-69e4-3ed2-a046-bad7b3e321b5";
+
+		result, err := h.SetPractitionerContext(practitionerID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_context":
+		result, err := h.GetContext()
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "clear_context":
+		result, err := h.ClearContext()
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "lookup_patient":
+		query, ok := args["query"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid query parameter")
+		}
+		debug.Log("lookup_patient called with query: '%s'", query)
+		result, err := h.LookupPatient(query)
+		if err != nil {
+			debug.Error("lookup_patient error: %v", err)
+			return "", err
+		}
+		textResult := h.ExtractTextFromMCPResult(result)
+		debug.Verbose("lookup_patient result: '%s'", textResult)
+		return textResult, nil
+
+	case "get_practitioner":
+		practitionerID, _ := args["practitioner_id"].(string)
+		debug.Log("get_practitioner called with ID: '%s'", practitionerID)
+		result, err := h.GetPractitioner(practitionerID)
+		if err != nil {
+			debug.Error("get_practitioner error: %v", err)
+			return "", err
+		}
+		textResult := h.ExtractTextFromMCPResult(result)
+		debug.Verbose("get_practitioner result: '%s'", textResult)
+		return textResult, nil
+
+	case "get_medical_history":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		category := "all"
+		if cat, exists := args["category"].(string); exists {
+			category = cat
+		}
+		limit, offset := 0, 0
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		if o, ok := args["offset"].(float64); ok {
+			offset = int(o)
+		}
+		result, err := h.GetMedicalHistory(patientID, category, limit, offset)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "schedule_appointment":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		practitionerID := ""
+		if prid, exists := args["practitioner_id"].(string); exists {
+			practitionerID = prid
+		}
+		datetime, ok := args["datetime"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid datetime parameter")
+		}
+		appointmentType := ""
+		if t, exists := args["type"].(string); exists {
+			appointmentType = t
+		}
+		result, err := h.ScheduleAppointment(patientID, practitionerID, datetime, appointmentType)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_medication_info":
+		medicationName, ok := args["medication_name"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid medication_name parameter")
+		}
+		result, err := h.GetMedicationInfo(ctx, medicationName)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_claims":
+		patientID, ok := args["patient_id"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid patient_id parameter")
+		}
+		result, err := h.GetClaims(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "add_observation":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		// For simplicity, we use a fixed code and display for demo
+		code := "voicemed-observation"
+		display := "VoiceMed Observation"
+
+		category := ""
+		if cat, exists := args["category"].(string); exists {
+			category = cat
+		}
+		status := ""
+		if st, exists := args["status"].(string); exists {
+			status = st
+		}
+		effectiveDateTime := ""
+		if edt, exists := args["effective_datetime"].(string); exists {
+			effectiveDateTime = edt
+		}
+		var valueQuantity *float64
+		if vq, exists := args["value_quantity"]; exists {
+			if vqFloat, ok := vq.(float64); ok {
+				valueQuantity = &vqFloat
+			}
+		}
+		var valueUnit *string
+		if vu, exists := args["value_unit"].(string); exists {
+			valueUnit = &vu
+		}
+		var valueString *string
+		if vs, exists := args["value_string"].(string); exists {
+			valueString = &vs
+		}
+		result, err := h.AddObservation(patientID, code, display, category, status, effectiveDateTime, valueQuantity, valueUnit, valueString)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_age":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CalculateAge(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "update_patient_birth_date":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		birthDate, ok := args["birth_date"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid birth_date parameter")
+		}
+		result, err := h.UpdatePatientBirthDate(patientID, birthDate)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_medical_guidelines":
+		query, ok := args["query"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid query parameter")
+		}
+		result, err := h.GetMedicalGuidelines(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "determine_apixaban_dose":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.DetermineApixabanDose(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_ibw":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CalculateIdealBodyWeight(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "check_contraindications":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		includeAIReview, _ := args["include_ai_review"].(bool)
+		result, err := h.CheckContraindications(ctx, patientID, includeAIReview)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_patient_fhir":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetPatientFHIR(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "add_contact_point":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		system, _ := args["system"].(string)
+		value, _ := args["value"].(string)
+		use, _ := args["use"].(string)
+		result, err := h.AddContactPoint(patientID, system, value, use)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "list_contact_points":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.ListContactPoints(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_screening_recommendations":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetScreeningRecommendations(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "explain_observation_trend":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		code, _ := args["code"].(string)
+		result, err := h.ExplainObservationTrend(ctx, patientID, code)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_vaccination_card":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetVaccinationCard(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "diff_patient_summary":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		since, _ := args["since"].(string)
+		result, err := h.DiffPatientSummary(patientID, since)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "complete_encounter":
+		encounterID, ok := args["encounter_id"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid encounter_id parameter")
+		}
+		outcome, _ := args["outcome"].(string)
+		actualStart, _ := args["actual_start"].(string)
+		result, err := h.CompleteEncounter(encounterID, outcome, actualStart)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "suggest_next_actions":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		includeAIReview, _ := args["include_ai_review"].(bool)
+		result, err := h.SuggestNextActions(ctx, patientID, includeAIReview)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_allergy_banner":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetAllergyBanner(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_meld":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CalculateMELD(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_surgical_history":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetSurgicalHistory(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "list_appointments":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		status, _ := args["status"].(string)
+		result, err := h.ListAppointments(patientID, status)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_claims_aging":
+		limit := 0
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		result, err := h.GetClaimsAging(limit)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "generate_sbar":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GenerateSBAR(ctx, patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
 
-	switch toolName {
-	case "set_patient_context":
-		patientID, ok := args["patient_id"].(string)
+	case "confirm_date_choice":
+		choice, ok := args["choice"].(string)
 		if !ok {
-			return "", fmt.Errorf("invalid patient_id parameter")
+			return "", fmt.Errorf("invalid choice parameter")
 		}
-		result, err := h.SetPatientContext(patientID)
+		result, err := h.ConfirmDateChoice(choice)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "set_practitioner_context":
-		// NOTE: Keep the practitioner ID hardcoded to avoid mistakes during demo
-		practitionerID := "5df7a318-69e4-3ed2-a046-bad7b3e321b5";
+	case "cancel_appointment":
+		encounterID, ok := args["encounter_id"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid encounter_id parameter")
+		}
+		reason, _ := args["reason"].(string)
+		result, err := h.CancelAppointment(encounterID, reason)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
 
-		result, err := h.SetPractitionerContext(practitionerID)
+	case "get_encounters":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetEncounters(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "get_context":
-		result, err := h.GetContext()
+	case "get_care_team":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetCareTeam(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "clear_context":
-		result, err := h.ClearContext()
+	case "get_visit_punctuality":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.GetVisitPunctuality(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "lookup_patient":
-		query, ok := args["query"].(string)
+	case "mark_patient_arrived":
+		encounterID, ok := args["encounter_id"].(string)
 		if !ok {
-			return "", fmt.Errorf("invalid query parameter")
+			return "", fmt.Errorf("invalid encounter_id parameter")
 		}
-		debug.Log("lookup_patient called with query: '%s'", query)
-		result, err := h.LookupPatient(query)
+		arrivedAt, _ := args["arrived_at"].(string)
+		result, err := h.MarkPatientArrived(encounterID, arrivedAt)
 		if err != nil {
-			debug.Error("lookup_patient error: %v", err)
 			return "", err
 		}
-		textResult := h.ExtractTextFromMCPResult(result)
-		debug.Verbose("lookup_patient result: '%s'", textResult)
-		return textResult, nil
+		return h.ExtractTextFromMCPResult(result), nil
 
-	case "get_practitioner":
-		practitionerID, _ := args["practitioner_id"].(string)
-		debug.Log("get_practitioner called with ID: '%s'", practitionerID)
-		result, err := h.GetPractitioner(practitionerID)
+	case "find_patients_on_medication":
+		medication, ok := args["medication"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid medication parameter")
+		}
+		limit := 0
+		if l, ok := args["limit"].(float64); ok {
+			limit = int(l)
+		}
+		result, err := h.FindPatientsOnMedication(medication, limit)
 		if err != nil {
-			debug.Error("get_practitioner error: %v", err)
 			return "", err
 		}
-		textResult := h.ExtractTextFromMCPResult(result)
-		debug.Verbose("get_practitioner result: '%s'", textResult)
-		return textResult, nil
+		return h.ExtractTextFromMCPResult(result), nil
 
-	case "get_medical_history":
+	case "calculate_bmi":
 		patientID := ""
 		if pid, exists := args["patient_id"].(string); exists {
 			patientID = pid
 		}
-		category := "all"
-		if cat, exists := args["category"].(string); exists {
-			category = cat
-		}
-		result, err := h.GetMedicalHistory(patientID, category)
+		result, err := h.CalculateBMI(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "schedule_appointment":
+	case "record_gcs":
 		patientID := ""
 		if pid, exists := args["patient_id"].(string); exists {
 			patientID = pid
 		}
-		practitionerID := ""
-		if prid, exists := args["practitioner_id"].(string); exists {
-			practitionerID = prid
+		eye, _ := args["eye"].(float64)
+		verbal, _ := args["verbal"].(float64)
+		motor, _ := args["motor"].(float64)
+		result, err := h.RecordGCS(patientID, int(eye), int(verbal), int(motor))
+		if err != nil {
+			return "", err
 		}
-		datetime, ok := args["datetime"].(string)
-		if !ok {
-			return "", fmt.Errorf("invalid datetime parameter")
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_egfr":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
 		}
-		appointmentType := ""
-		if t, exists := args["type"].(string); exists {
-			appointmentType = t
+		result, err := h.CalculateEGFR(patientID)
+		if err != nil {
+			return "", err
 		}
-		result, err := h.ScheduleAppointment(patientID, practitionerID, datetime, appointmentType)
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_corrected_sodium":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CalculateCorrectedSodium(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "get_medication_info":
+	case "get_medication_forms":
 		medicationName, ok := args["medication_name"].(string)
 		if !ok {
 			return "", fmt.Errorf("invalid medication_name parameter")
 		}
-		result, err := h.GetMedicationInfo(medicationName)
+		result, err := h.GetMedicationForms(medicationName)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "get_claims":
-		patientID, ok := args["patient_id"].(string)
+	case "add_condition":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		display, ok := args["display"].(string)
 		if !ok {
-			return "", fmt.Errorf("invalid patient_id parameter")
+			return "", fmt.Errorf("invalid display parameter")
 		}
-		result, err := h.GetClaims(patientID)
+		code, _ := args["code"].(string)
+		clinicalStatus, _ := args["clinical_status"].(string)
+		onsetDateTime, _ := args["onset_datetime"].(string)
+		result, err := h.AddCondition(patientID, code, display, clinicalStatus, onsetDateTime)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "add_observation":
+	case "add_medication":
 		patientID := ""
 		if pid, exists := args["patient_id"].(string); exists {
 			patientID = pid
 		}
-		// For simplicity, we use a fixed code and display for demo
-		code := "voicemed-observation"
-		display := "VoiceMed Observation"
+		medication, ok := args["medication"].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid medication parameter")
+		}
+		dosage, _ := args["dosage"].(string)
+		status, _ := args["status"].(string)
+		result, err := h.AddMedication(patientID, medication, dosage, status)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
 
-		category := ""
-		if cat, exists := args["category"].(string); exists {
-			category = cat
+	case "get_ranked_problem_list":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
 		}
-		status := ""
-		if st, exists := args["status"].(string); exists {
-			status = st
+		result, err := h.GetRankedProblemList(ctx, patientID)
+		if err != nil {
+			return "", err
 		}
-		effectiveDateTime := ""
-		if edt, exists := args["effective_datetime"].(string); exists {
-			effectiveDateTime = edt
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_growth_percentile":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
 		}
-		var valueQuantity *float64
-		if vq, exists := args["value_quantity"]; exists {
-			if vqFloat, ok := vq.(float64); ok {
-				valueQuantity = &vqFloat
-			}
+		result, err := h.GetGrowthPercentile(patientID)
+		if err != nil {
+			return "", err
 		}
-		var valueUnit *string
-		if vu, exists := args["value_unit"].(string); exists {
-			valueUnit = &vu
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "audit_patient_data":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
 		}
-		var valueString *string
-		if vs, exists := args["value_string"].(string); exists {
-			valueString = &vs
+		result, err := h.AuditPatientData(patientID)
+		if err != nil {
+			return "", err
 		}
-		result, err := h.AddObservation(patientID, code, display, category, status, effectiveDateTime, valueQuantity, valueUnit, valueString)
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_anion_gap":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CalculateAnionGap(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "calculate_age":
+	case "get_frequent_findings":
 		patientID := ""
 		if pid, exists := args["patient_id"].(string); exists {
 			patientID = pid
 		}
-		result, err := h.CalculateAge(patientID)
+		result, err := h.GetFrequentFindings(patientID)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "update_patient_birth_date":
+	case "get_patient_summary":
 		patientID := ""
 		if pid, exists := args["patient_id"].(string); exists {
 			patientID = pid
 		}
-		birthDate, ok := args["birth_date"].(string)
-		if !ok {
-			return "", fmt.Errorf("invalid birth_date parameter")
+		result, err := h.GetPatientSummary(patientID)
+		if err != nil {
+			return "", err
 		}
-		result, err := h.UpdatePatientBirthDate(patientID, birthDate)
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "reconcile_medications":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		since, _ := args["since"].(string)
+		result, err := h.ReconcileMedications(patientID, since)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "get_medical_guidelines":
-		query, ok := args["query"].(string)
+	case "check_drug_interactions":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CheckDrugInteractions(ctx, patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_wells_score":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		scoreType, _ := args["score_type"].(string)
+		criteria := make(map[string]bool)
+		if raw, ok := args["criteria"].(map[string]interface{}); ok {
+			for key, value := range raw {
+				if met, ok := value.(bool); ok {
+					criteria[key] = met
+				}
+			}
+		}
+		result, err := h.CalculateWellsScore(patientID, scoreType, criteria)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_observation_trend":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		code, _ := args["code"].(string)
+		result, err := h.GetObservationTrend(patientID, code)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_day_schedule":
+		date, ok := args["date"].(string)
 		if !ok {
-			return "", fmt.Errorf("invalid query parameter")
+			return "", fmt.Errorf("invalid date parameter")
 		}
-		result, err := h.GetMedicalGuidelines(query)
+		result, err := h.GetDaySchedule(date)
 		if err != nil {
 			return "", err
 		}
 		return h.ExtractTextFromMCPResult(result), nil
 
-	case "determine_apixaban_dose":
+	case "calculate_charlson":
 		patientID := ""
 		if pid, exists := args["patient_id"].(string); exists {
 			patientID = pid
 		}
-		result, err := h.DetermineApixabanDose(patientID)
+		result, err := h.CalculateCharlson(patientID)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "get_panel_result":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		date, _ := args["date"].(string)
+		result, err := h.GetPanelResult(patientID, date)
+		if err != nil {
+			return "", err
+		}
+		return h.ExtractTextFromMCPResult(result), nil
+
+	case "calculate_maintenance_fluids":
+		patientID := ""
+		if pid, exists := args["patient_id"].(string); exists {
+			patientID = pid
+		}
+		result, err := h.CalculateMaintenanceFluids(patientID)
 		if err != nil {
 			return "", err
 		}
@@ -1932,27 +4371,9 @@ func calculateAge(birthDateStr string) (int, error) {
 		return 0, fmt.Errorf("birth date is empty")
 	}
 
-	// Prioritize ISO 8601 formats
-	formats := []string{
-		"2006-01-02",           // YYYY-MM-DD
-		"2006-01-02T15:04:05Z", // ISO 8601 with time
-		"2006-01-02T15:04:05-07:00",
-	}
-
-	var birthDate time.Time
-	var err error
-	parsed := false
-
-	for _, format := range formats {
-		birthDate, err = time.Parse(format, birthDateStr)
-		if err == nil {
-			parsed = true
-			break
-		}
-	}
-
-	if !parsed {
-		return 0, fmt.Errorf("unable to parse birth date: %s (expected YYYY-MM-DD)", birthDateStr)
+	birthDate, err := parseBirthDate(birthDateStr)
+	if err != nil {
+		return 0, err
 	}
 
 	now := time.Now()
@@ -1970,6 +4391,41 @@ func calculateAge(birthDateStr string) (int, error) {
 	return age, nil
 }
 
+// maxPlausibleAge is the oldest age accepted for a birth date
+const maxPlausibleAge = 130
+
+func parseBirthDate(birthDateStr string) (time.Time, error) {
+	// Prioritize ISO 8601 formats
+	formats := []string{
+		"2006-01-02",           // YYYY-MM-DD
+		"2006-01-02T15:04:05Z", // ISO 8601 with time
+		"2006-01-02T15:04:05-07:00",
+	}
+
+	for _, format := range formats {
+		if birthDate, err := time.Parse(format, birthDateStr); err == nil {
+			return birthDate, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse birth date: %s (expected YYYY-MM-DD)", birthDateStr)
+}
+
+// validateBirthDate rejects birth dates that can't be parsed, lie in the
+// future or would make the patient older than maxPlausibleAge
+func validateBirthDate(birthDateStr string, now time.Time) error {
+	birthDate, err := parseBirthDate(strings.TrimSpace(birthDateStr))
+	if err != nil {
+		return err
+	}
+	if birthDate.After(now) {
+		return fmt.Errorf("birth date %s is in the future", birthDateStr)
+	}
+	if birthDate.Before(now.AddDate(-maxPlausibleAge, 0, 0)) {
+		return fmt.Errorf("birth date %s is implausible (more than %d years ago)", birthDateStr, maxPlausibleAge)
+	}
+	return nil
+}
+
 func formatPatientInfo(p database.Patient) string {
 	var info strings.Builder
 
@@ -2003,6 +4459,13 @@ func formatPatientInfo(p database.Patient) string {
 	if p.Phone != nil && *p.Phone != "" {
 		info.WriteString(fmt.Sprintf("Phone: %s\n", *p.Phone))
 	}
+	for _, c := range p.Contacts {
+		// The legacy phone column is already listed above
+		if c.System == "phone" && p.Phone != nil && c.Value == *p.Phone {
+			continue
+		}
+		info.WriteString(formatContactPoint(c) + "\n")
+	}
 	if p.City != nil && p.State != nil && (*p.City != "" || *p.State != "") {
 		info.WriteString(fmt.Sprintf("Location: %s, %s\n", *p.City, *p.State))
 	}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// defaultHistoryPageSize is how many records per category GetMedicalHistory
+// returns when no limit is given
+const defaultHistoryPageSize = 10
+
+// recordTotal counts the patient's rows in table, falling back to seen (the
+// records already read) if the count fails
+func (h *Handler) recordTotal(table, patientID string, seen int) int {
+	total, err := database.CountPatientRecords(h.db, table, patientID)
+	if err != nil {
+		return seen
+	}
+	return total
+}
+
+// historyPageNote describes where a page of shown records starting at offset
+// sits among total, or returns "" when the page reaches the end
+func historyPageNote(offset, shown, total int) string {
+	switch {
+	case shown == 0 && offset >= total:
+		return fmt.Sprintf("  (offset %d is past the end; %d record(s) in total)\n", offset, total)
+	case offset+shown < total:
+		return fmt.Sprintf("  (showing %d-%d of %d, use offset=%d to see more)\n", offset+1, offset+shown, total, offset+shown)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// Touch records activity on the context so the idle sweeper leaves it alone.
+// The MCP server calls it for every tool call and natural language queries
+// call it directly, so HTTP requests count as activity too.
+func (h *Handler) Touch() {
+	h.mu.Lock()
+	h.lastAccessed = time.Now()
+	h.mu.Unlock()
+}
+
+// clearIfIdle clears every session that hasn't been touched for idleTTL: the
+// default session's context (patient, cached summary and last response) is
+// reset to its default and other sessions are dropped. It reports whether
+// anything was cleared.
+func (h *Handler) clearIfIdle(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cleared := false
+	for id, s := range h.sessions {
+		if now.Sub(s.lastAccessed) < h.idleTTL {
+			continue
+		}
+		if id != "" {
+			delete(h.sessions, id)
+			cleared = true
+			continue
+		}
+		if s.context == defaultContext() {
+			continue
+		}
+		s.context = defaultContext()
+		if h.sessionID == "" {
+			h.saveContextLocked()
+		}
+		cleared = true
+	}
+	return cleared
+}
+
+// startIdleSweeper periodically clears the context once it has been idle for
+// idleTTL. Close stops it.
+func (h *Handler) startIdleSweeper() {
+	interval := h.idleTTL / 2
+	if interval <= 0 {
+		interval = h.idleTTL
+	}
+
+	h.stopSweeper = make(chan struct{})
+	h.sweeperDone = make(chan struct{})
+
+	go func() {
+		defer close(h.sweeperDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stopSweeper:
+				return
+			case now := <-ticker.C:
+				if h.clearIfIdle(now) {
+					debug.Log("Context cleared after %s idle", h.idleTTL)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops background work started by NewHandler. It is safe to call more
+// than once.
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() {
+		if h.stopSweeper != nil {
+			close(h.stopSweeper)
+			<-h.sweeperDone
+		}
+	})
+}
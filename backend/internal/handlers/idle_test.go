@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleContextCleared(t *testing.T) {
+	t.Setenv("MCP_SESSION_IDLE_TTL", "50ms")
+	h := newTestHandler(t)
+	defer h.Close()
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext: %v", err)
+	}
+	h.SetLastResponse("previous answer")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for currentContext(h).PatientID != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("context was not cleared after the idle TTL")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx := currentContext(h)
+	if ctx.PatientSummary != nil || ctx.LastResponse != "" {
+		t.Errorf("context not fully cleared: %+v", ctx)
+	}
+	if ctx.PractitionerID != defaultContext().PractitionerID {
+		t.Errorf("PractitionerID = %q, want the default", ctx.PractitionerID)
+	}
+}
+
+func currentContext(h *Handler) Context {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.context
+}
+
+func TestTouchKeepsContext(t *testing.T) {
+	h := newTestHandler(t)
+	h.idleTTL = time.Minute
+	h.context.PatientID = "p1"
+
+	h.Touch()
+	if h.clearIfIdle(time.Now()) {
+		t.Error("recently touched context was cleared")
+	}
+	if !h.clearIfIdle(time.Now().Add(2 * time.Minute)) {
+		t.Error("idle context was not cleared")
+	}
+	if h.context.PatientID != "" {
+		t.Errorf("PatientID = %q, want empty", h.context.PatientID)
+	}
+}
+
+func TestCloseWithoutSweeper(t *testing.T) {
+	h := newTestHandler(t)
+	h.Close()
+	h.Close()
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+	"github.com/google/uuid"
+)
+
+// Kinds of record an IDGenerator is asked to name
+const (
+	idKindPatient           = "patient"
+	idKindEncounter         = "encounter"
+	idKindObservation       = "observation"
+	idKindCondition         = "condition"
+	idKindMedicationRequest = "medication_request"
+	idKindContact           = "contact"
+)
+
+// IDGenerator creates the IDs of new records. kind says what is being
+// created (patient, encounter, observation, ...), so a generator can keep a
+// separate scheme per record type. Implementations must be safe for
+// concurrent use.
+type IDGenerator interface {
+	NewID(kind string) (string, error)
+}
+
+// UUIDGenerator gives every record a random UUID. It is the default.
+type UUIDGenerator struct{}
+
+// NewID returns a new random UUID
+func (UUIDGenerator) NewID(kind string) (string, error) {
+	return uuid.New().String(), nil
+}
+
+// sequencePrefixes are the ID prefixes SequentialGenerator uses per kind
+var sequencePrefixes = map[string]string{
+	idKindPatient:           "PAT",
+	idKindEncounter:         "ENC",
+	idKindObservation:       "OBS",
+	idKindCondition:         "CON",
+	idKindMedicationRequest: "MED",
+	idKindContact:           "CNT",
+}
+
+// SequentialGenerator gives records human-friendly sequential IDs such as
+// ENC-000123, counting separately per kind. The counters are kept in the
+// id_sequences table, so numbering carries on across restarts.
+type SequentialGenerator struct {
+	db *sql.DB
+}
+
+// NewSequentialGenerator returns a SequentialGenerator backed by db
+func NewSequentialGenerator(db *sql.DB) *SequentialGenerator {
+	return &SequentialGenerator{db: db}
+}
+
+// NewID returns the next ID for kind. Kinds without a known prefix are
+// numbered under their upper-cased name.
+func (g *SequentialGenerator) NewID(kind string) (string, error) {
+	prefix, ok := sequencePrefixes[kind]
+	if !ok {
+		prefix = strings.ToUpper(kind)
+	}
+	n, err := database.NextSequenceValue(g.db, kind)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s ID: %w", kind, err)
+	}
+	return fmt.Sprintf("%s-%06d", prefix, n), nil
+}
+
+// idGeneratorFromEnv picks the ID strategy named by MCP_ID_STRATEGY: "uuid"
+// (the default) or "sequential"
+func idGeneratorFromEnv(db *sql.DB) IDGenerator {
+	switch strategy := strings.ToLower(envString("MCP_ID_STRATEGY", "uuid")); strategy {
+	case "uuid":
+		return UUIDGenerator{}
+	case "sequential":
+		debug.Log("Using sequential record IDs")
+		return NewSequentialGenerator(db)
+	default:
+		debug.Error("Invalid value for MCP_ID_STRATEGY: %q, using uuid", strategy)
+		return UUIDGenerator{}
+	}
+}
+
+// SetIDGenerator replaces the generator used for the IDs of new records, for
+// integrations that mirror an external system's ID scheme. It must be called
+// before the handler starts serving requests.
+func (h *Handler) SetIDGenerator(g IDGenerator) {
+	h.idGenerator = g
+}
+
+// newID returns an ID for a new record of the given kind
+func (h *Handler) newID(kind string) (string, error) {
+	return h.idGenerator.NewID(kind)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSequentialGeneratorConcurrent(t *testing.T) {
+	h := newTestHandler(t)
+	g := NewSequentialGenerator(h.db)
+
+	const workers, perWorker = 8, 25
+	results := make([][]string, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := g.NewID(idKindEncounter)
+				if err != nil {
+					t.Errorf("NewID failed: %v", err)
+					return
+				}
+				results[w] = append(results[w], id)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for w, ids := range results {
+		// Each worker must see its own IDs increase
+		for i := 1; i < len(ids); i++ {
+			if ids[i] <= ids[i-1] {
+				t.Errorf("Worker %d got %s after %s", w, ids[i], ids[i-1])
+			}
+		}
+		for _, id := range ids {
+			if seen[id] {
+				t.Errorf("Duplicate ID %s", id)
+			}
+			seen[id] = true
+		}
+	}
+	// No gaps: every number from 1 to the total was handed out once
+	for n := 1; n <= workers*perWorker; n++ {
+		if id := fmt.Sprintf("ENC-%06d", n); !seen[id] {
+			t.Errorf("Missing ID %s", id)
+		}
+	}
+
+	// Kinds are numbered separately
+	id, err := g.NewID(idKindPatient)
+	if err != nil || id != "PAT-000001" {
+		t.Errorf("Expected PAT-000001, got %q (err: %v)", id, err)
+	}
+}
+
+func TestCreatePatientUsesIDGenerator(t *testing.T) {
+	h := newTestHandler(t)
+	h.SetIDGenerator(NewSequentialGenerator(h.db))
+
+	result, err := h.CreatePatient("Kari", "Nordmann", "female", "1985-04-12", nil, nil, nil)
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "PAT-000001") {
+		t.Errorf("Expected sequential patient ID, got:\n%s", text)
+	}
+}
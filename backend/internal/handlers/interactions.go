@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// activeMedicationNames returns the patient's active medications, each
+// listed once even if it was prescribed several times
+func activeMedicationNames(medications []database.MedicationRequest) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range medications {
+		name := strings.TrimSpace(m.MedicationDisplay)
+		key := strings.ToLower(name)
+		if !isActiveMedication(m) || name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// drugInteractionPrompt asks the model for clinically significant pairwise
+// interactions between medications, taking the patient's allergies into
+// account
+func drugInteractionPrompt(medications []string, allergies []database.AllergyIntolerance) string {
+	var prompt strings.Builder
+	prompt.WriteString("Check the following active medications of one patient for clinically significant drug-drug interactions. ")
+	prompt.WriteString("Consider every pair. For each interaction give the two drugs, a severity (major, moderate or minor), the effect and the recommended management. ")
+	prompt.WriteString("Also flag any medication the patient's allergies make unsafe. Leave out pairs without a significant interaction, and say so if there are none. Be concise.\n\n")
+	prompt.WriteString("Active medications:\n- ")
+	prompt.WriteString(strings.Join(medications, "\n- "))
+
+	prompt.WriteString("\n\nAllergies:\n")
+	listed := 0
+	for _, a := range allergies {
+		if a.ClinicalStatus != "active" && a.ClinicalStatus != "" {
+			continue
+		}
+		prompt.WriteString("- " + a.Display)
+		if criticality := derefString(a.Criticality); criticality != "" {
+			prompt.WriteString(" (criticality: " + criticality + ")")
+		}
+		prompt.WriteString("\n")
+		listed++
+	}
+	if listed == 0 {
+		prompt.WriteString("- None recorded\n")
+	}
+	return prompt.String()
+}
+
+// CheckDrugInteractions asks the guidelines model to scan the patient's
+// active medications for clinically significant pairwise interactions, with
+// their severity. The patient's allergies are included in the prompt.
+func (h *Handler) CheckDrugInteractions(ctx context.Context, patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	medications, err := database.GetMedicationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	activeMeds := activeMedicationNames(medications)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Drug Interaction Check for %s (ID: %s)\n\n", patientName, patientID))
+
+	if len(activeMeds) < 2 {
+		result.WriteString(fmt.Sprintf("%d active medication(s): no interactions to check.\n", len(activeMeds)))
+	} else {
+		allergies, err := database.GetAllergiesByPatientID(h.db, patientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get allergies: %w", err)
+		}
+
+		review, err := h.callGuidelinesModel(ctx, drugInteractionPrompt(activeMeds, allergies))
+		if err != nil {
+			return nil, fmt.Errorf("failed to check interactions: %w", err)
+		}
+		result.WriteString(fmt.Sprintf("Active medications (%d):\n", len(activeMeds)))
+		for _, m := range activeMeds {
+			result.WriteString("• " + m + "\n")
+		}
+		result.WriteString("\nInteractions:\n")
+		result.WriteString(strings.TrimSpace(review))
+		result.WriteString("\n")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
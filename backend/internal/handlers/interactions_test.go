@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckDrugInteractions(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1950-05-05")
+	seedMedication(t, h, "p1", "Warfarin 5 MG Oral Tablet", "active", "2023-01-10T09:00:00Z")
+	seedMedication(t, h, "p1", "Aspirin 81 MG Oral Tablet", "active", "2024-02-01T09:00:00Z")
+	seedMedication(t, h, "p1", "Aspirin 81 MG Oral Tablet", "active", "2024-05-01T09:00:00Z")
+	seedMedication(t, h, "p1", "Ibuprofen 400 MG Oral Tablet", "stopped", "2022-06-01T09:00:00Z")
+	seedAllergy(t, h, "p1", "Penicillin", "active", "high")
+
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, m := range req.Messages {
+			prompt += m.Content + "\n"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{
+				"role": "assistant", "content": "Warfarin + Aspirin: major - increased bleeding risk.",
+			}}},
+		})
+	}))
+	defer server.Close()
+	h.openRouterURL = server.URL
+
+	result, err := h.CheckDrugInteractions(context.Background(), "p1")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "Active medications (2)") || !strings.Contains(text, "increased bleeding risk") {
+		t.Errorf("Expected medications and the model's findings, got:\n%s", text)
+	}
+
+	for _, want := range []string{"- Warfarin 5 MG Oral Tablet\n", "- Aspirin 81 MG Oral Tablet", "Penicillin (criticality: high)", "severity"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected %q in the prompt, got:\n%s", want, prompt)
+		}
+	}
+	if strings.Count(prompt, "Aspirin") != 1 {
+		t.Errorf("Expected a renewed medication to be listed once, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "Ibuprofen") {
+		t.Errorf("Stopped medications should not be checked, got:\n%s", prompt)
+	}
+}
+
+func TestCheckDrugInteractionsNeedsTwoMedications(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1950-05-05")
+	seedMedication(t, h, "p1", "Metformin 500 MG Oral Tablet", "active", "2023-01-10T09:00:00Z")
+	// Any model request fails the check
+	h.openRouterURL = "http://127.0.0.1:0"
+
+	result, err := h.CheckDrugInteractions(context.Background(), "p1")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "no interactions to check") {
+		t.Errorf("Expected a short-circuit with one medication, got:\n%s", text)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"strings"
+)
+
+// repairJSON applies targeted fixes for the ways models commonly break tool
+// call arguments: a markdown code fence around the object, trailing commas
+// before a closing brace or bracket, and empty arguments for tools without
+// parameters. Anything else is returned unchanged for the caller to reject.
+func repairJSON(s string) string {
+	s = strings.TrimSpace(s)
+
+	if rest, ok := strings.CutPrefix(s, "```"); ok {
+		// Drop the language tag, e.g. ```json
+		if newline := strings.IndexByte(rest, '\n'); newline >= 0 {
+			rest = rest[newline+1:]
+		} else {
+			rest = strings.TrimPrefix(rest, "json")
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+
+	if s == "" {
+		return "{}"
+	}
+	return removeTrailingCommas(s)
+}
+
+// removeTrailingCommas drops commas that are followed only by whitespace and
+// a closing brace or bracket, leaving string contents alone
+func removeTrailingCommas(s string) string {
+	var b strings.Builder
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		} else if c == ',' {
+			next := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"valid", `{"patient_id":"p1"}`, `{"patient_id":"p1"}`},
+		{"code fence", "```json\n{\"patient_id\":\"p1\"}\n```", `{"patient_id":"p1"}`},
+		{"bare code fence", "```{\"patient_id\":\"p1\"}```", `{"patient_id":"p1"}`},
+		{"trailing comma", `{"patient_id":"p1", }`, `{"patient_id":"p1" }`},
+		{"nested trailing commas", `{"ids":["a","b",],"n":1,}`, `{"ids":["a","b"],"n":1}`},
+		{"comma inside string", `{"note":"a,}"}`, `{"note":"a,}"}`},
+		{"escaped quote", `{"note":"say \",}\"",}`, `{"note":"say \",}\""}`},
+		{"empty", "  ", `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repairJSON(tt.in)
+			if got != tt.want {
+				t.Errorf("repairJSON(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			var v map[string]interface{}
+			if err := json.Unmarshal([]byte(got), &v); err != nil {
+				t.Errorf("repaired JSON doesn't parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteToolRepairsArguments(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+
+	for _, arguments := range []string{
+		"```json\n{\"patient_id\": \"p1\"}\n```",
+		`{"patient_id": "p1",}`,
+	} {
+		text, err := h.executeTool(context.Background(), "set_patient_context", arguments, "")
+		if err != nil {
+			t.Errorf("executeTool(%q) failed: %v", arguments, err)
+		} else if !strings.Contains(text, "Kari") {
+			t.Errorf("executeTool(%q) = %q, want Kari's context", arguments, text)
+		}
+	}
+
+	if _, err := h.executeTool(context.Background(), "set_patient_context", `{"patient_id": `, ""); err == nil || !strings.Contains(err.Error(), "failed to parse arguments") {
+		t.Errorf("err = %v, want a parse error for unrepairable arguments", err)
+	}
+}
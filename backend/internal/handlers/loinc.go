@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// loincCodeRegex matches the LOINC format: up to 5 digits, a hyphen and a
+// check digit
+var loincCodeRegex = regexp.MustCompile(`^(\d{1,5})-(\d)$`)
+
+// loincEntry describes a common LOINC code. Terms are matched against the
+// observation display (lowercase) and Units lists the accepted units.
+type loincEntry struct {
+	Display string
+	Terms   []string
+	Units   []string
+}
+
+// commonLOINCCodes covers the vitals and labs most often entered by hand. It is
+// deliberately small; codes not listed here are only format-checked.
+var commonLOINCCodes = map[string]loincEntry{
+	"8867-4":  {"Heart rate", []string{"heart rate", "pulse"}, []string{"/min", "beats/min", "bpm"}},
+	"9279-1":  {"Respiratory rate", []string{"respiratory rate", "respiration"}, []string{"/min", "breaths/min"}},
+	"8480-6":  {"Systolic blood pressure", []string{"systolic"}, []string{"mm[Hg]", "mmHg"}},
+	"8462-4":  {"Diastolic blood pressure", []string{"diastolic"}, []string{"mm[Hg]", "mmHg"}},
+	"8310-5":  {"Body temperature", []string{"temperature"}, []string{"Cel", "°C", "[degF]", "°F"}},
+	"59408-5": {"Oxygen saturation in Arterial blood by Pulse oximetry", []string{"oxygen saturation", "spo2"}, []string{"%"}},
+	"29463-7": {"Body weight", []string{"weight"}, []string{"kg", "g", "[lb_av]", "lb"}},
+	"8302-2":  {"Body height", []string{"height"}, []string{"cm", "m", "[in_i]", "in"}},
+	"9269-2":  {"Glasgow coma score total", []string{"glasgow", "gcs"}, []string{"{score}"}},
+	"39156-5": {"Body mass index (BMI) [Ratio]", []string{"body mass index", "bmi"}, []string{"kg/m2"}},
+	"2339-0":  {"Glucose [Mass/volume] in Blood", []string{"glucose"}, []string{"mg/dL"}},
+	"2345-7":  {"Glucose [Mass/volume] in Serum or Plasma", []string{"glucose"}, []string{"mg/dL"}},
+	"4548-4":  {"Hemoglobin A1c/Hemoglobin.total in Blood", []string{"a1c"}, []string{"%"}},
+	"718-7":   {"Hemoglobin [Mass/volume] in Blood", []string{"hemoglobin", "haemoglobin"}, []string{"g/dL"}},
+	"2093-3":  {"Cholesterol [Mass/volume] in Serum or Plasma", []string{"cholesterol"}, []string{"mg/dL"}},
+	"2085-9":  {"Cholesterol in HDL [Mass/volume] in Serum or Plasma", []string{"hdl", "high density"}, []string{"mg/dL"}},
+	"18262-6": {"Cholesterol in LDL [Mass/volume] in Serum or Plasma by Direct assay", []string{"ldl", "low density"}, []string{"mg/dL"}},
+	"2571-8":  {"Triglyceride [Mass/volume] in Serum or Plasma", []string{"triglyceride"}, []string{"mg/dL"}},
+	"2160-0":  {"Creatinine [Mass/volume] in Serum or Plasma", []string{"creatinine"}, []string{"mg/dL"}},
+	"6299-2":  {"Urea nitrogen [Mass/volume] in Blood", []string{"urea nitrogen", "bun"}, []string{"mg/dL"}},
+	"2823-3":  {"Potassium [Moles/volume] in Serum or Plasma", []string{"potassium"}, []string{"mmol/L", "meq/L"}},
+	"2951-2":  {"Sodium [Moles/volume] in Serum or Plasma", []string{"sodium"}, []string{"mmol/L", "meq/L"}},
+	"1975-2":  {"Bilirubin.total [Mass/volume] in Serum or Plasma", []string{"bilirubin"}, []string{"mg/dL"}},
+	"6301-6":  {"INR in Platelet poor plasma by Coagulation assay", []string{"inr", "international normalized ratio"}, []string{"{INR}", "{ratio}", "1"}},
+	"33914-3": {"Glomerular filtration rate/1.73 sq M.predicted", []string{"glomerular filtration", "egfr"}, []string{"mL/min/{1.73_m2}", "mL/min/1.73m2"}},
+	"72514-3": {"Pain severity - 0-10 verbal numeric rating [Score]", []string{"pain"}, []string{"{score}"}},
+}
+
+// loincCheckDigit computes the mod 10 check digit of the numeric part of a
+// LOINC code
+func loincCheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		// Double every other digit, starting with the rightmost
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+// normalizeUnit makes unit comparison ignore case, spaces and the UCUM
+// brackets, so "mmHg" matches "mm[Hg]"
+func normalizeUnit(unit string) string {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	return strings.NewReplacer("[", "", "]", "", " ", "").Replace(unit)
+}
+
+// loincCategories are the observation categories coded with LOINC. Codes in
+// other categories, such as free-text exam findings, aren't checked.
+var loincCategories = map[string]bool{
+	"laboratory":  true,
+	"vital-signs": true,
+}
+
+// loincFormatError describes why code is not a well-formed LOINC code, or
+// returns "" if it is
+func loincFormatError(code string) string {
+	code = strings.TrimSpace(code)
+	match := loincCodeRegex.FindStringSubmatch(code)
+	if match == nil {
+		return fmt.Sprintf("code %q is not in LOINC format (expected e.g. 8867-4)", code)
+	}
+	if want := loincCheckDigit(match[1]); int(match[2][0]-'0') != want {
+		return fmt.Sprintf("code %q has an invalid LOINC check digit (expected %s-%d)", code, match[1], want)
+	}
+	return ""
+}
+
+// ValidateLOINC checks an observation code against the LOINC format and, for
+// common codes, the expected display and unit. It returns a human-readable
+// warning, or "" when nothing looks inconsistent. It never blocks a write.
+func ValidateLOINC(code, display, unit string) string {
+	if formatErr := loincFormatError(code); formatErr != "" {
+		return formatErr
+	}
+	code = strings.TrimSpace(code)
+
+	entry, known := commonLOINCCodes[code]
+	if !known {
+		return ""
+	}
+
+	var warnings []string
+	if display = strings.TrimSpace(display); display != "" && !containsAny(display, entry.Terms) {
+		warnings = append(warnings, fmt.Sprintf("display %q doesn't match %s (%s)", display, code, entry.Display))
+	}
+	if unit = strings.TrimSpace(unit); unit != "" {
+		unitOK := false
+		for _, u := range entry.Units {
+			if normalizeUnit(u) == normalizeUnit(unit) {
+				unitOK = true
+				break
+			}
+		}
+		if !unitOK {
+			warnings = append(warnings, fmt.Sprintf("unit %q is unexpected for %s (%s), expected %s",
+				unit, code, entry.Display, strings.Join(entry.Units, " or ")))
+		}
+	}
+	return strings.Join(warnings, "; ")
+}
+
+// ValidateObservationCode exposes ValidateLOINC as a tool so a code can be
+// checked before an observation is recorded
+func (h *Handler) ValidateObservationCode(code, display, unit string) (interface{}, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+
+	text := fmt.Sprintf("LOINC code %s looks consistent.", code)
+	if entry, ok := commonLOINCCodes[code]; ok {
+		text = fmt.Sprintf("LOINC code %s (%s) looks consistent.", code, entry.Display)
+	}
+	if warning := ValidateLOINC(code, display, unit); warning != "" {
+		text = "Warning: " + warning
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLOINC(t *testing.T) {
+	tests := []struct {
+		name, code, display, unit string
+		wantWarning               string
+	}{
+		{"consistent", "8867-4", "Heart rate", "/min", ""},
+		{"unit with UCUM brackets omitted", "8480-6", "Systolic blood pressure", "mmHg", ""},
+		{"mismatched unit", "2339-0", "Glucose [Mass/volume] in Blood", "mmol/L", `unit "mmol/L" is unexpected`},
+		{"mismatched display", "8867-4", "Body weight", "/min", `display "Body weight" doesn't match`},
+		{"bad format", "HR", "Heart rate", "/min", "not in LOINC format"},
+		{"bad check digit", "8867-5", "Heart rate", "/min", "expected 8867-4"},
+		{"unknown code", "12345-5", "Something else", "mg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateLOINC(tt.code, tt.display, tt.unit)
+			if tt.wantWarning == "" && got != "" {
+				t.Errorf("unexpected warning: %s", got)
+			}
+			if tt.wantWarning != "" && !strings.Contains(got, tt.wantWarning) {
+				t.Errorf("warning = %q, want it to contain %q", got, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestCommonLOINCCodesHaveValidCheckDigits(t *testing.T) {
+	for code := range commonLOINCCodes {
+		match := loincCodeRegex.FindStringSubmatch(code)
+		if match == nil || loincCheckDigit(match[1]) != int(match[2][0]-'0') {
+			t.Errorf("%s is not a valid LOINC code", code)
+		}
+	}
+}
+
+func TestAddObservationWarnsOnMismatchedUnit(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+
+	value, unit := 98.0, "kg"
+	result, err := h.AddObservation("p1", "8867-4", "Heart rate", "vital-signs", "", "", &value, &unit, nil)
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "Successfully added observation") {
+		t.Fatalf("observation not added:\n%s", text)
+	}
+	if !strings.Contains(text, `Warning: unit "kg" is unexpected for 8867-4`) {
+		t.Errorf("missing LOINC warning:\n%s", text)
+	}
+}
+
+func TestAddObservationChecksLOINCByCategory(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+	value, unit := 5.4, "mg/dL"
+	finding := "Clear breath sounds bilaterally"
+
+	tests := []struct {
+		name, code, category string
+		valueString          *string
+		wantWarning          string
+	}{
+		{"valid lab code", "2339-0", "laboratory", nil, ""},
+		{"lab code typo", "2339", "laboratory", nil, `code "2339" is not in LOINC format`},
+		{"vital sign check digit", "8867-5", "vital-signs", nil, `code "8867-5" has an invalid LOINC check digit`},
+		{"free-text exam", "lung-auscultation", "exam", &finding, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valueQuantity, valueUnit := &value, &unit
+			if tt.valueString != nil {
+				valueQuantity, valueUnit = nil, nil
+			}
+			result, err := h.AddObservation("p1", tt.code, "Glucose", tt.category, "", "", valueQuantity, valueUnit, tt.valueString)
+			text := resultText(t, h, result, err)
+			if !strings.Contains(text, "Successfully added observation") {
+				t.Fatalf("observation not added:\n%s", text)
+			}
+			if tt.wantWarning == "" && strings.Contains(text, "Warning:") {
+				t.Errorf("unexpected warning:\n%s", text)
+			}
+			if tt.wantWarning != "" && !strings.Contains(text, "Warning: "+tt.wantWarning) {
+				t.Errorf("missing warning %q:\n%s", tt.wantWarning, text)
+			}
+		})
+	}
+
+	h.strictLOINC = true
+	if _, err := h.AddObservation("p1", "2339", "Glucose", "laboratory", "", "", &value, &unit, nil); err == nil || !strings.Contains(err.Error(), "not in LOINC format") {
+		t.Errorf("expected strict mode to reject a malformed lab code, got %v", err)
+	}
+	if _, err := h.AddObservation("p1", "lung-auscultation", "Lung exam", "exam", "", "", nil, nil, &finding); err != nil {
+		t.Errorf("strict mode should not apply to exam observations: %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// ToKilograms converts a body weight to kilograms. The second return value is
+// false when the unit is not a recognised mass unit.
+func ToKilograms(value float64, unit string) (float64, bool) {
+	unitLower := strings.ToLower(strings.TrimSpace(unit))
+	switch {
+	case unitLower == "kg" || strings.Contains(unitLower, "kilogram"):
+		return value, true
+	case unitLower == "g" || unitLower == "gram" || unitLower == "grams":
+		return value / 1000.0, true
+	case strings.Contains(unitLower, "lb") || strings.Contains(unitLower, "pound"):
+		// 1 lb = 0.453592 kg
+		return value * 0.453592, true
+	}
+	return 0, false
+}
+
+// ToCentimeters converts a body height to centimeters. The second return value
+// is false when the unit is not a recognised length unit.
+func ToCentimeters(value float64, unit string) (float64, bool) {
+	unitLower := strings.ToLower(strings.TrimSpace(unit))
+	switch unitLower {
+	case "cm", "centimeter", "centimeters":
+		return value, true
+	case "m", "meter", "meters":
+		return value * 100.0, true
+	case "in", "[in_i]", "[in_us]", "inch", "inches":
+		return value * 2.54, true
+	case "ft", "[ft_i]", "foot", "feet":
+		return value * 30.48, true
+	}
+	return 0, false
+}
+
+// latestWeightKg returns the most recent body weight in kg. Observations are
+// expected in descending effective date order, as returned by
+// database.GetObservationsByPatientID.
+func latestWeightKg(observations []database.Observation) *float64 {
+	if _, kg, ok := latestWeight(observations); ok {
+		return &kg
+	}
+	return nil
+}
+
+// latestWeight returns the most recent body weight observation with its
+// value in kg
+func latestWeight(observations []database.Observation) (*database.Observation, float64, bool) {
+	for i, obs := range observations {
+		displayLower := strings.ToLower(obs.Display)
+		if !strings.Contains(displayLower, "weight") || obs.ValueQuantity == nil || obs.ValueUnit == nil {
+			continue
+		}
+		// Skip derived values such as "Weight-for-length Per age and sex"
+		if strings.Contains(displayLower, "percentile") || strings.Contains(displayLower, "per age") {
+			continue
+		}
+		if kg, ok := ToKilograms(*obs.ValueQuantity, *obs.ValueUnit); ok {
+			return &observations[i], kg, true
+		}
+	}
+	return nil, 0, false
+}
+
+// latestHeightCm returns the most recent body height in cm. Observations are
+// expected in descending effective date order.
+func latestHeightCm(observations []database.Observation) *float64 {
+	if _, cm, ok := latestHeight(observations); ok {
+		return &cm
+	}
+	return nil
+}
+
+// latestHeight returns the most recent body height or length observation with
+// its value in cm
+func latestHeight(observations []database.Observation) (*database.Observation, float64, bool) {
+	for i, obs := range observations {
+		displayLower := strings.ToLower(obs.Display)
+		if !(strings.Contains(displayLower, "height") || strings.Contains(displayLower, "stature") ||
+			strings.Contains(displayLower, "length")) || obs.ValueQuantity == nil || obs.ValueUnit == nil {
+			continue
+		}
+		if strings.Contains(displayLower, "percentile") || strings.Contains(displayLower, "per age") {
+			continue
+		}
+		if cm, ok := ToCentimeters(*obs.ValueQuantity, *obs.ValueUnit); ok {
+			return &observations[i], cm, true
+		}
+	}
+	return nil, 0, false
+}
+
+// toMgPerDL converts a serum concentration to mg/dL. umolPerMgDL is the
+// analyte-specific factor for µmol/L (17.1 for bilirubin, 88.4 for creatinine).
+// Units are matched case-insensitively anywhere in unit, so annotated units
+// such as "mg/dL (serum)" are recognised.
+func toMgPerDL(value float64, unit string, umolPerMgDL float64) (float64, bool) {
+	unitLower := strings.ToLower(unit)
+	switch {
+	case strings.Contains(unitLower, "mg/dl"):
+		return value, true
+	case strings.Contains(unitLower, "umol/l"), strings.Contains(unitLower, "µmol/l"), strings.Contains(unitLower, "μmol/l"):
+		return value / umolPerMgDL, true
+	}
+	return 0, false
+}
+
+// latestCreatinineMgDL returns the most recent creatinine observation whose
+// display contains one of terms and whose unit is mg/dL or µmol/L, with its
+// value in mg/dL. Observations in other units are skipped.
+func latestCreatinineMgDL(observations []database.Observation, terms []string) (*database.Observation, float64, bool) {
+	for i, obs := range observations {
+		if obs.ValueQuantity == nil || obs.ValueUnit == nil || !containsAny(obs.Display, terms) {
+			continue
+		}
+		// 1 mg/dL = 88.4 µmol/L
+		if mgDL, ok := toMgPerDL(*obs.ValueQuantity, *obs.ValueUnit, 88.4); ok {
+			return &observations[i], mgDL, true
+		}
+	}
+	return nil, 0, false
+}
+
+// latestGlucoseMgDL returns the most recent glucose observation whose display
+// contains one of terms and whose unit is mg/dL or mmol/L, with its value in
+// mg/dL. Observations in other units are skipped.
+func latestGlucoseMgDL(observations []database.Observation, terms []string) (*database.Observation, float64, bool) {
+	for i, obs := range observations {
+		if obs.ValueQuantity == nil || obs.ValueUnit == nil || !containsAny(obs.Display, terms) {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(*obs.ValueUnit)) {
+		case "mg/dl":
+			return &observations[i], *obs.ValueQuantity, true
+		case "mmol/l":
+			// 1 mmol/L glucose = 18 mg/dL
+			return &observations[i], *obs.ValueQuantity * 18.0, true
+		}
+	}
+	return nil, 0, false
+}
+
+// latestElectrolyte returns the most recent observation whose display
+// contains one of terms and whose unit is mmol/L or mEq/L, with its value.
+// For monovalent ions such as sodium the two units are the same.
+func latestElectrolyte(observations []database.Observation, terms []string) (*database.Observation, float64, bool) {
+	for i, obs := range observations {
+		if obs.ValueQuantity == nil || obs.ValueUnit == nil || !containsAny(obs.Display, terms) {
+			continue
+		}
+		if unit := strings.ToLower(strings.TrimSpace(*obs.ValueUnit)); unit == "mmol/l" || unit == "meq/l" {
+			return &observations[i], *obs.ValueQuantity, true
+		}
+	}
+	return nil, 0, false
+}
+
+// latestAlbuminGDL returns the most recent albumin observation whose display
+// contains one of terms and whose unit is g/dL or g/L, with its value in g/dL
+func latestAlbuminGDL(observations []database.Observation, terms []string) (*database.Observation, float64, bool) {
+	for i, obs := range observations {
+		if obs.ValueQuantity == nil || obs.ValueUnit == nil || !containsAny(obs.Display, terms) {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(*obs.ValueUnit)) {
+		case "g/dl":
+			return &observations[i], *obs.ValueQuantity, true
+		case "g/l":
+			return &observations[i], *obs.ValueQuantity / 10, true
+		}
+	}
+	return nil, 0, false
+}
+
+// latestLab returns the most recent observation whose display contains one of
+// terms and has a numeric value. Observations are expected in descending
+// effective date order.
+func latestLab(observations []database.Observation, terms []string) *database.Observation {
+	for i, obs := range observations {
+		if obs.ValueQuantity != nil && containsAny(obs.Display, terms) {
+			return &observations[i]
+		}
+	}
+	return nil
+}
+
+// valueSignificantFigures is used for values without a configured precision
+const valueSignificantFigures = 3
+
+// codeDecimals and unitDecimals give the decimals shown for an observation
+// code or unit; codes take precedence. Units are keyed by normalizeUnit.
+var codeDecimals = map[string]int{
+	"8867-4":  0, // Heart rate
+	"9279-1":  0, // Respiratory rate
+	"8480-6":  0, // Systolic blood pressure
+	"8462-4":  0, // Diastolic blood pressure
+	"59408-5": 0, // Oxygen saturation
+	"8310-5":  1, // Body temperature
+	"29463-7": 1, // Body weight
+	"8302-2":  1, // Body height
+	"39156-5": 1, // BMI
+	"4548-4":  1, // HbA1c
+	"2339-0":  0, // Glucose
+	"2345-7":  0, // Glucose
+	"2160-0":  2, // Creatinine
+	"6301-6":  1, // INR
+	"2823-3":  1, // Potassium
+	"2951-2":  0, // Sodium
+}
+
+var unitDecimals = map[string]int{
+	"/min":      0,
+	"mmhg":      0,
+	"beats/min": 0,
+	"cel":       1,
+	"degf":      1,
+	"kg":        1,
+	"cm":        1,
+	"kg/m2":     1,
+}
+
+// valuePrecisionOverrides reads MCP_VALUE_PRECISION, a comma-separated list of
+// code or unit "=decimals" pairs such as "8867-4=0,mg/dL=1", once
+var valuePrecisionOverrides = sync.OnceValue(func() map[string]int {
+	return parsePrecisionOverrides(os.Getenv("MCP_VALUE_PRECISION"))
+})
+
+func parsePrecisionOverrides(value string) map[string]int {
+	overrides := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, decimals, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(decimals))
+		if !ok || err != nil || n < 0 || strings.TrimSpace(key) == "" {
+			debug.Error("Invalid MCP_VALUE_PRECISION entry: %q", entry)
+			continue
+		}
+		overrides[normalizeUnit(key)] = n
+	}
+	return overrides
+}
+
+// valueDecimals returns the configured decimals for a code or unit. Overrides
+// win over the built-in tables, and codes over units.
+func valueDecimals(code, unit string, overrides map[string]int) (int, bool) {
+	code = strings.TrimSpace(code)
+	unitKey := normalizeUnit(unit)
+	for _, lookup := range []struct {
+		table map[string]int
+		key   string
+	}{{overrides, normalizeUnit(code)}, {overrides, unitKey}, {codeDecimals, code}, {unitDecimals, unitKey}} {
+		if lookup.key == "" {
+			continue
+		}
+		if n, ok := lookup.table[lookup.key]; ok {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// FormatValue renders an observation value with the precision configured for
+// its code or unit. Other values are shown with three significant figures
+// (keeping all integer digits) and without trailing zeros, so 72 prints as
+// "72" rather than "72.00".
+func FormatValue(value float64, code, unit string) string {
+	return formatValue(value, code, unit, valuePrecisionOverrides())
+}
+
+func formatValue(value float64, code, unit string, overrides map[string]int) string {
+	if decimals, ok := valueDecimals(code, unit, overrides); ok {
+		return strconv.FormatFloat(value, 'f', decimals, 64)
+	}
+	if value == math.Trunc(value) || math.IsInf(value, 0) || math.IsNaN(value) {
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	}
+
+	decimals := valueSignificantFigures - 1 - int(math.Floor(math.Log10(math.Abs(value))))
+	if decimals < 0 {
+		decimals = 0
+	}
+	formatted := strconv.FormatFloat(value, 'f', decimals, 64)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+	return formatted
+}
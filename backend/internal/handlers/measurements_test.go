@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		code  string
+		unit  string
+		want  string
+	}{
+		{"heart rate by code", 72, "8867-4", "/min", "72"},
+		{"heart rate rounds", 71.6, "8867-4", "/min", "72"},
+		{"blood pressure by unit", 120, "", "mm[Hg]", "120"},
+		{"unit without brackets", 80.4, "", "mmHg", "80"},
+		{"integer-like unknown unit", 5, "", "{count}", "5"},
+		{"creatinine keeps 2 decimals", 1.1, "2160-0", "mg/dL", "1.10"},
+		{"HbA1c", 7.26, "4548-4", "%", "7.3"},
+		{"temperature", 37, "8310-5", "Cel", "37.0"},
+		{"lab 3 significant figures", 0.123456, "", "mmol/L", "0.123"},
+		{"lab 3 significant figures trims zeros", 4.5012, "", "mmol/L", "4.5"},
+		{"large value keeps integer digits", 1234.567, "", "U/L", "1235"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatValue(tt.value, tt.code, tt.unit, nil); got != tt.want {
+				t.Errorf("formatValue(%v, %q, %q) = %q, want %q", tt.value, tt.code, tt.unit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMgPerDL(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  float64
+		unit   string
+		factor float64
+		want   float64
+		ok     bool
+	}{
+		{"mg/dL", 1.2, "mg/dL", 88.4, 1.2, true},
+		{"annotated mg/dL", 1.2, "mg/dL (serum)", 88.4, 1.2, true},
+		{"umol/L", 88.4, "umol/L", 88.4, 1, true},
+		{"µmol/L", 176.8, "µmol/L", 88.4, 2, true},
+		{"annotated µmol/L", 88.4, "μmol/L serum", 88.4, 1, true},
+		{"bilirubin µmol/L", 34.2, "umol/L", 17.1, 2, true},
+		{"mmol/L not converted", 0.1, "mmol/L", 88.4, 0, false},
+		{"no unit", 1.2, "", 88.4, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := toMgPerDL(tt.value, tt.unit, tt.factor)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("toMgPerDL(%v, %q) = %v, %v; want %v, %v", tt.value, tt.unit, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFormatValueOverrides(t *testing.T) {
+	overrides := parsePrecisionOverrides("8867-4=1, mg/dL=2, bad, x=-1")
+	if len(overrides) != 2 {
+		t.Fatalf("overrides = %v, want 2 valid entries", overrides)
+	}
+	if got := formatValue(72, "8867-4", "/min", overrides); got != "72.0" {
+		t.Errorf("code override: got %q, want 72.0", got)
+	}
+	if got := formatValue(98, "2339-0", "mg/dL", overrides); got != "98.00" {
+		t.Errorf("unit override should win over built-in code precision: got %q, want 98.00", got)
+	}
+	if got := formatValue(5.5, "", "MG/DL", overrides); got != "5.50" {
+		t.Errorf("unit override: got %q, want 5.50", got)
+	}
+}
+
+func TestMedicalHistoryValuePrecision(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 72, "/min", "2025-01-15T00:00:00Z")
+
+	result, err := h.GetMedicalHistory("p1", "observations", 0, 0)
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "Value: 72 /min") {
+		t.Errorf("heart rate not printed without decimals:\n%s", text)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// defaultMedicationSearchLimit and maxMedicationSearchLimit bound how many
+// patients FindPatientsOnMedication lists
+const (
+	defaultMedicationSearchLimit = 50
+	maxMedicationSearchLimit     = 500
+)
+
+// FindPatientsOnMedication lists the patients with an active prescription
+// whose name matches medicationQuery, e.g. for a drug recall
+func (h *Handler) FindPatientsOnMedication(medicationQuery string, limit int) (interface{}, error) {
+	medicationQuery = strings.TrimSpace(medicationQuery)
+	if medicationQuery == "" {
+		return nil, fmt.Errorf("medication name is required")
+	}
+	if limit <= 0 {
+		limit = defaultMedicationSearchLimit
+	}
+	if limit > maxMedicationSearchLimit {
+		limit = maxMedicationSearchLimit
+	}
+
+	// Fetch one extra row to tell whether the list was cut off
+	patients, err := database.FindPatientsOnMedication(h.db, medicationQuery, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search medications: %w", err)
+	}
+
+	var result strings.Builder
+	if len(patients) == 0 {
+		result.WriteString(fmt.Sprintf("No patients with an active prescription matching %q", medicationQuery))
+	} else {
+		truncated := len(patients) > limit
+		if truncated {
+			patients = patients[:limit]
+		}
+		result.WriteString(fmt.Sprintf("Patients with an active prescription matching %q (%d):\n\n", medicationQuery, len(patients)))
+		for _, p := range patients {
+			if h.withheldFromModel(p.PatientID) {
+				result.WriteString("• " + withheldPatientText + "\n")
+				continue
+			}
+			result.WriteString(fmt.Sprintf("• %s %s (ID: %s): %s\n", p.GivenName, p.FamilyName, p.PatientID, p.Medications))
+		}
+		if truncated {
+			result.WriteString(fmt.Sprintf("\nShowing the first %d patients; more match. Raise the limit or narrow the search.\n", limit))
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
+
+// GetMedicationForms lists every formulation (strength and form) of the
+// medications matching medicationName, so the right one can be confirmed
+// before prescribing
+func (h *Handler) GetMedicationForms(medicationName string) (interface{}, error) {
+	medicationName = strings.TrimSpace(medicationName)
+	if medicationName == "" {
+		return nil, fmt.Errorf("medication name is required")
+	}
+
+	medications, err := database.SearchMedicationsByName(h.db, medicationName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search medications: %w", err)
+	}
+
+	var result strings.Builder
+	if len(medications) == 0 {
+		result.WriteString(fmt.Sprintf("No medications found matching %q", medicationName))
+	} else {
+		result.WriteString(fmt.Sprintf("Formulations matching %q (%d):\n\n", medicationName, len(medications)))
+		for _, m := range medications {
+			result.WriteString(fmt.Sprintf("• %s (Code: %s)", m.Display, m.Code))
+			if m.Form != nil && *m.Form != "" {
+				result.WriteString(fmt.Sprintf(", form: %s", *m.Form))
+			}
+			result.WriteString("\n")
+		}
+		if len(medications) > 1 {
+			result.WriteString("\nSeveral formulations match; confirm which one is intended before prescribing.\n")
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
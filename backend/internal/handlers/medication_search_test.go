@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindPatientsOnMedication(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedPatient(t, h, "p2", "Jennifer", "Parker", "female", "1968-01-01")
+	seedPatient(t, h, "p3", "Biff", "Tannen", "male", "1937-03-26")
+	seedMedication(t, h, "p1", "Valsartan 160 MG Oral Tablet", "active", "2024-01-01")
+	seedMedication(t, h, "p1", "valsartan 80 MG Oral Tablet", "active", "2023-01-01")
+	seedMedication(t, h, "p2", "Valsartan 160 MG Oral Tablet", "active", "2024-02-01")
+	seedMedication(t, h, "p3", "Valsartan 160 MG Oral Tablet", "stopped", "2022-01-01")
+
+	result, err := h.FindPatientsOnMedication("VALSARTAN", 0)
+	text := resultText(t, h, result, err)
+
+	for _, want := range []string{"(2):", "Marty McFly (ID: p1)", "Jennifer Parker (ID: p2)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+	if strings.Count(text, "McFly") != 1 {
+		t.Errorf("patient with two matching prescriptions listed more than once:\n%s", text)
+	}
+	if strings.Contains(text, "Tannen") {
+		t.Errorf("patient with a stopped prescription listed:\n%s", text)
+	}
+
+	result, err = h.FindPatientsOnMedication("valsartan", 1)
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "McFly") || strings.Contains(text, "Parker") || !strings.Contains(text, "Showing the first 1 patients") {
+		t.Errorf("limit not applied:\n%s", text)
+	}
+}
+
+func TestGetMedicationForms(t *testing.T) {
+	h := newTestHandler(t)
+	seedMedicationProduct(t, h, "860975", "24 HR Metformin hydrochloride 500 MG Extended Release Oral Tablet", "Extended Release Oral Tablet")
+	seedMedicationProduct(t, h, "861007", "Metformin hydrochloride 500 MG Oral Tablet", "Oral Tablet")
+	seedMedicationProduct(t, h, "861007", "Metformin hydrochloride 500 MG Oral Tablet", "Oral Tablet") // duplicate row
+	seedMedicationProduct(t, h, "861004", "Metformin hydrochloride 1000 MG Oral Tablet", "")
+	seedMedicationProduct(t, h, "314076", "lisinopril 10 MG Oral Tablet", "Oral Tablet")
+
+	result, err := h.GetMedicationForms("metformin")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"(3):",
+		"Metformin hydrochloride 500 MG Oral Tablet (Code: 861007), form: Oral Tablet",
+		"Metformin hydrochloride 1000 MG Oral Tablet (Code: 861004)\n",
+		"Extended Release Oral Tablet (Code: 860975)",
+		"confirm which one is intended",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "lisinopril") {
+		t.Errorf("unrelated medication listed:\n%s", text)
+	}
+
+	result, err = h.GetMedicationForms("lisinopril")
+	if text := resultText(t, h, result, err); strings.Contains(text, "confirm which one") {
+		t.Errorf("single formulation should not ask for confirmation:\n%s", text)
+	}
+
+	if _, err := h.GetMedicationForms("  "); err == nil {
+		t.Error("expected an error for an empty medication name")
+	}
+}
+
+func seedMedicationProduct(t *testing.T, h *Handler, code, display, form string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO medications (id, code, display, form) VALUES (lower(hex(randomblob(16))), ?, ?, NULLIF(?, ''))`,
+		code, display, form)
+	if err != nil {
+		t.Fatalf("Failed to seed medication product: %v", err)
+	}
+}
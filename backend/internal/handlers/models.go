@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+const defaultOpenRouterModelsURL = "https://openrouter.ai/api/v1/models"
+
+// modelListTTL is how long the OpenRouter model list is cached
+const modelListTTL = time.Hour
+
+// openRouterModel is one entry of OpenRouter's /models response. Prices are
+// USD per token, sent as strings.
+type openRouterModel struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int    `json:"context_length"`
+	Pricing       struct {
+		Prompt     string `json:"prompt"`
+		Completion string `json:"completion"`
+	} `json:"pricing"`
+}
+
+// modelListCache holds the last model list fetched from OpenRouter
+type modelListCache struct {
+	mu        sync.Mutex
+	models    []openRouterModel
+	fetchedAt time.Time
+}
+
+// fetchOpenRouterModels downloads the model list from OpenRouter
+func (h *Handler) fetchOpenRouterModels(ctx context.Context) ([]openRouterModel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.openRouterModelsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	req.Header.Set("HTTP-Referer", "https://github.com/eythor/mcp-server")
+	req.Header.Set("X-Title", "Healthcare MCP Server")
+
+	resp, err := h.openRouterClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("OpenRouter API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var list struct {
+		Data []openRouterModel `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+	return list.Data, nil
+}
+
+// openRouterModels returns the OpenRouter model list, fetching it at most
+// once per modelListTTL. A failed fetch is not cached.
+func (h *Handler) openRouterModels(ctx context.Context) ([]openRouterModel, error) {
+	cache := &h.modelList
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.models != nil && time.Since(cache.fetchedAt) < modelListTTL {
+		debug.Verbose("Using cached OpenRouter model list (%d models)", len(cache.models))
+		return cache.models, nil
+	}
+
+	models, err := h.fetchOpenRouterModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	cache.models, cache.fetchedAt = models, time.Now()
+	debug.Log("Fetched %d models from OpenRouter", len(models))
+	return models, nil
+}
+
+// formatTokenPrice turns an OpenRouter per-token price into USD per million
+// tokens, e.g. "0.0000003" into "$0.30". Missing or variable (negative)
+// prices are shown as "?".
+func formatTokenPrice(perToken string) string {
+	price, err := strconv.ParseFloat(strings.TrimSpace(perToken), 64)
+	if err != nil || price < 0 {
+		return "?"
+	}
+	return fmt.Sprintf("$%.2f", price*1e6)
+}
+
+// ListAvailableModels lists the models available on OpenRouter with their
+// context length and price, to help operators choose OPENROUTER_MODEL and
+// friends. It is an admin tool, so it requires MCP_ENABLE_ADMIN_TOOLS.
+func (h *Handler) ListAvailableModels(ctx context.Context) (interface{}, error) {
+	if !h.adminToolsEnabled {
+		return nil, ErrAdminToolsDisabled
+	}
+
+	models, err := h.openRouterModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%d models available on OpenRouter (prices in USD per million prompt/completion tokens):\n\n", len(models)))
+	for _, m := range models {
+		result.WriteString(fmt.Sprintf("• %s - context %d tokens, %s/%s\n",
+			m.ID, m.ContextLength, formatTokenPrice(m.Pricing.Prompt), formatTokenPrice(m.Pricing.Completion)))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const modelsResponse = `{"data": [
+	{"id": "openai/gpt-4o-mini", "name": "GPT-4o mini", "context_length": 128000,
+	 "pricing": {"prompt": "0.00000015", "completion": "0.0000006"}},
+	{"id": "google/gemini-2.5-flash", "name": "Gemini 2.5 Flash", "context_length": 1048576,
+	 "pricing": {"prompt": "0.0000003", "completion": "0.0000025"}},
+	{"id": "openrouter/auto", "name": "Auto Router", "context_length": 2000000,
+	 "pricing": {"prompt": "-1", "completion": "-1"}}
+]}`
+
+func TestListAvailableModels(t *testing.T) {
+	h := newTestHandler(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodGet || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Unexpected request: %s, Authorization %q", r.Method, r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(modelsResponse))
+	}))
+	defer server.Close()
+	h.openRouterModelsURL = server.URL
+
+	if _, err := h.ListAvailableModels(context.Background()); !errors.Is(err, ErrAdminToolsDisabled) {
+		t.Fatalf("Expected ErrAdminToolsDisabled, got %v", err)
+	}
+	h.adminToolsEnabled = true
+
+	result, err := h.ListAvailableModels(context.Background())
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"3 models available",
+		"• google/gemini-2.5-flash - context 1048576 tokens, $0.30/$2.50\n",
+		"• openai/gpt-4o-mini - context 128000 tokens, $0.15/$0.60\n",
+		"• openrouter/auto - context 2000000 tokens, ?/?\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "google/") > strings.Index(text, "openai/") {
+		t.Errorf("Expected models sorted by ID, got:\n%s", text)
+	}
+
+	// Served from the cache until it expires
+	if _, err := h.ListAvailableModels(context.Background()); err != nil {
+		t.Fatalf("ListAvailableModels failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the second call to hit the cache, got %d requests", requests)
+	}
+
+	h.modelList.fetchedAt = time.Now().Add(-modelListTTL)
+	if _, err := h.ListAvailableModels(context.Background()); err != nil {
+		t.Fatalf("ListAvailableModels failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected an expired cache to be refreshed, got %d requests", requests)
+	}
+}
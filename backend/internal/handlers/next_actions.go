@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// polypharmacyThreshold is the number of active medications at which a
+// medication review is suggested
+const polypharmacyThreshold = 5
+
+// immunizationRule is a routine vaccine recommended for an age band, repeated
+// every IntervalYears (0 = once). Terms match the vaccine display.
+type immunizationRule struct {
+	Name          string
+	MinAge        int
+	MaxAge        int // inclusive; 0 means no upper limit
+	IntervalYears int
+	Terms         []string
+}
+
+var immunizationRules = []immunizationRule{
+	{Name: "Influenza vaccine", MinAge: 1, IntervalYears: 1, Terms: []string{"influenza"}},
+	{Name: "Td/Tdap booster", MinAge: 19, IntervalYears: 10, Terms: []string{"td (adult)", "tdap", "tetanus"}},
+	{Name: "Pneumococcal vaccine", MinAge: 65, Terms: []string{"pneumococcal"}},
+	{Name: "Shingles (zoster) vaccine", MinAge: 50, Terms: []string{"zoster"}},
+}
+
+// referenceRange flags the latest observation matching Terms when it falls
+// outside [Low, High]; a zero bound is not checked
+type referenceRange struct {
+	Name  string
+	Terms []string
+	Low   float64
+	High  float64
+}
+
+var referenceRanges = []referenceRange{
+	{Name: "Systolic blood pressure", Terms: []string{"systolic blood pressure"}, Low: 90, High: 140},
+	{Name: "Diastolic blood pressure", Terms: []string{"diastolic blood pressure"}, Low: 60, High: 90},
+	{Name: "HbA1c", Terms: []string{"a1c"}, High: 6.5},
+	{Name: "Glucose", Terms: []string{"glucose"}, Low: 70, High: 125},
+	{Name: "LDL cholesterol", Terms: []string{"low density lipoprotein", "ldl"}, High: 160},
+	{Name: "Total cholesterol", Terms: []string{"cholesterol [mass/volume]", "total cholesterol"}, High: 240},
+	{Name: "eGFR", Terms: []string{"glomerular filtration"}, Low: 60},
+	{Name: "Potassium", Terms: []string{"potassium"}, Low: 3.5, High: 5.5},
+	{Name: "BMI", Terms: []string{"body mass index"}, High: 30},
+	{Name: "Heart rate", Terms: []string{"heart rate"}, Low: 50, High: 100},
+}
+
+// Action priorities, most urgent first
+const (
+	priorityHigh = iota
+	priorityMedium
+	priorityLow
+)
+
+var priorityLabels = map[int]string{priorityHigh: "HIGH", priorityMedium: "MEDIUM", priorityLow: "LOW"}
+
+type suggestedAction struct {
+	Priority int
+	Text     string
+}
+
+// overdueImmunizations returns the immunization rules that apply to age and
+// have no dose recorded within their interval
+func overdueImmunizations(age int, immunizations []database.Immunization, now time.Time) []string {
+	var overdue []string
+	for _, rule := range immunizationRules {
+		if age < rule.MinAge || (rule.MaxAge > 0 && age > rule.MaxAge) {
+			continue
+		}
+		var last *time.Time
+		for _, imm := range immunizations {
+			if imm.Status != "completed" && imm.Status != "" {
+				continue
+			}
+			if !containsAny(imm.VaccineDisplay, rule.Terms) {
+				continue
+			}
+			if t, ok := parseRecordTime(imm.OccurrenceDateTime); ok && (last == nil || t.After(*last)) {
+				last = &t
+			}
+		}
+		switch {
+		case last == nil:
+			overdue = append(overdue, fmt.Sprintf("%s: no dose on record", rule.Name))
+		case rule.IntervalYears > 0 && !last.AddDate(rule.IntervalYears, 0, 0).After(now):
+			overdue = append(overdue, fmt.Sprintf("%s: last dose %s", rule.Name, last.Format("2006-01-02")))
+		}
+	}
+	return overdue
+}
+
+// abnormalObservations checks the most recent value of each reference range.
+// Observations must be ordered newest first, as GetObservationsByPatientID returns them.
+func abnormalObservations(observations []database.Observation) []string {
+	var abnormal []string
+	for _, rr := range referenceRanges {
+		for _, o := range observations {
+			if o.ValueQuantity == nil || strings.Contains(strings.ToLower(o.Display), "percentile") {
+				continue
+			}
+			if !containsAny(o.Display, rr.Terms) {
+				continue
+			}
+			value := *o.ValueQuantity
+			direction := ""
+			if rr.High > 0 && value > rr.High {
+				direction = "high"
+			} else if rr.Low > 0 && value < rr.Low {
+				direction = "low"
+			}
+			if direction != "" {
+				date := recordDay(derefString(o.EffectiveDateTime))
+				abnormal = append(abnormal, fmt.Sprintf("%s %s: %s %s (%s)", rr.Name, direction,
+						FormatValue(value, o.Code, derefString(o.ValueUnit)), derefString(o.ValueUnit), date))
+			}
+			break
+		}
+	}
+	return abnormal
+}
+
+// suggestNextActions combines the individual checks into a prioritized list
+func suggestNextActions(age int, gender string, conditions []database.Condition, medications []database.MedicationRequest,
+	observations []database.Observation, immunizations []database.Immunization, procedures []database.Procedure,
+	encounters []database.Encounter, now time.Time) []suggestedAction {
+	var actions []suggestedAction
+
+	for _, f := range findContraindications(medications, conditions) {
+		actions = append(actions, suggestedAction{priorityHigh,
+			fmt.Sprintf("Review %s: contraindicated with %s (%s)", f.Medication, f.Condition, f.Rule.Reason)})
+	}
+
+	for _, a := range abnormalObservations(observations) {
+		actions = append(actions, suggestedAction{priorityMedium, "Follow up abnormal result — " + a})
+	}
+
+	activeMeds := 0
+	for _, m := range medications {
+		if m.Status == "active" || m.Status == "" {
+			activeMeds++
+		}
+	}
+	if activeMeds >= polypharmacyThreshold {
+		actions = append(actions, suggestedAction{priorityMedium,
+			fmt.Sprintf("Medication review for polypharmacy: %d active medications", activeMeds)})
+	}
+
+	if age >= 0 {
+		for _, imm := range overdueImmunizations(age, immunizations, now) {
+			actions = append(actions, suggestedAction{priorityMedium, "Overdue immunization — " + imm})
+		}
+		for _, s := range evaluateScreenings(age, gender, procedures, observations, now) {
+			if s.Due {
+				actions = append(actions, suggestedAction{priorityLow, "Screening due — " + s.Rule.Name})
+			}
+		}
+	}
+
+	var upcoming []database.Encounter
+	for _, e := range encounters {
+		if e.Status != "planned" && e.Status != "booked" && e.Status != "arrived" {
+			continue
+		}
+		if t, ok := parseRecordTime(e.StartDateTime); ok && t.After(now) {
+			upcoming = append(upcoming, e)
+		}
+	}
+	if len(upcoming) > 0 {
+		// Encounters are ordered newest first, so the soonest is last
+		next := upcoming[len(upcoming)-1]
+		actions = append(actions, suggestedAction{priorityLow,
+			fmt.Sprintf("Upcoming appointment on %s (ID: %s): items above can be addressed then", next.StartDateTime, next.ID)})
+	} else if len(actions) > 0 {
+		actions = append(actions, suggestedAction{priorityLow, "No upcoming appointment scheduled: consider booking a follow-up"})
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].Priority < actions[j].Priority
+	})
+	return actions
+}
+
+func (h *Handler) SuggestNextActions(ctx context.Context, patientID string, includeAIReview bool) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+	medications, err := database.GetMedicationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	immunizations, err := database.GetImmunizationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get immunizations: %w", err)
+	}
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+	encounters, err := database.GetEncountersByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Recommended actions for %s %s (ID: %s)\n\n", patient.GivenName, patient.FamilyName, patientID))
+
+	// Age-based checks are skipped when the birth date is unknown
+	age, err := calculateAge(patient.BirthDate)
+	if err != nil {
+		age = -1
+		result.WriteString("Note: birth date unknown, immunization and screening checks skipped.\n\n")
+	}
+
+	actions := suggestNextActions(age, patient.Gender, conditions, medications, observations, immunizations, procedures, encounters, time.Now())
+	if len(actions) == 0 {
+		result.WriteString("No actions recommended at this time.\n")
+	}
+	for i, a := range actions {
+		result.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, priorityLabels[a.Priority], a.Text))
+	}
+
+	if includeAIReview && len(actions) > 0 {
+		var list strings.Builder
+		for _, a := range actions {
+			list.WriteString(fmt.Sprintf("- [%s] %s\n", priorityLabels[a.Priority], a.Text))
+		}
+		prompt := fmt.Sprintf("These recommended actions were generated by rules for a patient (%s, age %d) with active conditions: %s.\n\n%s\nBriefly point out anything that should be reprioritized and any important action that is missing. Be concise.",
+			patient.Gender, age, strings.Join(activeConditionNames(conditions), ", "), list.String())
+		review, err := h.callGuidelinesModel(ctx, prompt)
+		if err != nil {
+			debug.Error("Failed to review next actions: %v", err)
+			result.WriteString("\nAI review unavailable: unable to reach the guidelines model.\n")
+		} else {
+			result.WriteString("\nAI review:\n" + strings.TrimSpace(review) + "\n")
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
+
+func activeConditionNames(conditions []database.Condition) []string {
+	var names []string
+	for _, c := range conditions {
+		if c.ClinicalStatus == "active" || c.ClinicalStatus == "" {
+			names = append(names, c.Display)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"none recorded"}
+	}
+	return names
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuggestNextActions(t *testing.T) {
+	h := newTestHandler(t)
+	birthDate := time.Now().AddDate(-70, 0, -1).Format("2006-01-02")
+	seedPatient(t, h, "p1", "Greta", "Lund", "female", birthDate)
+
+	// Overdue influenza vaccine (last dose two years ago) and a recent Td
+	seedImmunization(t, h, "p1", "Influenza, seasonal, injectable", "completed", time.Now().AddDate(-2, 0, 0).Format(time.RFC3339))
+	seedImmunization(t, h, "p1", "Td (adult) preservative free", "completed", time.Now().AddDate(-1, 0, 0).Format(time.RFC3339))
+
+	// Abnormal blood pressure, with an older normal reading that should be ignored
+	seedObservation(t, h, "p1", "8480-6", "Systolic Blood Pressure", 165, "mm[Hg]", time.Now().AddDate(0, -1, 0).Format(time.RFC3339))
+	seedObservation(t, h, "p1", "8480-6", "Systolic Blood Pressure", 120, "mm[Hg]", time.Now().AddDate(-1, 0, 0).Format(time.RFC3339))
+
+	// Polypharmacy with a contraindicated NSAID
+	seedCondition(t, h, "p1", "88805009", "Chronic congestive heart failure", "active", "2018-01-01")
+	for _, med := range []string{"Ibuprofen 400 MG", "Lisinopril 10 MG", "Metoprolol 50 MG", "Furosemide 40 MG", "Atorvastatin 20 MG"} {
+		seedMedication(t, h, "p1", med, "active", "2024-01-01")
+	}
+
+	seedEncounter(t, h, "e1", "p1", "planned", time.Now().AddDate(0, 0, 14).Format(time.RFC3339))
+
+	result, err := h.SuggestNextActions(context.Background(), "p1", false)
+	text := resultText(t, h, result, err)
+
+	for _, want := range []string{
+		"Overdue immunization — Influenza vaccine",
+		"Systolic blood pressure high: 165",
+		"polypharmacy: 5 active medications",
+		"Review Ibuprofen 400 MG",
+		"Upcoming appointment",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in actions, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Td/Tdap booster") {
+		t.Errorf("Td given last year should not be overdue, got:\n%s", text)
+	}
+
+	// High priority items come before medium and low ones
+	high := strings.Index(text, "[HIGH]")
+	medium := strings.Index(text, "[MEDIUM]")
+	low := strings.Index(text, "[LOW]")
+	if high < 0 || medium < high || low < medium {
+		t.Errorf("Expected actions ordered by priority, got:\n%s", text)
+	}
+}
+
+func TestSuggestNextActionsAIReview(t *testing.T) {
+	h := newTestHandler(t)
+	prompt := fakeModel(t, h, "Prioritize the blood pressure follow-up.")
+	seedPatient(t, h, "p1", "Greta", "Lund", "female", "1955-01-01")
+
+	result, err := h.SuggestNextActions(context.Background(), "p1", true)
+	text := resultText(t, h, result, err)
+
+	if !strings.Contains(text, "AI review:\nPrioritize the blood pressure follow-up.") {
+		t.Errorf("Expected AI review in result, got:\n%s", text)
+	}
+	if !strings.Contains(*prompt, "Influenza vaccine") {
+		t.Errorf("Expected rule-based actions in the model prompt, got:\n%s", *prompt)
+	}
+}
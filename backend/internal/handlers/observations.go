@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// observationStatuses are the FHIR Observation.status codes
+var observationStatuses = []string{
+	"registered", "preliminary", "final", "amended", "corrected", "cancelled", "entered-in-error", "unknown",
+}
+
+func isObservationStatus(status string) bool {
+	for _, s := range observationStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// describeObservation renders an observation as "Heart rate: 72 /min (date)"
+func describeObservation(o *database.Observation) string {
+	description := o.Display
+	if o.ValueQuantity != nil {
+		unit := derefString(o.ValueUnit)
+		description += strings.TrimRight(fmt.Sprintf(": %s %s", FormatValue(*o.ValueQuantity, o.Code, unit), unit), " ")
+	} else if o.ValueString != nil {
+		description += ": " + *o.ValueString
+	}
+	if o.EffectiveDateTime != nil {
+		description += fmt.Sprintf(" (%s)", formatRecordTime(*o.EffectiveDateTime))
+	}
+	if o.Status != "" {
+		description += " [" + o.Status + "]"
+	}
+	return description
+}
+
+// getObservation looks up an observation, turning a missing row into an
+// "observation not found" error
+func (h *Handler) getObservation(observationID string) (*database.Observation, error) {
+	if observationID == "" {
+		return nil, fmt.Errorf("observation ID is required")
+	}
+	observation, err := database.GetObservationByID(h.db, observationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("observation not found: %s", observationID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return observation, nil
+}
+
+// refreshContextSummary reloads the cached medical summary if patientID is
+// the current patient, so it doesn't show data that was just changed
+func (h *Handler) refreshContextSummary(patientID string) {
+	h.mu.RLock()
+	currentPatientID := h.context.PatientID
+	h.mu.RUnlock()
+
+	if currentPatientID != patientID {
+		return
+	}
+	medicalSummary, err := h.fetchPatientMedicalSummary(patientID)
+	if err != nil {
+		debug.Error("Failed to refresh medical summary: %v", err)
+		return
+	}
+	h.mu.Lock()
+	h.context.PatientSummary = medicalSummary
+	h.mu.Unlock()
+}
+
+// DeleteObservation removes a mistakenly entered observation and confirms
+// which patient it belonged to
+func (h *Handler) DeleteObservation(observationID string) (interface{}, error) {
+	observationID = strings.TrimSpace(observationID)
+	observation, err := h.getObservation(observationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DeleteObservation(h.db, observationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("observation not found: %s", observationID)
+		}
+		return nil, fmt.Errorf("failed to delete observation: %w", err)
+	}
+	h.refreshContextSummary(observation.PatientID)
+
+	patientName, _ := database.GetPatientName(h.db, observation.PatientID)
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("✓ Deleted observation %s for patient %s (ID: %s)\n%s",
+					observationID, patientName, observation.PatientID, describeObservation(observation)),
+			},
+		},
+	}, nil
+}
+
+// UpdateObservation corrects an observation's value, unit or status. Only
+// the provided fields change; an empty status leaves the status as it is.
+func (h *Handler) UpdateObservation(observationID string, valueQuantity *float64, valueUnit, valueString *string, status string) (interface{}, error) {
+	observationID = strings.TrimSpace(observationID)
+	status = strings.ToLower(strings.TrimSpace(status))
+
+	if valueQuantity == nil && valueUnit == nil && valueString == nil && status == "" {
+		return nil, fmt.Errorf("nothing to update: provide a value, unit or status")
+	}
+	if status != "" && !isObservationStatus(status) {
+		return nil, fmt.Errorf("invalid observation status %q (must be one of: %s)", status, strings.Join(observationStatuses, ", "))
+	}
+
+	before, err := h.getObservation(observationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.UpdateObservation(h.db, observationID, valueQuantity, valueUnit, valueString, status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("observation not found: %s", observationID)
+		}
+		return nil, fmt.Errorf("failed to update observation: %w", err)
+	}
+
+	after, err := h.getObservation(observationID)
+	if err != nil {
+		return nil, err
+	}
+	h.refreshContextSummary(after.PatientID)
+
+	patientName, _ := database.GetPatientName(h.db, after.PatientID)
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("✓ Updated observation %s for patient %s (ID: %s)\nBefore: %s\nAfter: %s",
+					observationID, patientName, after.PatientID, describeObservation(before), describeObservation(after)),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestDeleteObservation(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 72, "/min", "2024-03-01T09:00:00Z")
+	seedObservation(t, h, "p1", "8310-5", "Body temperature", 37.2, "Cel", "2024-03-01T09:00:00Z")
+
+	var observationID string
+	if err := h.db.QueryRow("SELECT id FROM observations WHERE code = '8867-4'").Scan(&observationID); err != nil {
+		t.Fatalf("Failed to find seeded observation: %v", err)
+	}
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext: %v", err)
+	}
+
+	result, err := h.DeleteObservation(observationID)
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"Deleted observation " + observationID, "Marty McFly (ID: p1)", "Heart rate: 72 /min"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	var remaining int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM observations WHERE patient_id = 'p1'").Scan(&remaining); err != nil {
+		t.Fatalf("Failed to count observations: %v", err)
+	}
+	if remaining != 1 {
+		t.Errorf("got %d observations after delete, want 1", remaining)
+	}
+	for _, obs := range currentContext(h).PatientSummary.RecentObservations {
+		if strings.Contains(obs, "Heart rate") {
+			t.Errorf("deleted observation still in context summary: %q", obs)
+		}
+	}
+
+	if _, err := h.DeleteObservation(observationID); err == nil || !strings.Contains(err.Error(), "observation not found") {
+		t.Errorf("deleting twice: got %v, want observation not found", err)
+	}
+}
+
+func TestUpdateObservation(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 72, "/min", "2024-03-01T09:00:00Z")
+
+	var observationID string
+	if err := h.db.QueryRow("SELECT id FROM observations").Scan(&observationID); err != nil {
+		t.Fatalf("Failed to find seeded observation: %v", err)
+	}
+
+	value := 78.0
+	result, err := h.UpdateObservation(observationID, &value, nil, nil, "")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"Before: Heart rate: 72 /min", "After: Heart rate: 78 /min", "[final]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	// A status-only update leaves the value alone
+	result, err = h.UpdateObservation(observationID, nil, nil, nil, "Amended")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "After: Heart rate: 78 /min (2024-03-01 10:00 CET) [amended]") {
+		t.Errorf("status-only update:\n%s", text)
+	}
+
+	if _, err := h.UpdateObservation(observationID, nil, nil, nil, "revised"); err == nil || !strings.Contains(err.Error(), "invalid observation status") {
+		t.Errorf("invalid status: got %v", err)
+	}
+	if _, err := h.UpdateObservation(observationID, nil, nil, nil, ""); err == nil {
+		t.Error("update without fields succeeded")
+	}
+	if _, err := h.UpdateObservation("missing", &value, nil, nil, ""); err == nil || !strings.Contains(err.Error(), "observation not found") {
+		t.Errorf("unknown ID: got %v", err)
+	}
+}
+
+func TestAddObservationNormalizesTimeZone(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	weight, unit := 80.0, "kg"
+
+	// 10:30+02:00 is 08:30 UTC, so it is older than 09:00 UTC even though it
+	// sorts after it as text
+	if _, err := h.AddObservation("p1", "29463-7", "Body weight", "", "", "2024-03-01T09:00:00Z", &weight, &unit, nil); err != nil {
+		t.Fatalf("AddObservation: %v", err)
+	}
+	weight = 79.0
+	result, err := h.AddObservation("p1", "29463-7", "Body weight", "", "", "2024-03-01T10:30:00+02:00", &weight, &unit, nil)
+	if text := resultText(t, h, result, err); !strings.Contains(text, "Effective Date: 2024-03-01 09:30 CET") {
+		t.Errorf("effective date not shown in the display zone:\n%s", text)
+	}
+
+	var stored string
+	if err := h.db.QueryRow("SELECT CAST(effective_datetime AS TEXT) FROM observations WHERE value_quantity = 79").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read observation: %v", err)
+	}
+	if stored != "2024-03-01T08:30:00Z" {
+		t.Errorf("stored effective_datetime = %q, want UTC", stored)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, "p1")
+	if err != nil || len(observations) != 2 {
+		t.Fatalf("got %d observations (err %v)", len(observations), err)
+	}
+	if *observations[0].ValueQuantity != 80 {
+		t.Errorf("latest observation is %v kg, want 80 kg", *observations[0].ValueQuantity)
+	}
+}
+
+func TestAddObservationBackdated(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	weight, unit := 80.0, "kg"
+
+	// Clinical records can be backdated, even by years
+	result, err := h.AddObservation("p1", "29463-7", "Body weight", "", "", "14.03.2019 10:30", &weight, &unit, nil)
+	if text := resultText(t, h, result, err); !strings.Contains(text, "Effective Date: 2019-03-14 10:30 CET") {
+		t.Errorf("backdated observation not recorded:\n%s", text)
+	}
+	if _, err := h.AddObservation("p1", "29463-7", "Body weight", "", "", "yesterday", &weight, &unit, nil); err != nil {
+		t.Errorf("AddObservation(yesterday): %v", err)
+	}
+
+	future := time.Now().AddDate(0, 0, 3).Format(time.RFC3339)
+	if _, err := h.AddObservation("p1", "29463-7", "Body weight", "", "", future, &weight, &unit, nil); err == nil || !strings.Contains(err.Error(), "in the future") {
+		t.Errorf("expected a future observation to be rejected, got %v", err)
+	}
+}
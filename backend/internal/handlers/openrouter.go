@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// openRouterMaxAttempts is how many times a request is sent before a
+// transient failure is returned to the caller
+const openRouterMaxAttempts = 3
+
+// defaultRetryBaseDelay is the backoff before the first retry; it doubles
+// for each later retry
+const defaultRetryBaseDelay = 500 * time.Millisecond
+
+// maxRetryAfter caps how long a Retry-After header can make us wait
+const maxRetryAfter = 30 * time.Second
+
+// defaultOpenRouterTimeout bounds a whole OpenRouter request, including
+// reading a streamed response
+const defaultOpenRouterTimeout = 60 * time.Second
+
+// nonJSONLogBytes is how much of an unexpected non-JSON response is logged
+const nonJSONLogBytes = 512
+
+// errNonJSONResponse is returned when OpenRouter answers 200 with something
+// other than JSON, typically an HTML error page served during an outage
+var errNonJSONResponse = errors.New("LLM backend returned an unexpected non-JSON response")
+
+// checkJSONResponse detects a response that isn't JSON (or a JSON event
+// stream) by an HTML Content-Type or a body starting with "<". Such a
+// response is logged, closed and reported as errNonJSONResponse; otherwise
+// the body is left to be read from the start.
+func checkJSONResponse(resp *http.Response) error {
+	body := bufio.NewReader(resp.Body)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+
+	// Peek one byte at a time so a streamed response isn't held up waiting
+	// for more data than the first non-space character
+	var first byte
+	for n := 1; ; n++ {
+		peeked, err := body.Peek(n)
+		if err != nil {
+			break
+		}
+		if c := peeked[n-1]; c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			first = c
+			break
+		}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if first != '<' && !strings.Contains(strings.ToLower(contentType), "html") {
+		return nil
+	}
+
+	prefix, _ := io.ReadAll(io.LimitReader(body, nonJSONLogBytes))
+	resp.Body.Close()
+	debug.Verbose("OpenRouter returned a non-JSON response (Content-Type %q): %s", contentType, prefix)
+	return errNonJSONResponse
+}
+
+// newOpenRouterClient returns the client shared by all OpenRouter calls. Its
+// transport keeps connections to OpenRouter open between requests.
+func newOpenRouterClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// SetOpenRouterClient replaces the client used for OpenRouter requests, e.g.
+// to route them through a test server or a custom transport
+func (h *Handler) SetOpenRouterClient(client *http.Client) {
+	h.openRouterClient = client
+}
+
+// isRetryableStatus reports whether an OpenRouter status is worth retrying:
+// rate limiting and upstream outages, but not bad requests or auth failures
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryDelay returns how long to wait before retry number attempt (from 1).
+// A Retry-After header in seconds or as an HTTP date wins; otherwise the
+// delay is base doubled per attempt, jittered to between half and all of it.
+// Headers that don't parse or are in the past fall back to the backoff.
+func retryDelay(attempt int, base time.Duration, retryAfter string, now time.Time) time.Duration {
+	if retryAfter = strings.TrimSpace(retryAfter); retryAfter != "" {
+		wait := time.Duration(-1)
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(retryAfter); err == nil {
+			wait = at.Sub(now)
+		}
+		if wait > maxRetryAfter {
+			wait = maxRetryAfter
+		}
+		if wait >= 0 {
+			return wait
+		}
+	}
+
+	backoff := base << (attempt - 1)
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// postOpenRouter sends a chat completion request, retrying rate limits,
+// upstream errors and timeouts with backoff. It returns the response only
+// for status 200 with a body that isn't an HTML page; the caller closes its
+// body. Cancelling ctx aborts the request and any wait before a retry.
+func (h *Handler) postOpenRouter(ctx context.Context, reqBody map[string]interface{}) (*http.Response, error) {
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", h.openRouterURL, bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("HTTP-Referer", "https://github.com/eythor/mcp-server")
+		req.Header.Set("X-Title", "Healthcare MCP Server")
+
+		resp, err := h.openRouterClient.Do(req)
+		var retryAfter string
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !isTimeout(err) || attempt == openRouterMaxAttempts {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			debug.Error("OpenRouter request timed out: %v", err)
+		case resp.StatusCode == http.StatusOK:
+			if err := checkJSONResponse(resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		default:
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if !isRetryableStatus(resp.StatusCode) || attempt == openRouterMaxAttempts {
+				return nil, fmt.Errorf("OpenRouter API error (%d): %s", resp.StatusCode, string(body))
+			}
+			retryAfter = resp.Header.Get("Retry-After")
+			debug.Error("OpenRouter returned %d: %s", resp.StatusCode, string(body))
+		}
+
+		delay := retryDelay(attempt, h.retryBaseDelay, retryAfter, time.Now())
+		debug.Log("Retrying OpenRouter request in %s (attempt %d of %d)", delay, attempt+1, openRouterMaxAttempts)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("request failed: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	base := 100 * time.Millisecond
+
+	// Backoff is jittered, so it is checked against a range
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"first attempt", 1, "", base / 2, base},
+		{"second attempt", 2, "", base, 2 * base},
+		{"third attempt", 3, "", 2 * base, 4 * base},
+		{"Retry-After seconds", 1, "2", 2 * time.Second, 2 * time.Second},
+		{"Retry-After zero", 3, "0", 0, 0},
+		{"Retry-After capped", 1, "120", maxRetryAfter, maxRetryAfter},
+		{"Retry-After date", 1, now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, 5 * time.Second},
+		{"Retry-After date in the past", 2, now.Add(-time.Minute).Format(http.TimeFormat), base, 2 * base},
+		{"Retry-After unparseable", 1, "soon", base / 2, base},
+		{"Retry-After negative", 1, "-5", base / 2, base},
+	}
+	for _, tt := range tests {
+		if d := retryDelay(tt.attempt, base, tt.retryAfter, now); d < tt.min || d > tt.max {
+			t.Errorf("%s: delay %s outside [%s, %s]", tt.name, d, tt.min, tt.max)
+		}
+	}
+}
+
+// flakyModel answers with the given statuses in turn, then succeeds
+func flakyModel(t *testing.T, h *Handler, statuses ...int) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if n <= len(statuses) {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "upstream unavailable", statuses[n-1])
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": "recovered"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+	h.retryBaseDelay = time.Millisecond
+	return &requests
+}
+
+func TestOpenRouterRetriesTransientFailures(t *testing.T) {
+	h := newTestHandler(t)
+	requests := flakyModel(t, h, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	reply, err := h.callOpenRouter(context.Background(), "hello")
+	if err != nil || reply != "recovered" {
+		t.Fatalf("callOpenRouter = %q, %v; want recovered", reply, err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+
+	h = newTestHandler(t)
+	requests = flakyModel(t, h, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "hello", "", false); err == nil || !strings.Contains(err.Error(), "(502)") {
+		t.Errorf("expected the 502 after the last attempt, got %v", err)
+	}
+	if n := requests.Load(); n != openRouterMaxAttempts {
+		t.Errorf("requests = %d, want %d", n, openRouterMaxAttempts)
+	}
+}
+
+func TestOpenRouterDoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		h := newTestHandler(t)
+		requests := flakyModel(t, h, status)
+		if _, err := h.callGuidelinesModel(context.Background(), "hello"); err == nil {
+			t.Errorf("%d: expected an error", status)
+		}
+		if n := requests.Load(); n != 1 {
+			t.Errorf("%d: requests = %d, want 1", status, n)
+		}
+	}
+}
+
+func TestOpenRouterNonJSONResponse(t *testing.T) {
+	for _, contentType := range []string{"text/html; charset=utf-8", "application/json"} {
+		h := newTestHandler(t)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			io.WriteString(w, "\n<!DOCTYPE html><html><body><h1>502 Bad Gateway</h1></body></html>")
+		}))
+		t.Cleanup(server.Close)
+		h.openRouterURL = server.URL
+
+		if _, err := h.callOpenRouter(context.Background(), "hello"); !errors.Is(err, errNonJSONResponse) {
+			t.Errorf("%s: callOpenRouter error = %v, want %v", contentType, err, errNonJSONResponse)
+		}
+		if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "hello", "", false); err == nil || !strings.Contains(err.Error(), "unexpected non-JSON response") {
+			t.Errorf("%s: ProcessNaturalLanguageQuery error = %v, want the non-JSON error", contentType, err)
+		}
+	}
+}
+
+// roundTripFunc lets a function stand in for an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestSetOpenRouterClient(t *testing.T) {
+	h := newTestHandler(t)
+	var hosts []string
+	h.SetOpenRouterClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Host)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"role":"assistant","content":"injected"}}]}`)),
+		}, nil
+	})})
+
+	if reply, err := h.callOpenRouter(context.Background(), "hello"); err != nil || reply != "injected" {
+		t.Errorf("callOpenRouter reply = %q, %v; want injected", reply, err)
+	}
+	if reply, err := h.callGuidelinesModel(context.Background(), "hello"); err != nil || reply != "injected" {
+		t.Errorf("callGuidelinesModel reply = %q, %v; want injected", reply, err)
+	}
+	if len(hosts) != 2 || hosts[0] != "openrouter.ai" {
+		t.Errorf("requests = %v, want two to openrouter.ai", hosts)
+	}
+}
+
+func TestOpenRouterRetriesTimeouts(t *testing.T) {
+	t.Setenv("MCP_OPENROUTER_TIMEOUT", "50ms")
+	h := newTestHandler(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"second try"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+	h.retryBaseDelay = time.Millisecond
+
+	if reply, err := h.callOpenRouter(context.Background(), "hello"); err != nil || reply != "second try" {
+		t.Errorf("reply = %q, %v; want second try", reply, err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestOpenRouterCancelledContext(t *testing.T) {
+	h := newTestHandler(t)
+	var requests atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		io.Copy(io.Discard, r.Body)
+		// The client goes away while the model is still working
+		cancel()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+	h.retryBaseDelay = time.Millisecond
+
+	_, err := h.ProcessNaturalLanguageQuery(ctx, "hello", "", false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 (no retries after cancellation)", n)
+	}
+}
+
+func TestModelToolsStopWhenCancelled(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(h *Handler, ctx context.Context) error
+	}{
+		{"get_medication_info", func(h *Handler, ctx context.Context) error {
+			_, err := h.GetMedicationInfo(ctx, "metformin")
+			return err
+		}},
+		{"get_medical_guidelines", func(h *Handler, ctx context.Context) error {
+			_, err := h.GetMedicalGuidelines(ctx, "hypertension targets")
+			return err
+		}},
+		{"answer_health_question", func(h *Handler, ctx context.Context) error {
+			_, err := h.AnswerHealthQuestion(ctx, "is coffee bad for you?")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			var requests atomic.Int32
+			ctx, cancel := context.WithCancel(context.Background())
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				io.Copy(io.Discard, r.Body)
+				// The client goes away while the model is still working
+				cancel()
+				<-r.Context().Done()
+			}))
+			t.Cleanup(server.Close)
+			h.openRouterURL = server.URL
+			h.retryBaseDelay = time.Millisecond
+
+			if err := tt.call(h, ctx); !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+			if n := requests.Load(); n != 1 {
+				t.Errorf("requests = %d, want 1 (no retries after cancellation)", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// observationPanel is a group of observations of one category taken at the
+// same time, such as the results of a lipid panel or one set of vital signs.
+// An observation without related results forms a panel of its own.
+type observationPanel struct {
+	Category          string
+	EffectiveDateTime string
+	Observations      []database.Observation
+}
+
+// groupObservationPanels groups observations sharing an effective datetime
+// and category, keeping the order in which each group first appears.
+// Observations without a datetime are never grouped.
+func groupObservationPanels(observations []database.Observation) []observationPanel {
+	var panels []observationPanel
+	index := make(map[string]int)
+	for _, o := range observations {
+		if o.EffectiveDateTime != nil && *o.EffectiveDateTime != "" {
+			key := *o.EffectiveDateTime + "|" + o.Category
+			if i, ok := index[key]; ok {
+				panels[i].Observations = append(panels[i].Observations, o)
+				continue
+			}
+			index[key] = len(panels)
+		}
+		panels = append(panels, observationPanel{
+			Category:          o.Category,
+			EffectiveDateTime: observationDate(o),
+			Observations:      []database.Observation{o},
+		})
+	}
+	return panels
+}
+
+// observationValue renders an observation's value with its unit, or "" when
+// it has none
+func observationValue(o database.Observation) string {
+	if o.ValueQuantity != nil {
+		unit := derefString(o.ValueUnit)
+		value := FormatValue(*o.ValueQuantity, o.Code, unit)
+		if unit != "" {
+			value += " " + unit
+		}
+		return value
+	}
+	return derefString(o.ValueString)
+}
+
+// writeObservationPanel writes a panel as one block: a header with the
+// category, time and shared status, then one line per result. The status is
+// repeated per result only when the results differ.
+func writeObservationPanel(w io.StringWriter, panel observationPanel) {
+	status := panel.Observations[0].Status
+	for _, o := range panel.Observations[1:] {
+		if o.Status != status {
+			status = ""
+			break
+		}
+	}
+
+	w.WriteString(fmt.Sprintf("• %s panel (%d results)\n", panel.Category, len(panel.Observations)))
+	if panel.EffectiveDateTime != "" {
+		w.WriteString(fmt.Sprintf("  Date: %s\n", formatRecordTime(panel.EffectiveDateTime)))
+	}
+	if status != "" {
+		w.WriteString(fmt.Sprintf("  Status: %s\n", status))
+	}
+	for _, o := range panel.Observations {
+		line := "  - " + o.Display
+		if value := observationValue(o); value != "" {
+			line += ": " + value
+		}
+		if status == "" {
+			line += fmt.Sprintf(" [%s]", o.Status)
+		}
+		w.WriteString(line + "\n")
+	}
+}
+
+// GetPanelResult shows the patient's observation panels as single blocks:
+// those taken on date, or the most recent panel when date is empty.
+func (h *Handler) GetPanelResult(patientID, date string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	if strings.TrimSpace(date) == "" {
+		return h.panelResult(patientID, "")
+	}
+	day, err := ParseDateTimeRobust(date)
+	if err != nil {
+		var ambiguous *AmbiguousDateError
+		if errors.As(err, &ambiguous) {
+			return h.awaitDateChoice(ambiguous, "panel results", func(t time.Time) (interface{}, error) {
+				return h.panelResult(patientID, t.In(displayLocation).Format("2006-01-02"))
+			}), nil
+		}
+		return nil, fmt.Errorf("invalid date: %w", err)
+	}
+	return h.panelResult(patientID, day.In(displayLocation).Format("2006-01-02"))
+}
+
+func (h *Handler) panelResult(patientID, day string) (interface{}, error) {
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	// Only groups of related results are panels
+	var panels []observationPanel
+	for _, panel := range groupObservationPanels(observations) {
+		if len(panel.Observations) < 2 {
+			continue
+		}
+		if day != "" && recordDay(panel.EffectiveDateTime) != day {
+			continue
+		}
+		panels = append(panels, panel)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	switch {
+	case len(panels) == 0 && day != "":
+		result.WriteString(fmt.Sprintf("No observation panels found for %s (ID: %s) on %s.", patientName, patientID, day))
+	case len(panels) == 0:
+		result.WriteString(fmt.Sprintf("No observation panels found for %s (ID: %s).", patientName, patientID))
+	case day != "":
+		result.WriteString(fmt.Sprintf("Observation panels for %s (ID: %s) on %s\n\n", patientName, patientID, day))
+		for _, panel := range panels {
+			writeObservationPanel(&result, panel)
+		}
+	default:
+		// Observations come most recent first
+		result.WriteString(fmt.Sprintf("Most recent observation panel for %s (ID: %s)\n\n", patientName, patientID))
+		writeObservationPanel(&result, panels[0])
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
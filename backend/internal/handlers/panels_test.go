@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func seedLabObservation(t *testing.T, h *Handler, patientID, display string, value float64, unit, status, effectiveDateTime string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO observations (id, status, category, code, display, patient_id, effective_datetime, value_quantity, value_unit)
+		VALUES (lower(hex(randomblob(16))), ?, 'laboratory', '', ?, ?, ?, ?, ?)`,
+		status, display, patientID, effectiveDateTime, value, unit)
+	if err != nil {
+		t.Fatalf("Failed to seed observation: %v", err)
+	}
+}
+
+func TestGetPanelResult(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+	seedLabObservation(t, h, "p1", "Total cholesterol", 5.2, "mmol/L", "final", "2024-03-14T08:00:00Z")
+	seedLabObservation(t, h, "p1", "HDL cholesterol", 1.4, "mmol/L", "final", "2024-03-14T08:00:00Z")
+	seedLabObservation(t, h, "p1", "Triglycerides", 1.1, "mmol/L", "preliminary", "2024-03-14T08:00:00Z")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 72, "/min", "2024-03-14T08:00:00Z")
+	seedObservation(t, h, "p1", "8310-5", "Body temperature", 36.8, "Cel", "2024-01-02T09:00:00Z")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 80, "/min", "2024-01-02T09:00:00Z")
+
+	result, err := h.GetPanelResult("p1", "")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"Most recent observation panel for Anna Jonsdottir (ID: p1)",
+		"• laboratory panel (3 results)\n  Date: 2024-03-14 09:00 CET\n  - ",
+		"  - Total cholesterol: 5.2 mmol/L [final]\n",
+		"  - Triglycerides: 1.1 mmol/L [preliminary]\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q, got:\n%s", want, text)
+		}
+	}
+	// A heart rate at the same time is a different category
+	if strings.Contains(text, "Heart rate") {
+		t.Errorf("Expected only the laboratory panel, got:\n%s", text)
+	}
+
+	result, err = h.GetPanelResult("p1", "2024-01-02")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "• vital-signs panel (2 results)\n  Date: 2024-01-02 10:00 CET\n  Status: final\n") {
+		t.Errorf("Expected the vital signs panel, got:\n%s", text)
+	}
+
+	result, err = h.GetPanelResult("p1", "2024-02-02")
+	if text := resultText(t, h, result, err); !strings.Contains(text, "No observation panels found") {
+		t.Errorf("Expected no panels, got:\n%s", text)
+	}
+}
+
+func TestGetMedicalHistoryGroupsPanels(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1980-01-01")
+	seedLabObservation(t, h, "p1", "Total cholesterol", 5.2, "mmol/L", "final", "2024-03-14T08:00:00Z")
+	seedLabObservation(t, h, "p1", "HDL cholesterol", 1.4, "mmol/L", "final", "2024-03-14T08:00:00Z")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 72, "/min", "2024-03-14T08:00:00Z")
+
+	result, err := h.GetMedicalHistory("p1", "observations", 10, 0)
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "• laboratory panel (2 results)\n  Date: 2024-03-14 09:00 CET\n  Status: final\n  - ") {
+		t.Errorf("Expected the lab results grouped into one panel, got:\n%s", text)
+	}
+	// A lone observation keeps its own entry
+	if !strings.Contains(text, "• Heart rate\n  Category: vital-signs\n") {
+		t.Errorf("Expected the heart rate listed on its own, got:\n%s", text)
+	}
+}
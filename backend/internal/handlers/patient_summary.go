@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// writeSummarySection writes a heading and one bullet per item, or "None
+// recorded" when there are none
+func writeSummarySection(b *strings.Builder, heading string, items []string) {
+	b.WriteString("\n" + heading + ":\n")
+	if len(items) == 0 {
+		b.WriteString("• None recorded\n")
+		return
+	}
+	for _, item := range items {
+		b.WriteString("• " + item + "\n")
+	}
+}
+
+// formatPatientSummary renders a medical summary as the text returned by
+// get_patient_summary
+func formatPatientSummary(patientID string, summary *PatientMedicalSummary) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Patient summary (ID: %s)\n", patientID))
+	b.WriteString(summary.Demographics + "\n")
+
+	writeSummarySection(&b, "Active conditions", summary.ActiveConditions)
+	writeSummarySection(&b, "Current medications", summary.CurrentMedications)
+	writeSummarySection(&b, "Allergies", summary.Allergies)
+	writeSummarySection(&b, "Recent observations", summary.RecentObservations)
+	writeSummarySection(&b, "Recent encounters", summary.RecentEncounters)
+
+	b.WriteString("\nTotals:\n")
+	b.WriteString(fmt.Sprintf("• Active conditions: %d\n", len(summary.ActiveConditions)))
+	b.WriteString(fmt.Sprintf("• Current medications: %d\n", len(summary.CurrentMedications)))
+	b.WriteString(fmt.Sprintf("• Allergies: %d\n", len(summary.Allergies)))
+	visits := fmt.Sprintf("• Encounters: %d", summary.TotalEncounters)
+	if summary.LastEncounter != "" {
+		visits += fmt.Sprintf(" (last visit: %s)", summary.LastEncounter)
+	}
+	b.WriteString(visits + "\n")
+	return b.String()
+}
+
+// GetPatientSummary returns the full medical summary that is otherwise only
+// given to the model: demographics, active conditions, current medications,
+// allergies, recent observations and encounters, and totals
+func (h *Handler) GetPatientSummary(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	summary, err := h.fetchPatientMedicalSummary(patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": formatPatientSummary(patientID, summary),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetPatientSummary(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2010-05-01")
+	seedCondition(t, h, "p1", "10509002", "Acute bronchitis", "resolved", "2020-01-01")
+	seedMedication(t, h, "p1", "Lisinopril 10 MG", "active", "2015-06-01")
+	seedObservation(t, h, "p1", "29463-7", "Body Weight", 70, "kg", "2024-03-01")
+	seedEncounter(t, h, "e1", "p1", "finished", "2024-05-01T09:00:00Z")
+
+	h.SetPatientContext("p1")
+	result, err := h.GetPatientSummary("")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"Kari Nordmann, female",
+		"• Hypertension (since 2010-05-01)",
+		"• Lisinopril 10 MG",
+		"Allergies:\n• None recorded",
+		"• Body Weight: 70.0 kg (2024-03-01)",
+		"• Encounters: 1 (last visit: 2024-05-01)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Acute bronchitis") {
+		t.Errorf("resolved condition listed:\n%s", text)
+	}
+
+	if _, err := h.GetPatientSummary("missing"); err == nil || !strings.Contains(err.Error(), "patient not found") {
+		t.Errorf("err = %v, want patient not found", err)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// normalizeGender maps user input onto the FHIR AdministrativeGender codes,
+// defaulting to "unknown" when no gender is given
+func normalizeGender(gender string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m":
+		return "male", nil
+	case "female", "f":
+		return "female", nil
+	case "other":
+		return "other", nil
+	case "unknown", "":
+		return "unknown", nil
+	}
+	return "", fmt.Errorf("invalid gender: %s (use male, female, other, or unknown)", gender)
+}
+
+// trimmedOrNil returns nil for a missing or blank optional value
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func (h *Handler) CreatePatient(givenName, familyName, gender, birthDate string, phone, city, state *string) (interface{}, error) {
+	givenName = strings.TrimSpace(givenName)
+	familyName = strings.TrimSpace(familyName)
+	birthDate = strings.TrimSpace(birthDate)
+
+	if givenName == "" && familyName == "" {
+		return nil, fmt.Errorf("given name or family name is required")
+	}
+	gender, err := normalizeGender(gender)
+	if err != nil {
+		return nil, err
+	}
+	if birthDate != "" {
+		if err := validateBirthDate(birthDate, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := h.newID(idKindPatient)
+	if err != nil {
+		return nil, err
+	}
+	patient := &database.Patient{
+		ID:         id,
+		GivenName:  givenName,
+		FamilyName: familyName,
+		Gender:     gender,
+		BirthDate:  birthDate,
+		Phone:      trimmedOrNil(phone),
+		City:       trimmedOrNil(city),
+		State:      trimmedOrNil(state),
+	}
+	if err := database.CreatePatient(h.db, patient); err != nil {
+		return nil, fmt.Errorf("failed to create patient: %w", err)
+	}
+	debug.Log("Created patient %s", patient.ID)
+
+	medicalSummary, err := h.fetchPatientMedicalSummary(patient.ID)
+	if err != nil {
+		debug.Error("Failed to fetch medical summary: %v", err)
+		medicalSummary = nil
+	}
+	h.switchPatientContext(patient.ID, medicalSummary)
+
+	name := strings.TrimSpace(givenName + " " + familyName)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("✓ Patient created: %s (ID: %s)\n", name, patient.ID))
+	result.WriteString(fmt.Sprintf("Gender: %s\n", gender))
+	if birthDate != "" {
+		result.WriteString(fmt.Sprintf("Birth Date: %s\n", birthDate))
+	}
+	if patient.Phone != nil {
+		result.WriteString(fmt.Sprintf("Phone: %s\n", *patient.Phone))
+	}
+	if patient.City != nil || patient.State != nil {
+		var location []string
+		if patient.City != nil {
+			location = append(location, *patient.City)
+		}
+		if patient.State != nil {
+			location = append(location, *patient.State)
+		}
+		result.WriteString(fmt.Sprintf("Location: %s\n", strings.Join(location, ", ")))
+	}
+	result.WriteString(fmt.Sprintf("\n✓ Context updated: Current patient set to %s (ID: %s)", name, patient.ID))
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
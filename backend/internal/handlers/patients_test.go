@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestCreatePatient(t *testing.T) {
+	h := newTestHandler(t)
+	phone, blank := "555-0100", "  "
+
+	result, err := h.CreatePatient(" Marty ", "McFly", "M", "1968-06-12", &phone, &blank, nil)
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "✓ Patient created: Marty McFly") {
+		t.Errorf("unexpected result:\n%s", text)
+	}
+
+	id := currentContext(h).PatientID
+	if id == "" {
+		t.Fatal("patient context was not set")
+	}
+	patient, err := database.GetPatientByID(h.db, id)
+	if err != nil {
+		t.Fatalf("created patient not found: %v", err)
+	}
+	if patient.GivenName != "Marty" || patient.Gender != "male" || !strings.HasPrefix(patient.BirthDate, "1968-06-12") {
+		t.Errorf("stored patient = %+v", patient)
+	}
+	if patient.Phone == nil || *patient.Phone != phone || patient.City != nil || patient.State != nil {
+		t.Errorf("optional fields not stored as given: phone=%v city=%v state=%v", patient.Phone, patient.City, patient.State)
+	}
+
+	// A patient without a birth date or gender can still be looked up
+	result, err = h.CreatePatient("", "Brown", "", "", nil, nil, nil)
+	resultText(t, h, result, err)
+	if _, err := database.GetPatientByID(h.db, currentContext(h).PatientID); err != nil {
+		t.Errorf("patient without birth date not readable: %v", err)
+	}
+}
+
+func TestCreatePatientValidation(t *testing.T) {
+	h := newTestHandler(t)
+	tests := []struct {
+		name                                     string
+		givenName, familyName, gender, birthDate string
+		wantErr                                  string
+	}{
+		{"no name", " ", "", "male", "1990-01-01", "name is required"},
+		{"bad gender", "Marty", "McFly", "robot", "", "invalid gender"},
+		{"bad birth date", "Marty", "McFly", "male", "12/06/1968", "unable to parse birth date"},
+		{"future birth date", "Marty", "McFly", "male", "2999-01-01", "in the future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.CreatePatient(tt.givenName, tt.familyName, tt.gender, tt.birthDate, nil, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	var count int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM patients").Scan(&count); err != nil || count != 0 {
+		t.Errorf("rejected patients were stored: count=%d err=%v", count, err)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// medicationRequestStatuses are the FHIR MedicationRequest.status codes
+var medicationRequestStatuses = []string{
+	"active", "on-hold", "cancelled", "completed", "entered-in-error", "stopped", "draft", "unknown",
+}
+
+func isMedicationRequestStatus(status string) bool {
+	for _, s := range medicationRequestStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// AddMedication prescribes a medication for the patient. The medication code
+// is taken from the medications table when the name matches a known
+// formulation exactly, and the context practitioner is recorded as requester.
+func (h *Handler) AddMedication(patientID, medicationDisplay, dosageText, status string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	medicationDisplay = strings.TrimSpace(medicationDisplay)
+	if medicationDisplay == "" {
+		return nil, fmt.Errorf("medication name is required")
+	}
+
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		status = "active"
+	}
+	if !isMedicationRequestStatus(status) {
+		return nil, fmt.Errorf("invalid status: %s (use %s)", status, strings.Join(medicationRequestStatuses, ", "))
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	id, err := h.newID(idKindMedicationRequest)
+	if err != nil {
+		return nil, err
+	}
+	request := &database.MedicationRequest{
+		ID:                id,
+		Status:            status,
+		MedicationDisplay: medicationDisplay,
+		PatientID:         patientID,
+		AuthoredOn:        time.Now().Format(time.RFC3339),
+	}
+	if dosageText = strings.TrimSpace(dosageText); dosageText != "" {
+		request.DosageText = &dosageText
+	}
+
+	medication, err := database.SearchMedicationByName(h.db, medicationDisplay)
+	if err == nil && strings.EqualFold(medication.Display, medicationDisplay) {
+		request.MedicationCode = &medication.Code
+		request.MedicationDisplay = medication.Display
+	}
+
+	var prescriber *database.Practitioner
+	if practitionerID := h.GetContextPractitionerID(""); practitionerID != "" {
+		prescriber, err = database.GetPractitionerByID(h.db, practitionerID)
+		if err != nil {
+			debug.Error("Prescriber %s not recorded: %v", practitionerID, err)
+			prescriber = nil
+		} else {
+			request.RequesterID = &prescriber.ID
+		}
+	}
+
+	if err := database.CreateMedicationRequest(h.db, request); err != nil {
+		return nil, fmt.Errorf("failed to add medication: %w", err)
+	}
+	debug.Log("Added medication request %s for patient %s", request.ID, patientID)
+
+	h.refreshContextSummary(patientID)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	resultText := fmt.Sprintf("Successfully prescribed medication:\n\nMedication Request ID: %s\nPatient: %s (ID: %s)\nMedication: %s\n",
+		request.ID, patientName, patientID, request.MedicationDisplay)
+	if request.MedicationCode != nil {
+		resultText += fmt.Sprintf("Code: %s\n", *request.MedicationCode)
+	}
+	if request.DosageText != nil {
+		resultText += fmt.Sprintf("Dosage: %s\n", *request.DosageText)
+	}
+	resultText += fmt.Sprintf("Status: %s\nAuthored: %s\n", status, request.AuthoredOn)
+	if prescriber != nil {
+		resultText += fmt.Sprintf("Prescriber: %s (ID: %s)\n", strings.TrimSpace(prescriber.GivenName+" "+prescriber.FamilyName), prescriber.ID)
+	}
+	if request.MedicationCode == nil {
+		resultText += "\nNote: no exactly matching formulation was found in the medication catalogue, so no code was recorded. Use get_medication_forms to pick a formulation."
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
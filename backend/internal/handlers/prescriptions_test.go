@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddMedication(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	prescriberID := defaultContext().PractitionerID
+	seedPractitioner(t, h, prescriberID, "Emmett", "Brown")
+	seedMedicationProduct(t, h, "860975", "24 HR Metformin hydrochloride 500 MG Extended Release Oral Tablet", "")
+	seedMedicationProduct(t, h, "861007", "Metformin hydrochloride 500 MG Oral Tablet", "Oral Tablet")
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext: %v", err)
+	}
+
+	result, err := h.AddMedication("", "metformin hydrochloride 500 mg oral tablet", "1 tablet twice daily", "")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"Marty McFly (ID: p1)",
+		"Medication: Metformin hydrochloride 500 MG Oral Tablet",
+		"Code: 861007",
+		"Dosage: 1 tablet twice daily",
+		"Status: active",
+		"Prescriber: Emmett Brown",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+
+	var code, requester, intent string
+	err = h.db.QueryRow("SELECT medication_code, requester_id, intent FROM medication_requests WHERE patient_id = 'p1'").Scan(&code, &requester, &intent)
+	if err != nil {
+		t.Fatalf("Failed to read prescription: %v", err)
+	}
+	if code != "861007" || requester != prescriberID || intent != "order" {
+		t.Errorf("stored code=%q requester=%q intent=%q", code, requester, intent)
+	}
+
+	found := false
+	for _, m := range currentContext(h).PatientSummary.CurrentMedications {
+		found = found || strings.Contains(m, "Metformin")
+	}
+	if !found {
+		t.Errorf("context summary not refreshed: %v", currentContext(h).PatientSummary.CurrentMedications)
+	}
+
+	// A partial name is stored as given, without a code
+	result, err = h.AddMedication("p1", "Metformin", "", "draft")
+	text = resultText(t, h, result, err)
+	if strings.Contains(text, "Code:") || !strings.Contains(text, "get_medication_forms") || !strings.Contains(text, "Status: draft") {
+		t.Errorf("unexpected result for a partial name:\n%s", text)
+	}
+}
+
+func TestAddMedicationValidation(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+
+	if _, err := h.AddMedication("p1", " ", "", ""); err == nil || !strings.Contains(err.Error(), "medication name is required") {
+		t.Errorf("empty medication: got %v", err)
+	}
+	if _, err := h.AddMedication("p1", "Aspirin", "", "taking"); err == nil || !strings.Contains(err.Error(), "invalid status") {
+		t.Errorf("bad status: got %v", err)
+	}
+	if _, err := h.AddMedication("p2", "Aspirin", "", ""); err == nil || !strings.Contains(err.Error(), "patient not found") {
+		t.Errorf("unknown patient: got %v", err)
+	}
+
+	// Without a known practitioner the prescription is still recorded
+	result, err := h.AddMedication("p1", "Aspirin 81 MG Oral Tablet", "", "")
+	if text := resultText(t, h, result, err); strings.Contains(text, "Prescriber:") {
+		t.Errorf("unknown practitioner reported as prescriber:\n%s", text)
+	}
+}
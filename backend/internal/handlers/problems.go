@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// Problem severity levels, from minor (1) to critical (5); 0 is unknown
+const (
+	severityUnknown  = 0
+	severityMinor    = 1
+	severityCritical = 5
+)
+
+var severityLabels = map[int]string{
+	1: "minor", 2: "mild", 3: "moderate", 4: "severe", 5: "critical",
+}
+
+// defaultConditionSeverity maps condition display terms (lowercase
+// substrings) or codes to a severity level. A matching code wins, then the
+// longest matching term, so "prediabetes" is not rated as "diabetes".
+var defaultConditionSeverity = map[string]int{
+	"neoplasm": 5, "carcinoma": 5, "cancer": 5, "leukemia": 5, "lymphoma": 5,
+	"heart failure": 5, "myocardial infarction": 5, "stroke": 5, "sepsis": 5,
+	"cardiac arrest": 5, "respiratory failure": 5, "end-stage renal disease": 5,
+
+	"chronic kidney disease": 4, "diabetes": 4, "chronic obstructive": 4, "coronary": 4,
+	"atrial fibrillation": 4, "pulmonary embolism": 4, "pneumonia": 4, "dementia": 4,
+	"alzheimer": 4, "hepatitis": 4, "cirrhosis": 4, "epilepsy": 4,
+
+	"hypertension": 3, "asthma": 3, "depression": 3, "anemia": 3, "osteoporosis": 3,
+	"fracture": 3, "osteoarthritis": 3, "rheumatoid arthritis": 3, "hypothyroidism": 3,
+	"anxiety": 3, "prediabetes": 3,
+
+	"hyperlipidemia": 2, "hypertriglyceridemia": 2, "obesity": 2, "chronic pain": 2,
+	"migraine": 2, "allergic rhinitis": 2, "bronchitis": 2, "sleep apnea": 2,
+
+	"sinusitis": 1, "pharyngitis": 1, "otitis": 1, "sprain": 1, "gingivitis": 1,
+	"laceration": 1, "contusion": 1, "viral infection": 1, "common cold": 1,
+	"dental caries": 1, "cystitis": 1,
+}
+
+// conditionSeverityOverrides reads MCP_CONDITION_SEVERITY, a comma-separated
+// list of term or code "=level" pairs such as "hypertension=4,38341003=4", once
+var conditionSeverityOverrides = sync.OnceValue(func() map[string]int {
+	return parseSeverityOverrides(os.Getenv("MCP_CONDITION_SEVERITY"))
+})
+
+func parseSeverityOverrides(value string) map[string]int {
+	overrides := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, level, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(level))
+		if !ok || err != nil || n < severityMinor || n > severityCritical || strings.TrimSpace(key) == "" {
+			debug.Error("Invalid MCP_CONDITION_SEVERITY entry: %q", entry)
+			continue
+		}
+		overrides[strings.ToLower(strings.TrimSpace(key))] = n
+	}
+	return overrides
+}
+
+// conditionSeverity returns the severity of a condition from the overrides,
+// or failing that the defaults, or severityUnknown if neither matches
+func conditionSeverity(c database.Condition, overrides map[string]int) int {
+	display := strings.ToLower(c.Display)
+	for _, table := range []map[string]int{overrides, defaultConditionSeverity} {
+		if level, ok := table[strings.ToLower(c.Code)]; ok && c.Code != "" {
+			return level
+		}
+		severity, matched := severityUnknown, ""
+		for term, level := range table {
+			if !strings.Contains(display, term) {
+				continue
+			}
+			if len(term) > len(matched) || (len(term) == len(matched) && level > severity) {
+				severity, matched = level, term
+			}
+		}
+		if severity != severityUnknown {
+			return severity
+		}
+	}
+	return severityUnknown
+}
+
+// estimateSeverities asks the guidelines model to rate conditions that aren't
+// in the severity map. Conditions it doesn't rate are left out.
+func (h *Handler) estimateSeverities(ctx context.Context, displays []string) map[string]int {
+	var prompt strings.Builder
+	prompt.WriteString("Rate the typical clinical severity of each of these conditions from 1 (minor) to 5 (critical). Reply with only a JSON object mapping each condition, exactly as written, to its rating.\n")
+	for _, d := range displays {
+		prompt.WriteString("- " + d + "\n")
+	}
+
+	reply, err := h.callGuidelinesModel(ctx, prompt.String())
+	if err != nil {
+		debug.Error("Failed to estimate condition severities: %v", err)
+		return nil
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		debug.Error("Severity estimate is not JSON: %q", reply)
+		return nil
+	}
+	var ratings map[string]float64
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &ratings); err != nil {
+		debug.Error("Failed to parse severity estimate: %v", err)
+		return nil
+	}
+
+	severities := make(map[string]int, len(ratings))
+	for display, rating := range ratings {
+		if level := int(rating + 0.5); level >= severityMinor && level <= severityCritical {
+			severities[strings.ToLower(strings.TrimSpace(display))] = level
+		}
+	}
+	return severities
+}
+
+// rankedProblem is an active condition with its severity
+type rankedProblem struct {
+	Condition database.Condition
+	Severity  int
+	Estimated bool // severity came from the guidelines model
+}
+
+// rankProblems orders problems by severity, most severe first, then by
+// display name. Problems of unknown severity come last.
+func rankProblems(problems []rankedProblem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Severity != problems[j].Severity {
+			return problems[i].Severity > problems[j].Severity
+		}
+		return strings.ToLower(problems[i].Condition.Display) < strings.ToLower(problems[j].Condition.Display)
+	})
+}
+
+// isActiveCondition reports whether a condition's clinical status is one of
+// the active FHIR codes; a missing status counts as active
+func isActiveCondition(c database.Condition) bool {
+	switch c.ClinicalStatus {
+	case "active", "recurrence", "relapse", "":
+		return true
+	}
+	return false
+}
+
+func (h *Handler) GetRankedProblemList(ctx context.Context, patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+
+	// Conditions are listed once each, even if recorded repeatedly
+	var problems []rankedProblem
+	var unknown []string
+	seen := make(map[string]bool)
+	overrides := conditionSeverityOverrides()
+	for _, c := range conditions {
+		key := strings.ToLower(c.Display)
+		if !isActiveCondition(c) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		severity := conditionSeverity(c, overrides)
+		if severity == severityUnknown {
+			unknown = append(unknown, c.Display)
+		}
+		problems = append(problems, rankedProblem{Condition: c, Severity: severity})
+	}
+
+	if len(unknown) > 0 {
+		estimates := h.estimateSeverities(ctx, unknown)
+		for i, p := range problems {
+			if p.Severity != severityUnknown {
+				continue
+			}
+			if level, ok := estimates[strings.ToLower(p.Condition.Display)]; ok {
+				problems[i].Severity = level
+				problems[i].Estimated = true
+			}
+		}
+	}
+	rankProblems(problems)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Ranked problem list for %s (ID: %s)\n\n", patientName, patientID))
+	if len(problems) == 0 {
+		result.WriteString("No active conditions recorded.\n")
+	}
+	for i, p := range problems {
+		result.WriteString(fmt.Sprintf("%d. %s", i+1, p.Condition.Display))
+		switch {
+		case p.Severity == severityUnknown:
+			result.WriteString(" (severity unknown)")
+		case p.Estimated:
+			result.WriteString(fmt.Sprintf(" (%s, %d/5, estimated)", severityLabels[p.Severity], p.Severity))
+		default:
+			result.WriteString(fmt.Sprintf(" (%s, %d/5)", severityLabels[p.Severity], p.Severity))
+		}
+		if p.Condition.OnsetDateTime != nil {
+			result.WriteString(fmt.Sprintf(", since %s", recordDay(*p.Condition.OnsetDateTime)))
+		}
+		result.WriteString("\n")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestConditionSeverity(t *testing.T) {
+	tests := []struct {
+		code, display string
+		overrides     map[string]int
+		want          int
+	}{
+		{"88805009", "Chronic congestive heart failure (disorder)", nil, 5},
+		{"195662009", "Acute viral pharyngitis (disorder)", nil, 1},
+		{"15777000", "Prediabetes", nil, 3},
+		{"44054006", "Diabetes mellitus type 2 (disorder)", nil, 4},
+		{"59621000", "Essential hypertension (disorder)", map[string]int{"hypertension": 4}, 4},
+		{"59621000", "Essential hypertension (disorder)", map[string]int{"59621000": 2}, 2},
+		{"72892002", "Normal pregnancy (finding)", nil, severityUnknown},
+	}
+	for _, tt := range tests {
+		c := database.Condition{Code: tt.code, Display: tt.display}
+		if got := conditionSeverity(c, tt.overrides); got != tt.want {
+			t.Errorf("conditionSeverity(%q, %v) = %d, want %d", tt.display, tt.overrides, got, tt.want)
+		}
+	}
+}
+
+func TestParseSeverityOverrides(t *testing.T) {
+	got := parseSeverityOverrides("Hypertension=4, 38341003=2,bad,gout=9")
+	if len(got) != 2 || got["hypertension"] != 4 || got["38341003"] != 2 {
+		t.Errorf("parseSeverityOverrides = %v", got)
+	}
+}
+
+func TestGetRankedProblemList(t *testing.T) {
+	h := newTestHandler(t)
+	fakeModel(t, h, `{"Normal pregnancy (finding)": 2}`)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedCondition(t, h, "p1", "195662009", "Acute viral pharyngitis (disorder)", "active", "2024-01-01")
+	seedCondition(t, h, "p1", "72892002", "Normal pregnancy (finding)", "active", "2023-06-01")
+	seedCondition(t, h, "p1", "88805009", "Chronic congestive heart failure (disorder)", "active", "2020-01-01")
+	seedCondition(t, h, "p1", "88805009", "Chronic congestive heart failure (disorder)", "active", "2021-01-01")
+	seedCondition(t, h, "p1", "22298006", "Myocardial infarction (disorder)", "resolved", "2019-01-01")
+
+	result, err := h.GetRankedProblemList(context.Background(), "p1")
+	text := resultText(t, h, result, err)
+
+	heartFailure := strings.Index(text, "1. Chronic congestive heart failure (disorder) (critical, 5/5)")
+	pregnancy := strings.Index(text, "2. Normal pregnancy (finding) (mild, 2/5, estimated)")
+	pharyngitis := strings.Index(text, "3. Acute viral pharyngitis (disorder) (minor, 1/5)")
+	if heartFailure < 0 || pregnancy < 0 || pharyngitis < 0 {
+		t.Errorf("problems not ranked by severity:\n%s", text)
+	}
+	if strings.Count(text, "heart failure") != 1 {
+		t.Errorf("repeated condition listed more than once:\n%s", text)
+	}
+	if strings.Contains(text, "Myocardial infarction") {
+		t.Errorf("resolved condition listed:\n%s", text)
+	}
+}
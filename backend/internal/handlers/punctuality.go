@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// visitDelay is how late a visit started compared to its scheduled time;
+// negative when it started early
+type visitDelay struct {
+	Encounter database.Encounter
+	Delay     time.Duration
+}
+
+// visitDelays returns the delay of every encounter with both a scheduled and
+// an actual start time, in the order given
+func visitDelays(encounters []database.Encounter) []visitDelay {
+	var delays []visitDelay
+	for _, e := range encounters {
+		if e.ActualStartDateTime == nil {
+			continue
+		}
+		scheduled, ok := parseRecordTime(e.StartDateTime)
+		if !ok {
+			continue
+		}
+		actual, ok := parseRecordTime(*e.ActualStartDateTime)
+		if !ok {
+			continue
+		}
+		delays = append(delays, visitDelay{Encounter: e, Delay: actual.Sub(scheduled)})
+	}
+	return delays
+}
+
+// formatDelay renders a delay as "on time", "15 min late" or "5 min early"
+func formatDelay(d time.Duration) string {
+	minutes := int(math.Round(d.Minutes()))
+	switch {
+	case minutes == 0:
+		return "on time"
+	case minutes > 0:
+		return fmt.Sprintf("%d min late", minutes)
+	default:
+		return fmt.Sprintf("%d min early", -minutes)
+	}
+}
+
+// MarkPatientArrived sets an appointment's status to arrived and records the
+// arrival as its actual start time. arrivedAt defaults to now.
+func (h *Handler) MarkPatientArrived(encounterID, arrivedAt string) (interface{}, error) {
+	encounterID = strings.TrimSpace(encounterID)
+	if encounterID == "" {
+		return nil, fmt.Errorf("encounter ID is required")
+	}
+
+	arrival := time.Now()
+	if strings.TrimSpace(arrivedAt) != "" {
+		var err error
+		if arrival, err = ParseDateTimeRobust(arrivedAt); err != nil {
+			return nil, fmt.Errorf("invalid arrival time: %w", err)
+		}
+	}
+
+	status, err := database.GetEncounterStatus(h.db, encounterID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("encounter not found: %s", encounterID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if status == "cancelled" || status == "finished" {
+		return nil, fmt.Errorf("cannot mark arrival for %s encounter: %s", status, encounterID)
+	}
+
+	if err := database.MarkEncounterArrived(h.db, encounterID, arrival.Format(time.RFC3339)); err != nil {
+		return nil, fmt.Errorf("failed to record arrival: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("✓ Patient arrived for encounter %s at %s", encounterID, arrival.Format("2006-01-02 15:04")),
+			},
+		},
+	}, nil
+}
+
+// GetVisitPunctuality compares the scheduled and actual start times of the
+// patient's visits, listing each delay and the average
+func (h *Handler) GetVisitPunctuality(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	encounters, err := database.GetEncountersByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	delays := visitDelays(encounters)
+	if len(delays) == 0 {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("No visits with a recorded actual start time for %s (ID: %s)", patientName, patientID),
+				},
+			},
+		}, nil
+	}
+
+	var total time.Duration
+	lines := make([]string, len(delays))
+	for i, d := range delays {
+		total += d.Delay
+		actual, _ := parseRecordTime(*d.Encounter.ActualStartDateTime)
+		scheduled, _ := parseRecordTime(d.Encounter.StartDateTime)
+		lines[i] = fmt.Sprintf("• %s: scheduled %s, started %s (%s)", d.Encounter.ID,
+			scheduled.In(appointmentLocation).Format("2006-01-02 15:04"), actual.In(appointmentLocation).Format("15:04"), formatDelay(d.Delay))
+	}
+	average := total / time.Duration(len(delays))
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Visit punctuality for %s (ID: %s), %d visit(s):\n%s\n\nAverage: %s",
+					patientName, patientID, len(delays), strings.Join(lines, "\n"), formatDelay(average)),
+			},
+		},
+	}, nil
+}
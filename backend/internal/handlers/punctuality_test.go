@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestVisitDelays(t *testing.T) {
+	actual := func(s string) *string { return &s }
+	encounters := []database.Encounter{
+		{ID: "late", StartDateTime: "2024-03-01T09:00:00Z", ActualStartDateTime: actual("2024-03-01T09:25:00Z")},
+		{ID: "early", StartDateTime: "2024-04-01T09:00:00Z", ActualStartDateTime: actual("2024-04-01T08:55:00Z")},
+		{ID: "offset", StartDateTime: "2024-05-01T09:00:00Z", ActualStartDateTime: actual("2024-05-01T11:10:00+02:00")},
+		{ID: "not started", StartDateTime: "2024-06-01T09:00:00Z"},
+		{ID: "bad time", StartDateTime: "2024-07-01T09:00:00Z", ActualStartDateTime: actual("soon")},
+	}
+	want := map[string]time.Duration{"late": 25 * time.Minute, "early": -5 * time.Minute, "offset": 10 * time.Minute}
+
+	delays := visitDelays(encounters)
+	if len(delays) != len(want) {
+		t.Fatalf("got %d delays, want %d: %+v", len(delays), len(want), delays)
+	}
+	for _, d := range delays {
+		if d.Delay != want[d.Encounter.ID] {
+			t.Errorf("%s: delay = %v, want %v", d.Encounter.ID, d.Delay, want[d.Encounter.ID])
+		}
+	}
+}
+
+func TestFormatDelay(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  string
+	}{
+		{0, "on time"},
+		{20 * time.Second, "on time"},
+		{25 * time.Minute, "25 min late"},
+		{-5 * time.Minute, "5 min early"},
+		{90*time.Second + 1, "2 min late"},
+	}
+	for _, tt := range tests {
+		if got := formatDelay(tt.delay); got != tt.want {
+			t.Errorf("formatDelay(%v) = %q, want %q", tt.delay, got, tt.want)
+		}
+	}
+}
+
+func TestGetVisitPunctuality(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Marty", "McFly", "male", "1968-06-12")
+	seedEncounter(t, h, "e1", "p1", "planned", "2024-03-01T09:00:00Z")
+	seedEncounter(t, h, "e2", "p1", "planned", "2024-04-01T09:00:00Z")
+	seedEncounter(t, h, "e3", "p1", "planned", "2024-05-01T09:00:00Z")
+
+	if _, err := h.MarkPatientArrived("e1", "2024-03-01T09:25:00Z"); err != nil {
+		t.Fatalf("MarkPatientArrived: %v", err)
+	}
+	if _, err := h.CompleteEncounter("e2", "", "2024-04-01T08:55:00Z"); err != nil {
+		t.Fatalf("CompleteEncounter: %v", err)
+	}
+
+	result, err := h.GetVisitPunctuality("p1")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{"2 visit(s)", "e1: scheduled", "Average: 10 min late"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "e3") {
+		t.Errorf("visit without an actual start listed:\n%s", text)
+	}
+
+	if status := encounterStatus(t, h, "e1"); status != "arrived" {
+		t.Errorf("status after arrival = %q, want arrived", status)
+	}
+	if _, err := h.MarkPatientArrived("e2", ""); err == nil {
+		t.Error("arrival recorded for a finished encounter")
+	}
+}
+
+func encounterStatus(t *testing.T, h *Handler, encounterID string) string {
+	t.Helper()
+	var status string
+	if err := h.db.QueryRow("SELECT status FROM encounters WHERE id = ?", encounterID).Scan(&status); err != nil {
+		t.Fatalf("Failed to read encounter status: %v", err)
+	}
+	return status
+}
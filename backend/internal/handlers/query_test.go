@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProcessNaturalLanguageQueryAttributesPractitioner(t *testing.T) {
+	h := newTestHandler(t)
+	seedPractitioner(t, h, "prac-123", "Erik", "Hansen")
+	reply := map[string]interface{}{"role": "assistant", "content": "Hello."}
+	bodies := recordingModel(t, h, reply, reply)
+	contextPractitioner := h.GetContextPractitionerID("")
+
+	if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "Who am I?", "prac-123", false); err != nil {
+		t.Fatalf("ProcessNaturalLanguageQuery failed: %v", err)
+	}
+	if !strings.Contains((*bodies)[0], "Hansen") {
+		t.Errorf("Expected the query to be attributed to prac-123, got %s", (*bodies)[0])
+	}
+
+	// The practitioner applies to that query only
+	if got := h.GetContextPractitionerID(""); got != contextPractitioner {
+		t.Errorf("Practitioner context changed to %q, want %q", got, contextPractitioner)
+	}
+	if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "Who am I?", "", false); err != nil {
+		t.Fatalf("ProcessNaturalLanguageQuery failed: %v", err)
+	}
+	if strings.Contains((*bodies)[1], "Hansen") || strings.Contains((*bodies)[1], "prac-123") {
+		t.Errorf("Query without a practitioner was attributed to prac-123: %s", (*bodies)[1])
+	}
+
+	_, err := h.ProcessNaturalLanguageQuery(context.Background(), "Who am I?", "nobody", false)
+	if !errors.Is(err, ErrUnknownPractitioner) {
+		t.Errorf("Expected ErrUnknownPractitioner, got %v", err)
+	}
+}
+
+func TestProcessNaturalLanguageQueryPractitionerLookupFails(t *testing.T) {
+	h := newTestHandler(t)
+	h.db.Close()
+
+	_, err := h.ProcessNaturalLanguageQuery(context.Background(), "Who am I?", "prac-123", false)
+	if err == nil || errors.Is(err, ErrUnknownPractitioner) {
+		t.Errorf("Expected a database error rather than ErrUnknownPractitioner, got %v", err)
+	}
+}
+
+func TestProcessNaturalLanguageQueryRejectedBeforeAttribution(t *testing.T) {
+	t.Setenv("MCP_MAX_CONCURRENT_QUERIES", "1")
+	h := newTestHandler(t)
+	if !h.acquireQuerySlot() {
+		t.Fatal("expected a slot to be available")
+	}
+
+	// The practitioner isn't checked until the query gets a slot
+	_, err := h.ProcessNaturalLanguageQuery(context.Background(), "Who am I?", "nobody", false)
+	if !errors.Is(err, ErrTooManyQueries) {
+		t.Errorf("Expected ErrTooManyQueries, got %v", err)
+	}
+}
+
+// scriptedModel serves the given model messages in order, one per request,
+// recording the tool names offered in each request
+func scriptedModel(t *testing.T, h *Handler, replies ...map[string]interface{}) *[][]string {
+	t.Helper()
+	var offered [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tools []struct {
+				Function struct {
+					Name string `json:"name"`
+				} `json:"function"`
+			} `json:"tools"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode model request: %v", err)
+		}
+		var names []string
+		for _, tool := range req.Tools {
+			names = append(names, tool.Function.Name)
+		}
+		offered = append(offered, names)
+
+		if len(offered) > len(replies) {
+			t.Errorf("Unexpected model request #%d", len(offered))
+			http.Error(w, "no more replies", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": replies[len(offered)-1]}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+	return &offered
+}
+
+func TestNaturalLanguageQueryCancelsAppointment(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedEncounter(t, h, "abc-123", "p1", "planned", "2030-01-10T09:00:00Z")
+
+	offered := scriptedModel(t, h,
+		map[string]interface{}{
+			"role": "assistant",
+			"tool_calls": []map[string]interface{}{{
+				"id":       "call-1",
+				"type":     "function",
+				"function": map[string]interface{}{"name": "cancel_appointment", "arguments": `{"encounter_id": "abc-123"}`},
+			}},
+		},
+		map[string]interface{}{"role": "assistant", "content": "Appointment abc-123 has been cancelled."},
+	)
+
+	if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "cancel appointment abc-123", "", false); err != nil {
+		t.Fatalf("ProcessNaturalLanguageQuery failed: %v", err)
+	}
+
+	if len(*offered) == 0 || !slices.Contains((*offered)[0], "cancel_appointment") {
+		t.Errorf("cancel_appointment not offered to the model")
+	}
+
+	var status string
+	if err := h.db.QueryRow(`SELECT status FROM encounters WHERE id = 'abc-123'`).Scan(&status); err != nil {
+		t.Fatalf("Failed to read encounter: %v", err)
+	}
+	if status != "cancelled" {
+		t.Errorf("status = %q, want cancelled", status)
+	}
+}
+
+func TestModelsFromEnvironment(t *testing.T) {
+	t.Setenv("OPENROUTER_TOOLS_MODEL", "openai/gpt-4o-mini")
+	t.Setenv("OPENROUTER_GUIDELINES_MODEL", " ")
+	h := newTestHandler(t)
+
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, req.Model)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+
+	if _, err := h.ProcessNaturalLanguageQuery(context.Background(), "hello", "", false); err != nil {
+		t.Fatalf("ProcessNaturalLanguageQuery failed: %v", err)
+	}
+	if _, err := h.callGuidelinesModel(context.Background(), "hello"); err != nil {
+		t.Fatalf("callGuidelinesModel failed: %v", err)
+	}
+	if _, err := h.callOpenRouter(context.Background(), "hello"); err != nil {
+		t.Fatalf("callOpenRouter failed: %v", err)
+	}
+
+	want := []string{"openai/gpt-4o-mini", defaultGuidelinesModel, defaultChatModel}
+	if !slices.Equal(models, want) {
+		t.Errorf("models = %v, want %v", models, want)
+	}
+}
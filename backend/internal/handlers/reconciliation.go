@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// Medication reconciliation categories
+const (
+	medicationStarted   = "Started"
+	medicationStopped   = "Stopped"
+	medicationContinued = "Continued"
+)
+
+// reconciledMedication is one medication's status relative to the
+// reconciliation date
+type reconciledMedication struct {
+	Category string
+	Display  string
+	// Latest is the most recent prescription deciding the category
+	Latest database.MedicationRequest
+}
+
+// isActiveMedication reports whether a prescription is in effect
+func isActiveMedication(m database.MedicationRequest) bool {
+	return m.Status == "active" || m.Status == ""
+}
+
+// isStoppedMedication reports whether a prescription was ended or paused
+func isStoppedMedication(m database.MedicationRequest) bool {
+	switch m.Status {
+	case "stopped", "completed", "cancelled", "on-hold":
+		return true
+	}
+	return false
+}
+
+// reconcileMedications groups prescriptions by medication (ignoring case) and
+// sorts each medication into a category as of since:
+//   - Continued: an active prescription was written before since
+//   - Started: active, but only prescribed on or after since
+//   - Stopped: no active prescription left, only stopped, completed,
+//     cancelled or on-hold ones
+//
+// Drafts, entered-in-error and unknown prescriptions are left out, as are
+// active ones without a parseable authored_on date.
+func reconcileMedications(medications []database.MedicationRequest, since time.Time) []reconciledMedication {
+	byName := make(map[string][]database.MedicationRequest)
+	var order []string
+	for _, m := range medications {
+		key := strings.ToLower(strings.TrimSpace(m.MedicationDisplay))
+		if key == "" {
+			continue
+		}
+		if _, ok := byName[key]; !ok {
+			order = append(order, key)
+		}
+		byName[key] = append(byName[key], m)
+	}
+
+	var reconciled []reconciledMedication
+	for _, key := range order {
+		var category string
+		var latest database.MedicationRequest
+		var latestTime time.Time
+		consider := func(m database.MedicationRequest, t time.Time) {
+			if latest.ID == "" || t.After(latestTime) {
+				latest, latestTime = m, t
+			}
+		}
+
+		for _, m := range byName[key] {
+			if !isActiveMedication(m) {
+				continue
+			}
+			authored, ok := parseRecordTime(m.AuthoredOn)
+			if !ok {
+				continue
+			}
+			if authored.Before(since) {
+				category = medicationContinued
+			} else if category == "" {
+				category = medicationStarted
+			}
+			consider(m, authored)
+		}
+
+		if category == "" {
+			for _, m := range byName[key] {
+				if isStoppedMedication(m) {
+					category = medicationStopped
+					authored, _ := parseRecordTime(m.AuthoredOn)
+					consider(m, authored)
+				}
+			}
+		}
+		if category == "" {
+			continue
+		}
+
+		reconciled = append(reconciled, reconciledMedication{
+			Category: category,
+			Display:  strings.TrimSpace(latest.MedicationDisplay),
+			Latest:   latest,
+		})
+	}
+
+	sort.SliceStable(reconciled, func(i, j int) bool {
+		return strings.ToLower(reconciled[i].Display) < strings.ToLower(reconciled[j].Display)
+	})
+	return reconciled
+}
+
+// describeReconciledMedication formats one line of the reconciliation
+func describeReconciledMedication(r reconciledMedication, since time.Time) string {
+	line := r.Display
+	if dosage := derefString(r.Latest.DosageText); dosage != "" {
+		line += " - " + dosage
+	}
+	var details []string
+	if r.Category == medicationStopped {
+		details = append(details, r.Latest.Status)
+	}
+	if r.Latest.AuthoredOn != "" {
+		details = append(details, "prescribed "+recordDay(r.Latest.AuthoredOn))
+	}
+	if r.Category == medicationStopped && changedSince(r.Latest.AuthoredOn, since) {
+		details = append(details, "started and stopped since then")
+	}
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+	return line
+}
+
+// ReconcileMedications reports which of a patient's medications were
+// started, stopped or continued since sinceDateTime, for medication
+// reconciliation at admission, transfer or discharge
+func (h *Handler) ReconcileMedications(patientID, sinceDateTime string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	if strings.TrimSpace(sinceDateTime) == "" {
+		return nil, fmt.Errorf("since date is required")
+	}
+	since, ok := parseRecordTime(sinceDateTime)
+	if !ok {
+		return nil, fmt.Errorf("invalid since date: %s (expected YYYY-MM-DD or RFC3339)", sinceDateTime)
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	medications, err := database.GetMedicationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	reconciled := reconcileMedications(medications, since)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Medication reconciliation for %s (ID: %s) since %s\n", patientName, patientID, strings.TrimSpace(sinceDateTime)))
+
+	if len(reconciled) == 0 {
+		result.WriteString("\nNo medications recorded.\n")
+	} else {
+		for _, category := range []string{medicationStarted, medicationStopped, medicationContinued} {
+			var lines []string
+			for _, r := range reconciled {
+				if r.Category == category {
+					lines = append(lines, describeReconciledMedication(r, since))
+				}
+			}
+			result.WriteString(fmt.Sprintf("\n%s (%d):\n", category, len(lines)))
+			if len(lines) == 0 {
+				result.WriteString("• None\n")
+			}
+			for _, line := range lines {
+				result.WriteString("• " + line + "\n")
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+// reconciliationSection returns the lines listed under one category heading
+func reconciliationSection(text, category string) string {
+	start := strings.Index(text, "\n"+category+" (")
+	if start < 0 {
+		return ""
+	}
+	section := text[start+1:]
+	if end := strings.Index(section, "\n\n"); end >= 0 {
+		section = section[:end]
+	}
+	return section
+}
+
+func TestReconcileMedicationsAcrossDateBoundary(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1970-05-05")
+	seedMedication(t, h, "p1", "Lisinopril 10 MG Oral Tablet", "active", "2023-03-01T09:00:00Z")
+	seedMedication(t, h, "p1", "LISINOPRIL 10 MG Oral Tablet", "active", "2024-02-01T09:00:00Z")
+	seedMedication(t, h, "p1", "Metformin 500 MG Oral Tablet", "active", "2024-01-15T09:00:00Z")
+	seedMedication(t, h, "p1", "Ibuprofen 400 MG Oral Tablet", "stopped", "2023-11-20T09:00:00Z")
+	seedMedication(t, h, "p1", "Amoxicillin 500 MG Oral Capsule", "completed", "2024-01-05T09:00:00Z")
+	seedMedication(t, h, "p1", "Simvastatin 20 MG Oral Tablet", "draft", "2024-01-10T09:00:00Z")
+
+	result, err := h.ReconcileMedications("p1", "2024-01-01")
+	text := resultText(t, h, result, err)
+
+	tests := []struct {
+		category string
+		count    string
+		want     []string
+	}{
+		{medicationStarted, "(1)", []string{"Metformin"}},
+		{medicationStopped, "(2)", []string{
+			"Ibuprofen 400 MG Oral Tablet (stopped, prescribed 2023-11-20)",
+			"Amoxicillin 500 MG Oral Capsule (completed, prescribed 2024-01-05, started and stopped since then)",
+		}},
+		{medicationContinued, "(1)", []string{"LISINOPRIL 10 MG Oral Tablet (prescribed 2024-02-01)"}},
+	}
+	for _, tt := range tests {
+		section := reconciliationSection(text, tt.category)
+		if !strings.Contains(section, tt.category+" "+tt.count) {
+			t.Errorf("Expected %s %s, got:\n%s", tt.category, tt.count, text)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(section, want) {
+				t.Errorf("Expected %q under %s, got:\n%s", want, tt.category, text)
+			}
+		}
+	}
+	if strings.Contains(strings.ToLower(reconciliationSection(text, medicationStarted)), "lisinopril") {
+		t.Errorf("A renewed medication should be continued, not started, got:\n%s", text)
+	}
+	if strings.Contains(text, "Simvastatin") {
+		t.Errorf("Draft prescriptions should not be reconciled, got:\n%s", text)
+	}
+}
+
+func TestReconcileMedicationsRequiresSinceDate(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1970-05-05")
+
+	if _, err := h.ReconcileMedications("p1", ""); err == nil {
+		t.Error("Expected an error without a since date")
+	}
+	if _, err := h.ReconcileMedications("p1", "last admission"); err == nil {
+		t.Error("Expected an error for an unparseable since date")
+	}
+}
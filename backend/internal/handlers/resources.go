@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// ErrPatientNotFound is returned by PatientRecord for an unknown patient ID
+var ErrPatientNotFound = errors.New("patient not found")
+
+// ListPatients returns up to limit patients with an ID greater than afterID,
+// ordered by ID, for paging through all patients
+func (h *Handler) ListPatients(afterID string, limit int) ([]database.Patient, error) {
+	return database.GetPatientsAfter(h.db, afterID, limit)
+}
+
+// PatientRecord returns the patient's details and medical summary as text.
+// Unlike LookupPatient it doesn't change the patient context.
+func (h *Handler) PatientRecord(patientID string) (string, error) {
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %s", ErrPatientNotFound, patientID)
+		}
+		return "", fmt.Errorf("database error: %w", err)
+	}
+	h.loadContacts(patient)
+
+	summary, err := h.fetchPatientMedicalSummary(patientID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get medical summary: %w", err)
+	}
+	return formatPatientInfo(*patient) + "\n" + formatPatientSummary(patientID, summary), nil
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// maxSBARRecommendations caps the suggested actions listed under Recommendation
+const maxSBARRecommendations = 5
+
+// sbarSections are the headings of an SBAR handoff, in order
+var sbarSections = []string{"SITUATION", "BACKGROUND", "ASSESSMENT", "RECOMMENDATION"}
+
+// sbarInput is the patient data an SBAR handoff is composed from
+type sbarInput struct {
+	Summary      *PatientMedicalSummary
+	Age          int // -1 when the birth date is unknown
+	Gender       string
+	Conditions   []database.Condition
+	Medications  []database.MedicationRequest
+	Observations []database.Observation
+	Encounters   []database.Encounter
+	Actions      []suggestedAction
+}
+
+// composeSBAR renders the four SBAR sections from the patient data
+func composeSBAR(in sbarInput) string {
+	var b strings.Builder
+
+	b.WriteString("SITUATION\n")
+	b.WriteString(in.Summary.Demographics + "\n")
+	if len(in.Encounters) > 0 {
+		// Encounters are ordered newest first
+		e := in.Encounters[0]
+		encounterType := e.Class
+		if e.TypeDisplay != nil && *e.TypeDisplay != "" {
+			encounterType = *e.TypeDisplay
+		}
+		b.WriteString(fmt.Sprintf("Most recent encounter: %s on %s (%s)\n", encounterType, e.StartDateTime, e.Status))
+	} else {
+		b.WriteString("No encounters on record\n")
+	}
+
+	b.WriteString("\nBACKGROUND\n")
+	b.WriteString("Active conditions: " + joinOrNone(in.Summary.ActiveConditions) + "\n")
+	b.WriteString("Current medications: " + joinOrNone(in.Summary.CurrentMedications) + "\n")
+	if len(in.Summary.Allergies) > 0 {
+		b.WriteString("Allergies: " + strings.Join(in.Summary.Allergies, "; ") + "\n")
+	} else {
+		b.WriteString("Allergies: NKDA (no known drug allergies)\n")
+	}
+
+	b.WriteString("\nASSESSMENT\n")
+	if len(in.Summary.RecentObservations) > 0 {
+		b.WriteString("Recent results: " + strings.Join(in.Summary.RecentObservations, "; ") + "\n")
+	}
+	abnormal := abnormalObservations(in.Observations)
+	findings := findContraindications(in.Medications, in.Conditions)
+	if len(abnormal) == 0 && len(findings) == 0 {
+		b.WriteString("No abnormal results or medication concerns identified\n")
+	}
+	for _, a := range abnormal {
+		b.WriteString("Abnormal: " + a + "\n")
+	}
+	for _, f := range findings {
+		b.WriteString(fmt.Sprintf("Medication concern: %s with %s (%s)\n", f.Medication, f.Condition, f.Rule.Reason))
+	}
+
+	b.WriteString("\nRECOMMENDATION\n")
+	if len(in.Actions) == 0 {
+		b.WriteString("Continue current plan; no actions flagged\n")
+	}
+	for i, a := range in.Actions {
+		if i == maxSBARRecommendations {
+			b.WriteString(fmt.Sprintf("...and %d more (see suggest_next_actions)\n", len(in.Actions)-maxSBARRecommendations))
+			break
+		}
+		b.WriteString(fmt.Sprintf("[%s] %s\n", priorityLabels[a.Priority], a.Text))
+	}
+
+	return b.String()
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none recorded"
+	}
+	return strings.Join(items, "; ")
+}
+
+// hasSBARSections reports whether text still contains every SBAR heading,
+// which a polished rewrite must keep
+func hasSBARSections(text string) bool {
+	upper := strings.ToUpper(text)
+	for _, section := range sbarSections {
+		if !strings.Contains(upper, section) {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateSBAR composes a Situation/Background/Assessment/Recommendation
+// handoff for the patient. With MCP_SBAR_POLISH set the text is rewritten by
+// the guidelines model; the rule-based text is used if that fails.
+func (h *Handler) GenerateSBAR(ctx context.Context, patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	summary, err := h.fetchPatientMedicalSummary(patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch patient summary: %w", err)
+	}
+
+	in := sbarInput{Summary: summary, Gender: patient.Gender}
+	if in.Conditions, err = database.GetConditionsByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+	if in.Medications, err = database.GetMedicationsByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	if in.Observations, err = database.GetObservationsByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	if in.Encounters, err = database.GetEncountersByPatientID(h.db, patientID); err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+	immunizations, err := database.GetImmunizationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get immunizations: %w", err)
+	}
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+
+	if in.Age, err = calculateAge(patient.BirthDate); err != nil {
+		in.Age = -1
+	}
+	in.Actions = suggestNextActions(in.Age, in.Gender, in.Conditions, in.Medications, in.Observations,
+		immunizations, procedures, in.Encounters, time.Now())
+
+	sbar := composeSBAR(in)
+
+	if h.polishSBAR {
+		prompt := "Rewrite this SBAR handoff as concise clinical prose for a shift handoff. Keep the four headings SITUATION, BACKGROUND, ASSESSMENT and RECOMMENDATION in that order, keep every fact and value, and do not add anything that is not stated:\n\n" + sbar
+		polished, err := h.callGuidelinesModel(ctx, prompt)
+		switch {
+		case err != nil:
+			debug.Error("Failed to polish SBAR: %v", err)
+		case !hasSBARSections(polished):
+			debug.Error("Polished SBAR dropped a section, using the unpolished text")
+		default:
+			sbar = strings.TrimSpace(polished) + "\n"
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("SBAR handoff for %s %s (ID: %s)\n\n%s", patient.GivenName, patient.FamilyName, patientID, sbar),
+			},
+		},
+	}, nil
+}
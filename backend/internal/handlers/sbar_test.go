@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSBAR(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1960-01-01")
+	seedCondition(t, h, "p1", "44054006", "Diabetes mellitus type 2", "active", "2015-01-01T00:00:00Z")
+	seedMedication(t, h, "p1", "Metformin 500 MG", "active", "2015-02-01T00:00:00Z")
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 8.1, "%", "2025-01-15T00:00:00Z")
+	seedAllergy(t, h, "p1", "Allergy to penicillin", "active", "high")
+	seedEncounter(t, h, "e1", "p1", "in-progress", "2025-02-01T08:00:00Z")
+
+	result, err := h.GenerateSBAR(context.Background(), "p1")
+	text := resultText(t, h, result, err)
+
+	last := -1
+	for _, section := range sbarSections {
+		i := strings.Index(text, section)
+		if i < 0 {
+			t.Fatalf("section %s missing:\n%s", section, text)
+		}
+		if i < last {
+			t.Errorf("section %s out of order:\n%s", section, text)
+		}
+		last = i
+	}
+
+	for _, want := range []string{"Anna Jonsdottir", "in-progress", "Diabetes mellitus type 2", "Metformin 500 MG",
+		"Allergy to penicillin", "HbA1c high", "Follow up abnormal result"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q missing:\n%s", want, text)
+		}
+	}
+}
+
+func TestGenerateSBARPolish(t *testing.T) {
+	h := newTestHandler(t)
+	h.polishSBAR = true
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1960-01-01")
+
+	fakeModel(t, h, "SITUATION: stable.\nBACKGROUND: none.\nASSESSMENT: fine.\nRECOMMENDATION: continue.")
+	result, err := h.GenerateSBAR(context.Background(), "p1")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "SITUATION: stable.") {
+		t.Errorf("polished text not used:\n%s", text)
+	}
+
+	// A rewrite that drops a section is discarded
+	fakeModel(t, h, "Patient is stable.")
+	result, err = h.GenerateSBAR(context.Background(), "p1")
+	text = resultText(t, h, result, err)
+	if !hasSBARSections(text) || strings.Contains(text, "Patient is stable.") {
+		t.Errorf("incomplete polish not discarded:\n%s", text)
+	}
+}
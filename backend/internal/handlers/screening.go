@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// screeningRule describes a routine screening recommended for an age band and
+// (optionally) a single gender. A screening counts as done when a procedure or
+// observation display contains one of the terms (case-insensitive).
+type screeningRule struct {
+	Name             string
+	Gender           string // "male", "female" or "" for everyone
+	MinAge           int
+	MaxAge           int // inclusive; 0 means no upper limit
+	IntervalYears    int // 0 means a one-time screening
+	ProcedureTerms   []string
+	ObservationTerms []string
+}
+
+var screeningRules = []screeningRule{
+	{
+		Name:           "Breast cancer screening (mammography)",
+		Gender:         "female",
+		MinAge:         50,
+		MaxAge:         74,
+		IntervalYears:  2,
+		ProcedureTerms: []string{"mammogra"},
+	},
+	{
+		Name:             "Cervical cancer screening (Pap smear / HPV test)",
+		Gender:           "female",
+		MinAge:           21,
+		MaxAge:           65,
+		IntervalYears:    3,
+		ProcedureTerms:   []string{"cervical", "papanicolaou", "pap smear", "hpv"},
+		ObservationTerms: []string{"cytology", "papanicolaou", "hpv"},
+	},
+	{
+		Name:           "Colorectal cancer screening (colonoscopy)",
+		MinAge:         45,
+		MaxAge:         75,
+		IntervalYears:  10,
+		ProcedureTerms: []string{"colonoscopy", "sigmoidoscopy"},
+	},
+	{
+		Name:             "Diabetes screening (HbA1c)",
+		MinAge:           35,
+		MaxAge:           70,
+		IntervalYears:    3,
+		ObservationTerms: []string{"a1c", "glucose"},
+	},
+	{
+		Name:             "Lipid panel",
+		MinAge:           40,
+		MaxAge:           75,
+		IntervalYears:    5,
+		ObservationTerms: []string{"cholesterol", "triglycerides"},
+	},
+	{
+		Name:             "Blood pressure check",
+		MinAge:           18,
+		IntervalYears:    1,
+		ObservationTerms: []string{"blood pressure"},
+	},
+	{
+		Name:           "Abdominal aortic aneurysm ultrasound",
+		Gender:         "male",
+		MinAge:         65,
+		MaxAge:         75,
+		ProcedureTerms: []string{"aortic aneurysm", "ultrasound of abdominal aorta"},
+	},
+	{
+		Name:           "Osteoporosis screening (bone density scan)",
+		Gender:         "female",
+		MinAge:         65,
+		IntervalYears:  2,
+		ProcedureTerms: []string{"bone density", "dxa", "dexa"},
+	},
+}
+
+// applies reports whether the rule covers a patient of the given age and gender
+func (r screeningRule) applies(age int, gender string) bool {
+	if age < r.MinAge || (r.MaxAge > 0 && age > r.MaxAge) {
+		return false
+	}
+	return r.Gender == "" || r.Gender == fhirGender(gender)
+}
+
+// screeningStatus is the outcome of checking one rule against a patient's history
+type screeningStatus struct {
+	Rule     screeningRule
+	LastDone *time.Time
+	NextDue  *time.Time
+	Due      bool
+}
+
+// recordDate parses the date part of a stored datetime (YYYY-MM-DD...)
+func recordDate(value *string) (time.Time, bool) {
+	if value == nil || len(*value) < 10 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", (*value)[:10])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// evaluateScreenings checks every applicable rule against the patient's
+// procedures and observations as of now
+func evaluateScreenings(age int, gender string, procedures []database.Procedure, observations []database.Observation, now time.Time) []screeningStatus {
+	var statuses []screeningStatus
+	for _, rule := range screeningRules {
+		if !rule.applies(age, gender) {
+			continue
+		}
+
+		var last *time.Time
+		consider := func(display string, when *string, terms []string) {
+			if !containsAny(display, terms) {
+				return
+			}
+			if t, ok := recordDate(when); ok && (last == nil || t.After(*last)) {
+				last = &t
+			}
+		}
+		for _, p := range procedures {
+			consider(p.Display, p.PerformedDateTime, rule.ProcedureTerms)
+		}
+		for _, o := range observations {
+			consider(o.Display, o.EffectiveDateTime, rule.ObservationTerms)
+		}
+
+		status := screeningStatus{Rule: rule, LastDone: last, Due: last == nil}
+		if last != nil && rule.IntervalYears > 0 {
+			next := last.AddDate(rule.IntervalYears, 0, 0)
+			status.NextDue = &next
+			status.Due = !next.After(now)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (h *Handler) GetScreeningRecommendations(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	patientName := strings.TrimSpace(patient.GivenName + " " + patient.FamilyName)
+	resultText := fmt.Sprintf("Screening recommendations for %s (ID: %s)\n\n", patientName, patientID)
+
+	age, err := calculateAge(patient.BirthDate)
+	if err != nil {
+		resultText += "Unable to determine screening recommendations: the patient's birth date is not available."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": resultText,
+				},
+			},
+		}, nil
+	}
+
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	resultText += fmt.Sprintf("Age: %d years, Gender: %s\n", age, patient.Gender)
+	if g := fhirGender(patient.Gender); g != "male" && g != "female" {
+		resultText += "Note: gender is not recorded, so gender-specific screenings are not included.\n"
+	}
+
+	statuses := evaluateScreenings(age, patient.Gender, procedures, observations, time.Now())
+	if len(statuses) == 0 {
+		resultText += "\nNo routine screenings apply for this age and gender."
+	}
+
+	var due, upToDate strings.Builder
+	for _, s := range statuses {
+		switch {
+		case s.LastDone == nil:
+			due.WriteString(fmt.Sprintf("• %s: no record found\n", s.Rule.Name))
+		case s.Due:
+			due.WriteString(fmt.Sprintf("• %s: last done %s, overdue since %s\n",
+				s.Rule.Name, s.LastDone.Format("2006-01-02"), s.NextDue.Format("2006-01-02")))
+		case s.NextDue == nil:
+			upToDate.WriteString(fmt.Sprintf("• %s: done %s (one-time)\n", s.Rule.Name, s.LastDone.Format("2006-01-02")))
+		default:
+			upToDate.WriteString(fmt.Sprintf("• %s: last done %s, next due %s\n",
+				s.Rule.Name, s.LastDone.Format("2006-01-02"), s.NextDue.Format("2006-01-02")))
+		}
+	}
+	if due.Len() > 0 {
+		resultText += "\nDue now:\n" + due.String()
+	}
+	if upToDate.Len() > 0 {
+		resultText += "\nUp to date:\n" + upToDate.String()
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": resultText,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func screeningNames(statuses []screeningStatus, dueOnly bool) []string {
+	var names []string
+	for _, s := range statuses {
+		if !dueOnly || s.Due {
+			names = append(names, s.Rule.Name)
+		}
+	}
+	return names
+}
+
+func hasScreening(names []string, prefix string) bool {
+	for _, n := range names {
+		if strings.HasPrefix(n, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEvaluateScreeningsByAgeAndGender(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		age     int
+		gender  string
+		want    []string
+		notWant []string
+	}{
+		{"woman 55", 55, "female", []string{"Breast cancer", "Cervical cancer", "Colorectal cancer", "Diabetes", "Lipid panel"}, []string{"Abdominal aortic", "Osteoporosis"}},
+		{"man 68", 68, "male", []string{"Colorectal cancer", "Abdominal aortic", "Lipid panel"}, []string{"Breast cancer", "Cervical cancer", "Osteoporosis"}},
+		{"young adult", 22, "male", []string{"Blood pressure"}, []string{"Colorectal cancer", "Diabetes", "Lipid panel"}},
+		{"child", 10, "female", nil, []string{"Blood pressure", "Cervical cancer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := screeningNames(evaluateScreenings(tt.age, tt.gender, nil, nil, now), false)
+			for _, w := range tt.want {
+				if !hasScreening(names, w) {
+					t.Errorf("Expected %q to apply, got %v", w, names)
+				}
+			}
+			for _, nw := range tt.notWant {
+				if hasScreening(names, nw) {
+					t.Errorf("Did not expect %q to apply, got %v", nw, names)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateScreeningsUsesHistory(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := "2024-03-10T09:00:00Z"
+	old := "2020-01-15"
+
+	procedures := []database.Procedure{
+		{Display: "Screening mammography", PerformedDateTime: &recent},
+		{Display: "Colonoscopy", PerformedDateTime: &old},
+	}
+	observations := []database.Observation{
+		{Display: "Hemoglobin A1c/Hemoglobin.total in Blood", EffectiveDateTime: &old},
+	}
+
+	statuses := evaluateScreenings(55, "female", procedures, observations, now)
+	due := screeningNames(statuses, true)
+
+	if hasScreening(due, "Breast cancer") {
+		t.Errorf("Mammography done in 2024 should not be due, due list: %v", due)
+	}
+	if hasScreening(due, "Colorectal cancer") {
+		t.Errorf("Colonoscopy done in 2020 should not be due for 10 years, due list: %v", due)
+	}
+	if !hasScreening(due, "Diabetes") {
+		t.Errorf("HbA1c from 2020 should be overdue, due list: %v", due)
+	}
+	if !hasScreening(due, "Cervical cancer") {
+		t.Errorf("Cervical screening with no record should be due, due list: %v", due)
+	}
+}
+
+func TestGetScreeningRecommendations(t *testing.T) {
+	h := newTestHandler(t)
+	birthDate := time.Now().AddDate(-55, 0, -1).Format("2006-01-02")
+	seedPatient(t, h, "p1", "Maria", "Lopez", "female", birthDate)
+	seedProcedure(t, h, "p1", "Screening mammography", time.Now().AddDate(0, -6, 0).Format(time.RFC3339))
+
+	result, err := h.GetScreeningRecommendations("p1")
+	text := resultText(t, h, result, err)
+
+	due := text[strings.Index(text, "Due now:"):strings.Index(text, "Up to date:")]
+	if !strings.Contains(due, "Colorectal cancer screening") {
+		t.Errorf("Expected colonoscopy to be due, got:\n%s", text)
+	}
+	if strings.Contains(due, "Breast cancer screening") {
+		t.Errorf("Expected mammography to be up to date, got:\n%s", text)
+	}
+	if strings.Contains(text, "Abdominal aortic aneurysm") {
+		t.Errorf("AAA screening should not apply to women, got:\n%s", text)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// session is the conversation state of one client: its context and when it
+// was last used. Both are guarded by handlerState.mu.
+type session struct {
+	context      Context
+	lastAccessed time.Time
+}
+
+func newSession() *session {
+	return &session{context: defaultContext(), lastAccessed: time.Now()}
+}
+
+// Session returns the Handler for the session with the given ID, creating
+// the session with the default context on first use. Sessions keep separate
+// patient and practitioner contexts, so concurrent HTTP clients don't
+// overwrite each other's. An empty ID is the default session, shared by
+// clients that don't send one, such as the stdin MCP server.
+func (h *Handler) Session(id string) *Handler {
+	id = strings.TrimSpace(id)
+	if id == h.sessionID {
+		return h
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.sessions[id]
+	if !ok {
+		s = newSession()
+		h.sessions[id] = s
+		debug.Log("Started session %q (%d active)", id, len(h.sessions))
+	}
+	return &Handler{handlerState: h.handlerState, session: s, sessionID: id}
+}
+
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx that carries a session ID, for
+// SessionFromContext
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// SessionFromContext returns the session ID set by WithSession, or "" for the
+// default session
+func SessionFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSessionsKeepSeparateContexts(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+	seedPatient(t, h, "p2", "Ola", "Nordmann", "male", "1980-01-01")
+
+	alice, bob := h.Session("alice"), h.Session("bob")
+	if _, err := alice.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+	if _, err := bob.SetPatientContext("p2"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+
+	if got := alice.GetContextPatientID(""); got != "p1" {
+		t.Errorf("alice's patient = %q, want p1", got)
+	}
+	if got := h.Session("bob").GetContextPatientID(""); got != "p2" {
+		t.Errorf("bob's patient = %q, want p2", got)
+	}
+	if got := h.GetContextPatientID(""); got != "" {
+		t.Errorf("default session's patient = %q, want none", got)
+	}
+	if h.Session("") != h {
+		t.Error("empty session ID should be the default session")
+	}
+}
+
+func TestIdleSessionsDropped(t *testing.T) {
+	h := newTestHandler(t)
+	h.idleTTL = time.Minute
+	h.Session("alice").context.PatientID = "p1"
+	h.context.PatientID = "p2"
+	h.Touch()
+
+	if !h.clearIfIdle(time.Now().Add(2 * time.Minute)) {
+		t.Fatal("idle sessions were not cleared")
+	}
+	if _, ok := h.sessions["alice"]; ok {
+		t.Error("idle named session was kept")
+	}
+	if h.context.PatientID != "" || h.Session("alice").context.PatientID != "" {
+		t.Error("idle contexts were not reset")
+	}
+}
+
+func TestSessionFromContext(t *testing.T) {
+	if id := SessionFromContext(context.Background()); id != "" {
+		t.Errorf("SessionFromContext = %q, want the default session", id)
+	}
+	if id := SessionFromContext(WithSession(context.Background(), "tab-1")); id != "tab-1" {
+		t.Errorf("SessionFromContext = %q, want tab-1", id)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	speechHeaderRegex   = regexp.MustCompile(`^#{1,6}\s*`)
+	speechBulletRegex   = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+	speechLinkRegex     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	speechEmphasisRegex = regexp.MustCompile("\\*\\*|__|\\*|`")
+	speechRuleRegex     = regexp.MustCompile(`^(?:-{3,}|\*{3,}|_{3,})$`)
+)
+
+// speechDisclaimerPrefixes mark boilerplate the model (or GetMedicalGuidelines)
+// tends to append; it is dropped when it trails the response
+var speechDisclaimerPrefixes = []string{
+	"note:",
+	"disclaimer:",
+	"this information is general",
+	"this information is for",
+	"please consult",
+	"always consult",
+}
+
+// cleanForSpeech turns a markdown-formatted response into plain sentences
+// suitable for text-to-speech: headers, bullets, emphasis and links are
+// stripped, list items become sentences and trailing disclaimers are removed.
+func cleanForSpeech(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || speechRuleRegex.MatchString(line) {
+			continue
+		}
+		line = speechHeaderRegex.ReplaceAllString(line, "")
+		line = speechBulletRegex.ReplaceAllString(line, "")
+		line = speechLinkRegex.ReplaceAllString(line, "$1")
+		line = strings.TrimSpace(speechEmphasisRegex.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		// Headers and list items often lack punctuation, which TTS runs together
+		if !strings.ContainsAny(line[len(line)-1:], ".!?:;") {
+			line += "."
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) > 1 && isSpeechDisclaimer(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, " ")
+}
+
+func isSpeechDisclaimer(line string) bool {
+	lower := strings.ToLower(line)
+	for _, prefix := range speechDisclaimerPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCleanForSpeech(t *testing.T) {
+	input := "## Blood pressure\n\n**Latest reading:** 142/91 mmHg\n\n- Elevated compared to [last visit](http://example.com)\n- Consider `amlodipine`\n\nNote: This information is general medical guidance."
+	want := "Blood pressure. Latest reading: 142/91 mmHg. Elevated compared to last visit. Consider amlodipine."
+
+	if got := cleanForSpeech(input); got != want {
+		t.Errorf("cleanForSpeech() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestProcessNaturalLanguageQueryForAudio(t *testing.T) {
+	h := newTestHandler(t)
+	reply := "### Summary\n* **HbA1c** is 7.2%\n* Trending down\n\nDisclaimer: consult local guidelines."
+	fakeModel(t, h, reply)
+
+	result, err := h.ProcessNaturalLanguageQuery(context.Background(), "How is the patient's diabetes?", "", true)
+	text := resultText(t, h, result, err)
+	for _, md := range []string{"#", "*", "Disclaimer"} {
+		if strings.Contains(text, md) {
+			t.Errorf("Expected %q to be stripped for audio, got %q", md, text)
+		}
+	}
+	if !strings.Contains(text, "HbA1c is 7.2%.") {
+		t.Errorf("Expected content to be preserved, got %q", text)
+	}
+
+	result, err = h.ProcessNaturalLanguageQuery(context.Background(), "How is the patient's diabetes?", "", false)
+	if text := resultText(t, h, result, err); text != reply {
+		t.Errorf("Expected response unchanged without for_audio, got %q", text)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// chatToolCall is a function call requested by the model
+type chatToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// chatMessage is the assistant message of a chat completion
+type chatMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content,omitempty"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+}
+
+// readCompletionStream assembles a streamed ("stream": true) chat completion
+// from its server-sent events, passing each piece of content to onToken as
+// it arrives. Tool calls arrive in fragments keyed by index and are joined.
+func readCompletionStream(r io.Reader, onToken func(string)) (chatMessage, tokenUsage, error) {
+	message := chatMessage{Role: "assistant"}
+	var usage tokenUsage
+	var content strings.Builder
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// Skip blank separators and ": keep-alive" comments
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Role      string `json:"role"`
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Type     string `json:"type"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *tokenUsage `json:"usage"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return message, usage, fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return message, usage, fmt.Errorf("OpenRouter stream error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.Role != "" {
+			message.Role = delta.Role
+		}
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			onToken(delta.Content)
+		}
+		for _, fragment := range delta.ToolCalls {
+			if fragment.Index < 0 {
+				continue
+			}
+			for len(message.ToolCalls) <= fragment.Index {
+				message.ToolCalls = append(message.ToolCalls, chatToolCall{})
+			}
+			call := &message.ToolCalls[fragment.Index]
+			if fragment.ID != "" {
+				call.ID = fragment.ID
+			}
+			if fragment.Type != "" {
+				call.Type = fragment.Type
+			}
+			call.Function.Name += fragment.Function.Name
+			call.Function.Arguments += fragment.Function.Arguments
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return message, usage, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	message.Content = content.String()
+	return message, usage, nil
+}
+
+// StreamNaturalLanguageQuery answers a query like ProcessNaturalLanguageQuery
+// but passes the answer to onToken piece by piece as the model writes it, and
+// returns the whole answer when done. Audio answers are cleaned up only once
+// complete, so they reach onToken in one piece.
+func (h *Handler) StreamNaturalLanguageQuery(ctx context.Context, query string, practitionerID string, forAudio bool, onToken func(string)) (string, error) {
+	if forAudio {
+		response, err := h.answerQuery(ctx, query, practitionerID, true, nil)
+		if err == nil {
+			onToken(response)
+		}
+		return response, err
+	}
+	return h.answerQuery(ctx, query, practitionerID, false, onToken)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// streamingModel serves each reply as a server-sent event stream of the
+// given chunks, one reply per request
+func streamingModel(t *testing.T, h *Handler, replies ...[]string) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("model request doesn't set stream")
+		}
+		if requests >= len(replies) {
+			t.Errorf("Unexpected model request #%d", requests+1)
+			http.Error(w, "no more replies", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		for _, chunk := range replies[requests] {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		requests++
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+}
+
+func TestStreamNaturalLanguageQuery(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Kari", "Nordmann", "female", "1985-03-03")
+
+	streamingModel(t, h,
+		[]string{
+			`{"choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call-1","type":"function","function":{"name":"set_patient_context","arguments":"{\"patient_"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"id\": \"p1\"}"}}]}}]}`,
+		},
+		[]string{
+			`{"choices":[{"delta":{"role":"assistant","content":"Kari Nordmann "}}]}`,
+			`{"choices":[{"delta":{"content":"is selected."}}],"usage":{"prompt_tokens":120,"completion_tokens":6}}`,
+		},
+	)
+
+	var tokens []string
+	text, err := h.StreamNaturalLanguageQuery(context.Background(), "select Kari", "", false, func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatalf("StreamNaturalLanguageQuery failed: %v", err)
+	}
+	if text != "Kari Nordmann is selected." {
+		t.Errorf("text = %q", text)
+	}
+	if strings.Join(tokens, "|") != "Kari Nordmann |is selected." {
+		t.Errorf("tokens = %q", tokens)
+	}
+	if got := h.GetContextPatientID(""); got != "p1" {
+		t.Errorf("streamed tool call not run, patient context = %q", got)
+	}
+	if report := h.usage.report(); !strings.Contains(report, "120 prompt + 6 completion tokens") {
+		t.Errorf("streamed usage not recorded:\n%s", report)
+	}
+}
+
+func TestReadCompletionStreamError(t *testing.T) {
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"Ka\"}}]}\n\n" +
+		"data: {\"error\":{\"message\":\"upstream timeout\"}}\n\n"
+	_, _, err := readCompletionStream(strings.NewReader(stream), func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "upstream timeout") {
+		t.Errorf("expected the stream error, got %v", err)
+	}
+}
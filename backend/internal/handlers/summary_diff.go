@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// maxDiffItems caps how many entries of one kind DiffPatientSummary lists
+const maxDiffItems = 10
+
+// parseRecordTime parses the datetime formats found in the database and
+// accepted from callers (RFC 3339, with or without zone, or a plain date)
+func parseRecordTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// changedSince reports whether a record dated value falls on or after since.
+// Records without a parseable date are never reported as changes.
+func changedSince(value string, since time.Time) bool {
+	t, ok := parseRecordTime(value)
+	return ok && !t.Before(since)
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// summaryDelta is the list of new items of one kind since the snapshot
+type summaryDelta struct {
+	Title string
+	Items []string
+}
+
+// patientSummaryDeltas recomputes the patient's record against date-filtered
+// history, returning what was added on or after since
+func (h *Handler) patientSummaryDeltas(patientID string, since time.Time) ([]summaryDelta, error) {
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+	medications, err := database.GetMedicationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medications: %w", err)
+	}
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	encounters, err := database.GetEncountersByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encounters: %w", err)
+	}
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+	immunizations, err := database.GetImmunizationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get immunizations: %w", err)
+	}
+
+	deltas := []summaryDelta{{Title: "New conditions"}, {Title: "New medications"}, {Title: "New observations"},
+		{Title: "New encounters"}, {Title: "New procedures"}, {Title: "New immunizations"}}
+
+	for _, c := range conditions {
+		if onset := derefString(c.OnsetDateTime); changedSince(onset, since) {
+			deltas[0].Items = append(deltas[0].Items, fmt.Sprintf("%s (%s, onset %s)", c.Display, c.ClinicalStatus, onset[:10]))
+		}
+	}
+	for _, m := range medications {
+		if changedSince(m.AuthoredOn, since) {
+			deltas[1].Items = append(deltas[1].Items, fmt.Sprintf("%s (%s, prescribed %s)", m.MedicationDisplay, m.Status, m.AuthoredOn[:10]))
+		}
+	}
+	for _, o := range observations {
+		if effective := derefString(o.EffectiveDateTime); changedSince(effective, since) {
+			text := o.Display
+			if o.ValueQuantity != nil {
+				text += fmt.Sprintf(": %s %s", FormatValue(*o.ValueQuantity, o.Code, derefString(o.ValueUnit)), derefString(o.ValueUnit))
+			} else if o.ValueString != nil {
+				text += ": " + *o.ValueString
+			}
+			deltas[2].Items = append(deltas[2].Items, fmt.Sprintf("%s (%s)", strings.TrimSpace(text), effective[:10]))
+		}
+	}
+	for _, e := range encounters {
+		if changedSince(e.StartDateTime, since) {
+			encounterType := e.Class
+			if e.TypeDisplay != nil && *e.TypeDisplay != "" {
+				encounterType = *e.TypeDisplay
+			}
+			deltas[3].Items = append(deltas[3].Items, fmt.Sprintf("%s (%s, %s)", encounterType, e.Status, e.StartDateTime[:10]))
+		}
+	}
+	for _, p := range procedures {
+		if performed := derefString(p.PerformedDateTime); changedSince(performed, since) {
+			deltas[4].Items = append(deltas[4].Items, fmt.Sprintf("%s (%s)", p.Display, performed[:10]))
+		}
+	}
+	for _, i := range immunizations {
+		if changedSince(i.OccurrenceDateTime, since) {
+			deltas[5].Items = append(deltas[5].Items, fmt.Sprintf("%s (%s)", i.VaccineDisplay, i.OccurrenceDateTime[:10]))
+		}
+	}
+
+	return deltas, nil
+}
+
+// DiffPatientSummary reports what was added to the patient's record since
+// sinceDateTime. When no date is given it defaults to the time the patient's
+// context summary was loaded, i.e. what changed since the patient was selected.
+func (h *Handler) DiffPatientSummary(patientID, sinceDateTime string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	if strings.TrimSpace(sinceDateTime) == "" {
+		h.mu.RLock()
+		if h.context.PatientID == patientID && h.context.PatientSummary != nil {
+			sinceDateTime = h.context.PatientSummary.LastUpdated
+		}
+		h.mu.RUnlock()
+		if sinceDateTime == "" {
+			return nil, fmt.Errorf("since date is required (no summary snapshot for this patient in context)")
+		}
+	}
+
+	since, ok := parseRecordTime(sinceDateTime)
+	if !ok {
+		return nil, fmt.Errorf("invalid since date: %s (expected YYYY-MM-DD or RFC3339)", sinceDateTime)
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	deltas, err := h.patientSummaryDeltas(patientID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Changes for %s (ID: %s) since %s\n", patientName, patientID, sinceDateTime))
+
+	changes := 0
+	for _, d := range deltas {
+		if len(d.Items) == 0 {
+			continue
+		}
+		changes += len(d.Items)
+		result.WriteString(fmt.Sprintf("\n%s (%d):\n", d.Title, len(d.Items)))
+		for i, item := range d.Items {
+			if i == maxDiffItems {
+				result.WriteString(fmt.Sprintf("• ...and %d more\n", len(d.Items)-maxDiffItems))
+				break
+			}
+			result.WriteString("• " + item + "\n")
+		}
+	}
+	if changes == 0 {
+		result.WriteString("\nNo changes recorded since then.")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
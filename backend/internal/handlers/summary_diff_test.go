@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffPatientSummaryShowsNewCondition(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1970-05-05")
+	seedCondition(t, h, "p1", "38341003", "Hypertension", "active", "2015-02-01T00:00:00Z")
+
+	result, err := h.DiffPatientSummary("p1", "2024-01-01")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "No changes recorded") {
+		t.Errorf("Expected no changes before the new condition, got:\n%s", text)
+	}
+
+	seedCondition(t, h, "p1", "44054006", "Diabetes mellitus type 2", "active", "2024-06-15T10:00:00Z")
+
+	result, err = h.DiffPatientSummary("p1", "2024-01-01")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "New conditions (1)") || !strings.Contains(text, "Diabetes mellitus type 2") {
+		t.Errorf("Expected new condition in diff, got:\n%s", text)
+	}
+	if strings.Contains(text, "Hypertension") {
+		t.Errorf("Condition from before the since date should not be listed, got:\n%s", text)
+	}
+}
+
+func TestDiffPatientSummaryDefaultsToContextSnapshot(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1970-05-05")
+
+	if _, err := h.DiffPatientSummary("p1", ""); err == nil {
+		t.Error("Expected an error without a since date or context snapshot")
+	}
+
+	if _, err := h.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext failed: %v", err)
+	}
+	seedCondition(t, h, "p1", "195662009", "Acute viral pharyngitis", "active", time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+
+	result, err := h.DiffPatientSummary("", "")
+	text := resultText(t, h, result, err)
+	if !strings.Contains(text, "Acute viral pharyngitis") {
+		t.Errorf("Expected condition added after the snapshot, got:\n%s", text)
+	}
+}
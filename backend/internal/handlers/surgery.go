@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// defaultSurgicalCodes are SNOMED CT codes of common surgical procedures.
+// Override with MCP_SURGICAL_PROCEDURE_CODES.
+var defaultSurgicalCodes = []string{
+	"80146002",  // Appendectomy
+	"6025007",   // Laparoscopic appendectomy
+	"38102005",  // Cholecystectomy
+	"45595009",  // Laparoscopic cholecystectomy
+	"11466000",  // Cesarean section
+	"287664005", // Bilateral tubal ligation
+	"609588000", // Total knee replacement
+	"52734007",  // Total replacement of hip
+	"232717009", // Coronary artery bypass grafting
+}
+
+// defaultSurgicalTerms match the display of surgical procedures that aren't
+// covered by a code. Override with MCP_SURGICAL_PROCEDURE_TERMS.
+var defaultSurgicalTerms = []string{
+	"ectomy", "otomy", "ostomy", "plasty", "surgical", "surgery", "excision", "resection",
+	"repair of", "replacement of", "bypass", "transplant", "amputation", "cesarean", "ligation",
+}
+
+// isSurgical reports whether a procedure's code is in codes or its display
+// contains one of terms
+func isSurgical(p database.Procedure, codes, terms []string) bool {
+	if p.Code != nil {
+		for _, code := range codes {
+			if *p.Code == code {
+				return true
+			}
+		}
+	}
+	return containsAny(p.Display, terms)
+}
+
+// surgicalProcedures filters procedures to surgical ones, oldest first
+func surgicalProcedures(procedures []database.Procedure, codes, terms []string) []database.Procedure {
+	var surgical []database.Procedure
+	for _, p := range procedures {
+		if isSurgical(p, codes, terms) {
+			surgical = append(surgical, p)
+		}
+	}
+	sort.SliceStable(surgical, func(i, j int) bool {
+		return derefString(surgical[i].PerformedDateTime) < derefString(surgical[j].PerformedDateTime)
+	})
+	return surgical
+}
+
+func (h *Handler) GetSurgicalHistory(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+
+	codes, terms := h.surgicalCodes, h.surgicalTerms
+	if len(codes) == 0 {
+		codes = defaultSurgicalCodes
+	}
+	if len(terms) == 0 {
+		terms = defaultSurgicalTerms
+	}
+	surgical := surgicalProcedures(procedures, codes, terms)
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Surgical history for %s (ID: %s)\n\n", patientName, patientID))
+
+	if len(surgical) == 0 {
+		result.WriteString("No surgical procedures recorded.")
+	}
+	for _, p := range surgical {
+		date := derefString(p.PerformedDateTime)
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		if date == "" {
+			date = "unknown date"
+		}
+		result.WriteString(fmt.Sprintf("• %s: %s", date, p.Display))
+		if p.Status != "" && p.Status != "completed" {
+			result.WriteString(fmt.Sprintf(" (%s)", p.Status))
+		}
+		result.WriteString("\n")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
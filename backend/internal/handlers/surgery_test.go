@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetSurgicalHistory(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1970-01-01")
+	seedProcedure(t, h, "p1", "Appendectomy", "2015-03-02T10:00:00Z")
+	seedProcedure(t, h, "p1", "Medication reconciliation (procedure)", "2016-01-01T10:00:00Z")
+	seedProcedure(t, h, "p1", "Total replacement of hip", "2020-06-10T10:00:00Z")
+	seedProcedure(t, h, "p1", "Screening mammography", "2021-01-01T10:00:00Z")
+	seedProcedure(t, h, "p1", "Cholecystectomy", "2010-09-01T10:00:00Z")
+
+	if _, err := h.db.Exec(`INSERT INTO procedures (id, status, code, display, patient_id, performed_datetime)
+		VALUES ('proc-code', 'completed', '11466000', 'Delivery procedure', 'p1', '2005-05-05T10:00:00Z')`); err != nil {
+		t.Fatalf("Failed to seed procedure: %v", err)
+	}
+
+	result, err := h.GetSurgicalHistory("p1")
+	text := resultText(t, h, result, err)
+
+	for _, excluded := range []string{"Medication reconciliation", "mammography"} {
+		if strings.Contains(text, excluded) {
+			t.Errorf("non-surgical procedure %q listed:\n%s", excluded, text)
+		}
+	}
+
+	want := []string{"Delivery procedure", "Cholecystectomy", "Appendectomy", "Total replacement of hip"}
+	last := -1
+	for _, name := range want {
+		i := strings.Index(text, name)
+		if i < 0 {
+			t.Fatalf("%q missing:\n%s", name, text)
+		}
+		if i < last {
+			t.Errorf("%q out of chronological order:\n%s", name, text)
+		}
+		last = i
+	}
+}
+
+func TestGetSurgicalHistoryConfiguredTerms(t *testing.T) {
+	h := newTestHandler(t)
+	h.surgicalTerms = []string{"mammography"}
+	seedPatient(t, h, "p1", "Anna", "Jonsdottir", "female", "1970-01-01")
+	seedProcedure(t, h, "p1", "Appendectomy", "2015-03-02T10:00:00Z")
+	seedProcedure(t, h, "p1", "Screening mammography", "2021-01-01T10:00:00Z")
+
+	result, err := h.GetSurgicalHistory("p1")
+	text := resultText(t, h, result, err)
+	if strings.Contains(text, "Appendectomy") || !strings.Contains(text, "Screening mammography") {
+		t.Errorf("configured terms not applied:\n%s", text)
+	}
+}
+
+func seedProcedure(t *testing.T, h *Handler, patientID, display, performedDateTime string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO procedures (id, status, display, patient_id, performed_datetime)
+		VALUES (lower(hex(randomblob(16))), 'completed', ?, ?, ?)`,
+		display, patientID, performedDateTime)
+	if err != nil {
+		t.Fatalf("Failed to seed procedure: %v", err)
+	}
+}
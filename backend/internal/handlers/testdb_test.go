@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// newTestHandler returns a Handler backed by an in-memory database created
+// from schema.sql and migrated to the current version.
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open in-memory database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../../schema.sql")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("Failed to apply schema: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	return NewHandler(db, "test-key")
+}
+
+func seedPatient(t *testing.T, h *Handler, id, givenName, familyName, gender, birthDate string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES (?, ?, ?, ?, ?)`,
+		id, givenName, familyName, gender, birthDate)
+	if err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+}
+
+func seedObservation(t *testing.T, h *Handler, patientID, code, display string, value float64, unit, effectiveDateTime string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO observations (id, status, category, code, display, patient_id, effective_datetime, value_quantity, value_unit)
+		VALUES (lower(hex(randomblob(16))), 'final', 'vital-signs', ?, ?, ?, ?, ?, ?)`,
+		code, display, patientID, effectiveDateTime, value, unit)
+	if err != nil {
+		t.Fatalf("Failed to seed observation: %v", err)
+	}
+}
+
+func resultText(t *testing.T, h *Handler, result interface{}, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return h.ExtractTextFromMCPResult(result)
+}
+
+func seedCondition(t *testing.T, h *Handler, patientID, code, display, clinicalStatus, onsetDateTime string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO conditions (id, clinical_status, code, display, patient_id, onset_datetime)
+		VALUES (lower(hex(randomblob(16))), ?, ?, ?, ?, ?)`,
+		clinicalStatus, code, display, patientID, onsetDateTime)
+	if err != nil {
+		t.Fatalf("Failed to seed condition: %v", err)
+	}
+}
+
+func seedMedication(t *testing.T, h *Handler, patientID, display, status, authoredOn string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO medication_requests (id, status, medication_display, patient_id, authored_on)
+		VALUES (lower(hex(randomblob(16))), ?, ?, ?, ?)`,
+		status, display, patientID, authoredOn)
+	if err != nil {
+		t.Fatalf("Failed to seed medication: %v", err)
+	}
+}
+
+func seedEncounter(t *testing.T, h *Handler, id, patientID, status, startDateTime string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO encounters (id, status, class, patient_id, start_datetime) VALUES (?, ?, 'AMB', ?, ?)`,
+		id, status, patientID, startDateTime)
+	if err != nil {
+		t.Fatalf("Failed to seed encounter: %v", err)
+	}
+}
+
+func seedAllergy(t *testing.T, h *Handler, patientID, display, clinicalStatus, criticality string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO allergy_intolerances (id, clinical_status, display, patient_id, criticality)
+		VALUES (lower(hex(randomblob(16))), ?, ?, ?, ?)`,
+		clinicalStatus, display, patientID, criticality)
+	if err != nil {
+		t.Fatalf("Failed to seed allergy: %v", err)
+	}
+}
+
+func seedPractitioner(t *testing.T, h *Handler, id, givenName, familyName string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO practitioners (id, given_name, family_name) VALUES (?, ?, ?)`, id, givenName, familyName)
+	if err != nil {
+		t.Fatalf("Failed to seed practitioner: %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// matchingObservations returns the observations matching code, oldest
+// first. The code matches either the observation code exactly or, so callers
+// can pass names like "hemoglobin a1c", a substring of the display.
+func matchingObservations(observations []database.Observation, code string) []database.Observation {
+	code = strings.TrimSpace(code)
+	codeLower := strings.ToLower(code)
+
+	var matches []database.Observation
+	for _, o := range observations {
+		if o.Code != code && !strings.Contains(strings.ToLower(o.Display), codeLower) {
+			continue
+		}
+		matches = append(matches, o)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return observationBefore(matches[i], matches[j])
+	})
+	return matches
+}
+
+// observationBefore reports whether a was recorded before b. Times are
+// compared as instants, so imported times with a UTC offset sort correctly;
+// times that don't parse are compared as text.
+func observationBefore(a, b database.Observation) bool {
+	ta, okA := parseRecordTime(observationDate(a))
+	tb, okB := parseRecordTime(observationDate(b))
+	if okA && okB {
+		return ta.Before(tb)
+	}
+	return observationDate(a) < observationDate(b)
+}
+
+// observationTrend returns the numeric observations matching code, oldest
+// first (see matchingObservations)
+func observationTrend(observations []database.Observation, code string) []database.Observation {
+	var trend []database.Observation
+	for _, o := range matchingObservations(observations, code) {
+		if o.ValueQuantity != nil {
+			trend = append(trend, o)
+		}
+	}
+	return trend
+}
+
+func observationDate(o database.Observation) string {
+	if o.EffectiveDateTime == nil {
+		return ""
+	}
+	return *o.EffectiveDateTime
+}
+
+// observationDay returns the date of the observation's effective time in the
+// display zone
+func observationDay(o database.Observation) string {
+	date := recordDay(observationDate(o))
+	if date == "" {
+		date = "unknown date"
+	}
+	return date
+}
+
+// formatTrendValue renders one data point as "2024-01-15: 7.2 %"
+func formatTrendValue(o database.Observation) string {
+	value := fmt.Sprintf("%s: %s", observationDay(o), FormatValue(*o.ValueQuantity, o.Code, derefString(o.ValueUnit)))
+	if o.ValueUnit != nil && *o.ValueUnit != "" {
+		value += " " + *o.ValueUnit
+	}
+	return value
+}
+
+func (h *Handler) ExplainObservationTrend(ctx context.Context, patientID, code string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("observation code is required")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	trend := observationTrend(observations, code)
+	patientName, _ := database.GetPatientName(h.db, patientID)
+
+	if len(trend) < 2 {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("Not enough numeric results for '%s' to describe a trend for %s (found %d, need at least 2).", code, patientName, len(trend)),
+				},
+			},
+		}, nil
+	}
+
+	var values strings.Builder
+	for _, o := range trend {
+		values.WriteString("- " + formatTrendValue(o) + "\n")
+	}
+
+	name := trend[len(trend)-1].Display
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s trend for %s (ID: %s)\n\n", name, patientName, patientID))
+	result.WriteString(values.String())
+
+	prompt := fmt.Sprintf("A patient has the following %s results, oldest first:\n%s\nIn 2-4 sentences of plain language, say whether this trend is improving, worsening or stable and describe any clinical significance. Do not repeat the values back as a list.",
+		name, values.String())
+
+	explanation, err := h.callGuidelinesModel(ctx, prompt)
+	if err != nil {
+		debug.Error("Failed to explain observation trend: %v", err)
+		result.WriteString("\nExplanation unavailable: unable to reach the guidelines model.")
+	} else {
+		result.WriteString("\n" + strings.TrimSpace(explanation))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
+
+// SeriesPoint is one value of an observation time series
+type SeriesPoint struct {
+	DateTime string  `json:"datetime"`
+	Value    float64 `json:"value"`
+}
+
+// ObservationSeries is the machine-readable form of an observation trend,
+// e.g. for drawing a sparkline
+type ObservationSeries struct {
+	PatientID string        `json:"patient_id"`
+	Code      string        `json:"code"`
+	Display   string        `json:"display,omitempty"`
+	Unit      string        `json:"unit,omitempty"`
+	Points    []SeriesPoint `json:"points"`
+}
+
+// GetObservationSeries returns the numeric values for an observation code,
+// oldest first. Values recorded in more than one unit are an error. The series is returned both as MCP structuredContent and as
+// JSON text for clients that only read text content.
+func (h *Handler) GetObservationSeries(patientID, code string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("observation code is required")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	values := observationTrend(observations, code)
+	// A chart of values in different units would be silently wrong
+	units := observationUnits(values)
+	if len(units) > 1 {
+		return nil, fmt.Errorf("observation %s has values in mixed units (%s); convert them to one unit to chart them", code, strings.Join(units, ", "))
+	}
+
+	series := ObservationSeries{
+		PatientID: patientID,
+		Code:      code,
+		Points:    []SeriesPoint{},
+	}
+	if len(units) == 1 {
+		series.Unit = units[0]
+	}
+	for _, o := range values {
+		series.Display = o.Display
+		series.Points = append(series.Points, SeriesPoint{DateTime: observationDate(o), Value: *o.ValueQuantity})
+	}
+
+	encoded, err := json.Marshal(series)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode observation series: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": string(encoded),
+			},
+		},
+		"structuredContent": series,
+	}, nil
+}
+
+// observationUnits returns the distinct units of values in order of first
+// appearance, treating spellings that normalizeUnit maps together as one
+func observationUnits(values []database.Observation) []string {
+	var units []string
+	seen := make(map[string]bool)
+	for _, o := range values {
+		unit := derefString(o.ValueUnit)
+		if key := normalizeUnit(unit); !seen[key] {
+			seen[key] = true
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+// trendStableThreshold is the relative change between the first and last
+// value below which a trend is reported as stable
+const trendStableThreshold = 0.05
+
+// describeTrend summarizes numeric observations of one measurement, oldest
+// first, as rising, falling or stable. Values in more than one unit are
+// flagged instead, as comparing them would be misleading.
+func describeTrend(values []database.Observation) string {
+	if len(values) < 2 {
+		return fmt.Sprintf("not enough numeric values for a trend (found %d, need at least 2)", len(values))
+	}
+
+	units := observationUnits(values)
+	if len(units) > 1 {
+		return fmt.Sprintf("not computed, values are in mixed units (%s); convert them to one unit to compare", strings.Join(units, ", "))
+	}
+
+	first, last := values[0], values[len(values)-1]
+	from, to := *first.ValueQuantity, *last.ValueQuantity
+	direction := "stable"
+	change := ""
+	if from != 0 {
+		relative := (to - from) / from
+		change = fmt.Sprintf(" (%+.1f%%)", relative*100)
+		if relative >= trendStableThreshold {
+			direction = "rising"
+		} else if relative <= -trendStableThreshold {
+			direction = "falling"
+		}
+	} else if to > 0 {
+		direction = "rising"
+	} else if to < 0 {
+		direction = "falling"
+	}
+
+	unit := ""
+	if units[0] != "" {
+		unit = " " + units[0]
+	}
+	return fmt.Sprintf("%s, from %s to %s%s%s over %d values (%s to %s)", direction,
+		FormatValue(from, first.Code, units[0]), FormatValue(to, last.Code, units[0]), unit, change,
+		len(values), observationDay(first), observationDay(last))
+}
+
+// GetObservationTrend lists the history of a measurement, matched by code or
+// by a substring of its name, oldest first, with a rising/falling/stable
+// summary of the numeric values. A name such as "blood pressure" can match
+// several measurements (systolic and diastolic); each is listed separately.
+func (h *Handler) GetObservationTrend(patientID, codeOrDisplay string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+	if strings.TrimSpace(codeOrDisplay) == "" {
+		return nil, fmt.Errorf("observation code or name is required")
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	// Group by code, keeping the order in which measurements first appear
+	var codes []string
+	byCode := make(map[string][]database.Observation)
+	for _, o := range matchingObservations(observations, codeOrDisplay) {
+		key := o.Code
+		if key == "" {
+			key = strings.ToLower(o.Display)
+		}
+		if _, ok := byCode[key]; !ok {
+			codes = append(codes, key)
+		}
+		byCode[key] = append(byCode[key], o)
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Observation history for '%s' for %s (ID: %s)\n", strings.TrimSpace(codeOrDisplay), patientName, patientID))
+	if len(codes) == 0 {
+		result.WriteString("\nNo matching observations found.\n")
+	}
+
+	for _, key := range codes {
+		group := byCode[key]
+		latest := group[len(group)-1]
+		result.WriteString(fmt.Sprintf("\n%s", latest.Display))
+		if latest.Code != "" {
+			result.WriteString(fmt.Sprintf(" (%s)", latest.Code))
+		}
+		result.WriteString(":\n")
+
+		var numeric []database.Observation
+		for _, o := range group {
+			if o.ValueQuantity != nil {
+				numeric = append(numeric, o)
+				result.WriteString("• " + formatTrendValue(o) + "\n")
+			} else if value := derefString(o.ValueString); value != "" {
+				result.WriteString(fmt.Sprintf("• %s: %s\n", observationDay(o), value))
+			}
+		}
+		result.WriteString("Trend: " + describeTrend(numeric) + "\n")
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
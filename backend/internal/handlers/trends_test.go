@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// fakeModel starts a server that answers chat completion requests with reply
+// and records the last user prompt it received.
+func fakeModel(t *testing.T, h *Handler, reply string) *string {
+	t.Helper()
+	var lastPrompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode model request: %v", err)
+		}
+		for _, m := range req.Messages {
+			if m.Role == "user" {
+				lastPrompt = m.Content
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]interface{}{"role": "assistant", "content": reply}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+	return &lastPrompt
+}
+
+func TestExplainObservationTrend(t *testing.T) {
+	h := newTestHandler(t)
+	prompt := fakeModel(t, h, "Kidney function is declining steadily, which warrants review.")
+
+	seedPatient(t, h, "p1", "Tom", "Berg", "male", "1950-02-02")
+	seedObservation(t, h, "p1", "33914-3", "Glomerular filtration rate/1.73 sq M.predicted", 52, "mL/min/{1.73_m2}", "2024-09-01T10:00:00Z")
+	seedObservation(t, h, "p1", "33914-3", "Glomerular filtration rate/1.73 sq M.predicted", 68, "mL/min/{1.73_m2}", "2023-01-01T10:00:00Z")
+	seedObservation(t, h, "p1", "33914-3", "Glomerular filtration rate/1.73 sq M.predicted", 60, "mL/min/{1.73_m2}", "2023-10-01T10:00:00Z")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 70, "/min", "2024-09-01T10:00:00Z")
+
+	result, err := h.ExplainObservationTrend(context.Background(), "p1", "33914-3")
+	text := resultText(t, h, result, err)
+
+	if !strings.Contains(text, "declining steadily") {
+		t.Errorf("Expected model explanation in result, got:\n%s", text)
+	}
+
+	// The model should receive every value, oldest first, and nothing else
+	first := strings.Index(*prompt, "2023-01-01: 68")
+	second := strings.Index(*prompt, "2023-10-01: 60")
+	third := strings.Index(*prompt, "2024-09-01: 52")
+	if first < 0 || second < first || third < second {
+		t.Errorf("Expected values in chronological order in prompt, got:\n%s", *prompt)
+	}
+	if strings.Contains(*prompt, "Heart rate") {
+		t.Errorf("Prompt should only include the requested observation, got:\n%s", *prompt)
+	}
+}
+
+func TestExplainObservationTrendNeedsTwoValues(t *testing.T) {
+	h := newTestHandler(t)
+	prompt := fakeModel(t, h, "unused")
+
+	seedPatient(t, h, "p1", "Tom", "Berg", "male", "1950-02-02")
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 7.1, "%", "2024-09-01T10:00:00Z")
+
+	result, err := h.ExplainObservationTrend(context.Background(), "p1", "hemoglobin a1c")
+	text := resultText(t, h, result, err)
+
+	if !strings.Contains(text, "Not enough numeric results") {
+		t.Errorf("Expected not-enough-data message, got:\n%s", text)
+	}
+	if *prompt != "" {
+		t.Errorf("Model should not be called with a single value")
+	}
+}
+
+func TestGetObservationSeries(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Tom", "Berg", "male", "1950-02-02")
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 7.4, "%", "2024-06-01T10:00:00Z")
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 8.1, "%", "2023-06-01T10:00:00Z")
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 6.9, "%", "2025-01-15T10:00:00Z")
+
+	result, err := h.GetObservationSeries("p1", "4548-4")
+	text := resultText(t, h, result, err)
+
+	var decoded struct {
+		Code   string                   `json:"code"`
+		Unit   string                   `json:"unit"`
+		Points []map[string]interface{} `json:"points"`
+	}
+	if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+		t.Fatalf("Expected JSON text content, got %q: %v", text, err)
+	}
+	if decoded.Code != "4548-4" || decoded.Unit != "%" {
+		t.Errorf("Unexpected series metadata: %+v", decoded)
+	}
+	if len(decoded.Points) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(decoded.Points))
+	}
+
+	wantValues := []float64{8.1, 7.4, 6.9}
+	prev := ""
+	for i, p := range decoded.Points {
+		if len(p) != 2 {
+			t.Errorf("Point %d should only have datetime and value, got %v", i, p)
+		}
+		dt, _ := p["datetime"].(string)
+		if dt <= prev {
+			t.Errorf("Expected ascending datetimes, got %q after %q", dt, prev)
+		}
+		prev = dt
+		if v, _ := p["value"].(float64); v != wantValues[i] {
+			t.Errorf("Point %d value = %v, want %v", i, p["value"], wantValues[i])
+		}
+	}
+
+	resultMap := result.(map[string]interface{})
+	if _, ok := resultMap["structuredContent"].(ObservationSeries); !ok {
+		t.Errorf("Expected structuredContent with the series, got %T", resultMap["structuredContent"])
+	}
+}
+
+func TestGetObservationSeriesMixedUnits(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Tom", "Berg", "male", "1950-02-02")
+	seedObservation(t, h, "p1", "2339-0", "Glucose [Mass/volume] in Blood", 110, "mg/dL", "2024-06-01T10:00:00Z")
+	seedObservation(t, h, "p1", "2339-0", "Glucose [Mass/volume] in Blood", 6.1, "mmol/L", "2025-01-15T10:00:00Z")
+
+	_, err := h.GetObservationSeries("p1", "2339-0")
+	if err == nil || !strings.Contains(err.Error(), "mixed units (mg/dL, mmol/L)") {
+		t.Errorf("Expected a mixed units error, got %v", err)
+	}
+
+	// Spellings of the same unit are not mixed
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 7.4, "%", "2024-06-01T10:00:00Z")
+	seedObservation(t, h, "p1", "4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood", 6.9, " % ", "2025-01-15T10:00:00Z")
+	if _, err := h.GetObservationSeries("p1", "4548-4"); err != nil {
+		t.Errorf("GetObservationSeries failed: %v", err)
+	}
+}
+
+func TestGetObservationTrend(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1960-05-05")
+	seedObservation(t, h, "p1", "8480-6", "Systolic blood pressure", 135, "mm[Hg]", "2024-03-01T09:00:00Z")
+	seedObservation(t, h, "p1", "8480-6", "Systolic blood pressure", 120, "mm[Hg]", "2024-01-01T09:00:00Z")
+	seedObservation(t, h, "p1", "8462-4", "Diastolic blood pressure", 80, "mm[Hg]", "2024-01-01T09:00:00Z")
+	seedObservation(t, h, "p1", "8462-4", "Diastolic blood pressure", 81, "mm[Hg]", "2024-03-01T09:00:00Z")
+	seedObservation(t, h, "p1", "29463-7", "Body weight", 80, "kg", "2024-01-01T09:00:00Z")
+	seedObservation(t, h, "p1", "29463-7", "Body weight", 170, "[lb_av]", "2024-02-01T09:00:00Z")
+
+	result, err := h.GetObservationTrend("p1", "blood pressure")
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"Systolic blood pressure (8480-6):\n• 2024-01-01: 120 mm[Hg]\n• 2024-03-01: 135 mm[Hg]\nTrend: rising, from 120 to 135 mm[Hg] (+12.5%) over 2 values",
+		"Diastolic blood pressure (8462-4):\n• 2024-01-01: 80 mm[Hg]\n• 2024-03-01: 81 mm[Hg]\nTrend: stable",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q, got:\n%s", want, text)
+		}
+	}
+
+	result, err = h.GetObservationTrend("p1", "29463-7")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "Trend: not computed, values are in mixed units (kg, [lb_av])") {
+		t.Errorf("Expected mixed units to be flagged, got:\n%s", text)
+	}
+
+	result, err = h.GetObservationTrend("p1", "cholesterol")
+	text = resultText(t, h, result, err)
+	if !strings.Contains(text, "No matching observations found") {
+		t.Errorf("Expected no matches, got:\n%s", text)
+	}
+
+	if _, err := h.GetObservationTrend("p1", " "); err == nil {
+		t.Error("Expected an error without a code")
+	}
+}
+
+func TestObservationBefore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2015-03-12T12:00:00Z", "2015-03-12T10:53:47-04:00", true},
+		{"2015-03-12T10:53:47-04:00", "2015-03-12T12:00:00Z", false},
+		{"2015-03-11", "2015-03-12T00:00:00Z", true},
+		{"", "2015-03-12T12:00:00Z", true},
+		{"2015-03-12T12:00:00Z", "2015-03-12T12:00:00Z", false},
+	}
+	for _, tt := range tests {
+		a := database.Observation{EffectiveDateTime: &tt.a}
+		b := database.Observation{EffectiveDateTime: &tt.b}
+		if got := observationBefore(a, b); got != tt.want {
+			t.Errorf("observationBefore(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
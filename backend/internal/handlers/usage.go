@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// tokenUsage is the "usage" object of an OpenRouter chat completion
+type tokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// modelPrice is a model's price in USD per million tokens
+type modelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// defaultModelPrices covers the models this server calls; MCP_MODEL_PRICES
+// adds to or overrides them
+var defaultModelPrices = map[string]modelPrice{
+	"google/gemini-2.5-flash":               {Prompt: 0.30, Completion: 2.50},
+	"meta-llama/llama-3.2-3b-instruct:free": {Prompt: 0, Completion: 0},
+}
+
+// parseModelPrices parses a comma-separated list of
+// "model=prompt:completion" entries (USD per million tokens), such as
+// "google/gemini-2.5-flash=0.30:2.50". Invalid entries are logged and skipped.
+func parseModelPrices(value string) map[string]modelPrice {
+	prices := make(map[string]modelPrice)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Model names may contain ':' (e.g. ":free"), so split on the last '='
+		eq := strings.LastIndex(entry, "=")
+		if eq <= 0 {
+			debug.Error("Invalid MCP_MODEL_PRICES entry: %q", entry)
+			continue
+		}
+		promptStr, completionStr, ok := strings.Cut(entry[eq+1:], ":")
+		prompt, err1 := strconv.ParseFloat(strings.TrimSpace(promptStr), 64)
+		completion, err2 := strconv.ParseFloat(strings.TrimSpace(completionStr), 64)
+		if !ok || err1 != nil || err2 != nil || prompt < 0 || completion < 0 {
+			debug.Error("Invalid MCP_MODEL_PRICES entry: %q", entry)
+			continue
+		}
+		prices[strings.TrimSpace(entry[:eq])] = modelPrice{Prompt: prompt, Completion: completion}
+	}
+	return prices
+}
+
+// modelUsage is the accumulated usage of one model
+type modelUsage struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// usageTracker accumulates token usage and estimated cost per model for the
+// lifetime of the handler
+type usageTracker struct {
+	mu      sync.Mutex
+	prices  map[string]modelPrice
+	byModel map[string]*modelUsage
+}
+
+func newUsageTracker(prices map[string]modelPrice) *usageTracker {
+	return &usageTracker{prices: prices, byModel: make(map[string]*modelUsage)}
+}
+
+// modelPricesFromEnv returns the default prices with MCP_MODEL_PRICES applied
+func modelPricesFromEnv() map[string]modelPrice {
+	prices := make(map[string]modelPrice, len(defaultModelPrices))
+	for model, price := range defaultModelPrices {
+		prices[model] = price
+	}
+	for model, price := range parseModelPrices(os.Getenv("MCP_MODEL_PRICES")) {
+		prices[model] = price
+	}
+	return prices
+}
+
+// record adds one completion's usage for model
+func (t *usageTracker) record(model string, usage tokenUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.byModel[model]
+	if u == nil {
+		u = &modelUsage{}
+		t.byModel[model] = u
+	}
+	u.Calls++
+	u.PromptTokens += usage.PromptTokens
+	u.CompletionTokens += usage.CompletionTokens
+	if price, ok := t.prices[model]; ok {
+		u.Cost += (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6
+	}
+	debug.Verbose("Model %s used %d prompt + %d completion tokens", model, usage.PromptTokens, usage.CompletionTokens)
+}
+
+// report describes the accumulated usage, most expensive model first
+func (t *usageTracker) report() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.byModel) == 0 {
+		return "No model calls have been made in this session."
+	}
+
+	models := make([]string, 0, len(t.byModel))
+	calls := 0
+	total := 0.0
+	for model, u := range t.byModel {
+		models = append(models, model)
+		calls += u.Calls
+		total += u.Cost
+	}
+	sort.Slice(models, func(i, j int) bool {
+		a, b := t.byModel[models[i]], t.byModel[models[j]]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		return models[i] < models[j]
+	})
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Estimated model spend this session: $%.4f across %d call(s)\n\n", total, calls))
+	for _, model := range models {
+		u := t.byModel[model]
+		result.WriteString(fmt.Sprintf("• %s: %d call(s), %d prompt + %d completion tokens, ", model, u.Calls, u.PromptTokens, u.CompletionTokens))
+		if _, ok := t.prices[model]; ok {
+			result.WriteString(fmt.Sprintf("$%.4f\n", u.Cost))
+		} else {
+			result.WriteString("no price configured (set MCP_MODEL_PRICES)\n")
+		}
+	}
+	return result.String()
+}
+
+// recordUsage adds a completion's usage for the model named in reqBody
+func (h *Handler) recordUsage(reqBody map[string]interface{}, usage tokenUsage) {
+	model, _ := reqBody["model"].(string)
+	h.usage.record(model, usage)
+}
+
+func (h *Handler) GetCostReport() (interface{}, error) {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": h.usage.report(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseModelPrices(t *testing.T) {
+	prices := parseModelPrices("google/gemini-2.5-flash=0.30:2.50, meta-llama/llama-3.2-3b-instruct:free=0:0,broken,bad=1:x")
+	if len(prices) != 2 {
+		t.Fatalf("got %d prices, want 2: %v", len(prices), prices)
+	}
+	if p := prices["google/gemini-2.5-flash"]; p.Prompt != 0.30 || p.Completion != 2.50 {
+		t.Errorf("gemini price = %+v", p)
+	}
+	if _, ok := prices["meta-llama/llama-3.2-3b-instruct:free"]; !ok {
+		t.Error("model name containing ':' was not parsed")
+	}
+}
+
+func TestUsageTrackerCost(t *testing.T) {
+	tracker := newUsageTracker(map[string]modelPrice{"test/model": {Prompt: 0.30, Completion: 2.50}})
+	for i := 0; i < 3; i++ {
+		tracker.record("test/model", tokenUsage{PromptTokens: 20000, CompletionTokens: 4000})
+	}
+	tracker.record("other/model", tokenUsage{PromptTokens: 100, CompletionTokens: 10})
+
+	// 3 × (20000 × 0.30 + 4000 × 2.50) / 1e6 = $0.048
+	report := tracker.report()
+	for _, want := range []string{
+		"$0.0480 across 4 call(s)",
+		"• test/model: 3 call(s), 60000 prompt + 12000 completion tokens, $0.0480",
+		"• other/model: 1 call(s), 100 prompt + 10 completion tokens, no price configured",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("%q missing:\n%s", want, report)
+		}
+	}
+}
+
+func TestModelCallsAreAccounted(t *testing.T) {
+	t.Setenv("MCP_MODEL_PRICES", "google/gemini-2.5-flash=1:2")
+	h := newTestHandler(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]interface{}{"role": "assistant", "content": "ok"}},
+			},
+			"usage": map[string]interface{}{"prompt_tokens": 1000, "completion_tokens": 500},
+		})
+	}))
+	t.Cleanup(server.Close)
+	h.openRouterURL = server.URL
+
+	if _, err := h.callGuidelinesModel(context.Background(), "hello"); err != nil {
+		t.Fatalf("callGuidelinesModel failed: %v", err)
+	}
+	result, err := h.GetCostReport()
+	text := resultText(t, h, result, err)
+	// (1000 × 1 + 500 × 2) / 1e6 = $0.002
+	if !strings.Contains(text, "google/gemini-2.5-flash: 1 call(s), 1000 prompt + 500 completion tokens, $0.0020") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// vaccinationCardRow is one line of the vaccination record card. Dose is 0
+// for a vaccination that wasn't given (not-done, entered-in-error).
+type vaccinationCardRow struct {
+	Vaccine string
+	Dose    int
+	Date    string
+	Status  string
+}
+
+// vaccinationCardRows orders immunizations oldest first and numbers the doses
+// of each vaccine, as on a CDC vaccination record card. Only completed
+// immunizations count as doses; others are listed without a number.
+func vaccinationCardRows(immunizations []database.Immunization) []vaccinationCardRow {
+	sorted := make([]database.Immunization, len(immunizations))
+	copy(sorted, immunizations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OccurrenceDateTime < sorted[j].OccurrenceDateTime
+	})
+
+	doses := make(map[string]int)
+	rows := make([]vaccinationCardRow, 0, len(sorted))
+	for _, imm := range sorted {
+		vaccine := strings.TrimSpace(imm.VaccineDisplay)
+		if vaccine == "" {
+			vaccine = "Unknown vaccine"
+		}
+		dose := 0
+		if imm.Status == "completed" {
+			doses[strings.ToLower(vaccine)]++
+			dose = doses[strings.ToLower(vaccine)]
+		}
+
+		date := imm.OccurrenceDateTime
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		if date == "" {
+			date = "Unknown"
+		}
+
+		rows = append(rows, vaccinationCardRow{
+			Vaccine: vaccine,
+			Dose:    dose,
+			Date:    date,
+			Status:  imm.Status,
+		})
+	}
+	return rows
+}
+
+func (h *Handler) GetVaccinationCard(patientID string) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	patient, err := database.GetPatientByID(h.db, patientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("patient not found: %s", patientID)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	immunizations, err := database.GetImmunizationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get immunizations: %w", err)
+	}
+
+	var card strings.Builder
+	card.WriteString("Vaccination Record Card\n")
+	card.WriteString("=======================\n")
+	card.WriteString(fmt.Sprintf("Name: %s %s\n", patient.GivenName, patient.FamilyName))
+	if patient.BirthDate != "" {
+		card.WriteString(fmt.Sprintf("Date of birth: %s\n", patient.BirthDate))
+	}
+	card.WriteString(fmt.Sprintf("Patient ID: %s\n\n", patientID))
+
+	rows := vaccinationCardRows(immunizations)
+	if len(rows) == 0 {
+		card.WriteString("No immunizations on record.")
+	} else {
+		width := len("Vaccine")
+		for _, r := range rows {
+			if len(r.Vaccine) > width {
+				width = len(r.Vaccine)
+			}
+		}
+		card.WriteString(fmt.Sprintf("%-*s  %-4s  %-10s  %s\n", width, "Vaccine", "Dose", "Date", "Status"))
+		card.WriteString(strings.Repeat("-", width+2+4+2+10+2+len("Status")) + "\n")
+		for _, r := range rows {
+			dose := ""
+			if r.Dose > 0 {
+				dose = strconv.Itoa(r.Dose)
+			}
+			card.WriteString(fmt.Sprintf("%-*s  %-4s  %-10s  %s\n", width, r.Vaccine, dose, r.Date, r.Status))
+		}
+		card.WriteString(fmt.Sprintf("\nTotal: %d immunization(s)", len(rows)))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": card.String(),
+			},
+		},
+	}, nil
+}
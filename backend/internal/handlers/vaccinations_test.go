@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+func TestGetVaccinationCard(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Lena", "Holm", "female", "1990-04-12")
+	seedImmunization(t, h, "p1", "Influenza, seasonal", "completed", "2023-10-05T09:00:00Z")
+	seedImmunization(t, h, "p1", "Td (adult)", "completed", "2015-03-01T09:00:00Z")
+	seedImmunization(t, h, "p1", "Influenza, seasonal", "completed", "2021-10-10T09:00:00Z")
+	seedImmunization(t, h, "p1", "Hep B, adult", "not-done", "2019-06-20T09:00:00Z")
+
+	result, err := h.GetVaccinationCard("p1")
+	text := resultText(t, h, result, err)
+
+	// Rows should appear oldest first, with doses numbered per vaccine
+	expected := []string{
+		"2015-03-01",
+		"2019-06-20",
+		"2021-10-10",
+		"2023-10-05",
+	}
+	last := -1
+	for _, date := range expected {
+		idx := strings.Index(text, date)
+		if idx < 0 {
+			t.Fatalf("Expected immunization dated %s on card, got:\n%s", date, text)
+		}
+		if idx < last {
+			t.Errorf("Expected %s to appear after the previous entry, got:\n%s", date, text)
+		}
+		last = idx
+	}
+
+	if !strings.Contains(text, "Total: 4 immunization(s)") {
+		t.Errorf("Expected all 4 immunizations counted, got:\n%s", text)
+	}
+}
+
+func TestVaccinationCardRowsNumbersDoses(t *testing.T) {
+	rows := vaccinationCardRows([]database.Immunization{
+		{VaccineDisplay: "Influenza, seasonal", Status: "completed", OccurrenceDateTime: "2023-10-05T09:00:00Z"},
+		{VaccineDisplay: "Td (adult)", Status: "completed", OccurrenceDateTime: "2015-03-01"},
+		{VaccineDisplay: "Influenza, seasonal", Status: "completed", OccurrenceDateTime: "2021-10-10T09:00:00Z"},
+	})
+
+	want := []vaccinationCardRow{
+		{Vaccine: "Td (adult)", Dose: 1, Date: "2015-03-01", Status: "completed"},
+		{Vaccine: "Influenza, seasonal", Dose: 1, Date: "2021-10-10", Status: "completed"},
+		{Vaccine: "Influenza, seasonal", Dose: 2, Date: "2023-10-05", Status: "completed"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(rows))
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("Row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestVaccinationCardRowsSkipsDosesNotGiven(t *testing.T) {
+	rows := vaccinationCardRows([]database.Immunization{
+		{VaccineDisplay: "Hep B, adult", Status: "completed", OccurrenceDateTime: "2019-01-10"},
+		{VaccineDisplay: "Hep B, adult", Status: "not-done", OccurrenceDateTime: "2019-02-10"},
+		{VaccineDisplay: "Hep B, adult", Status: "completed", OccurrenceDateTime: "2019-03-10"},
+		{VaccineDisplay: "Hep B, adult", Status: "entered-in-error", OccurrenceDateTime: "2019-04-10"},
+	})
+
+	want := []int{1, 0, 2, 0}
+	if len(rows) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(rows))
+	}
+	for i, dose := range want {
+		if rows[i].Dose != dose {
+			t.Errorf("Row %d (%s) has dose %d, want %d", i, rows[i].Status, rows[i].Dose, dose)
+		}
+	}
+}
+
+func seedImmunization(t *testing.T, h *Handler, patientID, vaccine, status, occurrenceDateTime string) {
+	t.Helper()
+	_, err := h.db.Exec(`INSERT INTO immunizations (id, status, vaccine_display, patient_id, occurrence_datetime)
+		VALUES (lower(hex(randomblob(16))), ?, ?, ?, ?)`,
+		status, vaccine, patientID, occurrenceDateTime)
+	if err != nil {
+		t.Fatalf("Failed to seed immunization: %v", err)
+	}
+}
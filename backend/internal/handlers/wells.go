@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// wellsHeartRateMaxAge is how recent a heart rate must be to count as the
+// current presentation for the Wells PE score
+const wellsHeartRateMaxAge = 24 * time.Hour
+
+// Condition terms the Wells criteria look for in the record
+var (
+	wellsCancerTerms     = []string{"cancer", "carcinoma", "malignan", "neoplasm", "lymphoma", "leukemia", "leukaemia", "melanoma", "sarcoma", "myeloma"}
+	wellsDVTTerms        = []string{"deep venous thrombosis", "deep vein thrombosis", "dvt"}
+	wellsPETerms         = []string{"pulmonary embolism", "pulmonary thromboembolism"}
+	wellsHemoptysisTerms = []string{"hemoptysis", "haemoptysis"}
+)
+
+// wellsRecord is the part of the patient's record the Wells criteria are
+// derived from
+type wellsRecord struct {
+	observations  []database.Observation
+	conditions    []database.Condition
+	procedures    []database.Procedure
+	surgicalCodes []string
+	surgicalTerms []string
+	now           time.Time
+}
+
+// condition returns the first condition matching terms, active ones only
+// unless includeResolved is set
+func (r wellsRecord) condition(terms []string, includeResolved bool) (string, bool) {
+	for _, c := range r.conditions {
+		active := c.ClinicalStatus == "active" || c.ClinicalStatus == ""
+		if (active || includeResolved) && containsAny(c.Display, terms) {
+			return c.Display, true
+		}
+	}
+	return "", false
+}
+
+// surgerySince returns a surgical procedure performed within the last weeks
+func (r wellsRecord) surgerySince(weeks int) (string, bool) {
+	since := r.now.AddDate(0, 0, -7*weeks)
+	for _, p := range r.procedures {
+		performed := derefString(p.PerformedDateTime)
+		if isSurgical(p, r.surgicalCodes, r.surgicalTerms) && changedSince(performed, since) {
+			return fmt.Sprintf("%s on %s", p.Display, recordDay(performed)), true
+		}
+	}
+	return "", false
+}
+
+// tachycardia returns a heart rate over 100/min recorded within
+// wellsHeartRateMaxAge
+func (r wellsRecord) tachycardia() (string, bool) {
+	hr := latestLab(r.observations, []string{"heart rate", "pulse"})
+	if hr == nil || *hr.ValueQuantity <= 100 {
+		return "", false
+	}
+	if !changedSince(observationDate(*hr), r.now.Add(-wellsHeartRateMaxAge)) {
+		return "", false
+	}
+	return fmt.Sprintf("heart rate %s", formatTrendValue(*hr)), true
+}
+
+// wellsCriterion is one item of a Wells score. FromData finds it in the
+// record, returning the evidence; it is nil for items only a clinician can
+// assess.
+type wellsCriterion struct {
+	Key      string
+	Label    string
+	Points   float64
+	FromData func(r wellsRecord) (string, bool)
+}
+
+// wellsScore describes the PE or DVT variant of the Wells score
+type wellsScore struct {
+	Name     string
+	Criteria []wellsCriterion
+	// Risk returns the three-tier risk category and the two-tier
+	// likely/unlikely classification for a score
+	Risk func(score float64) (string, string)
+}
+
+var wellsScores = map[string]wellsScore{
+	"PE": {
+		Name: "Wells Score for Pulmonary Embolism",
+		Criteria: []wellsCriterion{
+			{"dvt_signs", "Clinical signs and symptoms of DVT", 3, nil},
+			{"pe_most_likely", "PE is the most likely diagnosis", 3, nil},
+			{"heart_rate_over_100", "Heart rate > 100/min", 1.5, wellsRecord.tachycardia},
+			{"immobilization_or_surgery", "Immobilization ≥ 3 days or surgery in the past 4 weeks", 1.5, func(r wellsRecord) (string, bool) {
+				return r.surgerySince(4)
+			}},
+			{"previous_dvt_pe", "Previous DVT or PE", 1.5, func(r wellsRecord) (string, bool) {
+				return r.condition(append(append([]string{}, wellsDVTTerms...), wellsPETerms...), true)
+			}},
+			{"hemoptysis", "Hemoptysis", 1, func(r wellsRecord) (string, bool) {
+				return r.condition(wellsHemoptysisTerms, false)
+			}},
+			{"malignancy", "Malignancy (treated within 6 months or palliative)", 1, func(r wellsRecord) (string, bool) {
+				return r.condition(wellsCancerTerms, false)
+			}},
+		},
+		Risk: func(score float64) (string, string) {
+			tier := "Low risk"
+			if score > 6 {
+				tier = "High risk"
+			} else if score >= 2 {
+				tier = "Moderate risk"
+			}
+			if score > 4 {
+				return tier, "PE likely"
+			}
+			return tier, "PE unlikely"
+		},
+	},
+	"DVT": {
+		Name: "Wells Score for Deep Vein Thrombosis",
+		Criteria: []wellsCriterion{
+			{"active_cancer", "Active cancer (treated within 6 months or palliative)", 1, func(r wellsRecord) (string, bool) {
+				return r.condition(wellsCancerTerms, false)
+			}},
+			{"bedridden_or_surgery", "Bedridden ≥ 3 days or major surgery in the past 12 weeks", 1, func(r wellsRecord) (string, bool) {
+				return r.surgerySince(12)
+			}},
+			{"calf_swelling", "Calf swelling > 3 cm compared to the other leg", 1, nil},
+			{"collateral_veins", "Collateral (non-varicose) superficial veins", 1, nil},
+			{"entire_leg_swollen", "Entire leg swollen", 1, nil},
+			{"localized_tenderness", "Localized tenderness along the deep venous system", 1, nil},
+			{"pitting_edema", "Pitting edema confined to the symptomatic leg", 1, nil},
+			{"paralysis_or_cast", "Paralysis, paresis or recent plaster immobilization of the leg", 1, nil},
+			{"previous_dvt", "Previously documented DVT", 1, func(r wellsRecord) (string, bool) {
+				return r.condition(wellsDVTTerms, true)
+			}},
+			{"alternative_diagnosis_likely", "Alternative diagnosis at least as likely as DVT", -2, nil},
+		},
+		Risk: func(score float64) (string, string) {
+			tier := "Low risk"
+			if score >= 3 {
+				tier = "High risk"
+			} else if score >= 1 {
+				tier = "Moderate risk"
+			}
+			if score >= 2 {
+				return tier, "DVT likely"
+			}
+			return tier, "DVT unlikely"
+		},
+	},
+}
+
+// wellsCriterionKeys lists the criteria keys of a score, for error messages
+func wellsCriterionKeys(s wellsScore) string {
+	keys := make([]string, len(s.Criteria))
+	for i, c := range s.Criteria {
+		keys[i] = c.Key
+	}
+	return strings.Join(keys, ", ")
+}
+
+// formatWellsPoints formats points without trailing zeros ("1.5", "3", "-2")
+func formatWellsPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+// CalculateWellsScore calculates the Wells score for pulmonary embolism
+// (scoreType "PE") or deep vein thrombosis ("DVT"). Criteria are derived from
+// the record where possible (recent heart rate, surgery, cancer, previous
+// DVT/PE, hemoptysis); criteria maps criterion keys to clinician-entered
+// values, which take precedence. The result marks where each item came from,
+// and items that are neither in the record nor entered count as absent.
+func (h *Handler) CalculateWellsScore(patientID, scoreType string, criteria map[string]bool) (interface{}, error) {
+	// Use context if patient ID not provided
+	patientID = h.GetContextPatientID(patientID)
+
+	if patientID == "" {
+		return nil, fmt.Errorf("patient ID is required (no patient ID provided and none set in context)")
+	}
+
+	scoreType = strings.ToUpper(strings.TrimSpace(scoreType))
+	score, ok := wellsScores[scoreType]
+	if !ok {
+		return nil, fmt.Errorf("invalid score type: %q (use PE or DVT)", scoreType)
+	}
+	for key := range criteria {
+		known := false
+		for _, c := range score.Criteria {
+			known = known || c.Key == key
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown Wells %s criterion: %s (use %s)", scoreType, key, wellsCriterionKeys(score))
+		}
+	}
+
+	patientExists, err := database.CheckPatientExists(h.db, patientID)
+	if err != nil || !patientExists {
+		return nil, fmt.Errorf("patient not found: %s", patientID)
+	}
+
+	observations, err := database.GetObservationsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+	conditions, err := database.GetConditionsByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditions: %w", err)
+	}
+	procedures, err := database.GetProceduresByPatientID(h.db, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get procedures: %w", err)
+	}
+	// Most recent surgery first
+	sort.SliceStable(procedures, func(i, j int) bool {
+		return derefString(procedures[i].PerformedDateTime) > derefString(procedures[j].PerformedDateTime)
+	})
+
+	record := wellsRecord{
+		observations:  observations,
+		conditions:    conditions,
+		procedures:    procedures,
+		surgicalCodes: h.surgicalCodes,
+		surgicalTerms: h.surgicalTerms,
+		now:           time.Now(),
+	}
+	if len(record.surgicalCodes) == 0 {
+		record.surgicalCodes = defaultSurgicalCodes
+	}
+	if len(record.surgicalTerms) == 0 {
+		record.surgicalTerms = defaultSurgicalTerms
+	}
+
+	patientName, _ := database.GetPatientName(h.db, patientID)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s for %s (ID: %s)\n\n", score.Name, patientName, patientID))
+
+	total := 0.0
+	var unassessed []string
+	for _, c := range score.Criteria {
+		met, entered := criteria[c.Key]
+		source := "clinician input"
+		if !entered {
+			source = "not assessed"
+			if c.FromData != nil {
+				var evidence string
+				if evidence, met = c.FromData(record); met {
+					source = "from data: " + evidence
+				} else {
+					source = "not found in record"
+				}
+			}
+			if !met {
+				unassessed = append(unassessed, c.Key)
+			}
+		}
+
+		mark, points := "[ ]", "0"
+		if met {
+			total += c.Points
+			mark, points = "[x]", formatWellsPoints(c.Points)
+			if c.Points > 0 {
+				points = "+" + points
+			}
+		}
+		result.WriteString(fmt.Sprintf("%s %s: %s (%s)\n", mark, c.Label, points, source))
+	}
+
+	tier, likelihood := score.Risk(total)
+	result.WriteString(fmt.Sprintf("\nScore: %s\nRisk: %s (%s)\n", formatWellsPoints(total), tier, likelihood))
+	if len(unassessed) > 0 {
+		result.WriteString(fmt.Sprintf("\nNote: %d item(s) were counted as absent because they are not in the record and were not entered: %s. Pass them as criteria to include them.\n",
+			len(unassessed), strings.Join(unassessed, ", ")))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eythor/mcp-server/internal/database"
+)
+
+// wellsCriterionByKey returns the criterion with key from the PE or DVT score
+func wellsCriterionByKey(t *testing.T, scoreType, key string) wellsCriterion {
+	t.Helper()
+	for _, c := range wellsScores[scoreType].Criteria {
+		if c.Key == key {
+			return c
+		}
+	}
+	t.Fatalf("no Wells %s criterion %s", scoreType, key)
+	return wellsCriterion{}
+}
+
+func TestWellsCriteriaFromRecord(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *string {
+		s := now.Add(-d).Format(time.RFC3339)
+		return &s
+	}
+	heartRate := func(value float64, ago time.Duration) database.Observation {
+		unit := "/min"
+		return database.Observation{Code: "8867-4", Display: "Heart rate", EffectiveDateTime: at(ago), ValueQuantity: &value, ValueUnit: &unit}
+	}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		scoreType string
+		key       string
+		record    wellsRecord
+		want      string // part of the evidence; empty when the criterion isn't met
+	}{
+		{"recent tachycardia", "PE", "heart_rate_over_100", wellsRecord{
+			observations: []database.Observation{heartRate(112, 2*time.Hour), heartRate(90, 30*day)},
+		}, "112 /min"},
+		{"tachycardia older than a day", "PE", "heart_rate_over_100", wellsRecord{
+			observations: []database.Observation{heartRate(130, 30*day)},
+		}, ""},
+		{"latest heart rate normal", "PE", "heart_rate_over_100", wellsRecord{
+			observations: []database.Observation{heartRate(88, time.Hour), heartRate(130, 2*time.Hour)},
+		}, ""},
+		{"resolved PE counts as previous", "PE", "previous_dvt_pe", wellsRecord{
+			conditions: []database.Condition{{Display: "Pulmonary embolism", ClinicalStatus: "resolved"}},
+		}, "Pulmonary embolism"},
+		{"active cancer", "PE", "malignancy", wellsRecord{
+			conditions: []database.Condition{{Display: "Non-small cell lung cancer", ClinicalStatus: "active"}},
+		}, "Non-small cell lung cancer"},
+		{"resolved cancer doesn't count", "DVT", "active_cancer", wellsRecord{
+			conditions: []database.Condition{{Display: "Non-small cell lung cancer", ClinicalStatus: "resolved"}},
+		}, ""},
+		{"surgery within 12 weeks", "DVT", "bedridden_or_surgery", wellsRecord{
+			procedures:    []database.Procedure{{Display: "Total replacement of hip", PerformedDateTime: at(20 * day)}},
+			surgicalTerms: defaultSurgicalTerms,
+		}, "Total replacement of hip on "},
+		{"surgery older than 4 weeks for PE", "PE", "immobilization_or_surgery", wellsRecord{
+			procedures:    []database.Procedure{{Display: "Total replacement of hip", PerformedDateTime: at(40 * day)}},
+			surgicalTerms: defaultSurgicalTerms,
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.record.now = now
+			evidence, met := wellsCriterionByKey(t, tt.scoreType, tt.key).FromData(tt.record)
+			if met != (tt.want != "") || !strings.Contains(evidence, tt.want) {
+				t.Errorf("FromData() = %q, %v; want %q", evidence, met, tt.want)
+			}
+		})
+	}
+}
+
+func TestWellsRisk(t *testing.T) {
+	tests := []struct {
+		scoreType  string
+		score      float64
+		tier       string
+		likelihood string
+	}{
+		{"PE", 1.5, "Low risk", "PE unlikely"},
+		{"PE", 2, "Moderate risk", "PE unlikely"},
+		{"PE", 4.5, "Moderate risk", "PE likely"},
+		{"PE", 7, "High risk", "PE likely"},
+		{"DVT", 0, "Low risk", "DVT unlikely"},
+		{"DVT", 1, "Moderate risk", "DVT unlikely"},
+		{"DVT", 2, "Moderate risk", "DVT likely"},
+		{"DVT", 3, "High risk", "DVT likely"},
+	}
+	for _, tt := range tests {
+		tier, likelihood := wellsScores[tt.scoreType].Risk(tt.score)
+		if tier != tt.tier || likelihood != tt.likelihood {
+			t.Errorf("%s risk for %v = %s (%s), want %s (%s)", tt.scoreType, tt.score, tier, likelihood, tt.tier, tt.likelihood)
+		}
+	}
+}
+
+func TestCalculateWellsScore(t *testing.T) {
+	h := newTestHandler(t)
+	seedPatient(t, h, "p1", "Ola", "Nordmann", "male", "1960-05-05")
+	seedObservation(t, h, "p1", "8867-4", "Heart rate", 112, "/min", time.Now().UTC().Add(-2*time.Hour).Format(time.RFC3339))
+	seedCondition(t, h, "p1", "254637007", "Non-small cell lung cancer", "active", "2024-01-10T00:00:00Z")
+
+	// A clinician's answer wins over the record; items neither entered nor
+	// found count as absent
+	result, err := h.CalculateWellsScore("p1", "pe", map[string]bool{"pe_most_likely": true, "malignancy": false})
+	text := resultText(t, h, result, err)
+	for _, want := range []string{
+		"[x] Heart rate > 100/min: +1.5 (from data: heart rate ",
+		"[x] PE is the most likely diagnosis: +3 (clinician input)",
+		"[ ] Malignancy (treated within 6 months or palliative): 0 (clinician input)",
+		"[ ] Hemoptysis: 0 (not found in record)",
+		"Score: 4.5\nRisk: Moderate risk (PE likely)",
+		"4 item(s) were counted as absent",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q, got:\n%s", want, text)
+		}
+	}
+
+	if _, err := h.CalculateWellsScore("p1", "DVT", map[string]bool{"hemoptysis": true}); err == nil || !strings.Contains(err.Error(), "unknown Wells DVT criterion") {
+		t.Errorf("Expected an error for a PE criterion on the DVT score, got %v", err)
+	}
+	if _, err := h.CalculateWellsScore("p1", "CHADS", nil); err == nil {
+		t.Error("Expected an error for an unknown score type")
+	}
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// ErrCodeInvalidRequest is the JSON-RPC error code for a request that is not
+// a valid request object
+const ErrCodeInvalidRequest = -32600
+
+// ErrCodeParseError is the JSON-RPC error code for a message that is not
+// valid JSON
+const ErrCodeParseError = -32700
+
+// HandlePayload handles either a single JSON-RPC request, like HandleMessage,
+// or a batch sent as a JSON array of requests. A batch is answered with an
+// array of responses in which notifications (requests without an ID and
+// "initialized") have no entry; nil is returned when there is nothing to send.
+// An empty batch is answered with a single Invalid Request error, as JSON-RPC
+// 2.0 requires.
+func (s *Server) HandlePayload(ctx context.Context, message []byte) (interface{}, error) {
+	trimmed := bytes.TrimLeft(message, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		response, err := s.HandleMessage(ctx, message)
+		if response == nil {
+			// Avoid returning a non-nil interface holding a nil pointer
+			return nil, err
+		}
+		return response, err
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(message, &batch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch: %w", err)
+	}
+	if len(batch) == 0 {
+		return invalidRequest("empty batch"), nil
+	}
+
+	responses := s.handleBatch(ctx, batch)
+	if responses == nil {
+		return nil, nil
+	}
+	return responses, nil
+}
+
+// handleBatch handles the requests of a non-empty batch, returning the
+// responses to those that are not notifications
+func (s *Server) handleBatch(ctx context.Context, batch []json.RawMessage) []*JSONRPCResponse {
+	debug.Log("MCP handling batch of %d request(s)", len(batch))
+
+	var responses []*JSONRPCResponse
+	for _, raw := range batch {
+		var request JSONRPCRequest
+		if err := json.Unmarshal(raw, &request); err != nil {
+			responses = append(responses, invalidRequest(err.Error()))
+			continue
+		}
+
+		response := s.handleRequest(ctx, request)
+		if response == nil || isNotification(request) {
+			continue
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// isNotification reports whether request expects no response
+func isNotification(request JSONRPCRequest) bool {
+	return request.ID == nil || request.Method == "initialized"
+}
+
+func invalidRequest(reason string) *JSONRPCResponse {
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		Error: &Error{
+			Code:    ErrCodeInvalidRequest,
+			Message: "Invalid Request: " + reason,
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errUnknownTool is returned for calls to a tool the server doesn't have.
+// Such calls are not counted, so clients can't grow the metrics without bound.
+var errUnknownTool = errors.New("unknown tool")
+
+// toolStats are the counters kept for one tool
+type toolStats struct {
+	calls    int64
+	duration time.Duration
+}
+
+// toolMetrics counts tool calls and their cumulative duration per tool name
+// for the lifetime of the server
+type toolMetrics struct {
+	mu     sync.Mutex
+	byTool map[string]*toolStats
+}
+
+func newToolMetrics() *toolMetrics {
+	return &toolMetrics{byTool: make(map[string]*toolStats)}
+}
+
+// record adds one call of tool that took d. A nil toolMetrics, as in a
+// zero Server, records nothing.
+func (m *toolMetrics) record(tool string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.byTool[tool]
+	if stats == nil {
+		stats = &toolStats{}
+		m.byTool[tool] = stats
+	}
+	stats.calls++
+	stats.duration += d
+}
+
+// snapshot returns a copy of the counters, so they can be written without
+// holding the lock
+func (m *toolMetrics) snapshot() map[string]toolStats {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]toolStats, len(m.byTool))
+	for tool, stats := range m.byTool {
+		snapshot[tool] = *stats
+	}
+	return snapshot
+}
+
+// labelEscaper escapes a Prometheus label value
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteMetrics writes the tool call counters in the Prometheus text
+// exposition format, one series per tool in name order
+func (s *Server) WriteMetrics(w io.Writer) error {
+	snapshot := s.metrics.snapshot()
+	tools := make([]string, 0, len(snapshot))
+	for tool := range snapshot {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	var b strings.Builder
+	b.WriteString("# HELP mcp_tool_calls_total Number of MCP tool calls by tool.\n")
+	b.WriteString("# TYPE mcp_tool_calls_total counter\n")
+	for _, tool := range tools {
+		fmt.Fprintf(&b, "mcp_tool_calls_total{tool=\"%s\"} %d\n", labelEscaper.Replace(tool), snapshot[tool].calls)
+	}
+	b.WriteString("# HELP mcp_tool_call_duration_seconds_total Time spent in MCP tool calls by tool.\n")
+	b.WriteString("# TYPE mcp_tool_call_duration_seconds_total counter\n")
+	for _, tool := range tools {
+		fmt.Fprintf(&b, "mcp_tool_call_duration_seconds_total{tool=\"%s\"} %g\n", labelEscaper.Replace(tool), snapshot[tool].duration.Seconds())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
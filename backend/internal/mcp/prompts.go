@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// errInvalidPrompt is returned for prompts/get requests naming an unknown
+// prompt or with malformed params
+var errInvalidPrompt = errors.New("invalid prompt request")
+
+// clinicalPrompt is a reusable prompt template offered to clients, which
+// typically show prompts as slash commands. The message is built from the
+// patient summary.
+type clinicalPrompt struct {
+	Name        string
+	Description string
+	// Instructions precede the patient summary in the prompt message
+	Instructions string
+}
+
+var clinicalPrompts = []clinicalPrompt{
+	{
+		Name:        "summarize_patient",
+		Description: "Summarize a patient's record for a clinician",
+		Instructions: "Summarize this patient's record for a clinician in a few short paragraphs: " +
+			"who the patient is, their active problems and how they are treated, allergies, " +
+			"notable recent results and visits, and anything that needs attention.",
+	},
+	{
+		Name:        "medication_reconciliation",
+		Description: "Review a patient's current medications against their conditions and allergies",
+		Instructions: "Reconcile this patient's current medications. For each medication, say which active condition it treats. " +
+			"Flag medications without a matching condition, conditions that appear untreated, duplicate therapy, " +
+			"and medications that conflict with documented allergies. End with a list of questions to clarify with the patient.",
+	},
+	{
+		Name:        "discharge_summary",
+		Description: "Draft a discharge summary for a patient",
+		Instructions: "Draft a discharge summary for this patient with the sections: Diagnoses, Course, " +
+			"Medications at discharge, Allergies, Relevant results, and Follow-up. " +
+			"Only use information from the record below and mark anything that must be completed by the clinician as [to be completed].",
+	},
+}
+
+// patientIDArgument is the argument every clinical prompt takes
+var patientIDArgument = map[string]interface{}{
+	"name":        "patient_id",
+	"description": "Patient ID (optional if patient context is set)",
+	"required":    false,
+}
+
+func (s *Server) handlePromptsList() map[string]interface{} {
+	prompts := make([]map[string]interface{}, len(clinicalPrompts))
+	for i, p := range clinicalPrompts {
+		prompts[i] = map[string]interface{}{
+			"name":        p.Name,
+			"description": p.Description,
+			"arguments":   []map[string]interface{}{patientIDArgument},
+		}
+	}
+	return map[string]interface{}{
+		"prompts": prompts,
+	}
+}
+
+// handlePromptsGet fills in a clinical prompt with the summary of the
+// patient given as the patient_id argument, or the context patient
+func (s *Server) handlePromptsGet(ctx context.Context, params json.RawMessage) (map[string]interface{}, error) {
+	var getParams struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &getParams); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidPrompt, err)
+	}
+
+	var prompt *clinicalPrompt
+	for i := range clinicalPrompts {
+		if clinicalPrompts[i].Name == getParams.Name {
+			prompt = &clinicalPrompts[i]
+		}
+	}
+	if prompt == nil {
+		return nil, fmt.Errorf("%w: unknown prompt: %s", errInvalidPrompt, getParams.Name)
+	}
+
+	handler := s.handlerFor(ctx)
+	if handler == nil {
+		return nil, fmt.Errorf("no handler available")
+	}
+	summary, err := handler.GetPatientSummary(getParams.Arguments["patient_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"description": prompt.Description,
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": prompt.Instructions + "\n\n" + handler.ExtractTextFromMCPResult(summary),
+				},
+			},
+		},
+	}, nil
+}
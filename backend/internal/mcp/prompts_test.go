@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/eythor/mcp-server/internal/database"
+	"github.com/eythor/mcp-server/internal/handlers"
+)
+
+func TestHandlePromptsList(t *testing.T) {
+	server := &Server{}
+
+	response, err := server.HandleMessage(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "initialize", "id": 1}`))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	capabilities := response.Result.(map[string]interface{})["capabilities"].(map[string]interface{})
+	if _, ok := capabilities["prompts"]; !ok {
+		t.Errorf("Expected the prompts capability, got %v", capabilities)
+	}
+
+	response, err = server.HandleMessage(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "prompts/list", "id": 2}`))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	prompts := response.Result.(map[string]interface{})["prompts"].([]map[string]interface{})
+	var names []string
+	for _, p := range prompts {
+		names = append(names, p["name"].(string))
+		if args := p["arguments"].([]map[string]interface{}); len(args) != 1 || args[0]["name"] != "patient_id" {
+			t.Errorf("Expected prompt %s to take a patient_id, got %v", p["name"], args)
+		}
+	}
+	if got := strings.Join(names, ","); got != "summarize_patient,medication_reconciliation,discharge_summary" {
+		t.Errorf("Unexpected prompts: %s", got)
+	}
+}
+
+// newTestServer returns a server backed by an empty in-memory database
+func newTestServer(t *testing.T) (*Server, *sql.DB) {
+	t.Helper()
+
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open in-memory database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	schema, err := os.ReadFile("../../schema.sql")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("Failed to apply schema: %v", err)
+	}
+	return NewServer(handlers.NewHandler(db, "test-key")), db
+}
+
+func TestHandlePromptsGet(t *testing.T) {
+	server, db := newTestServer(t)
+	if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES ('p1', 'Ola', 'Nordmann', 'male', '1960-05-05')`); err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+
+	response, err := server.HandleMessage(context.Background(), []byte(
+		`{"jsonrpc": "2.0", "method": "prompts/get", "params": {"name": "discharge_summary", "arguments": {"patient_id": "p1"}}, "id": 1}`))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %+v", response.Error)
+	}
+	encoded, _ := json.Marshal(response.Result)
+	var result struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		t.Fatalf("Invalid result %s: %v", encoded, err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Role != "user" || result.Messages[0].Content.Type != "text" {
+		t.Fatalf("Expected one user text message, got %s", encoded)
+	}
+	text := result.Messages[0].Content.Text
+	if !strings.HasPrefix(text, "Draft a discharge summary") || !strings.Contains(text, "Ola Nordmann") {
+		t.Errorf("Expected the instructions followed by the patient summary, got:\n%s", text)
+	}
+
+	for _, params := range []string{
+		`{"name": "no_such_prompt", "arguments": {"patient_id": "p1"}}`,
+		`"summarize_patient"`,
+	} {
+		response, err := server.HandleMessage(context.Background(), []byte(
+			`{"jsonrpc": "2.0", "method": "prompts/get", "params": `+params+`, "id": 2}`))
+		if err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+		if response.Error == nil || response.Error.Code != ErrCodeInvalidParams {
+			t.Errorf("Expected an invalid params error for %s, got %+v", params, response.Error)
+		}
+	}
+
+	response, err = server.HandleMessage(context.Background(), []byte(
+		`{"jsonrpc": "2.0", "method": "prompts/get", "params": {"name": "summarize_patient", "arguments": {"patient_id": "missing"}}, "id": 3}`))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if response.Error == nil || !strings.Contains(response.Error.Message, "patient not found") {
+		t.Errorf("Expected a patient not found error, got %+v", response.Error)
+	}
+}
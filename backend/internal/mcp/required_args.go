@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eythor/mcp-server/internal/debug"
+)
+
+// errMissingArguments is returned in strict mode for tool calls that leave
+// out a required argument the context doesn't supply
+var errMissingArguments = errors.New("missing required arguments")
+
+// strictToolArgs reports whether MCP_STRICT_TOOL_ARGS is set. By default a
+// call missing a required argument still reaches the tool, which reports
+// what is missing itself.
+func strictToolArgs() bool {
+	value := strings.TrimSpace(os.Getenv("MCP_STRICT_TOOL_ARGS"))
+	if value == "" {
+		return false
+	}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		debug.Error("Invalid MCP_STRICT_TOOL_ARGS %q, ignoring", value)
+		return false
+	}
+	return strict
+}
+
+// checkRequiredArguments checks a tool call against the required arguments
+// tools/list advertises for the session's context (see RequiredFields). An
+// argument that is null or an empty string counts as missing. Unknown tools
+// are left to handleToolsCall.
+func (s *Server) checkRequiredArguments(ctx context.Context, name string, arguments json.RawMessage) error {
+	required, ok := s.RequiredFields(ctx, name)
+	if !ok || len(required) == 0 {
+		return nil
+	}
+
+	var args map[string]interface{}
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return fmt.Errorf("%w for %s: arguments must be an object", errMissingArguments, name)
+		}
+	}
+
+	var missing []string
+	for _, field := range required {
+		value, present := args[field]
+		if text, isString := value.(string); !present || value == nil || (isString && strings.TrimSpace(text) == "") {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w for %s: %s", errMissingArguments, name, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestRequiredFieldsMatchNaturalLanguagePath checks that every tool offered
+// both over MCP and to the model requires the same arguments on both paths,
+// with and without a patient in the context
+func TestRequiredFieldsMatchNaturalLanguagePath(t *testing.T) {
+	server, db := newTestServer(t)
+	if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES ('p1', 'Ola', 'Nordmann', 'male', '1960-05-05')`); err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+	ctx := context.Background()
+	handler := server.handlerFor(ctx)
+
+	sorted := func(fields []string) string {
+		fields = append([]string{}, fields...)
+		sort.Strings(fields)
+		return strings.Join(fields, ",")
+	}
+	compare := func(label string) {
+		t.Helper()
+		compared := 0
+		for _, tool := range server.handleToolsList(ctx)["tools"].([]map[string]interface{}) {
+			name := tool["name"].(string)
+			llmRequired, offered := handler.ToolRequiredFields(name)
+			if !offered {
+				continue
+			}
+			compared++
+			mcpRequired, _ := server.RequiredFields(ctx, name)
+			if sorted(mcpRequired) != sorted(llmRequired) {
+				t.Errorf("%s: %s requires %v over MCP but %v for the model", label, name, mcpRequired, llmRequired)
+			}
+		}
+		if compared == 0 {
+			t.Fatalf("%s: no tools compared", label)
+		}
+	}
+
+	compare("without context")
+	if required, _ := server.RequiredFields(ctx, "get_patient_summary"); sorted(required) != "patient_id" {
+		t.Errorf("Expected patient_id to be required without context, got %v", required)
+	}
+
+	if _, err := handler.SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext: %v", err)
+	}
+	compare("with patient context")
+	if required, _ := server.RequiredFields(ctx, "get_observation_trend"); sorted(required) != "code" {
+		t.Errorf("Expected only code to be required with a patient set, got %v", required)
+	}
+}
+
+func TestStrictToolArgs(t *testing.T) {
+	t.Setenv("MCP_STRICT_TOOL_ARGS", "true")
+	server, db := newTestServer(t)
+	if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES ('p1', 'Ola', 'Nordmann', 'male', '1960-05-05')`); err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+	ctx := context.Background()
+
+	call := `{"jsonrpc": "2.0", "method": "tools/call", "id": 1, "params": {"name": "get_observation_trend", "arguments": {"patient_id": "", "code": "8867-4"}}}`
+	response, err := server.HandleMessage(ctx, []byte(call))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if response.Error == nil || response.Error.Code != ErrCodeInvalidParams || !strings.Contains(response.Error.Message, "get_observation_trend: patient_id") {
+		t.Fatalf("Expected an invalid params error naming patient_id, got %+v", response.Error)
+	}
+
+	// Once the context supplies the patient, the same call is complete
+	if _, err := server.handlerFor(ctx).SetPatientContext("p1"); err != nil {
+		t.Fatalf("SetPatientContext: %v", err)
+	}
+	response, err = server.HandleMessage(ctx, []byte(call))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if response.Error != nil {
+		t.Errorf("Expected the call to succeed with a patient in context, got %+v", response.Error)
+	}
+}
+
+func TestLenientToolArgs(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	// Without strict mode the tool itself reports the missing patient
+	call := `{"jsonrpc": "2.0", "method": "tools/call", "id": 1, "params": {"name": "get_observation_trend", "arguments": {"code": "8867-4"}}}`
+	response, err := server.HandleMessage(context.Background(), []byte(call))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if response.Error == nil || response.Error.Code == ErrCodeInvalidParams || !strings.Contains(response.Error.Message, "patient ID is required") {
+		t.Errorf("Expected the tool's own error, got %+v", response.Error)
+	}
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCodeResourceNotFound is the MCP error code for reading a resource that
+// doesn't exist
+const ErrCodeResourceNotFound = -32002
+
+// patientURIPrefix starts the URI of each patient resource, followed by the
+// patient ID
+const patientURIPrefix = "patient://"
+
+// resourcePageSize is the number of patients per resources/list page
+const resourcePageSize = 100
+
+// errInvalidResource is returned for resources requests with malformed params
+// or a URI that isn't a patient URI
+var errInvalidResource = errors.New("invalid resource request")
+
+// handleResourcesList lists patients as resources, resourcePageSize at a
+// time. The cursor is the ID of the last patient of the previous page.
+func (s *Server) handleResourcesList(ctx context.Context, params json.RawMessage) (map[string]interface{}, error) {
+	var listParams struct {
+		Cursor string `json:"cursor"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &listParams); err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidResource, err)
+		}
+	}
+
+	handler := s.handlerFor(ctx)
+	if handler == nil {
+		return nil, fmt.Errorf("no handler available")
+	}
+	// One extra patient tells whether there is another page
+	patients, err := handler.ListPatients(listParams.Cursor, resourcePageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list patients: %w", err)
+	}
+
+	result := map[string]interface{}{}
+	if len(patients) > resourcePageSize {
+		patients = patients[:resourcePageSize]
+		result["nextCursor"] = patients[len(patients)-1].ID
+	}
+
+	resources := make([]map[string]interface{}, len(patients))
+	for i, p := range patients {
+		name := strings.TrimSpace(p.GivenName + " " + p.FamilyName)
+		if name == "" {
+			name = p.ID
+		}
+		var details []string
+		if p.Gender != "" {
+			details = append(details, p.Gender)
+		}
+		if birthDate, _, _ := strings.Cut(p.BirthDate, "T"); birthDate != "" {
+			details = append(details, "born "+birthDate)
+		}
+		resources[i] = map[string]interface{}{
+			"uri":         patientURIPrefix + p.ID,
+			"name":        name,
+			"description": strings.TrimSpace(fmt.Sprintf("Patient %s %s", p.ID, strings.Join(details, ", "))),
+			"mimeType":    "text/plain",
+		}
+	}
+	result["resources"] = resources
+	return result, nil
+}
+
+// handleResourcesRead returns a patient's details and medical summary
+func (s *Server) handleResourcesRead(ctx context.Context, params json.RawMessage) (map[string]interface{}, error) {
+	var readParams struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &readParams); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidResource, err)
+	}
+	patientID, ok := strings.CutPrefix(readParams.URI, patientURIPrefix)
+	if !ok || patientID == "" {
+		return nil, fmt.Errorf("%w: unsupported URI %q (use %s<id>)", errInvalidResource, readParams.URI, patientURIPrefix)
+	}
+
+	handler := s.handlerFor(ctx)
+	if handler == nil {
+		return nil, fmt.Errorf("no handler available")
+	}
+	text, err := handler.PatientRecord(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      readParams.URI,
+				"mimeType": "text/plain",
+				"text":     text,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHandleResourcesList(t *testing.T) {
+	server, db := newTestServer(t)
+	for i := 0; i < resourcePageSize+5; i++ {
+		if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES (?, 'Test', ?, 'female', '1980-01-01')`,
+			fmt.Sprintf("p%03d", i), fmt.Sprintf("Patient%d", i)); err != nil {
+			t.Fatalf("Failed to seed patient: %v", err)
+		}
+	}
+
+	list := func(params string) map[string]interface{} {
+		t.Helper()
+		response, err := server.HandleMessage(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "resources/list", "params": `+params+`, "id": 1}`))
+		if err != nil || response.Error != nil {
+			t.Fatalf("resources/list failed: %v %+v", err, response.Error)
+		}
+		return response.Result.(map[string]interface{})
+	}
+
+	first := list(`{}`)
+	resources := first["resources"].([]map[string]interface{})
+	if len(resources) != resourcePageSize || first["nextCursor"] != "p099" {
+		t.Fatalf("Expected a full first page and a cursor, got %d resources and cursor %v", len(resources), first["nextCursor"])
+	}
+	if r := resources[0]; r["uri"] != "patient://p000" || r["name"] != "Test Patient0" || r["description"] != "Patient p000 female, born 1980-01-01" {
+		t.Errorf("Unexpected first resource: %v", r)
+	}
+
+	second := list(`{"cursor": "p099"}`)
+	resources = second["resources"].([]map[string]interface{})
+	if len(resources) != 5 || resources[0]["uri"] != "patient://p100" {
+		t.Errorf("Expected the remaining 5 patients, got %v", resources)
+	}
+	if _, ok := second["nextCursor"]; ok {
+		t.Errorf("Expected no cursor on the last page, got %v", second["nextCursor"])
+	}
+}
+
+func TestHandleResourcesRead(t *testing.T) {
+	server, db := newTestServer(t)
+	if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES ('p1', 'Ola', 'Nordmann', 'male', '1960-05-05')`); err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO conditions (id, clinical_status, code, display, patient_id) VALUES ('c1', 'active', '38341003', 'Hypertension', 'p1')`); err != nil {
+		t.Fatalf("Failed to seed condition: %v", err)
+	}
+
+	read := func(uri string) *JSONRPCResponse {
+		t.Helper()
+		response, err := server.HandleMessage(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "resources/read", "params": {"uri": "`+uri+`"}, "id": 1}`))
+		if err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+		return response
+	}
+
+	response := read("patient://p1")
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %+v", response.Error)
+	}
+	encoded, _ := json.Marshal(response.Result)
+	var result struct {
+		Contents []struct {
+			URI      string `json:"uri"`
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(encoded, &result); err != nil || len(result.Contents) != 1 {
+		t.Fatalf("Expected one content item, got %s", encoded)
+	}
+	content := result.Contents[0]
+	if content.URI != "patient://p1" || content.MimeType != "text/plain" ||
+		!strings.Contains(content.Text, "Name: Ola Nordmann") || !strings.Contains(content.Text, "Hypertension") {
+		t.Errorf("Expected the patient info and summary, got %+v", content)
+	}
+
+	// Reading a resource doesn't select the patient
+	if id := server.Handler().GetContextPatientID(""); id != "" {
+		t.Errorf("Expected the patient context to be unchanged, got %q", id)
+	}
+
+	if response := read("patient://missing"); response.Error == nil || response.Error.Code != ErrCodeResourceNotFound {
+		t.Errorf("Expected resource not found, got %+v", response.Error)
+	}
+	if response := read("file:///etc/passwd"); response.Error == nil || response.Error.Code != ErrCodeInvalidParams {
+		t.Errorf("Expected invalid params for a non-patient URI, got %+v", response.Error)
+	}
+}
@@ -1,21 +1,79 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/eythor/mcp-server/internal/debug"
 	"github.com/eythor/mcp-server/internal/handlers"
 )
 
+// Version is reported in the initialize result. Release builds set it with
+// -ldflags "-X github.com/eythor/mcp-server/internal/mcp.Version=1.2.3".
+var Version = "0.1.0"
+
+// defaultServerName is reported in the initialize result unless
+// MCP_SERVER_NAME is set
+const defaultServerName = "healthcare-mcp-server"
+
+// serverName returns MCP_SERVER_NAME, or defaultServerName if it is unset
+func serverName() string {
+	if name := strings.TrimSpace(os.Getenv("MCP_SERVER_NAME")); name != "" {
+		return name
+	}
+	return defaultServerName
+}
+
+// ErrCodeTooManyRequests is the JSON-RPC server error code returned when a
+// request is throttled
+const ErrCodeTooManyRequests = -32000
+
+// ErrCodeInvalidParams is the JSON-RPC error code for invalid method parameters
+const ErrCodeInvalidParams = -32602
+
+// supportedProtocolVersions lists the MCP protocol versions the server can
+// speak, newest first
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// defaultProtocolVersion is used for clients that don't request a version
+const defaultProtocolVersion = "2024-11-05"
+
 type Server struct {
 	handler *handlers.Handler
+
+	// metrics counts tool calls and their duration, for WriteMetrics
+	metrics *toolMetrics
+
+	// strictArgs rejects tool calls missing a required argument before they
+	// reach the tool (MCP_STRICT_TOOL_ARGS)
+	strictArgs bool
 }
 
 func NewServer(handler *handlers.Handler) *Server {
 	return &Server{
-		handler: handler,
+		handler:    handler,
+		metrics:    newToolMetrics(),
+		strictArgs: strictToolArgs(),
+	}
+}
+
+// Handler returns the handler the server dispatches tool calls to
+func (s *Server) Handler() *handlers.Handler {
+	return s.handler
+}
+
+// handlerFor returns the handler for the session carried by ctx (see
+// handlers.WithSession), or the default session when there is none
+func (s *Server) handlerFor(ctx context.Context) *handlers.Handler {
+	if s.handler == nil {
+		return nil
 	}
+	return s.handler.Session(handlers.SessionFromContext(ctx))
 }
 
 type JSONRPCRequest struct {
@@ -38,7 +96,9 @@ type Error struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-func (s *Server) HandleMessage(message []byte) (*JSONRPCResponse, error) {
+// HandleMessage handles a single JSON-RPC request. Cancelling ctx aborts any
+// model call the request makes.
+func (s *Server) HandleMessage(ctx context.Context, message []byte) (*JSONRPCResponse, error) {
 	debug.Trace("MCP HandleMessage received: %s", string(message))
 	
 	var request JSONRPCRequest
@@ -46,6 +106,12 @@ func (s *Server) HandleMessage(message []byte) (*JSONRPCResponse, error) {
 		return nil, fmt.Errorf("failed to unmarshal request: %w", err)
 	}
 
+	return s.handleRequest(ctx, request), nil
+}
+
+// handleRequest dispatches a single request, returning nil when no response
+// is sent
+func (s *Server) handleRequest(ctx context.Context, request JSONRPCRequest) *JSONRPCResponse {
 	debug.Log("MCP handling method: %s", request.Method)
 	
 	response := &JSONRPCResponse{
@@ -55,18 +121,65 @@ func (s *Server) HandleMessage(message []byte) (*JSONRPCResponse, error) {
 
 	switch request.Method {
 	case "initialize":
-		response.Result = s.handleInitialize()
+		response.Result = s.handleInitialize(request.Params)
 	case "initialized":
 		// No response needed for initialized
-		return nil, nil
+		return nil
 	case "tools/list":
-		response.Result = s.handleToolsList()
+		response.Result = s.handleToolsList(ctx)
 	case "tools/call":
 		debug.Verbose("Processing tools/call with params: %s", string(request.Params))
-		result, err := s.handleToolsCall(request.Params)
+		if handler := s.handlerFor(ctx); handler != nil {
+			handler.Touch()
+		}
+		result, err := s.handleToolsCall(ctx, request.Params)
+		if err != nil {
+			code := -32603
+			if errors.Is(err, handlers.ErrTooManyQueries) {
+				code = ErrCodeTooManyRequests
+			} else if errors.Is(err, handlers.ErrUnknownPractitioner) || errors.Is(err, errMissingArguments) {
+				code = ErrCodeInvalidParams
+			}
+			response.Error = &Error{
+				Code:    code,
+				Message: err.Error(),
+			}
+		} else {
+			response.Result = result
+		}
+	case "prompts/list":
+		response.Result = s.handlePromptsList()
+	case "prompts/get":
+		result, err := s.handlePromptsGet(ctx, request.Params)
+		if err != nil {
+			code := -32603
+			if errors.Is(err, errInvalidPrompt) {
+				code = ErrCodeInvalidParams
+			}
+			response.Error = &Error{
+				Code:    code,
+				Message: err.Error(),
+			}
+		} else {
+			response.Result = result
+		}
+	case "resources/list", "resources/read":
+		var result map[string]interface{}
+		var err error
+		if request.Method == "resources/list" {
+			result, err = s.handleResourcesList(ctx, request.Params)
+		} else {
+			result, err = s.handleResourcesRead(ctx, request.Params)
+		}
 		if err != nil {
+			code := -32603
+			if errors.Is(err, errInvalidResource) {
+				code = ErrCodeInvalidParams
+			} else if errors.Is(err, handlers.ErrPatientNotFound) {
+				code = ErrCodeResourceNotFound
+			}
 			response.Error = &Error{
-				Code:    -32603,
+				Code:    code,
 				Message: err.Error(),
 			}
 		} else {
@@ -79,23 +192,79 @@ func (s *Server) HandleMessage(message []byte) (*JSONRPCResponse, error) {
 		}
 	}
 
-	return response, nil
+	return response
+}
+
+// negotiateProtocolVersion echoes the client's requested version when the
+// server supports it and otherwise offers the latest supported version, as
+// the MCP lifecycle spec requires
+func negotiateProtocolVersion(requested string) string {
+	if requested == "" {
+		return defaultProtocolVersion
+	}
+	for _, v := range supportedProtocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	debug.Log("Client requested unsupported protocol version %s, offering %s", requested, supportedProtocolVersions[0])
+	return supportedProtocolVersions[0]
 }
 
-func (s *Server) handleInitialize() map[string]interface{} {
+func (s *Server) handleInitialize(params json.RawMessage) map[string]interface{} {
+	var initParams struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &initParams); err != nil {
+			debug.Error("Invalid initialize params: %v", err)
+		}
+	}
+
 	return map[string]interface{}{
-		"protocolVersion": "2024-11-05",
+		"protocolVersion": negotiateProtocolVersion(initParams.ProtocolVersion),
 		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{},
+			"tools":     map[string]interface{}{},
+			"prompts":   map[string]interface{}{},
+			"resources": map[string]interface{}{},
 		},
 		"serverInfo": map[string]interface{}{
-			"name":    "healthcare-mcp-server",
-			"version": "0.1.0",
+			"name":    serverName(),
+			"version": Version,
 		},
 	}
 }
 
-func (s *Server) handleToolsList() map[string]interface{} {
+// requiredFields returns the required arguments among fields for the
+// session's context (see handlers.Handler.ContextRequired), or all of them
+// when there is no handler
+func (s *Server) requiredFields(ctx context.Context, fields ...string) []string {
+	if handler := s.handlerFor(ctx); handler != nil {
+		return handler.ContextRequired(fields...)
+	}
+	return fields
+}
+
+// RequiredFields returns the required arguments of the named tool as listed
+// by tools/list for the session's context, and whether the tool exists. It
+// matches the natural-language path (see handlers.Handler.ToolRequiredFields).
+func (s *Server) RequiredFields(ctx context.Context, name string) ([]string, bool) {
+	tools, _ := s.handleToolsList(ctx)["tools"].([]map[string]interface{})
+	for _, tool := range tools {
+		if tool["name"] != name {
+			continue
+		}
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		return required, true
+	}
+	return nil, false
+}
+
+// handleToolsList lists the tools. Arguments the tools take from the
+// session's context, such as patient_id once a patient is set, are only
+// listed as required while the context doesn't supply them.
+func (s *Server) handleToolsList(ctx context.Context) map[string]interface{} {
 	tools := []map[string]interface{}{
 		{
 			"name":        "natural_language_query",
@@ -107,6 +276,14 @@ func (s *Server) handleToolsList() map[string]interface{} {
 						"type":        "string",
 						"description": "Natural language query about patients, medical history, appointments, etc.",
 					},
+					"for_audio": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Clean up the response for text-to-speech (strip markdown and trailing disclaimers)",
+					},
+					"practitioner_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Practitioner making the query; used for this query only instead of the practitioner context",
+					},
 				},
 				"required": []string{"query"},
 			},
@@ -190,27 +367,27 @@ func (s *Server) handleToolsList() map[string]interface{} {
 				"properties": map[string]interface{}{
 					"patient_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Patient ID",
+						"description": "Patient ID (optional if patient context is set)",
 					},
 					"practitioner_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Practitioner ID",
+						"description": "Practitioner ID (optional if practitioner context is set)",
 					},
 					"datetime": map[string]interface{}{
 						"type":        "string",
-						"description": "Appointment date and time (ISO 8601 format)",
+						"description": "Appointment date and time, e.g. 2025-03-14T10:30:00+01:00, 14.03.2025 10:30 or \"tomorrow at 10:30\" (Europe/Berlin if no offset)",
 					},
 					"type": map[string]interface{}{
 						"type":        "string",
 						"description": "Type of appointment",
 					},
 				},
-				"required": []string{"patient_id", "practitioner_id", "datetime"},
+				"required": s.requiredFields(ctx, "patient_id", "practitioner_id", "datetime"),
 			},
 		},
 		{
 			"name":        "cancel_appointment",
-			"description": "Cancel an appointment",
+			"description": "Cancel an appointment, optionally recording the reason",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -218,6 +395,10 @@ func (s *Server) handleToolsList() map[string]interface{} {
 						"type":        "string",
 						"description": "Encounter/Appointment ID to cancel",
 					},
+					"reason": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the appointment is cancelled, e.g. 'no-show' (optional)",
+					},
 				},
 				"required": []string{"encounter_id"},
 			},
@@ -230,15 +411,23 @@ func (s *Server) handleToolsList() map[string]interface{} {
 				"properties": map[string]interface{}{
 					"patient_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Patient ID",
+						"description": "Patient ID (optional if patient context is set)",
 					},
 					"category": map[string]interface{}{
 						"type":        "string",
 						"description": "Category of history (conditions, medications, procedures, immunizations, allergies, observations)",
 						"enum":        []string{"conditions", "medications", "procedures", "immunizations", "allergies", "observations", "all"},
 					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of records per category (default 10)",
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of records to skip in each category, for paging (default 0)",
+					},
 				},
-				"required": []string{"patient_id"},
+				"required": s.requiredFields(ctx, "patient_id"),
 			},
 		},
 		{
@@ -311,7 +500,7 @@ func (s *Server) handleToolsList() map[string]interface{} {
 					},
 					"effective_datetime": map[string]interface{}{
 						"type":        "string",
-						"description": "Date and time when observation was made (ISO 8601 format, defaults to now)",
+						"description": "Date and time when observation was made (ISO 8601 or e.g. 'yesterday at 10:00', defaults to now; not in the future)",
 					},
 					"value_quantity": map[string]interface{}{
 						"type":        "number",
@@ -340,7 +529,7 @@ func (s *Server) handleToolsList() map[string]interface{} {
 						"description": "Patient ID (optional if patient context is set)",
 					},
 				},
-				"required": []string{},
+				"required": s.requiredFields(ctx, "patient_id"),
 			},
 		},
 		{
@@ -361,176 +550,1662 @@ func (s *Server) handleToolsList() map[string]interface{} {
 				"required": []string{"birth_date"},
 			},
 		},
-	}
-
-	return map[string]interface{}{
-		"tools": tools,
-	}
-}
-
-func (s *Server) handleToolsCall(params json.RawMessage) (interface{}, error) {
-	var toolCall struct {
-		Name      string          `json:"name"`
-		Arguments json.RawMessage `json:"arguments"`
-	}
-
-	if err := json.Unmarshal(params, &toolCall); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal tool call: %w", err)
-	}
-
-	debug.Log("MCP tool call: %s", toolCall.Name)
-	debug.Verbose("Tool arguments: %s", string(toolCall.Arguments))
-	
-	switch toolCall.Name {
-	case "natural_language_query":
-		var args struct {
-			Query string `json:"query"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.ProcessNaturalLanguageQuery(args.Query, "")
-
-	case "set_patient_context":
-		var args struct {
-			PatientID string `json:"patient_id"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.SetPatientContext(args.PatientID)
-
-	case "set_practitioner_context":
-		var args struct {
-			PractitionerID string `json:"practitioner_id"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.SetPractitionerContext(args.PractitionerID)
-
-	case "get_context":
-		return s.handler.GetContext()
-
-	case "clear_context":
-		return s.handler.ClearContext()
-
-	case "lookup_patient":
-		var args struct {
-			Query string `json:"query"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.LookupPatient(args.Query)
-
-	case "get_practitioner":
-		var args struct {
-			PractitionerID string `json:"practitioner_id"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.GetPractitioner(args.PractitionerID)
-
-	case "schedule_appointment":
-		var args struct {
-			PatientID      string `json:"patient_id"`
-			PractitionerID string `json:"practitioner_id"`
-			DateTime       string `json:"datetime"`
-			Type           string `json:"type"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.ScheduleAppointment(args.PatientID, args.PractitionerID, args.DateTime, args.Type)
-
-	case "cancel_appointment":
-		var args struct {
-			EncounterID string `json:"encounter_id"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.CancelAppointment(args.EncounterID)
-
-	case "get_medical_history":
-		var args struct {
-			PatientID string `json:"patient_id"`
-			Category  string `json:"category"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		if args.Category == "" {
-			args.Category = "all"
-		}
-		return s.handler.GetMedicalHistory(args.PatientID, args.Category)
-
-	case "get_medication_info":
-		var args struct {
-			MedicationName string `json:"medication_name"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.GetMedicationInfo(args.MedicationName)
-
-	case "get_medical_guidelines":
-		var args struct {
-			Query string `json:"query"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.GetMedicalGuidelines(args.Query)
-
-	case "answer_health_question":
-		var args struct {
-			Question string `json:"question"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.AnswerHealthQuestion(args.Question)
-
-	case "add_observation":
-		var args struct {
-			PatientID         string   `json:"patient_id"`
-			Code              string   `json:"code"`
-			Display           string   `json:"display"`
-			Category          string   `json:"category"`
-			Status            string   `json:"status"`
-			EffectiveDateTime string   `json:"effective_datetime"`
-			ValueQuantity     *float64 `json:"value_quantity"`
-			ValueUnit         *string  `json:"value_unit"`
-			ValueString       *string  `json:"value_string"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.AddObservation(args.PatientID, args.Code, args.Display, args.Category, args.Status, args.EffectiveDateTime, args.ValueQuantity, args.ValueUnit, args.ValueString)
-
-	case "calculate_age":
-		var args struct {
-			PatientID string `json:"patient_id"`
-		}
-		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
-			return nil, err
-		}
-		return s.handler.CalculateAge(args.PatientID)
-
-	case "update_patient_birth_date":
-		var args struct {
-			PatientID string `json:"patient_id"`
-			BirthDate string `json:"birth_date"`
+		{
+			"name":        "calculate_ibw",
+			"description": "Estimate ideal body weight (Devine formula) from the patient's latest height and gender, plus adjusted body weight from the latest actual weight, for weight-based dosing. Uses patient context if patient_id is not provided.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "check_contraindications",
+			"description": "Cross-reference the patient's active medications against active conditions and flag contraindicated pairs (e.g., metformin with severe renal impairment, NSAIDs with CKD). Uses patient context if patient_id is not provided.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"include_ai_review": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also ask the guidelines model to review for contraindications not covered by the built-in rules (default: false)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_patient_fhir",
+			"description": "Get the patient's demographics as a FHIR R4 Patient resource (name, gender, birthDate, telecom, address) in JSON. Uses patient context if patient_id is not provided.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "add_contact_point",
+			"description": "Add a phone number, email address or other contact point for a patient",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"system": map[string]interface{}{
+						"type":        "string",
+						"description": "Contact system: phone, email, sms, fax, pager, url or other (default: phone)",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "Phone number, email address, etc.",
+					},
+					"use": map[string]interface{}{
+						"type":        "string",
+						"description": "Purpose of the contact point: home, work, mobile, temp or old",
+					},
+				},
+				"required": s.requiredFields(ctx, "value", "patient_id"),
+			},
+		},
+		{
+			"name":        "list_contact_points",
+			"description": "List all contact points (phone numbers, emails, etc.) for a patient",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_screening_recommendations",
+			"description": "List routine screenings (mammography, colonoscopy, HbA1c, etc.) the patient is due for based on age, gender and past procedures/observations",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "explain_observation_trend",
+			"description": "Explain in plain language whether a lab or vital sign trend is improving or worsening, with the raw values",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "Observation code (e.g. LOINC 4548-4) or name (e.g. 'hemoglobin a1c')",
+					},
+				},
+				"required": s.requiredFields(ctx, "code", "patient_id"),
+			},
+		},
+		{
+			"name":        "get_vaccination_card",
+			"description": "Get the patient's vaccination record as a printable card (vaccine, dose, date, status) in chronological order",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "diff_patient_summary",
+			"description": "Report what changed in a patient's record (new conditions, medications, observations, encounters, procedures, immunizations) since a date",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "Date or datetime to compare against, e.g. 2024-01-01 (defaults to when the patient's context was set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "complete_encounter",
+			"description": "Mark an encounter/appointment as finished and record the visit outcome",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"encounter_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Encounter/Appointment ID to complete",
+					},
+					"outcome": map[string]interface{}{
+						"type":        "string",
+						"description": "Outcome of the visit, e.g. 'Follow-up in 3 months, labs ordered'",
+					},
+					"actual_start": map[string]interface{}{
+						"type":        "string",
+						"description": "When the visit actually started, e.g. '2025-03-14 09:20' (optional, for punctuality tracking)",
+					},
+				},
+				"required": []string{"encounter_id"},
+			},
+		},
+		{
+			"name":        "suggest_next_actions",
+			"description": "Suggest a prioritized list of next actions for a patient (contraindications, abnormal results, polypharmacy, overdue immunizations and screenings, upcoming appointments)",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"include_ai_review": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also ask the guidelines model to refine the prioritization",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_allergy_banner",
+			"description": "Get a one-line alert banner of the patient's high-criticality allergies, or NKDA when none are on file",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "calculate_meld",
+			"description": "Calculate the MELD score for liver disease severity from the latest bilirubin, creatinine and INR results",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_observation_series",
+			"description": "Get the values of an observation as a JSON time series of {datetime, value} points in ascending order, for charting",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "Observation code (e.g. LOINC 4548-4) or name (e.g. 'hemoglobin a1c')",
+					},
+				},
+				"required": s.requiredFields(ctx, "code", "patient_id"),
+			},
+		},
+		{
+			"name":        "get_surgical_history",
+			"description": "Get a patient's surgical history in chronological order, excluding non-surgical procedures",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "list_appointments",
+			"description": "List a patient's past and upcoming appointments, optionally filtered by status",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list appointments with this status (e.g. planned, finished, cancelled)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_claims_aging",
+			"description": "Claims aging report for billing: counts and totals of all claims bucketed by days since creation (0-30, 31-60, 61-90, 90+)",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of oldest claims to list individually (default 10)",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			"name":        "validate_loinc",
+			"description": "Check an observation code against the LOINC format and, for common codes, the expected display and unit. Returns a warning when they are inconsistent",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "LOINC code, e.g. 8867-4",
+					},
+					"display": map[string]interface{}{
+						"type":        "string",
+						"description": "Observation display name (optional)",
+					},
+					"unit": map[string]interface{}{
+						"type":        "string",
+						"description": "Unit of the value (optional)",
+					},
+				},
+				"required": []string{"code"},
+			},
+		},
+		{
+			"name":        "reschedule_appointment",
+			"description": "Move an existing appointment to a new date and time",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"encounter_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Encounter/Appointment ID",
+					},
+					"datetime": map[string]interface{}{
+						"type":        "string",
+						"description": "New date and time, e.g. 2025-03-14T10:30:00+01:00, 14.03.2025 10:30 or \"tomorrow at 10:30\" (Europe/Berlin if no offset)",
+					},
+				},
+				"required": []string{"encounter_id", "datetime"},
+			},
+		},
+		{
+			"name":        "generate_sbar",
+			"description": "Generate an SBAR (Situation, Background, Assessment, Recommendation) shift handoff summary for a patient",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "confirm_date_choice",
+			"description": "Confirm which reading of an ambiguous date was meant (e.g. A or B) and complete the scheduling request that was waiting on it",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"choice": map[string]interface{}{
+						"type":        "string",
+						"description": "The option key offered for the ambiguous date, e.g. A or B",
+					},
+				},
+				"required": []string{"choice"},
+			},
+		},
+		{
+			"name":        "normalize_patient_data",
+			"description": "Admin: trim whitespace in all patient names and normalize gender values to male/female/other/unknown. Requires MCP_ENABLE_ADMIN_TOOLS",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "list_available_models",
+			"description": "Admin: list the models available on OpenRouter with context length and prompt/completion prices, cached for an hour. Requires MCP_ENABLE_ADMIN_TOOLS",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "get_encounters",
+			"description": "Get a patient's full visit history (encounters) with class, type, practitioner, start/end times and status",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_care_team",
+			"description": "List the practitioners a patient has seen (their care team) with the number of encounters with each",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "delete_observation",
+			"description": "Delete a mistakenly entered observation by its ID",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"observation_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Observation ID",
+					},
+				},
+				"required": []string{"observation_id"},
+			},
+		},
+		{
+			"name":        "update_observation",
+			"description": "Correct an observation's value, unit or status. Only the provided fields are changed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"observation_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Observation ID",
+					},
+					"value_quantity": map[string]interface{}{
+						"type":        "number",
+						"description": "New numeric value",
+					},
+					"value_unit": map[string]interface{}{
+						"type":        "string",
+						"description": "New unit of the numeric value",
+					},
+					"value_string": map[string]interface{}{
+						"type":        "string",
+						"description": "New text value",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "New FHIR observation status, e.g. amended or corrected",
+						"enum":        []string{"registered", "preliminary", "final", "amended", "corrected", "cancelled", "entered-in-error", "unknown"},
+					},
+				},
+				"required": []string{"observation_id"},
+			},
+		},
+		{
+			"name":        "get_visit_punctuality",
+			"description": "Compare scheduled and actual start times of a patient's visits and report the delays",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "mark_patient_arrived",
+			"description": "Record that the patient arrived for an appointment, setting its status to arrived and its actual start time",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"encounter_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Encounter/Appointment ID",
+					},
+					"arrived_at": map[string]interface{}{
+						"type":        "string",
+						"description": "Arrival time, e.g. '2025-03-14 09:20' (defaults to now)",
+					},
+				},
+				"required": []string{"encounter_id"},
+			},
+		},
+		{
+			"name":        "find_patients_on_medication",
+			"description": "Find all patients with an active prescription for a medication, e.g. for a drug recall",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"medication": map[string]interface{}{
+						"type":        "string",
+						"description": "Medication name or part of it",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of patients to list (default 50)",
+					},
+				},
+				"required": []string{"medication"},
+			},
+		},
+		{
+			"name":        "calculate_bmi",
+			"description": "Calculate a patient's body mass index from the latest weight and height observations",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "record_gcs",
+			"description": "Record a Glasgow Coma Scale assessment: validates the eye (1-4), verbal (1-5) and motor (1-6) scores, stores the total as an observation and returns its severity",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"eye": map[string]interface{}{
+						"type":        "integer",
+						"description": "Eye opening response (1-4)",
+					},
+					"verbal": map[string]interface{}{
+						"type":        "integer",
+						"description": "Verbal response (1-5)",
+					},
+					"motor": map[string]interface{}{
+						"type":        "integer",
+						"description": "Motor response (1-6)",
+					},
+				},
+				"required": s.requiredFields(ctx, "eye", "verbal", "motor", "patient_id"),
+			},
+		},
+		{
+			"name":        "calculate_egfr",
+			"description": "Estimate a patient's kidney function (eGFR, CKD-EPI 2021) from the latest serum creatinine, age and gender",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "calculate_corrected_sodium",
+			"description": "Calculate the patient's serum sodium corrected for hyperglycemia from their latest sodium and glucose results",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "create_patient",
+			"description": "Register a new patient and set them as the current patient",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"given_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Given (first) name",
+					},
+					"family_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Family (last) name",
+					},
+					"gender": map[string]interface{}{
+						"type":        "string",
+						"description": "Gender: male, female, other or unknown (default: unknown)",
+					},
+					"birth_date": map[string]interface{}{
+						"type":        "string",
+						"description": "Birth date in YYYY-MM-DD format",
+					},
+					"phone": map[string]interface{}{
+						"type":        "string",
+						"description": "Phone number",
+					},
+					"city": map[string]interface{}{
+						"type":        "string",
+						"description": "City",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "State",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			"name":        "get_cost_report",
+			"description": "Report model token usage and estimated spend for this session, per model",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "get_medication_forms",
+			"description": "List the available formulations (strengths and forms) of a medication",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"medication_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Medication name or part of it",
+					},
+				},
+				"required": []string{"medication_name"},
+			},
+		},
+		{
+			"name":        "add_condition",
+			"description": "Record a diagnosis (condition) for a patient",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "Condition code (e.g. SNOMED CT)",
+					},
+					"display": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the condition, e.g. 'Essential hypertension'",
+					},
+					"clinical_status": map[string]interface{}{
+						"type":        "string",
+						"description": "Clinical status: active, recurrence, relapse, inactive, remission or resolved (default: active)",
+					},
+					"onset_datetime": map[string]interface{}{
+						"type":        "string",
+						"description": "When the condition started, e.g. 2024-03-14 or 14.03.2024 (optional)",
+					},
+				},
+				"required": s.requiredFields(ctx, "display", "patient_id"),
+			},
+		},
+		{
+			"name":        "add_medication",
+			"description": "Prescribe a medication for a patient; the current practitioner is recorded as prescriber",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"medication": map[string]interface{}{
+						"type":        "string",
+						"description": "Medication and formulation, e.g. 'Metformin hydrochloride 500 MG Oral Tablet'",
+					},
+					"dosage": map[string]interface{}{
+						"type":        "string",
+						"description": "Dosage instructions, e.g. '1 tablet twice daily with meals'",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "Prescription status: active, on-hold, draft, etc. (default: active)",
+					},
+				},
+				"required": s.requiredFields(ctx, "medication", "patient_id"),
+			},
+		},
+		{
+			"name":        "get_ranked_problem_list",
+			"description": "List the patient's active conditions ranked by severity, most serious first",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_growth_percentile",
+			"description": "Get WHO growth-chart percentiles (weight-for-age and length-for-age) for a child under 2 from the latest measurements",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "audit_patient_data",
+			"description": "Check a patient's records for inconsistencies: active conditions with future onset dates, negative observation values, medications prescribed before birth and encounters that end before they start",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "calculate_anion_gap",
+			"description": "Calculate the serum anion gap from the latest sodium, chloride and bicarbonate, with albumin correction when available",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_frequent_findings",
+			"description": "List the conditions and encounter types that recur most often for a patient, with how many times each was seen",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_patient_summary",
+			"description": "Get a one-shot clinical overview of a patient: demographics, active conditions, current medications, allergies, recent observations, recent encounters and totals",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "reconcile_medications",
+			"description": "Reconcile a patient's medications against a date: which were started, stopped or continued since then, based on prescription date and status",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "Date or datetime to reconcile against, e.g. the admission date 2024-01-01",
+					},
+				},
+				"required": s.requiredFields(ctx, "since", "patient_id"),
+			},
+		},
+		{
+			"name":        "check_drug_interactions",
+			"description": "Scan a patient's active medications for clinically significant pairwise drug interactions with severity, taking allergies into account",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "calculate_wells_score",
+			"description": "Calculate the Wells score for pulmonary embolism (PE) or deep vein thrombosis (DVT) with risk category. Criteria are derived from the record where possible; clinician-assessed items can be passed in criteria",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"score_type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"PE", "DVT"},
+						"description": "PE for pulmonary embolism or DVT for deep vein thrombosis",
+					},
+					"criteria": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "boolean"},
+						"description":          "Clinician-assessed criteria as true/false, overriding the record. PE: dvt_signs, pe_most_likely, heart_rate_over_100, immobilization_or_surgery, previous_dvt_pe, hemoptysis, malignancy. DVT: active_cancer, bedridden_or_surgery, calf_swelling, collateral_veins, entire_leg_swollen, localized_tenderness, pitting_edema, paralysis_or_cast, previous_dvt, alternative_diagnosis_likely",
+					},
+				},
+				"required": s.requiredFields(ctx, "score_type", "patient_id"),
+			},
+		},
+		{
+			"name":        "get_observation_trend",
+			"description": "Show the history of a measurement (by LOINC code or name, e.g. 'weight' or 'blood pressure'), oldest first, with a rising/falling/stable summary. Mixed units are flagged instead of trended.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "Observation code (e.g. LOINC 29463-7) or name (e.g. 'weight', 'blood pressure')",
+					},
+				},
+				"required": s.requiredFields(ctx, "code", "patient_id"),
+			},
+		},
+		{
+			"name":        "get_day_schedule",
+			"description": "List all planned appointments on a day across all practitioners, grouped by practitioner, e.g. for a daily huddle",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "Day to list, e.g. '2025-03-14', '14.03.2025', 'today' or 'tomorrow'",
+					},
+				},
+				"required": []string{"date"},
+			},
+		},
+		{
+			"name":        "calculate_charlson",
+			"description": "Calculate a patient's age-adjusted Charlson Comorbidity Index with estimated 10-year survival, mapping recorded conditions to Charlson comorbidities and listing conditions that could not be mapped",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "get_panel_result",
+			"description": "Show related observations taken together (a panel, e.g. a lipid panel or a set of vital signs) as one block: the panels on a date, or the most recent panel",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "Day the panel was taken, e.g. 2024-03-14 or 'yesterday' (optional, defaults to the most recent panel)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "record_consent",
+			"description": "Record that a patient consents to sharing their data with the AI assistant",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "revoke_consent",
+			"description": "Revoke a patient's consent to sharing their data with the AI assistant. Their data is then withheld from AI tools.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+		{
+			"name":        "calculate_maintenance_fluids",
+			"description": "Calculate a patient's maintenance IV fluid rate (mL/hr) and daily volume from the latest weight using the 4-2-1 rule",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patient_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Patient ID (optional if patient context is set)",
+					},
+				},
+				"required": s.requiredFields(ctx, "patient_id"),
+			},
+		},
+	}
+
+	return map[string]interface{}{
+		"tools": tools,
+	}
+}
+
+func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (result interface{}, err error) {
+	var toolCall struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+
+	if err := json.Unmarshal(params, &toolCall); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tool call: %w", err)
+	}
+
+	debug.Log("MCP tool call: %s", toolCall.Name)
+	start := time.Now()
+	defer func() {
+		if errors.Is(err, errUnknownTool) {
+			return
+		}
+		elapsed := time.Since(start)
+		s.metrics.record(toolCall.Name, elapsed)
+		debug.Log("MCP tool call %s took %s", toolCall.Name, elapsed)
+	}()
+	handler := s.handlerFor(ctx)
+	debug.Verbose("Tool arguments: %s", string(toolCall.Arguments))
+	
+	message, blocked := handler.DemoModeBlocks(toolCall.Name)
+	if !blocked {
+		var patientArg struct {
+			PatientID string `json:"patient_id"`
+		}
+		json.Unmarshal(toolCall.Arguments, &patientArg)
+		message, blocked = handler.AIConsentBlocks(toolCall.Name, patientArg.PatientID)
+		if !blocked {
+			message, blocked = handler.AIRateLimitBlocks(toolCall.Name, patientArg.PatientID)
+		}
+	}
+	if blocked {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": message,
+				},
+			},
+		}, nil
+	}
+
+	if s.strictArgs {
+		if err := s.checkRequiredArguments(ctx, toolCall.Name, toolCall.Arguments); err != nil {
+			return nil, err
+		}
+	}
+
+	defer func() {
+		if err == nil {
+			handler.RecordToolAudit(toolCall.Name, toolCall.Arguments)
+		}
+	}()
+
+	switch toolCall.Name {
+	case "natural_language_query":
+		var args struct {
+			Query          string `json:"query"`
+			ForAudio       bool   `json:"for_audio"`
+			PractitionerID string `json:"practitioner_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ProcessNaturalLanguageQuery(ctx, args.Query, args.PractitionerID, args.ForAudio)
+
+	case "set_patient_context":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.SetPatientContext(args.PatientID)
+
+	case "set_practitioner_context":
+		var args struct {
+			PractitionerID string `json:"practitioner_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.SetPractitionerContext(args.PractitionerID)
+
+	case "get_context":
+		return handler.GetContext()
+
+	case "clear_context":
+		return handler.ClearContext()
+
+	case "lookup_patient":
+		var args struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.LookupPatient(args.Query)
+
+	case "get_practitioner":
+		var args struct {
+			PractitionerID string `json:"practitioner_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetPractitioner(args.PractitionerID)
+
+	case "schedule_appointment":
+		var args struct {
+			PatientID      string `json:"patient_id"`
+			PractitionerID string `json:"practitioner_id"`
+			DateTime       string `json:"datetime"`
+			Type           string `json:"type"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ScheduleAppointment(args.PatientID, args.PractitionerID, args.DateTime, args.Type)
+
+	case "cancel_appointment":
+		var args struct {
+			EncounterID string `json:"encounter_id"`
+			Reason      string `json:"reason"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CancelAppointment(args.EncounterID, args.Reason)
+
+	case "get_medical_history":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Category  string `json:"category"`
+			Limit     int    `json:"limit"`
+			Offset    int    `json:"offset"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.Category == "" {
+			args.Category = "all"
+		}
+		return handler.GetMedicalHistory(args.PatientID, args.Category, args.Limit, args.Offset)
+
+	case "get_medication_info":
+		var args struct {
+			MedicationName string `json:"medication_name"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetMedicationInfo(ctx, args.MedicationName)
+
+	case "get_medical_guidelines":
+		var args struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetMedicalGuidelines(ctx, args.Query)
+
+	case "answer_health_question":
+		var args struct {
+			Question string `json:"question"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.AnswerHealthQuestion(ctx, args.Question)
+
+	case "add_observation":
+		var args struct {
+			PatientID         string   `json:"patient_id"`
+			Code              string   `json:"code"`
+			Display           string   `json:"display"`
+			Category          string   `json:"category"`
+			Status            string   `json:"status"`
+			EffectiveDateTime string   `json:"effective_datetime"`
+			ValueQuantity     *float64 `json:"value_quantity"`
+			ValueUnit         *string  `json:"value_unit"`
+			ValueString       *string  `json:"value_string"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.AddObservation(args.PatientID, args.Code, args.Display, args.Category, args.Status, args.EffectiveDateTime, args.ValueQuantity, args.ValueUnit, args.ValueString)
+
+	case "calculate_age":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateAge(args.PatientID)
+
+	case "update_patient_birth_date":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			BirthDate string `json:"birth_date"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.UpdatePatientBirthDate(args.PatientID, args.BirthDate)
+
+	case "calculate_ibw":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateIdealBodyWeight(args.PatientID)
+
+	case "check_contraindications":
+		var args struct {
+			PatientID       string `json:"patient_id"`
+			IncludeAIReview bool   `json:"include_ai_review"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CheckContraindications(ctx, args.PatientID, args.IncludeAIReview)
+
+	case "get_patient_fhir":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetPatientFHIR(args.PatientID)
+
+	case "add_contact_point":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			System    string `json:"system"`
+			Value     string `json:"value"`
+			Use       string `json:"use"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.AddContactPoint(args.PatientID, args.System, args.Value, args.Use)
+
+	case "list_contact_points":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ListContactPoints(args.PatientID)
+
+	case "get_screening_recommendations":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetScreeningRecommendations(args.PatientID)
+
+	case "explain_observation_trend":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Code      string `json:"code"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ExplainObservationTrend(ctx, args.PatientID, args.Code)
+
+	case "get_vaccination_card":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetVaccinationCard(args.PatientID)
+
+	case "diff_patient_summary":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Since     string `json:"since"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.DiffPatientSummary(args.PatientID, args.Since)
+
+	case "complete_encounter":
+		var args struct {
+			EncounterID string `json:"encounter_id"`
+			Outcome     string `json:"outcome"`
+			ActualStart string `json:"actual_start"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CompleteEncounter(args.EncounterID, args.Outcome, args.ActualStart)
+
+	case "suggest_next_actions":
+		var args struct {
+			PatientID       string `json:"patient_id"`
+			IncludeAIReview bool   `json:"include_ai_review"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.SuggestNextActions(ctx, args.PatientID, args.IncludeAIReview)
+
+	case "get_allergy_banner":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetAllergyBanner(args.PatientID)
+
+	case "calculate_meld":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateMELD(args.PatientID)
+
+	case "get_observation_series":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Code      string `json:"code"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetObservationSeries(args.PatientID, args.Code)
+
+	case "get_surgical_history":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetSurgicalHistory(args.PatientID)
+
+	case "list_appointments":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Status    string `json:"status"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ListAppointments(args.PatientID, args.Status)
+
+	case "get_claims_aging":
+		var args struct {
+			Limit int `json:"limit"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetClaimsAging(args.Limit)
+
+	case "validate_loinc":
+		var args struct {
+			Code    string `json:"code"`
+			Display string `json:"display"`
+			Unit    string `json:"unit"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ValidateObservationCode(args.Code, args.Display, args.Unit)
+
+	case "reschedule_appointment":
+		var args struct {
+			EncounterID string `json:"encounter_id"`
+			DateTime    string `json:"datetime"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.RescheduleAppointment(args.EncounterID, args.DateTime)
+
+	case "generate_sbar":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GenerateSBAR(ctx, args.PatientID)
+
+	case "confirm_date_choice":
+		var args struct {
+			Choice string `json:"choice"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ConfirmDateChoice(args.Choice)
+
+	case "normalize_patient_data":
+		return handler.NormalizePatientData()
+
+	case "list_available_models":
+		return handler.ListAvailableModels(ctx)
+
+	case "get_encounters":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetEncounters(args.PatientID)
+
+	case "get_care_team":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetCareTeam(args.PatientID)
+
+	case "delete_observation":
+		var args struct {
+			ObservationID string `json:"observation_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.DeleteObservation(args.ObservationID)
+
+	case "update_observation":
+		var args struct {
+			ObservationID string   `json:"observation_id"`
+			ValueQuantity *float64 `json:"value_quantity"`
+			ValueUnit     *string  `json:"value_unit"`
+			ValueString   *string  `json:"value_string"`
+			Status        string   `json:"status"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.UpdateObservation(args.ObservationID, args.ValueQuantity, args.ValueUnit, args.ValueString, args.Status)
+
+	case "get_visit_punctuality":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetVisitPunctuality(args.PatientID)
+
+	case "mark_patient_arrived":
+		var args struct {
+			EncounterID string `json:"encounter_id"`
+			ArrivedAt   string `json:"arrived_at"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.MarkPatientArrived(args.EncounterID, args.ArrivedAt)
+
+	case "find_patients_on_medication":
+		var args struct {
+			Medication string `json:"medication"`
+			Limit      int    `json:"limit"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.FindPatientsOnMedication(args.Medication, args.Limit)
+
+	case "calculate_bmi":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateBMI(args.PatientID)
+
+	case "record_gcs":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Eye       int    `json:"eye"`
+			Verbal    int    `json:"verbal"`
+			Motor     int    `json:"motor"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.RecordGCS(args.PatientID, args.Eye, args.Verbal, args.Motor)
+
+	case "calculate_egfr":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateEGFR(args.PatientID)
+
+	case "calculate_corrected_sodium":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateCorrectedSodium(args.PatientID)
+
+	case "create_patient":
+		var args struct {
+			GivenName  string  `json:"given_name"`
+			FamilyName string  `json:"family_name"`
+			Gender     string  `json:"gender"`
+			BirthDate  string  `json:"birth_date"`
+			Phone      *string `json:"phone"`
+			City       *string `json:"city"`
+			State      *string `json:"state"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CreatePatient(args.GivenName, args.FamilyName, args.Gender, args.BirthDate, args.Phone, args.City, args.State)
+
+	case "get_cost_report":
+		return handler.GetCostReport()
+
+	case "get_medication_forms":
+		var args struct {
+			MedicationName string `json:"medication_name"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetMedicationForms(args.MedicationName)
+
+	case "add_condition":
+		var args struct {
+			PatientID      string `json:"patient_id"`
+			Code           string `json:"code"`
+			Display        string `json:"display"`
+			ClinicalStatus string `json:"clinical_status"`
+			OnsetDateTime  string `json:"onset_datetime"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.AddCondition(args.PatientID, args.Code, args.Display, args.ClinicalStatus, args.OnsetDateTime)
+
+	case "add_medication":
+		var args struct {
+			PatientID  string `json:"patient_id"`
+			Medication string `json:"medication"`
+			Dosage     string `json:"dosage"`
+			Status     string `json:"status"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.AddMedication(args.PatientID, args.Medication, args.Dosage, args.Status)
+
+	case "get_ranked_problem_list":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetRankedProblemList(ctx, args.PatientID)
+
+	case "get_growth_percentile":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetGrowthPercentile(args.PatientID)
+
+	case "audit_patient_data":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.AuditPatientData(args.PatientID)
+
+	case "calculate_anion_gap":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateAnionGap(args.PatientID)
+
+	case "get_frequent_findings":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetFrequentFindings(args.PatientID)
+
+	case "get_patient_summary":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetPatientSummary(args.PatientID)
+
+	case "reconcile_medications":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Since     string `json:"since"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.ReconcileMedications(args.PatientID, args.Since)
+
+	case "check_drug_interactions":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CheckDrugInteractions(ctx, args.PatientID)
+
+	case "calculate_wells_score":
+		var args struct {
+			PatientID string          `json:"patient_id"`
+			ScoreType string          `json:"score_type"`
+			Criteria  map[string]bool `json:"criteria"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateWellsScore(args.PatientID, args.ScoreType, args.Criteria)
+
+	case "get_observation_trend":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Code      string `json:"code"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetObservationTrend(args.PatientID, args.Code)
+
+	case "get_day_schedule":
+		var args struct {
+			Date string `json:"date"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetDaySchedule(args.Date)
+
+	case "calculate_charlson":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.CalculateCharlson(args.PatientID)
+
+	case "get_panel_result":
+		var args struct {
+			PatientID string `json:"patient_id"`
+			Date      string `json:"date"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.GetPanelResult(args.PatientID, args.Date)
+
+	case "record_consent":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.RecordConsent(args.PatientID)
+
+	case "revoke_consent":
+		var args struct {
+			PatientID string `json:"patient_id"`
+		}
+		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return handler.RevokeConsent(args.PatientID)
+
+	case "calculate_maintenance_fluids":
+		var args struct {
+			PatientID string `json:"patient_id"`
 		}
 		if err := json.Unmarshal(toolCall.Arguments, &args); err != nil {
 			return nil, err
 		}
-		return s.handler.UpdatePatientBirthDate(args.PatientID, args.BirthDate)
+		return handler.CalculateMaintenanceFluids(args.PatientID)
 
 	default:
-		return nil, fmt.Errorf("unknown tool: %s", toolCall.Name)
+		return nil, fmt.Errorf("%w: %s", errUnknownTool, toolCall.Name)
 	}
 }
@@ -1,8 +1,12 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
 	"testing"
+
+	"github.com/eythor/mcp-server/internal/handlers"
 )
 
 func TestHandleInitialize(t *testing.T) {
@@ -19,7 +23,7 @@ func TestHandleInitialize(t *testing.T) {
 		t.Fatalf("Failed to marshal request: %v", err)
 	}
 	
-	response, err := server.HandleMessage(reqBytes)
+	response, err := server.HandleMessage(context.Background(), reqBytes)
 	if err != nil {
 		t.Fatalf("HandleMessage failed: %v", err)
 	}
@@ -42,6 +46,73 @@ func TestHandleInitialize(t *testing.T) {
 	}
 }
 
+func TestHandleInitializeNegotiatesProtocolVersion(t *testing.T) {
+	server := &Server{}
+
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{"2025-03-26", "2025-03-26"},
+		{"2024-11-05", "2024-11-05"},
+		{"1999-01-01", supportedProtocolVersions[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			request := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "initialize",
+				"params": map[string]interface{}{
+					"protocolVersion": tt.requested,
+					"capabilities":    map[string]interface{}{},
+					"clientInfo":      map[string]interface{}{"name": "test-client", "version": "1.0"},
+				},
+				"id": 1,
+			}
+			reqBytes, err := json.Marshal(request)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			response, err := server.HandleMessage(context.Background(), reqBytes)
+			if err != nil {
+				t.Fatalf("HandleMessage failed: %v", err)
+			}
+
+			result, ok := response.Result.(map[string]interface{})
+			if !ok {
+				t.Fatal("Result is not a map")
+			}
+			if result["protocolVersion"] != tt.expected {
+				t.Errorf("Expected protocol version %s, got %v", tt.expected, result["protocolVersion"])
+			}
+		})
+	}
+}
+
+func TestHandleInitializeServerInfo(t *testing.T) {
+	t.Setenv("MCP_SERVER_NAME", "clinic-north")
+	defer func(v string) { Version = v }(Version)
+	Version = "1.4.2"
+
+	server := &Server{}
+	result := server.handleInitialize(nil)
+	info, ok := result["serverInfo"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("serverInfo missing: %v", result)
+	}
+	if info["name"] != "clinic-north" || info["version"] != "1.4.2" {
+		t.Errorf("serverInfo = %v, want clinic-north 1.4.2", info)
+	}
+
+	t.Setenv("MCP_SERVER_NAME", "")
+	info = server.handleInitialize(nil)["serverInfo"].(map[string]interface{})
+	if info["name"] != defaultServerName {
+		t.Errorf("name = %v, want %s", info["name"], defaultServerName)
+	}
+}
+
 func TestHandleToolsList(t *testing.T) {
 	server := &Server{}
 	
@@ -56,7 +127,7 @@ func TestHandleToolsList(t *testing.T) {
 		t.Fatalf("Failed to marshal request: %v", err)
 	}
 	
-	response, err := server.HandleMessage(reqBytes)
+	response, err := server.HandleMessage(context.Background(), reqBytes)
 	if err != nil {
 		t.Fatalf("HandleMessage failed: %v", err)
 	}
@@ -72,12 +143,77 @@ func TestHandleToolsList(t *testing.T) {
 	}
 	
 	expectedTools := []string{
+		"natural_language_query",
+		"set_patient_context",
+		"set_practitioner_context",
+		"get_context",
+		"clear_context",
 		"lookup_patient",
-		"schedule_appointment", 
+		"get_practitioner",
+		"schedule_appointment",
 		"cancel_appointment",
 		"get_medical_history",
 		"get_medication_info",
+		"get_medical_guidelines",
 		"answer_health_question",
+		"add_observation",
+		"calculate_age",
+		"update_patient_birth_date",
+		"calculate_ibw",
+		"check_contraindications",
+		"get_patient_fhir",
+		"add_contact_point",
+		"list_contact_points",
+		"get_screening_recommendations",
+		"explain_observation_trend",
+		"get_vaccination_card",
+		"diff_patient_summary",
+		"complete_encounter",
+		"suggest_next_actions",
+		"get_allergy_banner",
+		"calculate_meld",
+		"get_observation_series",
+		"get_surgical_history",
+		"list_appointments",
+		"get_claims_aging",
+		"validate_loinc",
+		"reschedule_appointment",
+		"generate_sbar",
+		"confirm_date_choice",
+		"normalize_patient_data",
+		"list_available_models",
+		"get_encounters",
+		"get_care_team",
+		"delete_observation",
+		"update_observation",
+		"get_visit_punctuality",
+		"mark_patient_arrived",
+		"find_patients_on_medication",
+		"calculate_bmi",
+		"record_gcs",
+		"calculate_egfr",
+		"calculate_corrected_sodium",
+		"create_patient",
+		"get_cost_report",
+		"get_medication_forms",
+		"add_condition",
+		"add_medication",
+		"get_ranked_problem_list",
+		"get_growth_percentile",
+		"audit_patient_data",
+		"calculate_anion_gap",
+		"get_frequent_findings",
+		"get_patient_summary",
+		"reconcile_medications",
+		"check_drug_interactions",
+		"calculate_wells_score",
+		"get_observation_trend",
+		"get_day_schedule",
+		"calculate_charlson",
+		"get_panel_result",
+		"record_consent",
+		"revoke_consent",
+		"calculate_maintenance_fluids",
 	}
 	
 	if len(tools) != len(expectedTools) {
@@ -105,7 +241,7 @@ func TestHandleUnknownMethod(t *testing.T) {
 		t.Fatalf("Failed to marshal request: %v", err)
 	}
 	
-	response, err := server.HandleMessage(reqBytes)
+	response, err := server.HandleMessage(context.Background(), reqBytes)
 	if err != nil {
 		t.Fatalf("HandleMessage failed: %v", err)
 	}
@@ -117,4 +253,122 @@ func TestHandleUnknownMethod(t *testing.T) {
 	if response.Error.Code != -32601 {
 		t.Errorf("Expected error code -32601, got %d", response.Error.Code)
 	}
-}
\ No newline at end of file
+}
+func TestHandlePayloadBatch(t *testing.T) {
+	server := &Server{}
+
+	payload := []byte(`[
+		{"jsonrpc": "2.0", "method": "initialize", "params": {}, "id": 1},
+		{"jsonrpc": "2.0", "method": "initialized"},
+		{"jsonrpc": "2.0", "method": "unknown_method", "id": "b"},
+		{"jsonrpc": "2.0", "method": "tools/list"},
+		42
+	]`)
+
+	result, err := server.HandlePayload(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("HandlePayload failed: %v", err)
+	}
+	responses, ok := result.([]*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("Expected a batch response, got %T", result)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("Expected 3 responses (notifications omitted), got %d", len(responses))
+	}
+	if responses[0].ID.(float64) != 1 || responses[0].Error != nil {
+		t.Errorf("Unexpected initialize response: %+v", responses[0])
+	}
+	if responses[1].ID != "b" || responses[1].Error == nil || responses[1].Error.Code != -32601 {
+		t.Errorf("Unexpected unknown method response: %+v", responses[1])
+	}
+	if responses[2].ID != nil || responses[2].Error == nil || responses[2].Error.Code != ErrCodeInvalidRequest {
+		t.Errorf("Unexpected response to a non-object entry: %+v", responses[2])
+	}
+}
+
+func TestHandlePayloadNotificationsOnly(t *testing.T) {
+	server := &Server{}
+
+	result, err := server.HandlePayload(context.Background(), []byte(`[{"jsonrpc": "2.0", "method": "initialized"}]`))
+	if err != nil {
+		t.Fatalf("HandlePayload failed: %v", err)
+	}
+	if result != nil {
+		t.Errorf("Expected no response to a batch of notifications, got %v", result)
+	}
+
+	result, err = server.HandlePayload(context.Background(), []byte(`{"jsonrpc": "2.0", "method": "initialize", "id": 7}`))
+	if err != nil {
+		t.Fatalf("HandlePayload failed: %v", err)
+	}
+	if response, ok := result.(*JSONRPCResponse); !ok || response.ID.(float64) != 7 {
+		t.Errorf("Expected a single response for a single request, got %#v", result)
+	}
+}
+
+func TestHandlePayloadEmptyBatch(t *testing.T) {
+	server := &Server{}
+
+	result, err := server.HandlePayload(context.Background(), []byte(` [ ] `))
+	if err != nil {
+		t.Fatalf("HandlePayload failed: %v", err)
+	}
+	response, ok := result.(*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("Expected a single response to an empty batch, got %T", result)
+	}
+	if response.ID != nil || response.Error == nil || response.Error.Code != ErrCodeInvalidRequest {
+		t.Errorf("Unexpected response to an empty batch: %+v", response)
+	}
+}
+
+func TestHandleToolsListContextRequired(t *testing.T) {
+	server, db := newTestServer(t)
+	if _, err := db.Exec(`INSERT INTO patients (id, given_name, family_name, gender, birth_date) VALUES ('p1', 'Ola', 'Nordmann', 'male', '1960-05-05')`); err != nil {
+		t.Fatalf("Failed to seed patient: %v", err)
+	}
+
+	required := func(ctx context.Context) map[string][]string {
+		t.Helper()
+		response, err := server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "method": "tools/list", "id": 1}`))
+		if err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+		byTool := make(map[string][]string)
+		for _, tool := range response.Result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+			fields, _ := tool["inputSchema"].(map[string]interface{})["required"].([]string)
+			byTool[tool["name"].(string)] = fields
+		}
+		return byTool
+	}
+
+	ctx := handlers.WithSession(context.Background(), "s1")
+	before := required(ctx)
+	if got := strings.Join(before["get_medical_history"], ","); got != "patient_id" {
+		t.Errorf("Expected patient_id to be required without a patient context, got %q", got)
+	}
+	// The default context has a practitioner
+	if got := strings.Join(before["schedule_appointment"], ","); got != "patient_id,datetime" {
+		t.Errorf("Expected patient_id and datetime to be required, got %q", got)
+	}
+
+	if _, err := server.HandleMessage(ctx, []byte(`{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "set_patient_context", "arguments": {"patient_id": "p1"}}, "id": 2}`)); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	after := required(ctx)
+	if got := after["get_medical_history"]; len(got) != 0 {
+		t.Errorf("Expected patient_id to be optional with a patient context, got %v", got)
+	}
+	if got := strings.Join(after["schedule_appointment"], ","); got != "datetime" {
+		t.Errorf("Expected only datetime to be required, got %q", got)
+	}
+	if got := strings.Join(after["set_patient_context"], ","); got != "patient_id" {
+		t.Errorf("Expected set_patient_context to always require patient_id, got %q", got)
+	}
+
+	// Other sessions have their own context
+	if got := strings.Join(required(handlers.WithSession(context.Background(), "s2"))["get_medical_history"], ","); got != "patient_id" {
+		t.Errorf("Expected patient_id to be required in another session, got %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"log"
 	"os"
@@ -26,11 +27,21 @@ func main() {
 	}
 	debug.Log("Using database at: %s", dbPath)
 
-	db, err := database.InitDB(dbPath)
+	dbOptions := database.OptionsFromEnv()
+	db, err := database.InitDBWithOptions(dbPath, dbOptions)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
+
+	if dbOptions.ReadOnly {
+		debug.Log("Database opened read-only, skipping migrations")
+		if err := database.CheckMigrated(db); err != nil {
+			log.Fatalf("Cannot use read-only database: %v", err)
+		}
+	} else if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 	debug.Verbose("Database initialized")
 
 	apiKey := os.Getenv("OPENROUTER_API_KEY")
@@ -40,6 +51,7 @@ func main() {
 	debug.Verbose("OPENROUTER_API_KEY configured")
 
 	handler := handlers.NewHandler(db, apiKey)
+	defer handler.Close()
 	server := mcp.NewServer(handler)
 	debug.Verbose("MCP server initialized")
 
@@ -54,7 +66,7 @@ func main() {
 		message := scanner.Bytes()
 		debug.Trace("Received message: %s", string(message))
 		
-		response, err := server.HandleMessage(message)
+		response, err := server.HandlePayload(context.Background(), message)
 		if err != nil {
 			debug.Error("Error handling message: %v", err)
 			log.Printf("Error handling message: %v", err)
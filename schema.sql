@@ -28,6 +28,40 @@ CREATE TABLE IF NOT EXISTS patients (
     raw_json TEXT
 );
 
+CREATE TABLE IF NOT EXISTS patient_contacts (
+    id TEXT PRIMARY KEY,
+    patient_id TEXT NOT NULL,
+    system TEXT NOT NULL,
+    use TEXT,
+    value TEXT NOT NULL,
+    created_datetime DATETIME,
+    FOREIGN KEY (patient_id) REFERENCES patients(id)
+);
+
+CREATE TABLE IF NOT EXISTS consents (
+    patient_id TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    status TEXT NOT NULL,
+    recorded_by TEXT,
+    recorded_datetime TEXT NOT NULL,
+    PRIMARY KEY (patient_id, scope),
+    FOREIGN KEY (patient_id) REFERENCES patients(id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp TEXT NOT NULL,
+    action TEXT NOT NULL,
+    entity_id TEXT,
+    practitioner_id TEXT,
+    details TEXT
+);
+
+CREATE TABLE IF NOT EXISTS id_sequences (
+    name TEXT PRIMARY KEY,
+    value INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS practitioners (
     id TEXT PRIMARY KEY,
     resource_type TEXT DEFAULT 'Practitioner',
@@ -86,6 +120,9 @@ CREATE TABLE IF NOT EXISTS encounters (
     location_id TEXT,
     start_datetime DATETIME,
     end_datetime DATETIME,
+    actual_start_datetime DATETIME,
+    outcome TEXT,
+    cancellation_reason TEXT,
     raw_json TEXT,
     FOREIGN KEY (patient_id) REFERENCES patients(id),
     FOREIGN KEY (practitioner_id) REFERENCES practitioners(id),
@@ -438,6 +475,8 @@ CREATE INDEX idx_immunizations_patient ON immunizations(patient_id);
 CREATE INDEX idx_medication_requests_patient ON medication_requests(patient_id);
 CREATE INDEX idx_diagnostic_reports_patient ON diagnostic_reports(patient_id);
 CREATE INDEX idx_claims_patient ON claims(patient_id);
+CREATE INDEX IF NOT EXISTS idx_patient_contacts_patient ON patient_contacts(patient_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
 
 -- View for patient summary
 CREATE VIEW patient_summary AS